@@ -34,6 +34,7 @@ func NewCmdBuild(out io.Writer) *cobra.Command {
 		},
 	}
 	AddRunDevFlags(cmd)
+	cmd.Flags().StringVar(&opts.BuildOutputFile, "file-output", "", "Write the name, tag, digest and local image id of the built images to a json file")
 	return cmd
 }
 
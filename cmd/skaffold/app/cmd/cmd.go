@@ -17,15 +17,26 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"io"
+	"os"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/features"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/preview"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/runner"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/verbosity"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/version"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/warnings"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/watch"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -35,66 +46,131 @@ var (
 	opts      = &config.SkaffoldOptions{}
 	v         string
 	filename  string
+	workdir   string
 	overwrite bool
+	noCleanup bool
 )
 
-var rootCmd = &cobra.Command{
-	Use:   "skaffold",
-	Short: "A tool that facilitates continuous development for Kubernetes applications.",
-}
-
 func NewSkaffoldCommand(out, err io.Writer) *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "skaffold",
+		Short: "A tool that facilitates continuous development for Kubernetes applications.",
+	}
+
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 		if err := SetUpLogs(err, v); err != nil {
 			return err
 		}
 		logrus.Infof("Skaffold %+v", version.Get())
-		return nil
+		if err := features.Load(constants.DefaultGlobalConfigFile, os.Getenv(features.EnvVar)); err != nil {
+			return errors.Wrap(err, "loading feature flags")
+		}
+		return changeWorkingDir(workdir)
 	}
 
 	rootCmd.AddCommand(NewCmdCompletion(out))
 	rootCmd.AddCommand(NewCmdVersion(out))
 	rootCmd.AddCommand(NewCmdRun(out))
 	rootCmd.AddCommand(NewCmdDev(out))
+	rootCmd.AddCommand(NewCmdDebug(out))
 	rootCmd.AddCommand(NewCmdBuild(out))
+	rootCmd.AddCommand(NewCmdRender(out))
 	rootCmd.AddCommand(NewCmdFix(out))
 	rootCmd.AddCommand(NewCmdDocker(out))
-
-	rootCmd.PersistentFlags().StringVarP(&v, "verbosity", "v", constants.DefaultLogLevel.String(), "Log level (debug, info, warn, error, fatal, panic")
+	rootCmd.AddCommand(NewCmdInspect(out))
+	rootCmd.AddCommand(NewCmdTags(out))
+	rootCmd.AddCommand(NewCmdLock(out))
+	rootCmd.AddCommand(NewCmdConfig(out))
+	rootCmd.AddCommand(NewCmdDelete(out))
+
+	rootCmd.PersistentFlags().StringVarP(&v, "verbosity", "v", constants.DefaultLogLevel.String(), "Log level (debug, info, warn, error, fatal, panic), optionally per subsystem, e.g. warn,watcher=debug")
+	for _, cmd := range rootCmd.Commands() {
+		// The docker commands have their own --filename, for the Dockerfile.
+		if cmd.Name() != "docker" {
+			cmd.PersistentFlags().StringVarP(&filename, "filename", "f", "skaffold.yaml", "Filename or URL to the pipeline file, relative to the working directory")
+		}
+	}
+	rootCmd.PersistentFlags().StringVar(&workdir, "workdir", "", "Directory that the pipeline file, workspaces and manifests are relative to")
 	return rootCmd
 }
 
 func AddDevFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&opts.Cleanup, "cleanup", true, "Delete deployments after dev mode is interrupted")
+	cmd.Flags().BoolVar(&noCleanup, "no-cleanup", false, "Keep the deployments and the build pods running after dev mode is interrupted")
+	cmd.Flags().StringVar(&opts.RecordFile, "record", "", "Record file changes and how long they took to handle to a file")
+	cmd.Flags().StringVar(&opts.ReplayFile, "replay", "", "Replay file changes recorded with --record instead of watching files, then exit")
+	cmd.Flags().BoolVar(&opts.Resume, "resume", false, "Skip building and deploying what's unchanged since the last interrupted dev session")
+	cmd.Flags().StringVar(&opts.SessionFile, "session-file", constants.DefaultSessionFile, "Location of the dev session state")
+	cmd.Flags().StringVar(&opts.Trigger, "trigger", watch.NotifyTrigger, "How changes are noticed: notify, polling or manual, where Enter builds and deploys the changes")
+	cmd.Flags().DurationVar(&opts.WatchPollInterval, "watch-poll-interval", time.Second, "How often files are checked for changes with --trigger=polling")
+	cmd.Flags().BoolVar(&opts.PortForward, "port-forward", true, "Forward the container ports of the deployed pods to local ports")
+	cmd.Flags().BoolVar(&opts.StubBuilds, "stub-builds", false, "Skip the builds and deploy the images as they are, to benchmark the rest of the dev loop")
 }
 
 func AddRunDevFlags(cmd *cobra.Command) {
-	cmd.Flags().StringVarP(&filename, "filename", "f", "skaffold.yaml", "Filename or URL to the pipeline file")
 	cmd.Flags().BoolVar(&opts.Notification, "toot", false, "Emit a terminal beep after the deploy is complete")
-	cmd.Flags().StringArrayVarP(&opts.Profiles, "profile", "p", nil, "Activate profiles by name")
+	cmd.Flags().StringArrayVarP(&opts.Profiles, "profile", "p", nil, "Activate profiles by name, later ones taking precedence")
+	cmd.Flags().BoolVar(&opts.CacheArtifacts, "cache-artifacts", false, "Skip building artifacts whose dependencies didn't change since the last build")
+	cmd.Flags().StringVar(&opts.CacheFile, "cache-file", constants.DefaultCacheFile, "Location of the build cache")
+	cmd.Flags().StringVar(&opts.EventAPIAddress, "event-api", "", "Serve build, deploy and port forwarding events over HTTP on this address, e.g. localhost:50052. The API has no authentication, keep it on localhost")
+	cmd.Flags().StringVar(&opts.Simulate, "simulate", "", "Inject latency and failures, e.g. build=2s,upload=fail:0.5,deploy=500ms+fail")
+	cmd.Flags().MarkHidden("simulate")
+}
+
+// serveEventAPI starts the event API when it's enabled.
+func serveEventAPI(out io.Writer) error {
+	if opts.EventAPIAddress == "" {
+		return nil
+	}
+
+	addr, err := event.Serve(context.Background(), opts.EventAPIAddress)
+	if err != nil {
+		return errors.Wrap(err, "starting event API")
+	}
+	fmt.Fprintf(out, "Event API listening on http://%s\n", addr)
+	return nil
 }
 
 func AddFixFlags(cmd *cobra.Command) {
-	cmd.Flags().StringVarP(&filename, "filename", "f", "skaffold.yaml", "Filename or URL to the pipeline file")
 	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite original config with fixed config")
 }
 
 func SetUpLogs(out io.Writer, level string) error {
 	logrus.SetOutput(out)
-	lvl, err := logrus.ParseLevel(v)
+	levels, err := verbosity.Parse(level)
 	if err != nil {
-		return errors.Wrap(err, "parsing log level")
+		return errors.Wrap(err, "parsing verbosity")
 	}
-	logrus.SetLevel(lvl)
+	verbosity.Apply(logrus.StandardLogger(), levels)
 	return nil
 }
 
+// changeWorkingDir makes all relative paths, including those in the pipeline
+// file and its profiles, resolve against the given directory.
+func changeWorkingDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	logrus.Debugf("Changing working directory to %s", dir)
+	return errors.Wrap(os.Chdir(dir), "changing working directory")
+}
+
 func NewRunner(out io.Writer, filename string) (*runner.SkaffoldRunner, error) {
 	config, err := readConfiguration(filename)
 	if err != nil {
 		return nil, errors.Wrap(err, "reading configuration")
 	}
 
+	return newRunnerForConfig(out, config)
+}
+
+func newRunnerForConfig(out io.Writer, config *config.SkaffoldConfig) (*runner.SkaffoldRunner, error) {
+	if opts.PreviewBranch != "" {
+		preview.Apply(config, opts.PreviewBranch)
+		opts.Namespace = preview.Namespace(opts.PreviewBranch)
+	}
+
 	r, err := runner.NewForConfig(opts, config, out)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting skaffold config")
@@ -115,19 +191,15 @@ func readConfiguration(filename string) (*config.SkaffoldConfig, error) {
 	}
 
 	if apiVersion.Version != config.LatestVersion {
-		return nil, errors.New("Config version out of date: run `skaffold fix`")
+		warnings.Warnf(warnings.OutdatedConfig, "%s uses %s, run `skaffold fix` to upgrade it to %s", filename, apiVersion.Version, config.LatestVersion)
 	}
 
-	cfg, err := config.GetConfig(buf, true)
+	latestConfig, err := schema.ParseConfig(buf, true)
 	if err != nil {
-		return nil, errors.Wrap(err, "parsing skaffold config")
+		return nil, err
 	}
 
-	// we already ensured that the versions match in the previous block,
-	// so this type assertion is safe.
-	latestConfig := cfg.(*config.SkaffoldConfig)
-
-	err = latestConfig.ApplyProfiles(opts.Profiles)
+	err = latestConfig.ApplyProfiles(config.SplitProfiles(opts.Profiles))
 	if err != nil {
 		return nil, errors.Wrap(err, "applying profiles")
 	}
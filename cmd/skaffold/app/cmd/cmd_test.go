@@ -17,6 +17,9 @@ limitations under the License.
 package cmd
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
@@ -57,3 +60,38 @@ func TestReadConfiguration(t *testing.T) {
 		testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expectedCfg, cfg)
 	}
 }
+
+func TestChangeWorkingDir(t *testing.T) {
+	dir, teardown := testutil.TempDir(t)
+	defer teardown()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+
+	testutil.CheckError(t, false, changeWorkingDir(""))
+	testutil.CheckError(t, true, changeWorkingDir(filepath.Join(dir, "missing")))
+	testutil.CheckError(t, false, changeWorkingDir(dir))
+
+	wd, err := os.Getwd()
+	expected, _ := filepath.EvalSymlinks(dir)
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, wd)
+}
+
+func TestFilenameFlag(t *testing.T) {
+	root := NewSkaffoldCommand(ioutil.Discard, ioutil.Discard)
+
+	for _, args := range [][]string{{"dev"}, {"inspect", "images"}, {"docker", "deps"}, {"docker", "context"}} {
+		cmd, _, err := root.Find(args)
+		testutil.CheckError(t, false, err)
+
+		expected := "skaffold.yaml"
+		if args[0] == "docker" {
+			expected = "Dockerfile"
+			testutil.CheckErrorAndDeepEqual(t, false, nil, true, cmd.InheritedFlags().Lookup("filename") == nil)
+		}
+		testutil.CheckErrorAndDeepEqual(t, false, nil, expected, cmd.Flag("filename").DefValue)
+	}
+}
@@ -23,11 +23,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// completionCmd represents the completion command
-var completionCmd = &cobra.Command{
-	Use:   "completion",
-	Short: "Generate shell completion scripts",
-	Long: `To enable command completion run
+func NewCmdCompletion(out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion",
+		Short: "Generate shell completion scripts",
+		Long: `To enable command completion run
 
 eval "$(skaffold completion bash)"
 
@@ -35,11 +35,8 @@ To configure bash shell completion for all your sessions, add the following to y
 ~/.bashrc or ~/.bash_profile:
 
 eval "$(skaffold completion bash)"`,
-	Run: func(cmd *cobra.Command, args []string) {
-		rootCmd.GenBashCompletion(os.Stdout)
-	},
-}
-
-func NewCmdCompletion(out io.Writer) *cobra.Command {
-	return completionCmd
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Root().GenBashCompletion(os.Stdout)
+		},
+	}
 }
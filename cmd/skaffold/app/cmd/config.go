@@ -0,0 +1,88 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/features"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var experimental bool
+
+// NewCmdConfig describes the CLI command to inspect the global configuration.
+func NewCmdConfig(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "A set of commands to inspect the global skaffold configuration",
+	}
+
+	cmd.AddCommand(NewCmdConfigList(out))
+	return cmd
+}
+
+// NewCmdConfigList describes the CLI command to print the global configuration.
+func NewCmdConfigList(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Prints the global configuration, or the state of the experimental features",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if experimental {
+				return listFeatures(out, features.All())
+			}
+			return listConfig(out, constants.DefaultGlobalConfigFile)
+		},
+	}
+	cmd.Flags().BoolVar(&experimental, "experimental", false, fmt.Sprintf("List the experimental features, which can be switched in the global configuration or with %s", features.EnvVar))
+	return cmd
+}
+
+func listConfig(out io.Writer, configFile string) error {
+	path, err := homedir.Expand(configFile)
+	if err != nil {
+		return errors.Wrapf(err, "expanding %s", configFile)
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "reading %s", path)
+	}
+
+	_, err = out.Write(buf)
+	return err
+}
+
+func listFeatures(out io.Writer, flags []*features.Flag) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tENABLED\tSOURCE\tDESCRIPTION")
+	for _, f := range flags {
+		fmt.Fprintf(w, "%s\t%t\t%s\t%s\n", f.Name, f.Enabled(), f.Source(), f.Description)
+	}
+	return w.Flush()
+}
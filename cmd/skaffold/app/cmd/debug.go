@@ -0,0 +1,42 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCmdDebug describes the CLI command to run a pipeline in debug mode.
+func NewCmdDebug(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "debug",
+		Short: "Runs a pipeline file in development mode, with the deployed containers ready for a debugger to attach",
+		Long: `Runs a pipeline file in development mode, with the deployed containers ready for a debugger to attach.
+The jdwp agent is enabled on port 5005 for JVMs and the inspector on port 9229 for Node.js.
+These ports are forwarded to local ports along with the other container ports.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Debug = true
+			return dev(out, filename)
+		},
+	}
+	AddRunDevFlags(cmd)
+	AddDevFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/preview"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// currentBranch is the value of --branch when it's given without a name.
+const currentBranch = "@"
+
+var deleteBranch string
+
+// NewCmdDelete describes the CLI command to delete what was deployed.
+func NewCmdDelete(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Deletes what a pipeline deployed",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return deleteDeployed(out, filename)
+		},
+	}
+	cmd.Flags().StringArrayVarP(&opts.Profiles, "profile", "p", nil, "Activate profiles by name, later ones taking precedence")
+	cmd.Flags().StringVar(&deleteBranch, "branch", "", "Delete the preview environment of a git branch, the current one if no name is given")
+	cmd.Flags().Lookup("branch").NoOptDefVal = currentBranch
+	return cmd
+}
+
+func deleteDeployed(out io.Writer, filename string) error {
+	ctx := context.Background()
+
+	branch := deleteBranch
+	if branch == currentBranch {
+		current, err := preview.CurrentBranch(".")
+		if err != nil {
+			return errors.Wrap(err, "finding the current branch")
+		}
+		branch = current
+	}
+	opts.PreviewBranch = branch
+
+	runner, err := NewRunner(out, filename)
+	if err != nil {
+		return err
+	}
+
+	if err := runner.Deployer.Cleanup(ctx, out); err != nil {
+		return errors.Wrap(err, "deleting deployed resources")
+	}
+	if branch == "" {
+		return nil
+	}
+
+	client, err := kubernetes.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+	if err := preview.DeleteNamespace(client, branch); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Deleted the preview environment of %s\n", branch)
+	return nil
+}
@@ -18,8 +18,17 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"os"
+	"strings"
 
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/runner"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/watch"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -35,16 +44,180 @@ func NewCmdDev(out io.Writer) *cobra.Command {
 	}
 	AddRunDevFlags(cmd)
 	AddDevFlags(cmd)
+	cmd.AddCommand(NewCmdDevProfiles(out))
 	return cmd
 }
 
+// NewCmdDevProfiles describes the CLI command to switch the profiles of a running dev session.
+func NewCmdDevProfiles(out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "profiles [profile...]",
+		Short: "Switches the profiles of the dev session running in the working directory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return switchProfiles(out, config.SplitProfiles(args))
+		},
+	}
+}
+
 func dev(out io.Writer, filename string) error {
-	ctx := context.Background()
+	opts.DevMode = true
+	if noCleanup {
+		opts.Cleanup = false
+	}
+	opts.Profiles = config.SplitProfiles(opts.Profiles)
+
+	if err := serveEventAPI(out); err != nil {
+		return err
+	}
+
+	profilesFile, err := activeProfilesFile()
+	if err != nil {
+		return err
+	}
+	if err := config.WriteActiveProfiles(profilesFile, opts.Profiles); err != nil {
+		return errors.Wrap(err, "writing active profiles")
+	}
+	defer os.Remove(profilesFile)
+
+	watcherFactory, err := runner.FileWatcherFactory(opts)
+	if err != nil {
+		return errors.Wrap(err, "creating watcher")
+	}
+
+	previous := opts.Profiles
+	var r *runner.SkaffoldRunner
+	for {
+		if r == nil {
+			r, err = NewRunner(out, filename)
+			if err != nil {
+				if strings.Join(opts.Profiles, ",") == strings.Join(previous, ",") {
+					return err
+				}
+				logrus.Errorf("switching profiles: %s", err)
+				opts.Profiles = previous
+				config.WriteActiveProfiles(profilesFile, previous)
+				continue
+			}
+		}
+		previous = opts.Profiles
+
+		ctx, cancel := context.WithCancel(context.Background())
+		switched := make(chan []string, 1)
+		reloaded := make(chan *runner.SkaffoldRunner, 1)
+		go watchActiveProfiles(ctx, watcherFactory, profilesFile, opts.Profiles, switched, cancel)
+		if filename != "-" && !util.IsURL(filename) {
+			go watchConfiguration(ctx, out, watcherFactory, filename, reloaded, cancel)
+		}
+
+		err = r.Dev(ctx)
+		cancel()
+
+		select {
+		case profiles := <-switched:
+			fmt.Fprintf(out, "Switching to profiles [%s]\n", strings.Join(profiles, ", "))
+			opts.Profiles = profiles
+			r = nil
+		case r = <-reloaded:
+		default:
+			return err
+		}
+	}
+}
+
+// watchConfiguration interrupts the dev session when the configuration file
+// changes, once the new configuration is known to be valid. The pipeline is
+// then run again with the runner it prepared.
+func watchConfiguration(ctx context.Context, out io.Writer, watcherFactory watch.WatcherFactory, filename string, reloaded chan<- *runner.SkaffoldRunner, cancel func()) {
+	current, err := readConfiguration(filename)
+	if err != nil {
+		logrus.Warnf("%s won't be reloaded: %s", filename, err)
+		return
+	}
+
+	watcher, err := watcherFactory([]string{filename})
+	if err != nil {
+		logrus.Warnf("%s won't be reloaded: %s", filename, err)
+		return
+	}
+
+	watcher.Start(ctx, func([]string) {
+		next, err := readConfiguration(filename)
+		if err != nil {
+			fmt.Fprintf(out, "Keeping the current pipeline, %s is invalid: %s\n", filename, err)
+			return
+		}
+		changes := config.Changes(current, next)
+		if len(changes) == 0 {
+			return
+		}
+
+		r, err := newRunnerForConfig(out, next)
+		if err != nil {
+			fmt.Fprintf(out, "Keeping the current pipeline, %s can't be run: %s\n", filename, err)
+			return
+		}
+
+		current = next
+		fmt.Fprintf(out, "Reloading %s: %s\n", filename, strings.Join(changes, ", "))
+		select {
+		case reloaded <- r:
+			cancel()
+		default:
+		}
+	})
+}
+
+// watchActiveProfiles interrupts the dev session when it's asked to switch profiles.
+func watchActiveProfiles(ctx context.Context, watcherFactory watch.WatcherFactory, profilesFile string, current []string, switched chan<- []string, cancel func()) {
+	watcher, err := watcherFactory([]string{profilesFile})
+	if err != nil {
+		logrus.Warnf("Profiles can't be switched: %s", err)
+		return
+	}
 
-	runner, err := NewRunner(out, filename)
+	watcher.Start(ctx, func([]string) {
+		profiles, err := config.ReadActiveProfiles(profilesFile)
+		if err != nil {
+			logrus.Warnf("reading active profiles: %s", err)
+			return
+		}
+		if strings.Join(profiles, ",") == strings.Join(current, ",") {
+			return
+		}
+
+		select {
+		case switched <- profiles:
+			cancel()
+		default:
+		}
+	})
+}
+
+func switchProfiles(out io.Writer, profiles []string) error {
+	profilesFile, err := activeProfilesFile()
 	if err != nil {
 		return err
 	}
+	if _, err := os.Stat(profilesFile); os.IsNotExist(err) {
+		return errors.New("no dev session is running in this directory")
+	}
+
+	if err := config.WriteActiveProfiles(profilesFile, profiles); err != nil {
+		return errors.Wrap(err, "writing active profiles")
+	}
+	fmt.Fprintln(out, "Asked the dev session to switch profiles")
+	return nil
+}
+
+func activeProfilesFile() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", errors.Wrap(err, "getting working directory")
+	}
 
-	return runner.Dev(ctx)
+	profilesFile, err := config.ActiveProfilesFile(cwd)
+	if err != nil {
+		return "", errors.Wrap(err, "locating active profiles file")
+	}
+	return profilesFile, nil
 }
@@ -0,0 +1,101 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/runner"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/watch"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestWatchConfigurationInCurrentDir(t *testing.T) {
+	dir, teardown := testutil.TempDir(t)
+	defer teardown()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	kubeConfig := []byte("apiVersion: v1\nkind: Config\nclusters:\n- name: test\n  cluster:\n    server: https://localhost:6443\ncontexts:\n- name: test\n  context:\n    cluster: test\ncurrent-context: test\n")
+	if err := ioutil.WriteFile("kubeconfig", kubeConfig, 0640); err != nil {
+		t.Fatal(err)
+	}
+	unsetEnvs := testutil.SetEnvs(t, map[string]string{"KUBECONFIG": filepath.Join(dir, "kubeconfig")})
+	defer unsetEnvs(t)
+
+	writeConfig := func(manifest int) {
+		config := fmt.Sprintf("apiVersion: skaffold/v1alpha2\nkind: Config\ndeploy:\n  kubectl:\n    manifests: [k8s-%d.yaml]\n", manifest)
+		if err := ioutil.WriteFile("skaffold.yaml", []byte(config), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tests = []struct {
+		description    string
+		watcherFactory watch.WatcherFactory
+	}{
+		{
+			description:    "notify",
+			watcherFactory: watch.NewWatcher,
+		},
+		{
+			description:    "polling",
+			watcherFactory: watch.NewPollingWatcherFactory(100 * time.Millisecond),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			writeConfig(0)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var out bytes.Buffer
+			reloaded := make(chan *runner.SkaffoldRunner, 1)
+			go watchConfiguration(ctx, &out, test.watcherFactory, "skaffold.yaml", reloaded, cancel)
+
+			// Keep changing the configuration until the watcher is started and notices.
+			timeout := time.After(10 * time.Second)
+			for manifest := 1; ; manifest++ {
+				select {
+				case r := <-reloaded:
+					testutil.CheckErrorAndDeepEqual(t, false, nil, true, r != nil)
+					return
+				case <-timeout:
+					t.Fatalf("skaffold.yaml wasn't reloaded: %s", out.String())
+				case <-time.After(time.Second):
+					writeConfig(manifest)
+				}
+			}
+		})
+	}
+}
@@ -21,15 +21,12 @@ import (
 	"io"
 	"io/ioutil"
 
-	yaml "gopkg.in/yaml.v2"
-
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema"
-	schemautil "github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/util"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 )
 
@@ -37,23 +34,8 @@ func NewCmdFix(out io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "fix",
 		Short: "Converts old skaffold.yaml to newest schema version",
-		Run: func(cmd *cobra.Command, args []string) {
-			contents, err := util.ReadConfiguration(filename)
-			if err != nil {
-				logrus.Errorf("fix: %s", err)
-			}
-			cfg, err := config.GetConfig(contents, false)
-			if err != nil {
-				logrus.Error(err)
-				return
-			}
-			if cfg.GetVersion() == config.LatestVersion {
-				fmt.Fprintln(out, "config is already latest version")
-				return
-			}
-			if err := runFix(out, cfg); err != nil {
-				logrus.Errorf("fix: %s", err)
-			}
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFix(out, filename)
 		},
 		Args: cobra.NoArgs,
 	}
@@ -61,22 +43,38 @@ func NewCmdFix(out io.Writer) *cobra.Command {
 	return cmd
 }
 
-func runFix(out io.Writer, cfg schemautil.VersionedConfig) error {
-	cfg, err := schema.RunTransform(cfg)
+// runFix upgrades a configuration of any supported version to the latest
+// one and prints it, or writes it back with --overwrite.
+func runFix(out io.Writer, filename string) error {
+	contents, err := util.ReadConfiguration(filename)
 	if err != nil {
-		return err
+		return errors.Wrap(err, "reading configuration")
+	}
+
+	apiVersion := &config.ApiVersion{}
+	if err := yaml.Unmarshal(contents, apiVersion); err != nil {
+		return errors.Wrap(err, "parsing api version")
 	}
-	newCfg, err := yaml.Marshal(cfg)
+	if apiVersion.Version == config.LatestVersion {
+		fmt.Fprintln(out, "config is already latest version")
+		return nil
+	}
+
+	upgraded, err := schema.Upgrade(contents)
 	if err != nil {
-		return errors.Wrap(err, "marshaling new config")
+		return errors.Wrap(err, "upgrading configuration")
+	}
+
+	if !overwrite {
+		_, err := out.Write(upgraded)
+		return err
+	}
+	if filename == "-" || util.IsURL(filename) {
+		return fmt.Errorf("%s can't be overwritten", filename)
 	}
-	if overwrite {
-		if err := ioutil.WriteFile(filename, newCfg, 0644); err != nil {
-			return errors.Wrap(err, "writing config file")
-		}
-		fmt.Fprintf(out, "New config at version %s generated and written to %s\n", cfg.GetVersion(), filename)
-	} else {
-		out.Write(newCfg)
+	if err := ioutil.WriteFile(filename, upgraded, 0644); err != nil {
+		return errors.Wrap(err, "writing config file")
 	}
+	fmt.Fprintf(out, "New config at version %s generated and written to %s\n", config.LatestVersion, filename)
 	return nil
 }
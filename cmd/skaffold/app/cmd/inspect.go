@@ -0,0 +1,160 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"text/template"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/inspect"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/preview"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const defaultInspectArtifactsFormat = `{{range .}}{{.ImageName}}: type={{.Type}} builder={{.Builder}} tagPolicy={{.TagPolicy}} dependencies={{len .Dependencies}} contextSize={{.ContextSize}}
+{{end}}`
+
+const defaultInspectPreviewsFormat = `{{range .}}{{.Branch}}: namespace={{.Namespace}} created={{.Created.Format "2006-01-02 15:04"}}
+{{end}}`
+
+const defaultInspectImagesFormat = `{{range .}}{{.Image}}: source={{.Source}} artifact={{or .Artifact "-"}} pinning={{.Pinning}}
+{{end}}`
+
+var inspectFormat string
+
+// NewCmdInspect describes the CLI command to inspect what skaffold would do.
+func NewCmdInspect(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "Prints information about the resolved pipeline without running it",
+	}
+
+	cmd.AddCommand(NewCmdInspectArtifacts(out))
+	cmd.AddCommand(NewCmdInspectImages(out))
+	cmd.AddCommand(NewCmdInspectPreviews(out))
+	return cmd
+}
+
+// NewCmdInspectArtifacts describes the CLI command to inspect artifacts.
+func NewCmdInspectArtifacts(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "artifacts",
+		Short: "Prints the builder, tag policy and dependencies of each artifact",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return inspectArtifacts(out, filename)
+		},
+	}
+	AddInspectFlags(cmd, defaultInspectArtifactsFormat)
+	return cmd
+}
+
+// NewCmdInspectImages describes the CLI command to list the images that are deployed.
+func NewCmdInspectImages(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "images",
+		Short: "Prints the images of the manifests and charts, the artifact that builds them and how they're pinned",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return inspectImages(out, filename)
+		},
+	}
+	AddInspectFlags(cmd, defaultInspectImagesFormat)
+	return cmd
+}
+
+// NewCmdInspectPreviews describes the CLI command to list the preview environments.
+func NewCmdInspectPreviews(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "previews",
+		Short: "Prints the preview environments deployed to the cluster with `skaffold run --preview`",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return inspectPreviews(out)
+		},
+	}
+	cmd.Flags().StringVar(&inspectFormat, "format", defaultInspectPreviewsFormat, "Output format: json or a go-template")
+	return cmd
+}
+
+func AddInspectFlags(cmd *cobra.Command, defaultFormat string) {
+	cmd.Flags().StringArrayVarP(&opts.Profiles, "profile", "p", nil, "Activate profiles by name, later ones taking precedence")
+	cmd.Flags().StringVar(&inspectFormat, "format", defaultFormat, "Output format: json or a go-template")
+}
+
+func inspectArtifacts(out io.Writer, filename string) error {
+	config, err := readConfiguration(filename)
+	if err != nil {
+		return errors.Wrap(err, "reading configuration")
+	}
+
+	infos, err := inspect.Artifacts(&config.Build)
+	if err != nil {
+		return errors.Wrap(err, "inspecting artifacts")
+	}
+
+	return writeFormatted(out, inspectFormat, infos)
+}
+
+func inspectImages(out io.Writer, filename string) error {
+	config, err := readConfiguration(filename)
+	if err != nil {
+		return errors.Wrap(err, "reading configuration")
+	}
+
+	infos, err := inspect.Images(config)
+	if err != nil {
+		return errors.Wrap(err, "inspecting images")
+	}
+
+	return writeFormatted(out, inspectFormat, infos)
+}
+
+func inspectPreviews(out io.Writer) error {
+	client, err := kubernetes.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+
+	previews, err := preview.List(client)
+	if err != nil {
+		return errors.Wrap(err, "listing preview environments")
+	}
+
+	return writeFormatted(out, inspectFormat, previews)
+}
+
+// writeFormatted writes v as indented json or executes a go-template against it.
+func writeFormatted(out io.Writer, format string, v interface{}) error {
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	tmpl, err := template.New("format").Parse(format)
+	if err != nil {
+		return errors.Wrap(err, "parsing format template")
+	}
+	if err := tmpl.Execute(out, v); err != nil {
+		return errors.Wrap(err, "executing template")
+	}
+	return nil
+}
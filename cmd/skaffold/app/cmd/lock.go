@@ -0,0 +1,87 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdLock describes the CLI command to manage the lock file of base images.
+func NewCmdLock(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "A set of commands to pin the base images of Dockerfiles",
+	}
+
+	cmd.AddCommand(NewCmdLockUpdate(out))
+	return cmd
+}
+
+// NewCmdLockUpdate describes the CLI command to resolve the digests of base images.
+func NewCmdLockUpdate(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Records the current digests of the base images in the lock file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return lockUpdate(out, filename)
+		},
+	}
+	cmd.Flags().StringArrayVarP(&opts.Profiles, "profile", "p", nil, "Activate profiles by name, later ones taking precedence")
+	return cmd
+}
+
+func lockUpdate(out io.Writer, filename string) error {
+	config, err := readConfiguration(filename)
+	if err != nil {
+		return errors.Wrap(err, "reading configuration")
+	}
+
+	lockFile := config.Build.LockFile
+	if lockFile == "" {
+		lockFile = constants.DefaultLockFile
+	}
+
+	lock, err := docker.UpdateLockFile(config.Build.Artifacts, docker.RemoteDigest)
+	if err != nil {
+		return errors.Wrap(err, "resolving base images")
+	}
+
+	var images []string
+	for image := range lock.Images {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	for _, image := range images {
+		fmt.Fprintf(out, "%s -> %s\n", image, lock.Images[image])
+	}
+
+	if err := lock.Write(lockFile); err != nil {
+		return errors.Wrapf(err, "writing %s", lockFile)
+	}
+	if config.Build.LockFile == "" {
+		fmt.Fprintf(out, "Set build.lockFile to %s to build with the pinned images\n", lockFile)
+	}
+	return nil
+}
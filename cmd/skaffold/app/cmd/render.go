@@ -0,0 +1,60 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderOutput      string
+	addSkaffoldLabels bool
+)
+
+// NewCmdRender describes the CLI command to render the manifests that would be deployed.
+func NewCmdRender(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Builds the artifacts and prints the manifests that would be deployed, without deploying them",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return render(out, filename)
+		},
+	}
+	AddRunDevFlags(cmd)
+	cmd.Flags().StringVarP(&opts.CustomTag, "tag", "t", "", "The optional custom tag to use for images which overrides the current Tagger configuration")
+	cmd.Flags().StringVarP(&renderOutput, "output", "o", "", "Write the manifests to a directory, one file per resource, instead of printing them")
+	cmd.Flags().BoolVar(&addSkaffoldLabels, "add-skaffold-labels", true, "Add the run id and managed-by labels, which change on every run, to the manifests")
+	return cmd
+}
+
+func render(out io.Writer, filename string) error {
+	ctx := context.Background()
+	opts.SkipRunLabels = !addSkaffoldLabels
+
+	// The build logs are kept apart from the manifests.
+	runner, err := NewRunner(os.Stderr, filename)
+	if err != nil {
+		return err
+	}
+
+	return runner.Render(ctx, out, renderOutput)
+}
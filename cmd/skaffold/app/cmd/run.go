@@ -18,8 +18,12 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"io"
 
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/preview"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -36,12 +40,31 @@ func NewCmdRun(out io.Writer) *cobra.Command {
 	AddRunDevFlags(cmd)
 
 	cmd.Flags().StringVarP(&opts.CustomTag, "tag", "t", "", "The optional custom tag to use for images which overrides the current Tagger configuration")
+	cmd.Flags().BoolVar(&opts.Tail, "tail", false, "Stream the logs of the deployed pods until interrupted")
+	cmd.Flags().BoolVar(&runPreview, "preview", false, "Deploy a preview environment of the current git branch to its own namespace. Manifests can't set metadata.namespace")
 	return cmd
 }
 
+var runPreview bool
+
 func run(out io.Writer, filename string) error {
 	ctx := context.Background()
 
+	if runPreview {
+		branch, err := preview.CurrentBranch(".")
+		if err != nil {
+			return errors.Wrap(err, "finding the branch to preview")
+		}
+		if err := createPreview(out, branch); err != nil {
+			return err
+		}
+		opts.PreviewBranch = branch
+	}
+
+	if err := serveEventAPI(out); err != nil {
+		return err
+	}
+
 	runner, err := NewRunner(out, filename)
 	if err != nil {
 		return err
@@ -49,3 +72,17 @@ func run(out io.Writer, filename string) error {
 
 	return runner.Run(ctx)
 }
+
+// createPreview creates the namespace of a branch's preview environment.
+func createPreview(out io.Writer, branch string) error {
+	client, err := kubernetes.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+	if err := preview.CreateNamespace(client, branch); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Deploying the preview environment of %s to namespace %s\n", branch, preview.Namespace(branch))
+	return nil
+}
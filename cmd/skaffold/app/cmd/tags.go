@@ -0,0 +1,66 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/inspect"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/runner"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const defaultTagsFormat = `{{range .}}{{.ImageName}} -> {{.Tag}}
+{{end}}`
+
+var tagsFormat string
+
+// NewCmdTags describes the CLI command to preview the tags of the artifacts.
+func NewCmdTags(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tags",
+		Short: "Prints the tags that the artifacts would be given, without building them",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tags(out, filename)
+		},
+	}
+	cmd.Flags().StringArrayVarP(&opts.Profiles, "profile", "p", nil, "Activate profiles by name, later ones taking precedence")
+	cmd.Flags().StringVarP(&opts.CustomTag, "tag", "t", "", "The optional custom tag to use for images which overrides the current Tagger configuration")
+	cmd.Flags().StringVar(&tagsFormat, "format", defaultTagsFormat, "Output format: json or a go-template")
+	return cmd
+}
+
+func tags(out io.Writer, filename string) error {
+	config, err := readConfiguration(filename)
+	if err != nil {
+		return errors.Wrap(err, "reading configuration")
+	}
+
+	tagger, err := runner.NewTagger(config.Build.TagPolicy, opts.CustomTag, config.Build.Artifacts)
+	if err != nil {
+		return errors.Wrap(err, "parsing skaffold tag config")
+	}
+
+	infos, err := inspect.Tags(config.Build.Artifacts, tagger)
+	if err != nil {
+		return errors.Wrap(err, "generating tags")
+	}
+
+	return writeFormatted(out, tagsFormat, infos)
+}
@@ -20,9 +20,14 @@ import (
 	"os"
 
 	"github.com/GoogleContainerTools/skaffold/cmd/skaffold/app/cmd"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/warnings"
 )
 
 func Run() error {
+	// Warnings are summed up on stderr, where they can't be mixed with
+	// the output of commands like fix or tags.
+	defer warnings.Default.PrintSummary(os.Stderr)
+
 	c := cmd.NewSkaffoldCommand(os.Stdout, os.Stderr)
 	return c.Execute()
 }
@@ -28,8 +28,12 @@ import (
 
 type BazelDependencyResolver struct{}
 
-const sourceQuery = "kind('source file', deps('%s'))"
+// sourceQuery lists the source files of a target and the BUILD and .bzl
+// files that define it and its dependencies.
+const sourceQuery = "kind('source file', deps('%[1]s')) union buildfiles(deps('%[1]s'))"
 
+// GetDependencies queries bazel for the inputs of the target, so that only
+// changes to actual inputs trigger a rebuild.
 func (*BazelDependencyResolver) GetDependencies(a *v1alpha2.Artifact) ([]string, error) {
 	cmd := exec.Command("bazel", "query", fmt.Sprintf(sourceQuery, a.BazelArtifact.BuildTarget), "--noimplicit_deps", "--order_output=no")
 	cmd.Dir = a.Workspace
@@ -18,8 +18,30 @@ package bazel
 
 import (
 	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
 )
 
+func TestGetDependencies(t *testing.T) {
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = testutil.NewFakeCmdOut(
+		"bazel query kind('source file', deps('//:app.tar')) union buildfiles(deps('//:app.tar')) --noimplicit_deps --order_output=no",
+		"//:BUILD\n//:main.go\n//lib:lib.go\n@io_bazel_rules_go//go:def.bzl\n//external:go_sdk\n",
+		nil,
+	)
+
+	deps, err := (&BazelDependencyResolver{}).GetDependencies(&v1alpha2.Artifact{
+		Workspace: ".",
+		ArtifactType: v1alpha2.ArtifactType{
+			BazelArtifact: &v1alpha2.BazelArtifact{BuildTarget: "//:app.tar"},
+		},
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"BUILD", "main.go", "lib/lib.go"}, deps)
+}
+
 func TestDepToPath(t *testing.T) {
 	var tests = []struct {
 		description string
@@ -27,15 +27,16 @@ import (
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/pkg/errors"
 )
 
 func (l *LocalBuilder) buildBazel(ctx context.Context, out io.Writer, a *v1alpha2.Artifact) (string, error) {
-	cmd := exec.Command("bazel", "build", a.BazelArtifact.BuildTarget)
+	cmd := exec.CommandContext(ctx, "bazel", bazelArgs(a.BazelArtifact)...)
 	cmd.Dir = a.Workspace
 	cmd.Stdout = out
 	cmd.Stderr = out
-	if err := cmd.Run(); err != nil {
+	if err := util.RunCmd(cmd); err != nil {
 		return "", errors.Wrap(err, "running command")
 	}
 
@@ -63,3 +64,14 @@ func (l *LocalBuilder) buildBazel(ctx context.Context, out io.Writer, a *v1alpha
 
 	return fmt.Sprintf("bazel:%s", imageTag), nil
 }
+
+// bazelArgs returns the arguments of `bazel build`. Configs come first so
+// that explicit args can override the flags they set.
+func bazelArgs(a *v1alpha2.BazelArtifact) []string {
+	args := []string{"build"}
+	for _, config := range a.Configs {
+		args = append(args, fmt.Sprintf("--config=%s", config))
+	}
+	args = append(args, a.BuildArgs...)
+	return append(args, a.BuildTarget)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestBazelArgs(t *testing.T) {
+	var tests = []struct {
+		description string
+		artifact    *v1alpha2.BazelArtifact
+		expected    []string
+	}{
+		{
+			description: "target only",
+			artifact:    &v1alpha2.BazelArtifact{BuildTarget: "//:app.tar"},
+			expected:    []string{"build", "//:app.tar"},
+		},
+		{
+			description: "configs and args",
+			artifact: &v1alpha2.BazelArtifact{
+				BuildTarget: "//:app.tar",
+				BuildArgs:   []string{"--compilation_mode=dbg"},
+				Configs:     []string{"remote", "ci"},
+			},
+			expected: []string{"build", "--config=remote", "--config=ci", "--compilation_mode=dbg", "//:app.tar"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, bazelArgs(test.artifact))
+		})
+	}
+}
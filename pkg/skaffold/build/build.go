@@ -36,6 +36,17 @@ type Build struct {
 	Artifact  *v1alpha2.Artifact // The artifact used in the build.
 }
 
+// PartialBuildError is returned when some artifacts failed to build but those
+// that were built can still be deployed.
+type PartialBuildError struct {
+	Result *BuildResult
+	Err    error
+}
+
+func (e *PartialBuildError) Error() string {
+	return e.Err.Error()
+}
+
 // Builder is an interface to the Build API of Skaffold.
 // It must build and make the resulting image accesible to the cluster.
 // This could include pushing to a authorized repository or loading the nodes with the image.
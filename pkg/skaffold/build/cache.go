@@ -0,0 +1,216 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ArtifactCache maps each image to the hash of its inputs and the tag
+// it was last built with.
+type ArtifactCache map[string]CachedArtifact
+
+// CachedArtifact is a previously built artifact.
+type CachedArtifact struct {
+	Hash      string `yaml:"hash"`
+	TagPolicy string `yaml:"tagPolicy,omitempty"`
+	Tag       string `yaml:"tag"`
+}
+
+// cachingBuilder skips artifacts whose inputs didn't change since they were last built.
+type cachingBuilder struct {
+	Builder
+
+	cacheFile   string
+	cache       ArtifactCache
+	tagPolicy   string
+	imageDigest func(string) (string, error)
+}
+
+// WithCache wraps a Builder so that built tags are persisted to the given file
+// and reused until an artifact's dependencies or the tag policy change.
+// A cached tag is only reused if imageDigest can still find its image.
+func WithCache(builder Builder, cacheFile, tagPolicy string, imageDigest func(string) (string, error)) (Builder, error) {
+	path, err := homedir.Expand(cacheFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "expanding %s", cacheFile)
+	}
+
+	cache, err := readCache(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cachingBuilder{
+		Builder:     builder,
+		cacheFile:   path,
+		cache:       cache,
+		tagPolicy:   tagPolicy,
+		imageDigest: imageDigest,
+	}, nil
+}
+
+// Build only builds the artifacts that aren't in the cache.
+func (b *cachingBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) (*BuildResult, error) {
+	hashes := map[string]string{}
+	cached := map[string]string{}
+	var needed []*v1alpha2.Artifact
+
+	for _, a := range artifacts {
+		hash, err := InputDigest(a)
+		if err != nil {
+			return nil, errors.Wrapf(err, "hashing dependencies of %s", a.ImageName)
+		}
+		hashes[a.ImageName] = hash
+
+		if entry, present := b.cache[a.ImageName]; present && entry.Hash == hash && entry.TagPolicy == b.tagPolicy {
+			_, err := b.imageDigest(entry.Tag)
+			if err == nil {
+				fmt.Fprintf(out, "Found %s in cache, skipping build\n", entry.Tag)
+				cached[a.ImageName] = entry.Tag
+				continue
+			}
+			logrus.Debugf("Image %s of the cache not found: %s", entry.Tag, err)
+		}
+		needed = append(needed, a)
+	}
+
+	built := map[string]Build{}
+	if len(needed) > 0 {
+		bRes, err := b.Builder.Build(ctx, out, tagger, needed)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, build := range bRes.Builds {
+			built[build.ImageName] = build
+			b.cache[build.ImageName] = CachedArtifact{
+				Hash:      hashes[build.ImageName],
+				TagPolicy: b.tagPolicy,
+				Tag:       build.Tag,
+			}
+		}
+
+		if err := writeCache(b.cacheFile, b.cache); err != nil {
+			logrus.Warnf("Unable to save build cache: %s", err)
+		}
+	}
+
+	res := &BuildResult{}
+	for _, a := range artifacts {
+		if tag, present := cached[a.ImageName]; present {
+			res.Builds = append(res.Builds, Build{
+				ImageName: a.ImageName,
+				Tag:       tag,
+				Artifact:  a,
+			})
+		} else if build, present := built[a.ImageName]; present {
+			res.Builds = append(res.Builds, build)
+		}
+	}
+
+	return res, nil
+}
+
+// InputDigest computes a hash of the artifact's configuration and of the
+// content of all its dependencies. It doesn't depend on where the workspace
+// is, so that the same sources give the same digest on every machine.
+// Dependencies are relative to the workspace.
+func InputDigest(a *v1alpha2.Artifact) (string, error) {
+	h := sha256.New()
+
+	relocatable := *a
+	relocatable.Workspace = ""
+	config, err := json.Marshal(relocatable)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling artifact")
+	}
+	h.Write(config)
+
+	deps, err := GetDependenciesForArtifact(a)
+	if err != nil {
+		return "", errors.Wrap(err, "getting dependencies")
+	}
+	sort.Strings(deps)
+
+	for _, dep := range deps {
+		path := filepath.Join(a.Workspace, dep)
+		if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return "", errors.Wrapf(err, "opening %s", path)
+		}
+
+		h.Write([]byte(filepath.ToSlash(dep)))
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", errors.Wrapf(err, "reading %s", path)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func readCache(path string) (ArtifactCache, error) {
+	cache := ArtifactCache{}
+
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading cache file %s", path)
+	}
+
+	if err := yaml.Unmarshal(contents, &cache); err != nil {
+		logrus.Warnf("Ignoring invalid cache file %s: %s", path, err)
+		return ArtifactCache{}, nil
+	}
+	return cache, nil
+}
+
+func writeCache(path string, cache ArtifactCache) error {
+	contents, err := yaml.Marshal(cache)
+	if err != nil {
+		return errors.Wrap(err, "marshalling cache")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "creating cache directory")
+	}
+	return ioutil.WriteFile(path, contents, 0644)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestWithCache(t *testing.T) {
+	dir, teardown := testutil.TempDir(t)
+	defer teardown()
+
+	dep := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(dep, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(r DependencyResolver) { DefaultDockerfileDepResolver = r }(DefaultDockerfileDepResolver)
+	DefaultDockerfileDepResolver = &FakeDependencyResolver{deps: []string{"main.go"}}
+
+	cacheFile := filepath.Join(dir, "cache")
+	app := dockerArtifact("gcr.io/project/app")
+	app.Workspace = dir
+	other := dockerArtifact("gcr.io/project/other")
+	other.Workspace = dir
+	artifacts := []*v1alpha2.Artifact{app, other}
+
+	tagPolicy := "sha256"
+	deleted := map[string]bool{}
+	imageDigest := func(ref string) (string, error) {
+		if deleted[ref] {
+			return "", fmt.Errorf("not found")
+		}
+		return "sha256:abacab", nil
+	}
+
+	build := func() *recordingBuilder {
+		recorder := &recordingBuilder{buildArgs: map[string]map[string]string{}}
+		builder, err := WithCache(recorder, cacheFile, tagPolicy, imageDigest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := builder.Build(context.Background(), ioutil.Discard, nil, artifacts)
+		testutil.CheckErrorAndDeepEqual(t, false, err, []Build{
+			{ImageName: "gcr.io/project/app", Tag: "gcr.io/project/app:tag", Artifact: app},
+			{ImageName: "gcr.io/project/other", Tag: "gcr.io/project/other:tag", Artifact: other},
+		}, res.Builds)
+		return recorder
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, [][]string{{"gcr.io/project/app", "gcr.io/project/other"}}, build().calls)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(build().calls))
+
+	app.DockerArtifact.BuildArgs = map[string]*string{"key": &dep}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, [][]string{{"gcr.io/project/app"}}, build().calls)
+
+	if err := ioutil.WriteFile(dep, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, [][]string{{"gcr.io/project/app", "gcr.io/project/other"}}, build().calls)
+
+	// The image was deleted since it was built.
+	deleted["gcr.io/project/other:tag"] = true
+	testutil.CheckErrorAndDeepEqual(t, false, nil, [][]string{{"gcr.io/project/other"}}, build().calls)
+	deleted = map[string]bool{}
+
+	tagPolicy = "gitCommit"
+	testutil.CheckErrorAndDeepEqual(t, false, nil, [][]string{{"gcr.io/project/app", "gcr.io/project/other"}}, build().calls)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(build().calls))
+}
+
+func TestInputDigestIsRelocatable(t *testing.T) {
+	defer func(r DependencyResolver) { DefaultDockerfileDepResolver = r }(DefaultDockerfileDepResolver)
+
+	digest := func(content string) string {
+		workspace, teardown := testutil.TempDir(t)
+		defer teardown()
+
+		if err := ioutil.WriteFile(filepath.Join(workspace, "main.go"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		DefaultDockerfileDepResolver = &FakeDependencyResolver{deps: []string{"main.go"}}
+
+		artifact := dockerArtifact("gcr.io/project/app")
+		artifact.Workspace = workspace
+
+		digest, err := InputDigest(artifact)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return digest
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, digest("v1"), digest("v1"))
+	if digest("v1") == digest("v2") {
+		t.Error("digest should depend on the content of the dependencies")
+	}
+}
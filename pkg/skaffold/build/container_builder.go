@@ -21,9 +21,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"time"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/version"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/warnings"
 
 	cstorage "cloud.google.com/go/storage"
 	"golang.org/x/oauth2/google"
@@ -34,6 +36,7 @@ import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/features"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/pkg/errors"
@@ -71,13 +74,27 @@ const (
 
 type GoogleCloudBuilder struct {
 	*v1alpha2.BuildConfig
+
+	retry *docker.RetryPolicy
 }
 
 func NewGoogleCloudBuilder(cfg *v1alpha2.BuildConfig) (*GoogleCloudBuilder, error) {
-	return &GoogleCloudBuilder{cfg}, nil
+	retry, err := docker.NewRetryPolicy(cfg.Retry)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading retry policy")
+	}
+
+	return &GoogleCloudBuilder{
+		BuildConfig: cfg,
+		retry:       retry,
+	}, nil
 }
 
 func (cb *GoogleCloudBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) (*BuildResult, error) {
+	if err := checkPlatforms("Google Cloud Build", artifacts); err != nil {
+		return nil, err
+	}
+
 	client, err := google.DefaultClient(ctx, cloudbuild.CloudPlatformScope)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting google client")
@@ -92,31 +109,22 @@ func (cb *GoogleCloudBuilder) Build(ctx context.Context, out io.Writer, tagger t
 		return nil, errors.Wrap(err, "getting cloud storage client")
 	}
 	defer c.Close()
-	builds := []Build{}
-	for _, artifact := range artifacts {
-		build, err := cb.buildArtifact(ctx, out, tagger, cbclient, c, artifact)
-		if err != nil {
-			return nil, errors.Wrapf(err, "building artifact %s", artifact.ImageName)
-		}
-		builds = append(builds, *build)
+
+	buildArtifact := func(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact) (*Build, error) {
+		return cb.buildArtifact(ctx, out, tagger, cbclient, c, artifact)
 	}
 
-	return &BuildResult{
-		Builds: builds,
-	}, nil
+	if cb.Concurrency > 1 && len(artifacts) > 1 && features.ParallelBuilds.Enabled() {
+		return inParallel(ctx, out, artifacts, cb.Concurrency, !cb.ContinueOnError, buildArtifact, nil)
+	}
+	return inSequence(ctx, out, artifacts, buildArtifact)
 }
 
 func (cb *GoogleCloudBuilder) buildArtifact(ctx context.Context, out io.Writer, tagger tag.Tagger, cbclient *cloudbuild.Service, c *cstorage.Client, artifact *v1alpha2.Artifact) (*Build, error) {
 	logrus.Infof("Building artifact: %+v", artifact)
-
-	// need to format build args as strings to pass to container builder docker
-	var buildArgs []string
-	for k, v := range artifact.DockerArtifact.BuildArgs {
-		if v != nil {
-			buildArgs = append(buildArgs, []string{"--build-arg", fmt.Sprintf("%s=%s", k, *v)}...)
-		}
+	if artifact.DockerArtifact == nil {
+		return nil, fmt.Errorf("unsupported artifact type for Google Cloud Build: %s only builds docker artifacts", artifact.ImageName)
 	}
-	logrus.Debugf("Build args: %s", buildArgs)
 
 	cbBucket := fmt.Sprintf("%s%s", cb.GoogleCloudBuild.ProjectID, constants.GCSBucketSuffix)
 	buildObject := fmt.Sprintf("source/%s-%s.tar.gz", cb.GoogleCloudBuild.ProjectID, util.RandomID())
@@ -133,25 +141,12 @@ func (cb *GoogleCloudBuilder) buildArtifact(ctx context.Context, out io.Writer,
 		return nil, errors.Wrap(err, "uploading source tarball")
 	}
 
-	args := append([]string{"build", "--tag", artifact.ImageName, "-f", artifact.DockerArtifact.DockerfilePath}, buildArgs...)
-	args = append(args, ".")
-	call := cbclient.Projects.Builds.Create(cb.GoogleCloudBuild.ProjectID, &cloudbuild.Build{
-		LogsBucket: cbBucket,
-		Source: &cloudbuild.Source{
-			StorageSource: &cloudbuild.StorageSource{
-				Bucket: cbBucket,
-				Object: buildObject,
-			},
-		},
-		Steps: []*cloudbuild.BuildStep{
-			{
-				Name: "gcr.io/cloud-builders/docker",
-				Args: args,
-			},
-		},
-		Images: []string{artifact.ImageName},
-	})
-	op, err := call.Context(ctx).Do()
+	build := cb.buildSpec(artifact, cbBucket, buildObject)
+	logrus.Debugf("Build steps: %+v", build.Steps[0])
+	if err := setBuildOptions(build, cb.GoogleCloudBuild); err != nil {
+		return nil, errors.Wrap(err, "setting build options")
+	}
+	op, err := cbclient.Projects.Builds.Create(cb.GoogleCloudBuild.ProjectID, build).Context(ctx).Do()
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create build")
 	}
@@ -187,7 +182,7 @@ watch:
 		switch b.Status {
 		case StatusQueued, StatusWorking, StatusUnknown:
 		case StatusSuccess:
-			imageID, err = getImageID(b)
+			imageID, err = cb.getImageID(ctx, b, artifact.ImageName)
 			if err != nil {
 				return nil, errors.Wrap(err, "getting image id from finished build")
 			}
@@ -217,7 +212,9 @@ watch:
 		return nil, errors.Wrap(err, "generating tag")
 	}
 
-	if err := docker.AddTag(builtTag, newTag); err != nil {
+	if err := cb.retry.Do(ctx, "tagging "+newTag, func() error {
+		return docker.AddTag(builtTag, newTag)
+	}); err != nil {
 		return nil, errors.Wrap(err, "tagging image")
 	}
 
@@ -242,7 +239,18 @@ func getBuildID(op *cloudbuild.Operation) (string, error) {
 	return buildMeta.Build.Id, nil
 }
 
-func getImageID(b *cloudbuild.Build) (string, error) {
+// getImageID returns the digest of the built image. Kaniko pushes the image
+// itself so its digest isn't part of the build results.
+func (cb *GoogleCloudBuilder) getImageID(ctx context.Context, b *cloudbuild.Build, imageName string) (string, error) {
+	if cb.GoogleCloudBuild.KanikoImage != "" {
+		var digest string
+		err := cb.retry.Do(ctx, "getting digest of "+imageName, func() error {
+			var err error
+			digest, err = docker.RemoteDigest(imageName)
+			return err
+		})
+		return digest, err
+	}
 	if b.Results == nil || len(b.Results.Images) == 0 {
 		return "", errors.New("build failed")
 	}
@@ -325,3 +333,132 @@ func (cb *GoogleCloudBuilder) createBucketIfNotExists(ctx context.Context, bucke
 	logrus.Debugf("Created bucket %s in %s", bucket, cb.GoogleCloudBuild.ProjectID)
 	return nil
 }
+
+// buildSpec describes the cloud build of an artifact whose sources were
+// uploaded to gs://bucket/object. The image is built either with docker, and
+// pushed by Cloud Build, or with kaniko, which pushes it directly.
+func (cb *GoogleCloudBuilder) buildSpec(artifact *v1alpha2.Artifact, bucket, object string) *cloudbuild.Build {
+	build := &cloudbuild.Build{
+		LogsBucket: bucket,
+		Source: &cloudbuild.Source{
+			StorageSource: &cloudbuild.StorageSource{
+				Bucket: bucket,
+				Object: object,
+			},
+		},
+	}
+
+	if cb.GoogleCloudBuild.KanikoImage != "" {
+		build.Steps = []*cloudbuild.BuildStep{
+			{
+				Name: cb.GoogleCloudBuild.KanikoImage,
+				Args: kanikoBuildArgs(artifact.ImageName, artifact.DockerArtifact),
+			},
+		}
+		return build
+	}
+
+	dockerImage := cb.GoogleCloudBuild.DockerImage
+	if dockerImage == "" {
+		dockerImage = constants.DefaultCloudBuildDockerImage
+	}
+	// Cache images are pulled on a best effort basis, like for local builds.
+	for _, image := range artifact.DockerArtifact.CacheFrom {
+		build.Steps = append(build.Steps, &cloudbuild.BuildStep{
+			Name:       dockerImage,
+			Entrypoint: "bash",
+			Args:       []string{"-c", fmt.Sprintf("docker pull %s || true", image)},
+		})
+	}
+	build.Steps = append(build.Steps, &cloudbuild.BuildStep{
+		Name: dockerImage,
+		Args: dockerBuildArgs(artifact.ImageName, artifact.DockerArtifact),
+	})
+	build.Images = []string{artifact.ImageName}
+	return build
+}
+
+// dockerBuildArgs returns the arguments of the docker build step.
+func dockerBuildArgs(imageName string, artifact *v1alpha2.DockerArtifact) []string {
+	args := []string{"build", "--tag", imageName, "-f", artifact.DockerfilePath}
+	for _, buildArg := range sortedBuildArgs(artifact.BuildArgs) {
+		args = append(args, "--build-arg", buildArg)
+	}
+	if artifact.Target != "" {
+		args = append(args, "--target", artifact.Target)
+	}
+	if artifact.Network != "" {
+		args = append(args, "--network", artifact.Network)
+	}
+	for _, image := range artifact.CacheFrom {
+		args = append(args, "--cache-from", image)
+	}
+	if docker.RequiresBuildKit(artifact) {
+		warnings.Warnf(warnings.UnsupportedOption, "Ignoring secrets and ssh: BuildKit isn't available on Google Cloud Build")
+	}
+
+	return append(args, ".")
+}
+
+// kanikoBuildArgs returns the arguments of the kaniko build step. The sources
+// are extracted in the step's working directory, which kaniko uses as context.
+func kanikoBuildArgs(imageName string, artifact *v1alpha2.DockerArtifact) []string {
+	args := []string{"--destination", imageName, "--dockerfile", artifact.DockerfilePath}
+	for _, buildArg := range sortedBuildArgs(artifact.BuildArgs) {
+		args = append(args, "--build-arg", buildArg)
+	}
+	if artifact.Target != "" {
+		args = append(args, "--target", artifact.Target)
+	}
+	if artifact.Network != "" {
+		warnings.Warnf(warnings.UnsupportedOption, "Ignoring network %s: not supported by kaniko", artifact.Network)
+	}
+	if len(artifact.CacheFrom) > 0 {
+		warnings.Warnf(warnings.UnsupportedOption, "Ignoring cacheFrom images: not supported by kaniko")
+	}
+	if docker.RequiresBuildKit(artifact) {
+		warnings.Warnf(warnings.UnsupportedOption, "Ignoring secrets and ssh: not supported by kaniko")
+	}
+
+	return args
+}
+
+// sortedBuildArgs formats build args as KEY=VALUE, sorted so that the same
+// artifact always produces the same build steps.
+func sortedBuildArgs(buildArgs map[string]*string) []string {
+	var keys []string
+	for k := range buildArgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var formatted []string
+	for _, k := range keys {
+		if v := buildArgs[k]; v != nil {
+			formatted = append(formatted, fmt.Sprintf("%s=%s", k, *v))
+		} else {
+			warnings.Warnf(warnings.UnsupportedOption, "Ignoring build arg %s without a value: not supported by Google Cloud Build", k)
+		}
+	}
+	return formatted
+}
+
+// setBuildOptions sets the worker and the timeout of a build.
+func setBuildOptions(build *cloudbuild.Build, cfg *v1alpha2.GoogleCloudBuild) error {
+	if cfg.MachineType != "" || cfg.DiskSizeGb > 0 {
+		build.Options = &cloudbuild.BuildOptions{
+			MachineType: cfg.MachineType,
+			DiskSizeGb:  cfg.DiskSizeGb,
+		}
+	}
+
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return errors.Wrapf(err, "parsing timeout %s", cfg.Timeout)
+		}
+		build.Timeout = fmt.Sprintf("%.0fs", timeout.Seconds())
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	cloudbuild "google.golang.org/api/cloudbuild/v1"
+)
+
+func TestSetBuildOptions(t *testing.T) {
+	var tests = []struct {
+		description string
+		cfg         *v1alpha2.GoogleCloudBuild
+		expected    *cloudbuild.Build
+		shouldErr   bool
+	}{
+		{
+			description: "defaults",
+			cfg:         &v1alpha2.GoogleCloudBuild{ProjectID: "project"},
+			expected:    &cloudbuild.Build{},
+		},
+		{
+			description: "worker and timeout",
+			cfg: &v1alpha2.GoogleCloudBuild{
+				ProjectID:   "project",
+				MachineType: "N1_HIGHCPU_8",
+				DiskSizeGb:  200,
+				Timeout:     "20m",
+			},
+			expected: &cloudbuild.Build{
+				Options: &cloudbuild.BuildOptions{
+					MachineType: "N1_HIGHCPU_8",
+					DiskSizeGb:  200,
+				},
+				Timeout: "1200s",
+			},
+		},
+		{
+			description: "invalid timeout",
+			cfg:         &v1alpha2.GoogleCloudBuild{Timeout: "forever"},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			build := &cloudbuild.Build{}
+			err := setBuildOptions(build, test.cfg)
+
+			if test.shouldErr {
+				testutil.CheckError(t, true, err)
+				return
+			}
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, build)
+		})
+	}
+}
+
+func TestDockerBuildArgs(t *testing.T) {
+	var tests = []struct {
+		description string
+		artifact    *v1alpha2.DockerArtifact
+		expected    []string
+	}{
+		{
+			description: "default",
+			artifact:    &v1alpha2.DockerArtifact{DockerfilePath: "Dockerfile"},
+			expected:    []string{"build", "--tag", "gcr.io/image", "-f", "Dockerfile", "."},
+		},
+		{
+			description: "build args, dockerfile, target and network",
+			artifact: &v1alpha2.DockerArtifact{
+				DockerfilePath: "docker/Dockerfile.prod",
+				BuildArgs: map[string]*string{
+					"VERSION": util.StringPtr("1.0"),
+					"ENV":     util.StringPtr("prod"),
+					"EMPTY":   nil,
+				},
+				Target:  "release",
+				Network: "host",
+			},
+			expected: []string{"build", "--tag", "gcr.io/image", "-f", "docker/Dockerfile.prod",
+				"--build-arg", "ENV=prod", "--build-arg", "VERSION=1.0", "--target", "release", "--network", "host", "."},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			args := dockerBuildArgs("gcr.io/image", test.artifact)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, args)
+		})
+	}
+}
+
+func TestBuildSpec(t *testing.T) {
+	artifact := &v1alpha2.Artifact{
+		ImageName: "gcr.io/image",
+		ArtifactType: v1alpha2.ArtifactType{
+			DockerArtifact: &v1alpha2.DockerArtifact{
+				DockerfilePath: "Dockerfile",
+				BuildArgs:      map[string]*string{"VERSION": util.StringPtr("1.0")},
+			},
+		},
+	}
+
+	var tests = []struct {
+		description    string
+		cfg            *v1alpha2.GoogleCloudBuild
+		cacheFrom      []string
+		expectedSteps  []*cloudbuild.BuildStep
+		expectedImages []string
+	}{
+		{
+			description: "docker",
+			cfg:         &v1alpha2.GoogleCloudBuild{},
+			expectedSteps: []*cloudbuild.BuildStep{{
+				Name: "gcr.io/cloud-builders/docker",
+				Args: []string{"build", "--tag", "gcr.io/image", "-f", "Dockerfile", "--build-arg", "VERSION=1.0", "."},
+			}},
+			expectedImages: []string{"gcr.io/image"},
+		},
+		{
+			description: "custom docker image",
+			cfg:         &v1alpha2.GoogleCloudBuild{DockerImage: "gcr.io/my-builders/docker"},
+			expectedSteps: []*cloudbuild.BuildStep{{
+				Name: "gcr.io/my-builders/docker",
+				Args: []string{"build", "--tag", "gcr.io/image", "-f", "Dockerfile", "--build-arg", "VERSION=1.0", "."},
+			}},
+			expectedImages: []string{"gcr.io/image"},
+		},
+		{
+			description: "cache from",
+			cfg:         &v1alpha2.GoogleCloudBuild{},
+			cacheFrom:   []string{"gcr.io/image:latest"},
+			expectedSteps: []*cloudbuild.BuildStep{
+				{
+					Name:       "gcr.io/cloud-builders/docker",
+					Entrypoint: "bash",
+					Args:       []string{"-c", "docker pull gcr.io/image:latest || true"},
+				},
+				{
+					Name: "gcr.io/cloud-builders/docker",
+					Args: []string{"build", "--tag", "gcr.io/image", "-f", "Dockerfile", "--build-arg", "VERSION=1.0", "--cache-from", "gcr.io/image:latest", "."},
+				},
+			},
+			expectedImages: []string{"gcr.io/image"},
+		},
+		{
+			description: "kaniko",
+			cfg:         &v1alpha2.GoogleCloudBuild{KanikoImage: "gcr.io/kaniko-project/executor"},
+			expectedSteps: []*cloudbuild.BuildStep{{
+				Name: "gcr.io/kaniko-project/executor",
+				Args: []string{"--destination", "gcr.io/image", "--dockerfile", "Dockerfile", "--build-arg", "VERSION=1.0"},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cb := &GoogleCloudBuilder{BuildConfig: &v1alpha2.BuildConfig{
+				BuildType: v1alpha2.BuildType{GoogleCloudBuild: test.cfg},
+			}}
+
+			artifact.DockerArtifact.CacheFrom = test.cacheFrom
+			build := cb.buildSpec(artifact, "bucket", "source.tar.gz")
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedSteps, build.Steps)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedImages, build.Images)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, "bucket", build.Source.StorageSource.Bucket)
+		})
+	}
+}
+
+func TestBuildUnsupportedArtifact(t *testing.T) {
+	cb := &GoogleCloudBuilder{
+		BuildConfig: &v1alpha2.BuildConfig{
+			BuildType: v1alpha2.BuildType{
+				GoogleCloudBuild: &v1alpha2.GoogleCloudBuild{ProjectID: "project"},
+			},
+		},
+	}
+	artifact := &v1alpha2.Artifact{
+		ImageName: "gcr.io/test/app",
+		ArtifactType: v1alpha2.ArtifactType{
+			S2IArtifact: &v1alpha2.S2IArtifact{BuilderImage: "builder"},
+		},
+	}
+
+	_, err := cb.buildArtifact(context.Background(), ioutil.Discard, nil, nil, nil, artifact)
+
+	testutil.CheckError(t, true, err)
+}
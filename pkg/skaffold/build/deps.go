@@ -18,12 +18,14 @@ package build
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/bazel"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/s2i"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -50,6 +52,8 @@ func (d *DependencyMap) Paths() []string {
 	return allPaths
 }
 
+// ArtifactsForPaths returns the artifacts that depend on any of the paths,
+// in the order of the configuration.
 func (d *DependencyMap) ArtifactsForPaths(paths []string) []*v1alpha2.Artifact {
 	m := map[*v1alpha2.Artifact]struct{}{}
 	for _, p := range paths {
@@ -59,12 +63,28 @@ func (d *DependencyMap) ArtifactsForPaths(paths []string) []*v1alpha2.Artifact {
 		}
 	}
 	artifacts := []*v1alpha2.Artifact{}
-	for a := range m {
-		artifacts = append(artifacts, a)
+	for _, a := range d.artifacts {
+		if _, present := m[a]; present {
+			artifacts = append(artifacts, a)
+		}
 	}
 	return artifacts
 }
 
+// ChangedPaths filters the paths that are dependencies of an artifact.
+func (d *DependencyMap) ChangedPaths(a *v1alpha2.Artifact, paths []string) []string {
+	var changed []string
+	for _, p := range paths {
+		for _, dependent := range d.pathToArtifacts[p] {
+			if dependent == a {
+				changed = append(changed, p)
+				break
+			}
+		}
+	}
+	return changed
+}
+
 func NewDependencyMap(artifacts []*v1alpha2.Artifact) (*DependencyMap, error) {
 	m, err := pathToArtifactMap(artifacts)
 	if err != nil {
@@ -91,7 +111,14 @@ func pathToArtifactMap(artifacts []*v1alpha2.Artifact) (map[string][]*v1alpha2.A
 	for _, a := range artifacts {
 		paths, err := pathsForArtifact(a)
 		if err != nil {
-			return nil, errors.Wrapf(err, "getting paths for artifact %s", a.ImageName)
+			// A broken Dockerfile shouldn't stop the dev loop: watching it
+			// is enough to try again once it's fixed.
+			fallback := fallbackPaths(a)
+			if len(fallback) == 0 {
+				return nil, errors.Wrapf(err, "getting paths for artifact %s", a.ImageName)
+			}
+			logrus.Warnf("Only watching %s for artifact %s: %s", fallback, a.ImageName, err)
+			paths = fallback
 		}
 
 		for _, p := range paths {
@@ -102,6 +129,27 @@ func pathToArtifactMap(artifacts []*v1alpha2.Artifact) (map[string][]*v1alpha2.A
 	return m, nil
 }
 
+// fallbackPaths lists the files describing how an artifact is built, to
+// watch when its dependencies can't be resolved.
+func fallbackPaths(a *v1alpha2.Artifact) []string {
+	var paths []string
+	switch {
+	case a.DockerArtifact != nil:
+		paths = []string{a.DockerArtifact.DockerfilePath}
+	case a.BazelArtifact != nil:
+		paths = []string{"WORKSPACE", "BUILD", "BUILD.bazel"}
+	}
+
+	var existing []string
+	for _, p := range paths {
+		p = filepath.Join(a.Workspace, p)
+		if _, err := os.Stat(p); err == nil {
+			existing = append(existing, p)
+		}
+	}
+	return existing
+}
+
 func pathsForArtifact(a *v1alpha2.Artifact) ([]string, error) {
 	deps, err := GetDependenciesForArtifact(a)
 	if err != nil {
@@ -128,6 +176,7 @@ func pathsForArtifact(a *v1alpha2.Artifact) ([]string, error) {
 var (
 	DefaultDockerfileDepResolver DependencyResolver = &docker.DockerfileDepResolver{}
 	DefaultBazelDepResolver      DependencyResolver = &bazel.BazelDependencyResolver{}
+	DefaultS2IDepResolver        DependencyResolver = &s2i.S2IDependencyResolver{}
 )
 
 func GetDependenciesForArtifact(artifact *v1alpha2.Artifact) ([]string, error) {
@@ -137,6 +186,9 @@ func GetDependenciesForArtifact(artifact *v1alpha2.Artifact) ([]string, error) {
 	if artifact.BazelArtifact != nil {
 		return DefaultBazelDepResolver.GetDependencies(artifact)
 	}
+	if artifact.S2IArtifact != nil {
+		return DefaultS2IDepResolver.GetDependencies(artifact)
+	}
 
 	return nil, fmt.Errorf("undefined artifact type: %+v", artifact.ArtifactType)
 }
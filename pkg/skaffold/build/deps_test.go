@@ -16,6 +16,9 @@ limitations under the License.
 package build
 
 import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
@@ -24,10 +27,11 @@ import (
 
 type FakeDependencyResolver struct {
 	deps []string
+	err  error
 }
 
 func (f *FakeDependencyResolver) GetDependencies(a *v1alpha2.Artifact) ([]string, error) {
-	return f.deps, nil
+	return f.deps, f.err
 }
 
 func TestPaths(t *testing.T) {
@@ -79,3 +83,50 @@ func TestPaths(t *testing.T) {
 		})
 	}
 }
+
+func TestArtifactsForPaths(t *testing.T) {
+	defer func(r DependencyResolver) { DefaultDockerfileDepResolver = r }(DefaultDockerfileDepResolver)
+	DefaultDockerfileDepResolver = &FakeDependencyResolver{deps: []string{"Dockerfile", "main.go"}}
+
+	var artifacts []*v1alpha2.Artifact
+	for _, workspace := range []string{"web", "api", "worker", "db"} {
+		artifacts = append(artifacts, &v1alpha2.Artifact{
+			ImageName: workspace,
+			Workspace: workspace,
+			ArtifactType: v1alpha2.ArtifactType{
+				DockerArtifact: &v1alpha2.DockerArtifact{},
+			},
+		})
+	}
+
+	m, err := NewDependencyMap(artifacts)
+	testutil.CheckError(t, false, err)
+
+	changed := m.ArtifactsForPaths([]string{"db/main.go", "api/Dockerfile", "web/main.go", "unknown"})
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []*v1alpha2.Artifact{artifacts[0], artifacts[1], artifacts[3]}, changed)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"api/Dockerfile"}, m.ChangedPaths(artifacts[1], []string{"db/main.go", "api/Dockerfile"}))
+}
+
+func TestBrokenDependencies(t *testing.T) {
+	defer func(r DependencyResolver) { DefaultDockerfileDepResolver = r }(DefaultDockerfileDepResolver)
+	DefaultDockerfileDepResolver = &FakeDependencyResolver{err: fmt.Errorf("dockerfile parse error")}
+
+	tmp, teardown := testutil.TempDir(t)
+	defer teardown()
+	ioutil.WriteFile(filepath.Join(tmp, "Dockerfile"), []byte("FROM"), 0644)
+
+	artifact := func(dockerfile string) *v1alpha2.Artifact {
+		return &v1alpha2.Artifact{
+			Workspace: tmp,
+			ArtifactType: v1alpha2.ArtifactType{
+				DockerArtifact: &v1alpha2.DockerArtifact{DockerfilePath: dockerfile},
+			},
+		}
+	}
+
+	m, err := NewDependencyMap([]*v1alpha2.Artifact{artifact("Dockerfile")})
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{filepath.Join(tmp, "Dockerfile")}, m.Paths())
+
+	_, err = NewDependencyMap([]*v1alpha2.Artifact{artifact("missing/Dockerfile")})
+	testutil.CheckError(t, true, err)
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/sirupsen/logrus"
+)
+
+// existingImagesBuilder skips artifacts whose tag is already in the registry.
+type existingImagesBuilder struct {
+	Builder
+
+	remoteDigest func(string) (string, error)
+}
+
+// WithExistingImages wraps a Builder so that an artifact is not built when
+// the image it would be tagged with can already be found by remoteDigest.
+// This only works with taggers whose tags identify the content of an image,
+// like input digests or git tags of a clean tree: for the others, artifacts
+// are always built.
+func WithExistingImages(builder Builder, remoteDigest func(string) (string, error)) Builder {
+	return &existingImagesBuilder{
+		Builder:      builder,
+		remoteDigest: remoteDigest,
+	}
+}
+
+// Build only builds the artifacts that can't be found in the registry.
+func (b *existingImagesBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) (*BuildResult, error) {
+	existing := map[string]string{}
+	var needed []*v1alpha2.Artifact
+
+	for _, a := range artifacts {
+		if fqn, found := b.lookup(tagger, a); found {
+			fmt.Fprintf(out, "Found %s in the registry, skipping build\n", fqn)
+			existing[a.ImageName] = fqn
+			continue
+		}
+		needed = append(needed, a)
+	}
+
+	built := map[string]Build{}
+	if len(needed) > 0 {
+		bRes, err := b.Builder.Build(ctx, out, tagger, needed)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, build := range bRes.Builds {
+			built[build.ImageName] = build
+		}
+	}
+
+	res := &BuildResult{}
+	for _, a := range artifacts {
+		if fqn, present := existing[a.ImageName]; present {
+			res.Builds = append(res.Builds, Build{
+				ImageName: a.ImageName,
+				Tag:       fqn,
+				Artifact:  a,
+			})
+		} else if build, present := built[a.ImageName]; present {
+			res.Builds = append(res.Builds, build)
+		}
+	}
+
+	return res, nil
+}
+
+// lookup computes the tag of an artifact without building it and checks
+// whether the registry already has an image with that tag.
+func (b *existingImagesBuilder) lookup(tagger tag.Tagger, a *v1alpha2.Artifact) (string, bool) {
+	fqn, reproducible, err := tag.GenerateReproducible(tagger, a.Workspace, &tag.TagOptions{
+		ImageName: a.ImageName,
+	})
+	if err != nil {
+		logrus.Debugf("Unable to compute the tag of %s before building: %s", a.ImageName, err)
+		return "", false
+	}
+	if !reproducible {
+		logrus.Debugf("The tag of %s doesn't identify its content, building it", a.ImageName)
+		return "", false
+	}
+
+	if _, err := b.remoteDigest(fqn); err != nil {
+		logrus.Debugf("Image %s not found in the registry: %s", fqn, err)
+		return "", false
+	}
+
+	return fqn, true
+}
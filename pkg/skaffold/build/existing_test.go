@@ -0,0 +1,104 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestWithExistingImages(t *testing.T) {
+	digestTagger := tag.NewInputDigestTagger([]*v1alpha2.Artifact{
+		dockerArtifact("app1"),
+		dockerArtifact("app2"),
+	}, func(*v1alpha2.Artifact) (string, error) {
+		return "v1", nil
+	})
+	envTagger, err := tag.NewEnvTemplateTagger("{{.IMAGE_NAME}}:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		description   string
+		tagger        tag.Tagger
+		registry      map[string]bool
+		expectedCalls [][]string
+		expectedTags  []string
+	}{
+		{
+			description:  "all images exist",
+			tagger:       digestTagger,
+			registry:     map[string]bool{"app1:v1": true, "app2:v1": true},
+			expectedTags: []string{"app1:v1", "app2:v1"},
+		},
+		{
+			description:   "one image is missing",
+			tagger:        digestTagger,
+			registry:      map[string]bool{"app2:v1": true},
+			expectedCalls: [][]string{{"app1"}},
+			expectedTags:  []string{"app1:tag", "app2:v1"},
+		},
+		{
+			description:   "static tag",
+			tagger:        envTagger,
+			registry:      map[string]bool{"app1:v1": true, "app2:v1": true},
+			expectedCalls: [][]string{{"app1", "app2"}},
+			expectedTags:  []string{"app1:tag", "app2:tag"},
+		},
+		{
+			description:   "tag depends on the built image",
+			tagger:        &tag.ChecksumTagger{},
+			registry:      map[string]bool{"app1:v1": true, "app2:v1": true},
+			expectedCalls: [][]string{{"app1", "app2"}},
+			expectedTags:  []string{"app1:tag", "app2:tag"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			remoteDigest := func(image string) (string, error) {
+				if test.registry[image] {
+					return "sha256:abacab", nil
+				}
+				return "", fmt.Errorf("not found: %s", image)
+			}
+
+			recorder := &recordingBuilder{buildArgs: map[string]map[string]string{}}
+			builder := WithExistingImages(recorder, remoteDigest)
+			res, err := builder.Build(context.Background(), ioutil.Discard, test.tagger, []*v1alpha2.Artifact{
+				dockerArtifact("app1"),
+				dockerArtifact("app2"),
+			})
+
+			var tags []string
+			if res != nil {
+				for _, b := range res.Builds {
+					tags = append(tags, b.Tag)
+				}
+			}
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectedTags, tags)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedCalls, recorder.calls)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// HasHooks returns true if any artifact has hooks.
+func HasHooks(artifacts []*v1alpha2.Artifact) bool {
+	for _, a := range artifacts {
+		if a.Hooks != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hooksBuilder runs the hooks of artifacts around their build.
+type hooksBuilder struct {
+	Builder
+}
+
+// WithHooks wraps a Builder so that the before hooks of the artifacts run
+// before they're built and the after hooks once they're built.
+func WithHooks(builder Builder) Builder {
+	return &hooksBuilder{
+		Builder: builder,
+	}
+}
+
+// Build runs the before hooks, builds the artifacts then runs the after hooks.
+// A failing hook fails the build.
+func (b *hooksBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) (*BuildResult, error) {
+	byName := map[string]*v1alpha2.Artifact{}
+	for _, a := range artifacts {
+		byName[a.ImageName] = a
+		if a.Hooks == nil {
+			continue
+		}
+		if err := runHooks(ctx, out, a.Hooks.Before, a, ""); err != nil {
+			return nil, errors.Wrapf(err, "running before hook of %s", a.ImageName)
+		}
+	}
+
+	bRes, err := b.Builder.Build(ctx, out, tagger, artifacts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, build := range bRes.Builds {
+		a := byName[build.ImageName]
+		if a == nil || a.Hooks == nil {
+			continue
+		}
+		if err := runHooks(ctx, out, a.Hooks.After, a, build.Tag); err != nil {
+			return nil, errors.Wrapf(err, "running after hook of %s", a.ImageName)
+		}
+	}
+
+	return bRes, nil
+}
+
+// runHooks runs commands with sh, in the workspace of the artifact. The image
+// name, the workspace and, after the build, the tag are passed in the environment.
+func runHooks(ctx context.Context, out io.Writer, commands []string, a *v1alpha2.Artifact, tag string) error {
+	env := append(os.Environ(), hookEnv(a, tag)...)
+	for _, command := range commands {
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Dir = a.Workspace
+		cmd.Env = env
+		cmd.Stdout = out
+		cmd.Stderr = out
+		if err := util.RunCmd(cmd); err != nil {
+			return errors.Wrapf(err, "running %s", command)
+		}
+	}
+	return nil
+}
+
+func hookEnv(a *v1alpha2.Artifact, tag string) []string {
+	env := []string{
+		fmt.Sprintf("SKAFFOLD_IMAGE_NAME=%s", a.ImageName),
+		fmt.Sprintf("SKAFFOLD_WORKSPACE=%s", a.Workspace),
+	}
+	if tag != "" {
+		env = append(env, fmt.Sprintf("SKAFFOLD_TAG=%s", tag))
+	}
+	return env
+}
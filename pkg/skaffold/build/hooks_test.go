@@ -0,0 +1,81 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestHooks(t *testing.T) {
+	var tests = []struct {
+		description string
+		hooks       *v1alpha2.Hooks
+		shouldErr   bool
+		built       bool
+		expected    map[string]string
+	}{
+		{
+			description: "before and after",
+			hooks: &v1alpha2.Hooks{
+				Before: []string{"echo $SKAFFOLD_IMAGE_NAME > before"},
+				After:  []string{"echo $SKAFFOLD_TAG > after"},
+			},
+			built: true,
+			expected: map[string]string{
+				"before": "app\n",
+				"after":  "app:tag\n",
+			},
+		},
+		{
+			description: "failing before hook",
+			hooks: &v1alpha2.Hooks{
+				Before: []string{"exit 1"},
+			},
+			shouldErr: true,
+			expected:  map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			workspace, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			artifact := dockerArtifact("app")
+			artifact.Workspace = workspace
+			artifact.Hooks = test.hooks
+
+			recorder := &recordingBuilder{buildArgs: map[string]map[string]string{}}
+			_, err := WithHooks(recorder).Build(context.Background(), ioutil.Discard, nil, []*v1alpha2.Artifact{artifact})
+
+			outputs := map[string]string{}
+			for _, name := range []string{"before", "after"} {
+				if content, err := ioutil.ReadFile(filepath.Join(workspace, name)); err == nil {
+					outputs[name] = string(content)
+				}
+			}
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, outputs)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.built, len(recorder.calls) > 0)
+		})
+	}
+}
@@ -18,11 +18,14 @@ package build
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/features"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kaniko"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
@@ -34,15 +37,33 @@ import (
 
 type KanikoBuilder struct {
 	*v1alpha2.BuildConfig
+
+	kubeContext string
+	retry       *docker.RetryPolicy
 }
 
-func NewKanikoBuilder(cfg *v1alpha2.BuildConfig) (*KanikoBuilder, error) {
+func NewKanikoBuilder(cfg *v1alpha2.BuildConfig, kubeContext string) (*KanikoBuilder, error) {
+	if cfg.KanikoBuild.Fallback != "" && cfg.KanikoBuild.Fallback != constants.KanikoFallbackLocal {
+		return nil, fmt.Errorf("unknown kaniko fallback %s", cfg.KanikoBuild.Fallback)
+	}
+
+	retry, err := docker.NewRetryPolicy(cfg.Retry)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading retry policy")
+	}
+
 	return &KanikoBuilder{
 		BuildConfig: cfg,
+		kubeContext: kubeContext,
+		retry:       retry,
 	}, nil
 }
 
 func (k *KanikoBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) (*BuildResult, error) {
+	if err := checkPlatforms("kaniko", artifacts); err != nil {
+		return nil, err
+	}
+
 	res := &BuildResult{}
 
 	client, err := kubernetes.GetClientset()
@@ -55,7 +76,7 @@ func (k *KanikoBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tag
 		return nil, errors.Wrap(err, "reading secret")
 	}
 
-	_, err = client.CoreV1().Secrets("default").Create(&v1.Secret{
+	_, err = client.CoreV1().Secrets(k.KanikoBuild.Namespace).Create(&v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   "kaniko-secret",
 			Labels: map[string]string{"kaniko": "kaniko"},
@@ -68,19 +89,33 @@ func (k *KanikoBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tag
 		logrus.Warnf("creating secret: %s", err)
 	}
 	defer func() {
-		if err := client.CoreV1().Secrets("default").Delete("kaniko-secret", &metav1.DeleteOptions{}); err != nil {
+		if err := client.CoreV1().Secrets(k.KanikoBuild.Namespace).Delete("kaniko-secret", &metav1.DeleteOptions{}); err != nil {
 			logrus.Warnf("deleting secret")
 		}
 	}()
 
 	// TODO(r2d4): parallel builds
 	for _, artifact := range artifacts {
-		initialTag, err := kaniko.RunKanikoBuild(ctx, out, artifact, k.KanikoBuild)
+		initialTag, err := k.runKanikoBuild(ctx, out, artifact)
+		if _, notStarted := errors.Cause(err).(*kaniko.NotStartedError); notStarted && k.KanikoBuild.Fallback == constants.KanikoFallbackLocal {
+			fmt.Fprintf(out, "%s\nFalling back to a local build of %s\n", err, artifact.ImageName)
+			built, err := k.buildLocally(ctx, out, tagger, artifact)
+			if err != nil {
+				return nil, errors.Wrapf(err, "building %s locally", artifact.ImageName)
+			}
+			res.Builds = append(res.Builds, built.Builds...)
+			continue
+		}
 		if err != nil {
 			return nil, errors.Wrapf(err, "running kaniko build for %s", artifact.ImageName)
 		}
 
-		digest, err := docker.RemoteDigest(initialTag)
+		var digest string
+		err = k.retry.Do(ctx, "getting digest of "+initialTag, func() error {
+			var err error
+			digest, err = docker.RemoteDigest(initialTag)
+			return err
+		})
 		if err != nil {
 			return nil, errors.Wrap(err, "getting digest")
 		}
@@ -93,7 +128,9 @@ func (k *KanikoBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tag
 			return nil, errors.Wrap(err, "generating tag")
 		}
 
-		if err := docker.AddTag(initialTag, tag); err != nil {
+		if err := k.retry.Do(ctx, "tagging "+tag, func() error {
+			return docker.AddTag(initialTag, tag)
+		}); err != nil {
 			return nil, errors.Wrap(err, "tagging image")
 		}
 
@@ -105,3 +142,39 @@ func (k *KanikoBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tag
 	}
 	return res, nil
 }
+
+// buildLocally builds an artifact with the local docker daemon, for when
+// the kaniko pod can't start. The image is pushed like kaniko would.
+func (k *KanikoBuilder) buildLocally(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha2.Artifact) (*BuildResult, error) {
+	cfg := *k.BuildConfig
+	cfg.Artifacts = []*v1alpha2.Artifact{artifact}
+	cfg.BuildType = v1alpha2.BuildType{
+		LocalBuild: &v1alpha2.LocalBuild{Push: constants.PushAlways},
+	}
+
+	local, err := NewLocalBuilder(&cfg, k.kubeContext)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating local builder")
+	}
+	return local.Build(ctx, out, tagger, cfg.Artifacts)
+}
+
+// runKanikoBuild builds docker artifacts, and s2i artifacts through the
+// Dockerfile that s2i generates for them.
+func (k *KanikoBuilder) runKanikoBuild(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact) (string, error) {
+	if artifact.S2IArtifact == nil {
+		return kaniko.RunKanikoBuild(ctx, out, artifact, k.KanikoBuild)
+	}
+
+	if !features.S2IOnCluster.Enabled() {
+		return "", features.S2IOnCluster.Disabled()
+	}
+
+	generated, cleanup, err := s2iAsDockerfile(ctx, out, artifact)
+	if err != nil {
+		return "", errors.Wrap(err, "generating s2i Dockerfile")
+	}
+	defer cleanup()
+
+	return kaniko.RunKanikoBuild(ctx, out, generated, k.KanikoBuild)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// kindCluster returns the name of the kind cluster behind a kube context.
+func kindCluster(kubeContext string) (string, bool) {
+	switch {
+	case strings.HasPrefix(kubeContext, constants.KindContextPrefix):
+		return strings.TrimPrefix(kubeContext, constants.KindContextPrefix), true
+	case kubeContext == constants.LegacyKindContext:
+		return "kind", true
+	default:
+		return "", false
+	}
+}
+
+// loadIntoKind copies an image from the local daemon to the nodes of a kind
+// cluster, which don't share the host's images.
+func loadIntoKind(ctx context.Context, out io.Writer, cluster, tag string) error {
+	fmt.Fprintf(out, "Loading %s into kind cluster %s\n", tag, cluster)
+
+	cmd := exec.CommandContext(ctx, "kind", "load", "docker-image", tag, "--name", cluster)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := util.RunCmd(cmd); err != nil {
+		return errors.Wrapf(err, "loading %s into kind cluster %s", tag, cluster)
+	}
+	return nil
+}
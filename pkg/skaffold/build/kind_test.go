@@ -0,0 +1,78 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestKindCluster(t *testing.T) {
+	var tests = []struct {
+		kubeContext     string
+		expectedCluster string
+		expectedKind    bool
+	}{
+		{kubeContext: "kind-dev", expectedCluster: "dev", expectedKind: true},
+		{kubeContext: "kubernetes-admin@kind", expectedCluster: "kind", expectedKind: true},
+		{kubeContext: "minikube"},
+		{kubeContext: "gke_project_zone_kind-cluster"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.kubeContext, func(t *testing.T) {
+			cluster, isKind := kindCluster(test.kubeContext)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedCluster, cluster)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedKind, isKind)
+		})
+	}
+}
+
+func TestLoadIntoKind(t *testing.T) {
+	var tests = []struct {
+		description string
+		command     util.Command
+		shouldErr   bool
+	}{
+		{
+			description: "load",
+			command:     testutil.NewFakeCmd("kind load docker-image gcr.io/test/image:tag --name dev", nil),
+		},
+		{
+			description: "kind failure",
+			command:     testutil.NewFakeCmd("kind load docker-image gcr.io/test/image:tag --name dev", fmt.Errorf("")),
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = test.command
+
+			err := loadIntoKind(context.Background(), ioutil.Discard, "dev", "gcr.io/test/image:tag")
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
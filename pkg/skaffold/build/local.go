@@ -22,12 +22,16 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/features"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/warnings"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -38,45 +42,105 @@ type LocalBuilder struct {
 
 	api          docker.DockerAPIClient
 	localCluster bool
+	kindCluster  string
 	kubeContext  string
+	checkedAPI   bool
+	sizes        sizeTracker
+	retry        *docker.RetryPolicy
+	lock         *docker.LockFile
 }
 
 // NewLocalBuilder returns an new instance of a LocalBuilder
 func NewLocalBuilder(cfg *v1alpha2.BuildConfig, kubeContext string) (*LocalBuilder, error) {
-	api, err := docker.NewDockerAPIClient()
+	api, err := newLocalDockerAPIClient(cfg.LocalBuild)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting docker client")
 	}
 
+	retry, err := docker.NewRetryPolicy(cfg.Retry)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading retry policy")
+	}
+
+	kind, isKind := kindCluster(kubeContext)
 	l := &LocalBuilder{
 		BuildConfig: cfg,
 
 		kubeContext:  kubeContext,
 		api:          api,
-		localCluster: kubeContext == constants.DefaultMinikubeContext || kubeContext == constants.DefaultDockerForDesktopContext,
+		retry:        retry,
+		kindCluster:  kind,
+		localCluster: isKind || kubeContext == constants.DefaultMinikubeContext || kubeContext == constants.DefaultDockerForDesktopContext,
 	}
 
-	if cfg.LocalBuild.SkipPush == nil {
-		logrus.Debugf("skipPush value not present. defaulting to cluster default %t (minikube=true, d4d=true, gke=false)", l.localCluster)
-		cfg.LocalBuild.SkipPush = &l.localCluster
+	skipPush, err := skipPush(cfg.LocalBuild, l.localCluster)
+	if err != nil {
+		return nil, err
+	}
+	cfg.LocalBuild.SkipPush = &skipPush
+
+	if cfg.LockFile != "" {
+		if l.lock, err = docker.ReadLockFile(cfg.LockFile); err != nil {
+			return nil, err
+		}
 	}
 
 	return l, nil
 }
 
+// skipPush decides if built images are pushed. Unless configured otherwise,
+// images built for local clusters aren't.
+func skipPush(cfg *v1alpha2.LocalBuild, localCluster bool) (bool, error) {
+	if cfg.Push != "" && cfg.SkipPush != nil {
+		warnings.Warnf(warnings.DeprecatedField, "skipPush is ignored, push is set to %s", cfg.Push)
+	}
+
+	switch cfg.Push {
+	case constants.PushAlways:
+		return false, nil
+	case constants.PushNever:
+		return true, nil
+	case "", constants.PushAuto:
+		if cfg.SkipPush != nil && cfg.Push == "" {
+			return *cfg.SkipPush, nil
+		}
+		logrus.Debugf("Defaulting to cluster default skipPush=%t (minikube=true, d4d=true, kind=true, gke=false)", localCluster)
+		return localCluster, nil
+	default:
+		return false, fmt.Errorf("invalid push value %q, expected %s, %s or %s", cfg.Push, constants.PushAuto, constants.PushAlways, constants.PushNever)
+	}
+}
+
+func newLocalDockerAPIClient(cfg *v1alpha2.LocalBuild) (docker.DockerAPIClient, error) {
+	if cfg.Daemon != nil && cfg.Daemon.Host != "" {
+		return docker.NewDockerAPIClientForDaemon(cfg.Daemon)
+	}
+	return docker.NewDockerAPIClient()
+}
+
 func (l *LocalBuilder) runBuildForArtifact(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact) (string, error) {
 	if artifact.DockerArtifact != nil {
-		return l.buildDocker(ctx, out, artifact)
+		var platform string
+		if l.LocalBuild != nil {
+			platform = l.LocalBuild.Platform
+		}
+		return l.buildDocker(ctx, out, artifact, platform)
 	}
 	if artifact.BazelArtifact != nil {
 		return l.buildBazel(ctx, out, artifact)
 	}
+	if artifact.S2IArtifact != nil {
+		return l.buildS2I(ctx, out, artifact)
+	}
 
 	return "", fmt.Errorf("undefined artifact type: %+v", artifact.ArtifactType)
 }
 
 // Build runs a docker build on the host and tags the resulting image with
 // its checksum. It streams build progress to the writer argument.
+// Each image is tagged and pushed as soon as it's built, while the other
+// artifacts are still building. Images are only deployed once every artifact
+// was built, unless partial deploys are allowed.
 func (l *LocalBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) (*BuildResult, error) {
 	if l.localCluster {
 		if _, err := fmt.Fprintf(out, "Found [%s] context, using local docker daemon.\n", l.kubeContext); err != nil {
@@ -85,50 +149,159 @@ func (l *LocalBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagg
 	}
 	defer l.api.Close()
 
-	res := &BuildResult{}
-	for _, artifact := range artifacts {
-		initialTag, err := l.runBuildForArtifact(ctx, out, artifact)
-		if err != nil {
-			return nil, errors.Wrap(err, "running build for artifact")
-		}
+	if err := l.preflight(ctx); err != nil {
+		return nil, err
+	}
 
-		digest, err := docker.Digest(ctx, l.api, initialTag)
-		if err != nil {
-			return nil, errors.Wrapf(err, "build and tag: %s", initialTag)
-		}
-		if digest == "" {
-			return nil, fmt.Errorf("digest not found")
-		}
-		tag, err := tagger.GenerateFullyQualifiedImageName(artifact.Workspace, &tag.TagOptions{
-			ImageName: artifact.ImageName,
-			Digest:    digest,
+	buildArtifact := func(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact) (*Build, error) {
+		return l.buildArtifact(ctx, out, artifact)
+	}
+	finalize := func(ctx context.Context, out io.Writer, build *Build) (*Build, error) {
+		return l.finalize(ctx, out, tagger, build)
+	}
+
+	var (
+		built *BuildResult
+		err   error
+	)
+	if l.Concurrency > 1 && len(artifacts) > 1 && features.ParallelBuilds.Enabled() {
+		built, err = inParallel(ctx, out, artifacts, l.Concurrency, !l.ContinueOnError, buildArtifact, finalize)
+	} else {
+		built, err = inSequence(ctx, out, artifacts, func(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact) (*Build, error) {
+			build, err := buildArtifact(ctx, out, artifact)
+			if err != nil {
+				return nil, err
+			}
+			return finalize(ctx, out, build)
 		})
-		if err != nil {
-			return nil, errors.Wrap(err, "generating tag")
+	}
+	if err != nil {
+		if !l.PartialDeploy || len(built.Builds) == 0 {
+			return nil, err
 		}
-		if err := l.api.ImageTag(ctx, initialTag, tag); err != nil {
-			return nil, errors.Wrap(err, "tagging image")
+		return nil, &PartialBuildError{Result: built, Err: err}
+	}
+
+	return built, nil
+}
+
+// finalize tags and pushes a built image.
+func (l *LocalBuilder) finalize(ctx context.Context, out io.Writer, tagger tag.Tagger, b *Build) (*Build, error) {
+	var (
+		build *Build
+		err   error
+	)
+	if len(b.Artifact.Platforms) > 0 {
+		build, err = l.pushPlatforms(ctx, out, tagger, b)
+	} else {
+		build, err = l.tagAndPush(ctx, out, tagger, b.Artifact, b.Tag)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "finalizing [%s]", b.ImageName)
+	}
+	return build, nil
+}
+
+// preflight checks, once, that the docker daemon can build all the artifacts.
+func (l *LocalBuilder) preflight(ctx context.Context) error {
+	if l.checkedAPI {
+		return nil
+	}
+
+	var req docker.DaemonRequirements
+	if l.LocalBuild != nil {
+		req.Platform = l.LocalBuild.Platform
+	}
+	for _, a := range l.Artifacts {
+		if a.DockerArtifact != nil && a.DockerArtifact.Target != "" {
+			req.Target = true
 		}
-		if _, err := io.WriteString(out, fmt.Sprintf("Successfully tagged %s\n", tag)); err != nil {
-			return nil, errors.Wrap(err, "writing tag status")
+		if a.DockerArtifact != nil && docker.RequiresBuildKit(a.DockerArtifact) {
+			req.BuildKit = true
 		}
-		if !*l.LocalBuild.SkipPush {
-			if err := docker.RunPush(ctx, l.api, tag, out); err != nil {
-				return nil, errors.Wrap(err, "running push")
-			}
+		if len(a.Platforms) > 0 {
+			req.Platform = a.Platforms[0]
 		}
+	}
 
-		res.Builds = append(res.Builds, Build{
-			ImageName: artifact.ImageName,
-			Tag:       tag,
-			Artifact:  artifact,
-		})
+	info, err := docker.Preflight(ctx, l.api, req)
+	if err != nil {
+		return errors.Wrap(err, "checking docker daemon")
 	}
+	logrus.Infof("Using %s", info)
 
-	return res, nil
+	l.checkedAPI = true
+	return nil
 }
 
-func (l *LocalBuilder) buildDocker(ctx context.Context, out io.Writer, a *v1alpha2.Artifact) (string, error) {
+// buildArtifact builds an image that is tagged with a random tag until it's finalized.
+func (l *LocalBuilder) buildArtifact(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact) (*Build, error) {
+	if len(artifact.Platforms) > 0 {
+		return l.buildForPlatforms(ctx, out, artifact)
+	}
+
+	initialTag, err := l.runBuildForArtifact(ctx, out, artifact)
+	if err != nil {
+		return nil, errors.Wrap(err, "running build for artifact")
+	}
+
+	return &Build{
+		ImageName: artifact.ImageName,
+		Tag:       initialTag,
+		Artifact:  artifact,
+	}, nil
+}
+
+func (l *LocalBuilder) tagAndPush(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha2.Artifact, initialTag string) (*Build, error) {
+	tagStart := time.Now()
+	digest, err := docker.Digest(ctx, l.api, initialTag)
+	if err != nil {
+		return nil, errors.Wrapf(err, "build and tag: %s", initialTag)
+	}
+	if digest == "" {
+		return nil, fmt.Errorf("digest not found")
+	}
+	tag, err := tagger.GenerateFullyQualifiedImageName(artifact.Workspace, &tag.TagOptions{
+		ImageName: artifact.ImageName,
+		Digest:    digest,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "generating tag")
+	}
+	if err := l.api.ImageTag(ctx, initialTag, tag); err != nil {
+		return nil, errors.Wrap(err, "tagging image")
+	}
+	event.TimePhase(event.TagPhase, tagStart)
+	if _, err := io.WriteString(out, fmt.Sprintf("Successfully tagged %s\n", tag)); err != nil {
+		return nil, errors.Wrap(err, "writing tag status")
+	}
+	if size, layers, err := docker.ImageSize(ctx, l.api, tag); err != nil {
+		logrus.Debugf("Unable to get the size of %s: %s", tag, err)
+	} else {
+		l.sizes.record(out, artifact.ImageName, imageSize{size: size, layers: layers})
+	}
+	switch {
+	case !*l.LocalBuild.SkipPush:
+		defer event.TimePhase(event.PushPhase, time.Now())
+		if err := l.retry.Do(ctx, "pushing "+tag, func() error {
+			return docker.RunPush(ctx, l.api, tag, out)
+		}); err != nil {
+			return nil, errors.Wrap(err, "running push")
+		}
+	case l.kindCluster != "":
+		if err := loadIntoKind(ctx, out, l.kindCluster, tag); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Build{
+		ImageName: artifact.ImageName,
+		Tag:       tag,
+		Artifact:  artifact,
+	}, nil
+}
+
+func (l *LocalBuilder) buildDocker(ctx context.Context, out io.Writer, a *v1alpha2.Artifact, platform string) (string, error) {
 	initialTag := util.RandomID()
 	// Add a sanity check to check if the dockerfile exists before running the build
 	if _, err := util.Fs.Stat(filepath.Join(a.Workspace, a.DockerArtifact.DockerfilePath)); err != nil {
@@ -137,16 +310,56 @@ func (l *LocalBuilder) buildDocker(ctx context.Context, out io.Writer, a *v1alph
 		}
 		return "", errors.Wrap(err, "stat dockerfile")
 	}
-	err := docker.RunBuild(ctx, l.api, &docker.BuildOptions{
+	if _, err := util.Fs.Stat(filepath.Join(a.Workspace, ".dockerignore")); os.IsNotExist(err) {
+		warnings.Warnf(warnings.MissingIgnoreFile, "%s has no .dockerignore in %s, unwanted files might be sent to the docker daemon", a.ImageName, a.Workspace)
+	}
+	l.pullCacheFrom(ctx, out, a.DockerArtifact.CacheFrom)
+	opts := &docker.BuildOptions{
 		ImageName:   initialTag,
 		Dockerfile:  a.DockerArtifact.DockerfilePath,
 		ContextDir:  a.Workspace,
 		ProgressBuf: out,
 		BuildBuf:    out,
 		BuildArgs:   a.DockerArtifact.BuildArgs,
-	})
+		Target:      a.DockerArtifact.Target,
+		Network:     a.DockerArtifact.Network,
+		CacheFrom:   a.DockerArtifact.CacheFrom,
+		Platform:    platform,
+		Secrets:     a.DockerArtifact.Secrets,
+		SSH:         a.DockerArtifact.SSH,
+	}
+	if l.lock != nil {
+		dockerfile, pinned, err := docker.PinnedDockerfile(a.Workspace, a.DockerArtifact.DockerfilePath, l.lock)
+		if err != nil {
+			return "", errors.Wrap(err, "pinning base images")
+		}
+		if pinned {
+			opts.DockerfileContent = dockerfile
+		}
+	}
+
+	var err error
+	if docker.RequiresBuildKit(a.DockerArtifact) {
+		var daemon *v1alpha2.DockerDaemon
+		if l.LocalBuild != nil {
+			daemon = l.LocalBuild.Daemon
+		}
+		err = docker.RunBuildKitBuild(ctx, daemon, l.kubeContext, opts)
+	} else {
+		err = docker.RunBuild(ctx, l.api, opts)
+	}
 	if err != nil {
 		return "", errors.Wrap(err, "running build")
 	}
 	return fmt.Sprintf("%s:latest", initialTag), nil
 }
+
+// pullCacheFrom pulls the images used as cache sources. Missing images only
+// make the build slower, so failures aren't fatal.
+func (l *LocalBuilder) pullCacheFrom(ctx context.Context, out io.Writer, images []string) {
+	for _, image := range images {
+		if err := docker.RunPull(ctx, l.api, image, out); err != nil {
+			logrus.Warnf("Unable to pull cache image %s: %s", image, err)
+		}
+	}
+}
@@ -55,6 +55,16 @@ var testImage1 = &v1alpha2.Artifact{
 	},
 }
 
+var testImageCacheFrom = &v1alpha2.Artifact{
+	ImageName: "gcr.io/test/image",
+	Workspace: "../../../testdata/docker",
+	ArtifactType: v1alpha2.ArtifactType{
+		DockerArtifact: &v1alpha2.DockerArtifact{
+			CacheFrom: []string{"gcr.io/test/image:latest"},
+		},
+	},
+}
+
 var testImage2 = &v1alpha2.Artifact{
 	ImageName: "gcr.io/test/image2",
 	Workspace: "../../../testdata/docker",
@@ -155,6 +165,31 @@ func TestLocalRun(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "cache from pull failure is ignored",
+			out:         &bytes.Buffer{},
+			config: &v1alpha2.BuildConfig{
+				Artifacts: []*v1alpha2.Artifact{
+					testImageCacheFrom,
+				},
+				BuildType: v1alpha2.BuildType{
+					LocalBuild: &v1alpha2.LocalBuild{
+						SkipPush: util.BoolPtr(true),
+					},
+				},
+			},
+			tagger: &tag.ChecksumTagger{},
+			api:    testutil.NewFakeImageAPIClient(map[string]string{}, &testutil.FakeImageAPIOptions{ErrImagePull: true}),
+			expectedBuild: &BuildResult{
+				[]Build{
+					{
+						ImageName: "gcr.io/test/image",
+						Tag:       "gcr.io/test/image:imageid",
+						Artifact:  testImageCacheFrom,
+					},
+				},
+			},
+		},
 		{
 			description:  "local cluster bad writer",
 			out:          &testutil.BadWriter{},
@@ -259,3 +294,128 @@ func TestLocalRun(t *testing.T) {
 		})
 	}
 }
+
+func TestLocalRunPartialFailure(t *testing.T) {
+	defer func(h docker.AuthConfigHelper) { docker.DefaultAuthHelper = h }(docker.DefaultAuthHelper)
+	docker.DefaultAuthHelper = testAuthHelper{}
+
+	missingDockerfile := &v1alpha2.Artifact{
+		ImageName: "gcr.io/test/missing",
+		Workspace: "../../../testdata/docker",
+		ArtifactType: v1alpha2.ArtifactType{
+			DockerArtifact: &v1alpha2.DockerArtifact{DockerfilePath: "Dockerfile.missing"},
+		},
+	}
+
+	var tests = []struct {
+		description   string
+		partialDeploy bool
+		expectedTags  bool
+		expected      *BuildResult
+	}{
+		{
+			description:  "built images are tagged anyway",
+			expectedTags: true,
+		},
+		{
+			description:   "partial deploy",
+			partialDeploy: true,
+			expectedTags:  true,
+			expected: &BuildResult{
+				[]Build{
+					{
+						ImageName: "gcr.io/test/image",
+						Tag:       "gcr.io/test/image:imageid",
+						Artifact:  testImage1,
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			images := map[string]string{}
+			l := LocalBuilder{
+				BuildConfig: &v1alpha2.BuildConfig{
+					Artifacts:       []*v1alpha2.Artifact{testImage1, missingDockerfile},
+					ContinueOnError: true,
+					PartialDeploy:   test.partialDeploy,
+					BuildType: v1alpha2.BuildType{
+						LocalBuild: &v1alpha2.LocalBuild{
+							SkipPush: util.BoolPtr(true),
+						},
+					},
+				},
+				api: testutil.NewFakeImageAPIClient(images, &testutil.FakeImageAPIOptions{}),
+			}
+
+			res, err := l.Build(context.Background(), &bytes.Buffer{}, &tag.ChecksumTagger{}, l.Artifacts)
+
+			testutil.CheckError(t, true, err)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, (*BuildResult)(nil), res)
+			_, tagged := images["gcr.io/test/image:imageid"]
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedTags, tagged)
+			if partial, ok := err.(*PartialBuildError); ok {
+				testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, partial.Result)
+			} else if test.partialDeploy {
+				t.Errorf("expected a partial build error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSkipPush(t *testing.T) {
+	var tests = []struct {
+		description  string
+		cfg          *v1alpha2.LocalBuild
+		localCluster bool
+		shouldErr    bool
+		expected     bool
+	}{
+		{
+			description:  "local cluster default",
+			cfg:          &v1alpha2.LocalBuild{},
+			localCluster: true,
+			expected:     true,
+		},
+		{
+			description: "remote cluster default",
+			cfg:         &v1alpha2.LocalBuild{},
+		},
+		{
+			description:  "legacy skipPush",
+			cfg:          &v1alpha2.LocalBuild{SkipPush: util.BoolPtr(false)},
+			localCluster: true,
+		},
+		{
+			description:  "auto takes precedence",
+			cfg:          &v1alpha2.LocalBuild{Push: "auto", SkipPush: util.BoolPtr(false)},
+			localCluster: true,
+			expected:     true,
+		},
+		{
+			description:  "always push",
+			cfg:          &v1alpha2.LocalBuild{Push: "true"},
+			localCluster: true,
+		},
+		{
+			description: "never push",
+			cfg:         &v1alpha2.LocalBuild{Push: "false"},
+			expected:    true,
+		},
+		{
+			description: "invalid value",
+			cfg:         &v1alpha2.LocalBuild{Push: "sometimes"},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			skip, err := skipPush(test.cfg, test.localCluster)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, skip)
+		})
+	}
+}
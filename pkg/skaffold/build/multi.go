@@ -0,0 +1,79 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"io"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+)
+
+// perArtifactBuilder routes each artifact to the builder it is configured with.
+type perArtifactBuilder struct {
+	defaultBuilder Builder
+	builders       map[string]Builder
+}
+
+// WithPerArtifactBuilders returns a Builder that builds the artifacts listed in
+// builders, by image name, with their own builder and the others with the default one.
+func WithPerArtifactBuilders(defaultBuilder Builder, builders map[string]Builder) Builder {
+	return &perArtifactBuilder{
+		defaultBuilder: defaultBuilder,
+		builders:       builders,
+	}
+}
+
+// Build builds the artifacts in groups, one group per builder.
+func (b *perArtifactBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) (*BuildResult, error) {
+	var order []Builder
+	groups := map[Builder][]*v1alpha2.Artifact{}
+	for _, a := range artifacts {
+		builder := b.builderFor(a)
+		if _, present := groups[builder]; !present {
+			order = append(order, builder)
+		}
+		groups[builder] = append(groups[builder], a)
+	}
+
+	built := map[string]Build{}
+	for _, builder := range order {
+		bRes, err := builder.Build(ctx, out, tagger, groups[builder])
+		if err != nil {
+			return nil, err
+		}
+		for _, build := range bRes.Builds {
+			built[build.ImageName] = build
+		}
+	}
+
+	res := &BuildResult{}
+	for _, a := range artifacts {
+		if build, present := built[a.ImageName]; present {
+			res.Builds = append(res.Builds, build)
+		}
+	}
+	return res, nil
+}
+
+func (b *perArtifactBuilder) builderFor(a *v1alpha2.Artifact) Builder {
+	if builder, present := b.builders[a.ImageName]; present {
+		return builder
+	}
+	return b.defaultBuilder
+}
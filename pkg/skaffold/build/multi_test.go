@@ -0,0 +1,46 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestWithPerArtifactBuilders(t *testing.T) {
+	local := &recordingBuilder{buildArgs: map[string]map[string]string{}}
+	kaniko := &recordingBuilder{buildArgs: map[string]map[string]string{}}
+
+	app := dockerArtifact("app")
+	base := dockerArtifact("base")
+	other := dockerArtifact("other")
+
+	builder := WithPerArtifactBuilders(local, map[string]Builder{"base": kaniko})
+	res, err := builder.Build(context.Background(), ioutil.Discard, nil, []*v1alpha2.Artifact{app, base, other})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []Build{
+		{ImageName: "app", Tag: "app:tag", Artifact: app},
+		{ImageName: "base", Tag: "base:tag", Artifact: base},
+		{ImageName: "other", Tag: "other:tag", Artifact: other},
+	}, res.Builds)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, [][]string{{"app", "other"}}, local.calls)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, [][]string{{"base"}}, kaniko.calls)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// BuildOutput is what `skaffold build` writes to its output file, for other
+// tools to consume exactly what was built.
+type BuildOutput struct {
+	Builds []BuiltImage `json:"builds"`
+}
+
+// BuiltImage describes an image in the build output file.
+type BuiltImage struct {
+	ImageName string `json:"imageName"`
+	Tag       string `json:"tag"`
+	Digest    string `json:"digest,omitempty"`
+	ImageID   string `json:"imageID,omitempty"`
+}
+
+// WriteOutputFile writes the builds as json. The digest, that can be used to
+// pull the image, comes from the tag if it has one, otherwise it's looked up.
+// The id of the image in the local daemon is written separately. Both are
+// left out when they can't be found.
+func WriteOutputFile(path string, builds []Build, lookupDigest func(Build) (string, error), lookupImageID func(string) (string, error)) error {
+	output := BuildOutput{
+		Builds: []BuiltImage{},
+	}
+	for _, b := range builds {
+		output.Builds = append(output.Builds, BuiltImage{
+			ImageName: b.ImageName,
+			Tag:       b.Tag,
+			Digest:    digestOf(b, lookupDigest),
+			ImageID:   imageIDOf(b.Tag, lookupImageID),
+		})
+	}
+
+	buf, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshalling build output")
+	}
+	return ioutil.WriteFile(path, append(buf, '\n'), 0644)
+}
+
+func digestOf(b Build, lookupDigest func(Build) (string, error)) string {
+	if i := strings.Index(b.Tag, "@"); i >= 0 {
+		return b.Tag[i+1:]
+	}
+
+	digest, err := lookupDigest(b)
+	if err != nil {
+		logrus.Debugf("No digest for %s: %s", b.Tag, err)
+		return ""
+	}
+	return digest
+}
+
+func imageIDOf(tag string, lookupImageID func(string) (string, error)) string {
+	if strings.Contains(tag, "@") {
+		return ""
+	}
+
+	id, err := lookupImageID(tag)
+	if err != nil {
+		logrus.Debugf("No local image for %s: %s", tag, err)
+		return ""
+	}
+	return id
+}
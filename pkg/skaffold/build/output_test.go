@@ -0,0 +1,75 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestWriteOutputFile(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	path := filepath.Join(dir, "build.json")
+
+	builds := []Build{
+		{ImageName: "gcr.io/project/web", Tag: "gcr.io/project/web:v1"},
+		{ImageName: "gcr.io/project/api", Tag: "gcr.io/project/api:v1@sha256:abc"},
+		{ImageName: "local", Tag: "local:v1"},
+	}
+	remoteDigest := func(b Build) (string, error) {
+		if b.Tag == "gcr.io/project/web:v1" {
+			return "sha256:def", nil
+		}
+		return "", fmt.Errorf("not found")
+	}
+	localImageID := func(tag string) (string, error) {
+		if tag == "local:v1" {
+			return "sha256:imageid", nil
+		}
+		return "", fmt.Errorf("not found")
+	}
+
+	err := WriteOutputFile(path, builds, remoteDigest, localImageID)
+	testutil.CheckError(t, false, err)
+
+	content, err := ioutil.ReadFile(path)
+	testutil.CheckErrorAndDeepEqual(t, false, err, `{
+  "builds": [
+    {
+      "imageName": "gcr.io/project/web",
+      "tag": "gcr.io/project/web:v1",
+      "digest": "sha256:def"
+    },
+    {
+      "imageName": "gcr.io/project/api",
+      "tag": "gcr.io/project/api:v1@sha256:abc",
+      "digest": "sha256:abc"
+    },
+    {
+      "imageName": "local",
+      "tag": "local:v1",
+      "imageID": "sha256:imageid"
+    }
+  ]
+}
+`, string(content))
+}
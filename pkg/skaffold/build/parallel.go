@@ -0,0 +1,164 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+)
+
+// artifactBuilder builds a single artifact and writes its progress to out.
+type artifactBuilder func(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact) (*Build, error)
+
+// artifactFinalizer completes a build, e.g. by tagging and pushing the image.
+type artifactFinalizer func(ctx context.Context, out io.Writer, build *Build) (*Build, error)
+
+// inSequence builds the artifacts one after the other, stopping at the first failure.
+// On failure, the artifacts that were built are returned along with the error.
+func inSequence(ctx context.Context, out io.Writer, artifacts []*v1alpha2.Artifact, buildArtifact artifactBuilder) (*BuildResult, error) {
+	res := &BuildResult{}
+	for _, artifact := range artifacts {
+		build, err := buildArtifact(ctx, out, artifact)
+		if err != nil {
+			return res, errors.Wrapf(err, "building [%s]", artifact.ImageName)
+		}
+
+		res.Builds = append(res.Builds, *build)
+	}
+
+	return res, nil
+}
+
+// inParallel builds up to concurrency artifacts at the same time. Each artifact's
+// output is prefixed with its image name. When failFast is true, the first failure
+// cancels the other builds. Otherwise, every artifact is built and all the errors
+// are reported together. On failure, the artifacts that were built are returned
+// along with the error.
+// Each build is finalized as soon as it completes. finalize, which can be nil,
+// doesn't count against the concurrency so that pushing an image doesn't hold
+// back the next build.
+func inParallel(ctx context.Context, out io.Writer, artifacts []*v1alpha2.Artifact, concurrency int, failFast bool, buildArtifact artifactBuilder, finalize artifactFinalizer) (*BuildResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		outLock sync.Mutex
+		sem     = make(chan struct{}, concurrency)
+		builds  = make([]*Build, len(artifacts))
+		errs    = make([]error, len(artifacts))
+	)
+
+	for i := range artifacts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			artifact := artifacts[i]
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = errors.Wrapf(ctx.Err(), "building [%s]", artifact.ImageName)
+				return
+			}
+
+			w := &prefixWriter{
+				out:    out,
+				lock:   &outLock,
+				prefix: fmt.Sprintf("[%s] ", artifact.ImageName),
+			}
+			builds[i], errs[i] = buildArtifact(ctx, w, artifact)
+			<-sem
+			if errs[i] == nil && finalize != nil {
+				builds[i], errs[i] = finalize(ctx, w, builds[i])
+			}
+			w.Flush()
+
+			if errs[i] != nil {
+				errs[i] = errors.Wrapf(errs[i], "building [%s]", artifact.ImageName)
+				if failFast {
+					cancel()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	res := &BuildResult{}
+	var messages []string
+	for i, err := range errs {
+		if err != nil {
+			messages = append(messages, err.Error())
+		} else {
+			res.Builds = append(res.Builds, *builds[i])
+		}
+	}
+	if len(messages) > 0 {
+		return res, errors.New(strings.Join(messages, "; "))
+	}
+
+	return res, nil
+}
+
+// prefixWriter writes complete lines, prefixed, to a shared output.
+type prefixWriter struct {
+	out    io.Writer
+	lock   *sync.Mutex
+	prefix string
+	buf    bytes.Buffer
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+
+		line := w.buf.Next(i + 1)
+		if err := w.writeLine(line); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Flush writes the last incomplete line, if any.
+func (w *prefixWriter) Flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	line := append(w.buf.Bytes(), '\n')
+	w.buf.Reset()
+	return w.writeLine(line)
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	_, err := fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+	return err
+}
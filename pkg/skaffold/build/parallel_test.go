@@ -0,0 +1,221 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestInParallel(t *testing.T) {
+	var tests = []struct {
+		description string
+		concurrency int
+		failing     string
+		failFast    bool
+		expected    []Build
+		shouldErr   bool
+	}{
+		{
+			description: "all succeed",
+			concurrency: 1,
+			expected: []Build{
+				{ImageName: "image1", Tag: "image1:tag"},
+				{ImageName: "image2", Tag: "image2:tag"},
+				{ImageName: "image3", Tag: "image3:tag"},
+			},
+		},
+		{
+			description: "all succeed in parallel",
+			concurrency: 2,
+			expected: []Build{
+				{ImageName: "image1", Tag: "image1:tag"},
+				{ImageName: "image2", Tag: "image2:tag"},
+				{ImageName: "image3", Tag: "image3:tag"},
+			},
+		},
+		{
+			description: "fail fast",
+			concurrency: 1,
+			failing:     "image2",
+			failFast:    true,
+			shouldErr:   true,
+		},
+		{
+			description: "continue on error",
+			concurrency: 1,
+			failing:     "image1",
+			expected: []Build{
+				{ImageName: "image2", Tag: "image2:tag"},
+				{ImageName: "image3", Tag: "image3:tag"},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "continue on error in parallel",
+			concurrency: 2,
+			failing:     "image2",
+			expected: []Build{
+				{ImageName: "image1", Tag: "image1:tag"},
+				{ImageName: "image3", Tag: "image3:tag"},
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			artifacts := []*v1alpha2.Artifact{
+				{ImageName: "image1"},
+				{ImageName: "image2"},
+				{ImageName: "image3"},
+			}
+
+			var (
+				lock                sync.Mutex
+				built               = map[string]bool{}
+				running, maxRunning int
+			)
+			buildArtifact := func(ctx context.Context, out io.Writer, a *v1alpha2.Artifact) (*Build, error) {
+				lock.Lock()
+				built[a.ImageName] = true
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				lock.Unlock()
+
+				// Give the other builds a chance to run at the same time.
+				time.Sleep(10 * time.Millisecond)
+
+				lock.Lock()
+				running--
+				lock.Unlock()
+
+				if a.ImageName == test.failing {
+					return nil, fmt.Errorf("failed")
+				}
+				fmt.Fprintln(out, "done")
+				return &Build{ImageName: a.ImageName, Tag: a.ImageName + ":tag"}, nil
+			}
+
+			res, err := inParallel(context.Background(), &bytes.Buffer{}, artifacts, test.concurrency, test.failFast, buildArtifact, nil)
+
+			if maxRunning > test.concurrency {
+				t.Errorf("expected at most %d concurrent builds, got %d", test.concurrency, maxRunning)
+			}
+			if test.failFast {
+				// Which builds complete before the others are cancelled isn't deterministic.
+				testutil.CheckError(t, true, err)
+				return
+			}
+			if test.shouldErr && len(built) != len(artifacts) {
+				t.Errorf("expected all artifacts to be built, got %v", built)
+			}
+			testutil.CheckError(t, test.shouldErr, err)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, res.Builds)
+		})
+	}
+}
+
+func TestInParallelFailFastCancelsOtherBuilds(t *testing.T) {
+	artifacts := []*v1alpha2.Artifact{
+		{ImageName: "image1"},
+		{ImageName: "image2"},
+		{ImageName: "image3"},
+	}
+
+	// image1 and image3 only complete when they're cancelled.
+	buildArtifact := func(ctx context.Context, out io.Writer, a *v1alpha2.Artifact) (*Build, error) {
+		if a.ImageName == "image2" {
+			return nil, fmt.Errorf("failed")
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Second):
+			return &Build{ImageName: a.ImageName, Tag: a.ImageName + ":tag"}, nil
+		}
+	}
+
+	res, err := inParallel(context.Background(), &bytes.Buffer{}, artifacts, len(artifacts), true, buildArtifact, nil)
+
+	testutil.CheckErrorAndDeepEqual(t, true, err, 0, len(res.Builds))
+	for _, expected := range []string{"building [image1]: context canceled", "building [image2]: failed", "building [image3]: context canceled"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in error, got %s", expected, err)
+		}
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := &prefixWriter{
+		out:    &out,
+		lock:   &sync.Mutex{},
+		prefix: "[image] ",
+	}
+
+	fmt.Fprint(w, "first line\nsecond ")
+	fmt.Fprint(w, "line\nunterminated")
+	w.Flush()
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "[image] first line\n[image] second line\n[image] unterminated\n", out.String())
+}
+
+func TestInParallelFinalizesOutsideOfConcurrency(t *testing.T) {
+	artifacts := []*v1alpha2.Artifact{
+		{ImageName: "image1"},
+		{ImageName: "image2"},
+	}
+
+	// With a concurrency of 1, image1 can only be finalized once image2
+	// started building if finalizing doesn't hold the build slot.
+	image2Started := make(chan struct{})
+	buildArtifact := func(ctx context.Context, out io.Writer, a *v1alpha2.Artifact) (*Build, error) {
+		if a.ImageName == "image2" {
+			close(image2Started)
+		}
+		return &Build{ImageName: a.ImageName, Tag: a.ImageName + ":tag"}, nil
+	}
+	finalize := func(ctx context.Context, out io.Writer, b *Build) (*Build, error) {
+		if b.ImageName == "image1" {
+			select {
+			case <-image2Started:
+			case <-time.After(10 * time.Second):
+				return nil, fmt.Errorf("image2 didn't start building")
+			}
+		}
+		return &Build{ImageName: b.ImageName, Tag: b.ImageName + ":final"}, nil
+	}
+
+	res, err := inParallel(context.Background(), &bytes.Buffer{}, artifacts, 1, true, buildArtifact, finalize)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []Build{
+		{ImageName: "image1", Tag: "image1:final"},
+		{ImageName: "image2", Tag: "image2:final"},
+	}, res.Builds)
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// checkPlatforms fails for artifacts that have to be built for several
+// platforms, which only the local builder supports.
+func checkPlatforms(builder string, artifacts []*v1alpha2.Artifact) error {
+	for _, a := range artifacts {
+		if len(a.Platforms) > 0 {
+			return fmt.Errorf("%s can't be built for several platforms with %s, only the local builder supports platforms", a.ImageName, builder)
+		}
+	}
+	return nil
+}
+
+// buildForPlatforms builds a docker artifact once per platform. The images
+// are tagged with the same random tag, followed by their platform, until
+// they're finalized.
+func (l *LocalBuilder) buildForPlatforms(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact) (*Build, error) {
+	if artifact.DockerArtifact == nil {
+		return nil, fmt.Errorf("building for several platforms is only supported for docker artifacts")
+	}
+	if *l.LocalBuild.SkipPush {
+		return nil, fmt.Errorf("images built for several platforms have to be pushed, skipPush must be false")
+	}
+
+	initialTag := util.RandomID()
+	for _, platform := range artifact.Platforms {
+		fmt.Fprintf(out, "Building %s for %s\n", artifact.ImageName, platform)
+
+		platformImage, err := l.buildDocker(ctx, out, artifact, platform)
+		if err != nil {
+			return nil, errors.Wrapf(err, "building for %s", platform)
+		}
+		if err := l.api.ImageTag(ctx, platformImage, platformTag(initialTag, platform)); err != nil {
+			return nil, errors.Wrap(err, "tagging image")
+		}
+	}
+
+	return &Build{
+		ImageName: artifact.ImageName,
+		Tag:       initialTag,
+		Artifact:  artifact,
+	}, nil
+}
+
+// pushPlatforms tags the images built for each platform, pushes them and
+// publishes them together as a manifest list. The artifact is then deployed
+// by the digest of the list.
+func (l *LocalBuilder) pushPlatforms(ctx context.Context, out io.Writer, tagger tag.Tagger, b *Build) (*Build, error) {
+	artifact := b.Artifact
+
+	firstImage := platformTag(b.Tag, artifact.Platforms[0])
+	digest, err := docker.Digest(ctx, l.api, firstImage)
+	if err != nil {
+		return nil, errors.Wrapf(err, "build and tag: %s", firstImage)
+	}
+	listTag, err := tagger.GenerateFullyQualifiedImageName(artifact.Workspace, &tag.TagOptions{
+		ImageName: artifact.ImageName,
+		Digest:    digest,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "generating tag")
+	}
+
+	var images []docker.PlatformImage
+	for _, platform := range artifact.Platforms {
+		ref := platformTag(listTag, platform)
+		if err := l.api.ImageTag(ctx, platformTag(b.Tag, platform), ref); err != nil {
+			return nil, errors.Wrap(err, "tagging image")
+		}
+		if err := l.retry.Do(ctx, "pushing "+ref, func() error {
+			return docker.RunPush(ctx, l.api, ref, out)
+		}); err != nil {
+			return nil, errors.Wrap(err, "running push")
+		}
+		images = append(images, docker.PlatformImage{Platform: platform, Ref: ref})
+	}
+
+	err = l.retry.Do(ctx, "publishing manifest list "+listTag, func() error {
+		var err error
+		digest, err = docker.PushManifestList(listTag, images)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "publishing manifest list")
+	}
+	fmt.Fprintf(out, "Published manifest list %s@%s\n", listTag, digest)
+
+	return &Build{
+		ImageName: artifact.ImageName,
+		Tag:       fmt.Sprintf("%s@%s", listTag, digest),
+		Artifact:  artifact,
+	}, nil
+}
+
+// platformTag is the tag of the image built for a platform, eg.
+// gcr.io/project/app:v1-linux-arm64 for linux/arm64.
+func platformTag(listTag, platform string) string {
+	return fmt.Sprintf("%s-%s", listTag, strings.Replace(platform, "/", "-", -1))
+}
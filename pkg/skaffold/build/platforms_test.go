@@ -0,0 +1,49 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestPlatformTag(t *testing.T) {
+	var tests = []struct {
+		listTag  string
+		platform string
+		expected string
+	}{
+		{listTag: "gcr.io/project/app:v1", platform: "linux/amd64", expected: "gcr.io/project/app:v1-linux-amd64"},
+		{listTag: "localhost:5000/app:v1", platform: "linux/arm/v7", expected: "localhost:5000/app:v1-linux-arm-v7"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.platform, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, platformTag(test.listTag, test.platform))
+		})
+	}
+}
+
+func TestCheckPlatforms(t *testing.T) {
+	app := &v1alpha2.Artifact{ImageName: "app"}
+	multiArch := &v1alpha2.Artifact{ImageName: "multi-arch", Platforms: []string{"linux/amd64", "linux/arm64"}}
+
+	testutil.CheckError(t, false, checkPlatforms("kaniko", []*v1alpha2.Artifact{app}))
+	testutil.CheckError(t, true, checkPlatforms("kaniko", []*v1alpha2.Artifact{app, multiArch}))
+}
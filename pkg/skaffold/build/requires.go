@@ -0,0 +1,272 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/sirupsen/logrus"
+)
+
+var nonAlphanumeric = regexp.MustCompile("[^A-Za-z0-9]+")
+
+// RequiredImageBuildArg returns the name of the build arg used to pass the tag
+// of a required image to the artifacts that depend on it.
+// For example, the tag of `gcr.io/project/base-image` is passed as `BASE_IMAGE`.
+func RequiredImageBuildArg(imageName string) string {
+	name := imageName[strings.LastIndex(imageName, "/")+1:]
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[:i]
+	}
+	return strings.ToUpper(strings.Trim(nonAlphanumeric.ReplaceAllString(name, "_"), "_"))
+}
+
+// HasRequirements returns true if any artifact requires another artifact.
+func HasRequirements(artifacts []*v1alpha2.Artifact) bool {
+	for _, a := range artifacts {
+		if len(a.Requires) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// requirementsBuilder builds required artifacts before the artifacts that depend on them
+// and passes their tags as build args.
+type requirementsBuilder struct {
+	Builder
+
+	artifacts []*v1alpha2.Artifact
+	byName    map[string]*v1alpha2.Artifact
+	tags      map[string]string
+}
+
+// WithRequirements wraps a Builder so that artifacts are built in the order
+// given by their requirements.
+func WithRequirements(builder Builder, artifacts []*v1alpha2.Artifact) (Builder, error) {
+	byName := map[string]*v1alpha2.Artifact{}
+	for _, a := range artifacts {
+		byName[a.ImageName] = a
+	}
+
+	for _, a := range artifacts {
+		for _, required := range a.Requires {
+			if _, present := byName[required]; !present {
+				return nil, fmt.Errorf("artifact %s requires unknown artifact %s", a.ImageName, required)
+			}
+		}
+	}
+
+	b := &requirementsBuilder{
+		Builder:   builder,
+		artifacts: artifacts,
+		byName:    byName,
+		tags:      map[string]string{},
+	}
+	if _, err := b.levels(artifacts); err != nil {
+		return nil, err
+	}
+
+	for _, a := range artifacts {
+		args := map[string]string{}
+		for _, required := range b.allRequirements(a) {
+			arg := RequiredImageBuildArg(required)
+			if other, present := args[arg]; present {
+				return nil, fmt.Errorf("artifact %s requires both %s and %s, which are passed as the same build arg %s", a.ImageName, other, required, arg)
+			}
+			args[arg] = required
+		}
+	}
+
+	return b, nil
+}
+
+// Build builds the given artifacts, the artifacts that depend on them and the
+// required artifacts that were never built.
+func (b *requirementsBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) (*BuildResult, error) {
+	levels, err := b.levels(b.selection(artifacts))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &BuildResult{}
+	for _, level := range levels {
+		var prepared []*v1alpha2.Artifact
+		for _, a := range level {
+			prepared = append(prepared, b.withRequiredTags(a))
+		}
+
+		bRes, err := b.Builder.Build(ctx, out, tagger, prepared)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, build := range bRes.Builds {
+			if original, present := b.byName[build.ImageName]; present {
+				build.Artifact = original
+			}
+			b.tags[build.ImageName] = build.Tag
+			res.Builds = append(res.Builds, build)
+		}
+	}
+
+	return res, nil
+}
+
+// selection adds to the requested artifacts all the artifacts that depend on them
+// and the required artifacts that were never built.
+func (b *requirementsBuilder) selection(artifacts []*v1alpha2.Artifact) []*v1alpha2.Artifact {
+	selected := map[string]bool{}
+	var visit func(a *v1alpha2.Artifact)
+	visit = func(a *v1alpha2.Artifact) {
+		if selected[a.ImageName] {
+			return
+		}
+		selected[a.ImageName] = true
+
+		for _, required := range a.Requires {
+			if _, built := b.tags[required]; !built {
+				visit(b.byName[required])
+			}
+		}
+		for _, other := range b.artifacts {
+			if requires(other, a.ImageName) {
+				visit(other)
+			}
+		}
+	}
+
+	for _, a := range artifacts {
+		visit(b.byName[a.ImageName])
+	}
+
+	var selection []*v1alpha2.Artifact
+	for _, a := range b.artifacts {
+		if selected[a.ImageName] {
+			selection = append(selection, a)
+		}
+	}
+	return selection
+}
+
+// levels groups artifacts so that each group only requires artifacts from the previous groups.
+func (b *requirementsBuilder) levels(artifacts []*v1alpha2.Artifact) ([][]*v1alpha2.Artifact, error) {
+	pending := map[string]bool{}
+	for _, a := range artifacts {
+		pending[a.ImageName] = true
+	}
+
+	var levels [][]*v1alpha2.Artifact
+	for len(pending) > 0 {
+		var level []*v1alpha2.Artifact
+		for _, a := range artifacts {
+			if pending[a.ImageName] && !requiresAny(a, pending) {
+				level = append(level, a)
+			}
+		}
+		if len(level) == 0 {
+			var cycle []string
+			for _, a := range artifacts {
+				if pending[a.ImageName] {
+					cycle = append(cycle, a.ImageName)
+				}
+			}
+			return nil, fmt.Errorf("cycle detected between artifacts %s", strings.Join(cycle, ", "))
+		}
+
+		for _, a := range level {
+			delete(pending, a.ImageName)
+		}
+		levels = append(levels, level)
+	}
+
+	return levels, nil
+}
+
+// allRequirements lists the artifacts required by an artifact, directly or
+// through other requirements, closest first.
+func (b *requirementsBuilder) allRequirements(a *v1alpha2.Artifact) []string {
+	var all []string
+	seen := map[string]bool{a.ImageName: true}
+
+	queue := a.Requires
+	for len(queue) > 0 {
+		required := queue[0]
+		queue = queue[1:]
+		if seen[required] {
+			continue
+		}
+		seen[required] = true
+
+		all = append(all, required)
+		queue = append(queue, b.byName[required].Requires...)
+	}
+
+	return all
+}
+
+// withRequiredTags returns a copy of the artifact with the tags of all its
+// required artifacts, direct or not, set as build args.
+func (b *requirementsBuilder) withRequiredTags(a *v1alpha2.Artifact) *v1alpha2.Artifact {
+	if len(a.Requires) == 0 || a.DockerArtifact == nil {
+		return a
+	}
+
+	buildArgs := map[string]*string{}
+	for k, v := range a.DockerArtifact.BuildArgs {
+		buildArgs[k] = v
+	}
+	for _, required := range b.allRequirements(a) {
+		arg := RequiredImageBuildArg(required)
+		if _, present := buildArgs[arg]; present {
+			logrus.Warnf("Build arg %s is set explicitly for %s, not overriding it with the tag of %s", arg, a.ImageName, required)
+			continue
+		}
+		tag := b.tags[required]
+		buildArgs[arg] = &tag
+	}
+
+	docker := *a.DockerArtifact
+	docker.BuildArgs = buildArgs
+	copied := *a
+	copied.DockerArtifact = &docker
+	return &copied
+}
+
+func requires(a *v1alpha2.Artifact, imageName string) bool {
+	for _, required := range a.Requires {
+		if required == imageName {
+			return true
+		}
+	}
+	return false
+}
+
+func requiresAny(a *v1alpha2.Artifact, imageNames map[string]bool) bool {
+	for _, required := range a.Requires {
+		if imageNames[required] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+// recordingBuilder records the build args each artifact was built with.
+type recordingBuilder struct {
+	calls     [][]string
+	buildArgs map[string]map[string]string
+}
+
+func (r *recordingBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) (*BuildResult, error) {
+	var names []string
+	res := &BuildResult{}
+	for _, a := range artifacts {
+		names = append(names, a.ImageName)
+		args := map[string]string{}
+		for k, v := range a.DockerArtifact.BuildArgs {
+			args[k] = *v
+		}
+		r.buildArgs[a.ImageName] = args
+		res.Builds = append(res.Builds, Build{ImageName: a.ImageName, Tag: a.ImageName + ":tag", Artifact: a})
+	}
+	r.calls = append(r.calls, names)
+	return res, nil
+}
+
+func dockerArtifact(imageName string, requires ...string) *v1alpha2.Artifact {
+	return &v1alpha2.Artifact{
+		ImageName: imageName,
+		Requires:  requires,
+		ArtifactType: v1alpha2.ArtifactType{
+			DockerArtifact: &v1alpha2.DockerArtifact{},
+		},
+	}
+}
+
+func TestRequiredImageBuildArg(t *testing.T) {
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "BASE_IMAGE", RequiredImageBuildArg("gcr.io/project/base-image"))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "BASE", RequiredImageBuildArg("localhost:5000/base"))
+}
+
+func TestWithRequirements(t *testing.T) {
+	base := dockerArtifact("gcr.io/project/base")
+	app := dockerArtifact("gcr.io/project/app", "gcr.io/project/base")
+	other := dockerArtifact("gcr.io/project/other")
+	all := []*v1alpha2.Artifact{app, other, base}
+
+	var tests = []struct {
+		description string
+		builds      [][]*v1alpha2.Artifact
+		expected    [][]string
+	}{
+		{
+			description: "build everything",
+			builds:      [][]*v1alpha2.Artifact{all},
+			expected:    [][]string{{"gcr.io/project/other", "gcr.io/project/base"}, {"gcr.io/project/app"}},
+		},
+		{
+			description: "build required artifact first",
+			builds:      [][]*v1alpha2.Artifact{{app}},
+			expected:    [][]string{{"gcr.io/project/base"}, {"gcr.io/project/app"}},
+		},
+		{
+			description: "rebuild dependents",
+			builds:      [][]*v1alpha2.Artifact{all, {base}},
+			expected:    [][]string{{"gcr.io/project/other", "gcr.io/project/base"}, {"gcr.io/project/app"}, {"gcr.io/project/base"}, {"gcr.io/project/app"}},
+		},
+		{
+			description: "reuse tag of required artifact",
+			builds:      [][]*v1alpha2.Artifact{all, {app}},
+			expected:    [][]string{{"gcr.io/project/other", "gcr.io/project/base"}, {"gcr.io/project/app"}, {"gcr.io/project/app"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			recorder := &recordingBuilder{buildArgs: map[string]map[string]string{}}
+			builder, err := WithRequirements(recorder, all)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, artifacts := range test.builds {
+				if _, err := builder.Build(context.Background(), ioutil.Discard, nil, artifacts); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, recorder.calls)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]string{"BASE": "gcr.io/project/base:tag"}, recorder.buildArgs["gcr.io/project/app"])
+			testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(app.DockerArtifact.BuildArgs))
+		})
+	}
+}
+
+func TestTransitiveRequirements(t *testing.T) {
+	base := dockerArtifact("gcr.io/project/base")
+	mid := dockerArtifact("gcr.io/project/mid", "gcr.io/project/base")
+	app := dockerArtifact("gcr.io/project/app", "gcr.io/project/mid")
+
+	recorder := &recordingBuilder{buildArgs: map[string]map[string]string{}}
+	builder, err := WithRequirements(recorder, []*v1alpha2.Artifact{app, mid, base})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = builder.Build(context.Background(), ioutil.Discard, nil, []*v1alpha2.Artifact{app})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, [][]string{{"gcr.io/project/base"}, {"gcr.io/project/mid"}, {"gcr.io/project/app"}}, recorder.calls)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]string{
+		"MID":  "gcr.io/project/mid:tag",
+		"BASE": "gcr.io/project/base:tag",
+	}, recorder.buildArgs["gcr.io/project/app"])
+}
+
+func TestWithRequirementsErrors(t *testing.T) {
+	var tests = []struct {
+		description string
+		artifacts   []*v1alpha2.Artifact
+	}{
+		{
+			description: "unknown requirement",
+			artifacts:   []*v1alpha2.Artifact{dockerArtifact("app", "unknown")},
+		},
+		{
+			description: "cycle",
+			artifacts:   []*v1alpha2.Artifact{dockerArtifact("a", "b"), dockerArtifact("b", "a")},
+		},
+		{
+			description: "build arg collision",
+			artifacts:   []*v1alpha2.Artifact{dockerArtifact("app", "gcr.io/a/base", "gcr.io/b/base"), dockerArtifact("gcr.io/a/base"), dockerArtifact("gcr.io/b/base")},
+		},
+		{
+			description: "transitive build arg collision",
+			artifacts:   []*v1alpha2.Artifact{dockerArtifact("app", "gcr.io/a/base", "mid"), dockerArtifact("mid", "gcr.io/b/base"), dockerArtifact("gcr.io/a/base"), dockerArtifact("gcr.io/b/base")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			_, err := WithRequirements(&recordingBuilder{}, test.artifacts)
+
+			testutil.CheckError(t, true, err)
+		})
+	}
+}
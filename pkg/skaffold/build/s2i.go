@@ -0,0 +1,164 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/s2i"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/warnings"
+	"github.com/pkg/errors"
+)
+
+// incrementalTag is the tag given to s2i images built incrementally, so that
+// the next build finds the artifacts saved by the previous one.
+const incrementalTag = "skaffold-s2i-incremental"
+
+// buildS2I runs the source-to-image CLI against the local docker daemon.
+func (l *LocalBuilder) buildS2I(ctx context.Context, out io.Writer, a *v1alpha2.Artifact) (string, error) {
+	if err := checkBuilderImage(a); err != nil {
+		return "", err
+	}
+
+	tag := fmt.Sprintf("%s:latest", util.RandomID())
+	incremental := false
+	if a.S2IArtifact.Incremental {
+		tag = fmt.Sprintf("%s:%s", a.ImageName, incrementalTag)
+
+		previous, err := docker.Digest(ctx, l.api, tag)
+		if err != nil {
+			return "", errors.Wrap(err, "looking for the previous image")
+		}
+		if incremental = previous != ""; !incremental {
+			fmt.Fprintf(out, "No previous image of %s, running a clean build\n", a.ImageName)
+		}
+	}
+
+	sources, err := s2i.CreateContext(a)
+	if err != nil {
+		return "", errors.Wrap(err, "creating s2i context")
+	}
+	defer os.RemoveAll(sources)
+
+	cmd := exec.CommandContext(ctx, "s2i", s2iArgs(a.S2IArtifact, tag, incremental)...)
+	cmd.Dir = sources
+	cmd.Stdout = out
+	cmd.Stderr = out
+	var daemon *v1alpha2.DockerDaemon
+	if l.LocalBuild != nil {
+		daemon = l.LocalBuild.Daemon
+	}
+	cmd.Env = append(os.Environ(), docker.DaemonEnv(daemon, l.kubeContext)...)
+	if err := util.RunCmd(cmd); err != nil {
+		return "", errors.Wrap(err, "running s2i build")
+	}
+
+	return tag, nil
+}
+
+// s2iAsDockerfile has s2i generate a Dockerfile and the context that goes with
+// it, so that builders which only know Dockerfiles can build the artifact.
+func s2iAsDockerfile(ctx context.Context, out io.Writer, a *v1alpha2.Artifact) (*v1alpha2.Artifact, func(), error) {
+	if err := checkBuilderImage(a); err != nil {
+		return nil, nil, err
+	}
+	if a.S2IArtifact.Incremental {
+		warnings.Warnf(warnings.UnsupportedOption, "Ignoring incremental for %s: s2i can't generate incremental Dockerfiles", a.ImageName)
+	}
+
+	sources, err := s2i.CreateContext(a)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "creating s2i context")
+	}
+	defer os.RemoveAll(sources)
+
+	dir, err := ioutil.TempDir("", "skaffold-s2i")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "creating context directory")
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	args := append([]string{"build", ".", a.S2IArtifact.BuilderImage}, s2iOptions(a.S2IArtifact)...)
+	args = append(args, "--as-dockerfile", filepath.Join(dir, "Dockerfile"))
+
+	cmd := exec.CommandContext(ctx, "s2i", args...)
+	cmd.Dir = sources
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := util.RunCmd(cmd); err != nil {
+		cleanup()
+		return nil, nil, errors.Wrap(err, "running s2i build")
+	}
+
+	return &v1alpha2.Artifact{
+		ImageName: a.ImageName,
+		Workspace: dir,
+		ArtifactType: v1alpha2.ArtifactType{
+			DockerArtifact: &v1alpha2.DockerArtifact{
+				DockerfilePath: constants.DefaultDockerfilePath,
+			},
+		},
+	}, cleanup, nil
+}
+
+// checkBuilderImage fails early on artifacts without a builder image, which
+// s2i would otherwise mistake the tag for.
+func checkBuilderImage(a *v1alpha2.Artifact) error {
+	if strings.TrimSpace(a.S2IArtifact.BuilderImage) == "" {
+		return fmt.Errorf("s2i artifact %s has no builderImage", a.ImageName)
+	}
+	return nil
+}
+
+func s2iArgs(a *v1alpha2.S2IArtifact, tag string, incremental bool) []string {
+	args := append([]string{"build", ".", a.BuilderImage, tag}, s2iOptions(a)...)
+	if incremental {
+		// The previous image is local, there's no point in pulling it.
+		args = append(args, "--incremental", "--incremental-pull-policy", "never")
+	}
+	return args
+}
+
+// s2iOptions returns the s2i flags that every kind of build uses.
+func s2iOptions(a *v1alpha2.S2IArtifact) []string {
+	var args []string
+	if a.ScriptsURL != "" {
+		args = append(args, "--scripts-url", a.ScriptsURL)
+	}
+
+	var keys []string
+	for k := range a.Environment {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", k, a.Environment[k]))
+	}
+	return args
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestS2IArgs(t *testing.T) {
+	var tests = []struct {
+		description string
+		artifact    *v1alpha2.S2IArtifact
+		incremental bool
+		expected    []string
+	}{
+		{
+			description: "builder image only",
+			artifact:    &v1alpha2.S2IArtifact{BuilderImage: "centos/nodejs-8-centos7"},
+			expected:    []string{"build", ".", "centos/nodejs-8-centos7", "tag"},
+		},
+		{
+			description: "all options",
+			artifact: &v1alpha2.S2IArtifact{
+				BuilderImage: "centos/nodejs-8-centos7",
+				ScriptsURL:   "https://example.com/s2i/bin",
+				Environment:  map[string]string{"B": "2", "A": "1"},
+				Incremental:  true,
+			},
+			incremental: true,
+			expected:    []string{"build", ".", "centos/nodejs-8-centos7", "tag", "--scripts-url", "https://example.com/s2i/bin", "--env", "A=1", "--env", "B=2", "--incremental", "--incremental-pull-policy", "never"},
+		},
+		{
+			description: "clean build without previous image",
+			artifact:    &v1alpha2.S2IArtifact{BuilderImage: "centos/nodejs-8-centos7", Incremental: true},
+			expected:    []string{"build", ".", "centos/nodejs-8-centos7", "tag"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, s2iArgs(test.artifact, "tag", test.incremental))
+		})
+	}
+}
+
+func TestBuildS2IIncremental(t *testing.T) {
+	artifact := &v1alpha2.Artifact{
+		ImageName: "gcr.io/test/app",
+		Workspace: ".",
+		ArtifactType: v1alpha2.ArtifactType{
+			S2IArtifact: &v1alpha2.S2IArtifact{BuilderImage: "builder", Incremental: true},
+		},
+	}
+
+	var tests = []struct {
+		description string
+		images      map[string]string
+		command     string
+	}{
+		{
+			description: "previous image",
+			images:      map[string]string{"gcr.io/test/app:skaffold-s2i-incremental": "imageid"},
+			command:     "s2i build . builder gcr.io/test/app:skaffold-s2i-incremental --incremental --incremental-pull-policy never",
+		},
+		{
+			description: "clean build",
+			images:      map[string]string{},
+			command:     "s2i build . builder gcr.io/test/app:skaffold-s2i-incremental",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = testutil.NewFakeCmd(test.command, nil)
+
+			l := &LocalBuilder{
+				BuildConfig: &v1alpha2.BuildConfig{},
+				api:         testutil.NewFakeImageAPIClient(test.images, &testutil.FakeImageAPIOptions{}),
+			}
+			tag, err := l.buildS2I(context.Background(), ioutil.Discard, artifact)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, "gcr.io/test/app:skaffold-s2i-incremental", tag)
+		})
+	}
+}
+
+// fakeS2I writes the Dockerfile that `s2i build --as-dockerfile` would generate.
+type fakeS2I struct {
+	args []string
+}
+
+func (f *fakeS2I) RunCmdOut(cmd *exec.Cmd) ([]byte, error) {
+	return nil, f.RunCmd(cmd)
+}
+
+func (f *fakeS2I) RunCmd(cmd *exec.Cmd) error {
+	f.args = cmd.Args
+	return ioutil.WriteFile(cmd.Args[len(cmd.Args)-1], []byte("FROM builder"), 0644)
+}
+
+func TestS2IAsDockerfile(t *testing.T) {
+	s2i := &fakeS2I{}
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = s2i
+
+	artifact := &v1alpha2.Artifact{
+		ImageName: "gcr.io/test/app",
+		Workspace: ".",
+		ArtifactType: v1alpha2.ArtifactType{
+			S2IArtifact: &v1alpha2.S2IArtifact{
+				BuilderImage: "builder",
+				Environment:  map[string]string{"A": "1"},
+			},
+		},
+	}
+	generated, cleanup, err := s2iAsDockerfile(context.Background(), ioutil.Discard, artifact)
+	testutil.CheckError(t, false, err)
+
+	expectedArgs := []string{"s2i", "build", ".", "builder", "--env", "A=1", "--as-dockerfile", filepath.Join(generated.Workspace, "Dockerfile")}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, strings.Join(expectedArgs, " "), strings.Join(s2i.args, " "))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "Dockerfile", generated.DockerArtifact.DockerfilePath)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "gcr.io/test/app", generated.ImageName)
+
+	cleanup()
+	if _, err := os.Stat(generated.Workspace); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", generated.Workspace)
+	}
+}
+
+func TestS2IWithoutBuilderImage(t *testing.T) {
+	artifact := &v1alpha2.Artifact{
+		ImageName: "gcr.io/test/app",
+		Workspace: ".",
+		ArtifactType: v1alpha2.ArtifactType{
+			S2IArtifact: &v1alpha2.S2IArtifact{},
+		},
+	}
+
+	_, err := (&LocalBuilder{BuildConfig: &v1alpha2.BuildConfig{}}).buildS2I(context.Background(), ioutil.Discard, artifact)
+	testutil.CheckError(t, true, err)
+
+	_, _, err = s2iAsDockerfile(context.Background(), ioutil.Discard, artifact)
+	testutil.CheckError(t, true, err)
+}
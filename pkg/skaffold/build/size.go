@@ -0,0 +1,71 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	units "github.com/docker/go-units"
+	"github.com/sirupsen/logrus"
+)
+
+// imageGrowthWarningRatio is how much an image can grow between two
+// iterations before a warning is printed.
+const imageGrowthWarningRatio = 1.2
+
+type imageSize struct {
+	size   int64
+	layers int
+}
+
+// sizeTracker remembers the size of the last build of each image.
+type sizeTracker struct {
+	lock  sync.Mutex
+	sizes map[string]imageSize
+}
+
+// record prints the size of a new build of an image and how it changed
+// since the previous build.
+func (t *sizeTracker) record(out io.Writer, imageName string, current imageSize) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.sizes == nil {
+		t.sizes = map[string]imageSize{}
+	}
+	previous, present := t.sizes[imageName]
+	t.sizes[imageName] = current
+
+	if !present {
+		fmt.Fprintf(out, "Image size: %s, %d layers\n", units.HumanSize(float64(current.size)), current.layers)
+		return
+	}
+
+	fmt.Fprintf(out, "Image size: %s (%s), %d layers (%+d)\n", units.HumanSize(float64(current.size)), sizeDelta(current.size-previous.size), current.layers, current.layers-previous.layers)
+	if previous.size > 0 && float64(current.size) > float64(previous.size)*imageGrowthWarningRatio {
+		logrus.Warnf("%s grew from %s to %s since the previous build, check for unwanted files in the build context", imageName, units.HumanSize(float64(previous.size)), units.HumanSize(float64(current.size)))
+	}
+}
+
+func sizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + units.HumanSize(float64(-delta))
+	}
+	return "+" + units.HumanSize(float64(delta))
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestSizeTracker(t *testing.T) {
+	var tracker sizeTracker
+	var out bytes.Buffer
+
+	tracker.record(&out, "image", imageSize{size: 10 * 1000 * 1000, layers: 5})
+	tracker.record(&out, "image", imageSize{size: 15 * 1000 * 1000, layers: 6})
+	tracker.record(&out, "image", imageSize{size: 12 * 1000 * 1000, layers: 6})
+	tracker.record(&out, "other", imageSize{size: 1000, layers: 1})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, `Image size: 10MB, 5 layers
+Image size: 15MB (+5MB), 6 layers (+1)
+Image size: 12MB (-3MB), 6 layers (+0)
+Image size: 1kB, 1 layers
+`, out.String())
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// CustomCommandTagger tags an image with the output of a user provided command.
+type CustomCommandTagger struct {
+	Command string
+}
+
+// GenerateFullyQualifiedImageName runs the command in the artifact's workspace
+// and tags the image with what it printed.
+func (c *CustomCommandTagger) GenerateFullyQualifiedImageName(workingDir string, opts *TagOptions) (string, error) {
+	if opts == nil {
+		return "", fmt.Errorf("Tag options not provided")
+	}
+
+	cmd := exec.Command("sh", "-c", c.Command)
+	cmd.Dir = workingDir
+	cmd.Env = append(os.Environ(), "IMAGE_NAME="+opts.ImageName, "DIGEST="+opts.Digest)
+
+	out, err := util.RunCmdOut(cmd)
+	if err != nil {
+		return "", errors.Wrapf(err, "running tag command %s", c.Command)
+	}
+
+	tag := strings.TrimSpace(string(out))
+	if tag == "" {
+		return "", fmt.Errorf("tag command %s printed nothing", c.Command)
+	}
+	if strings.ContainsAny(tag, " \t\n") {
+		return "", fmt.Errorf("tag command %s printed an invalid tag: %q", c.Command, tag)
+	}
+
+	return fmt.Sprintf("%s:%s", opts.ImageName, tag), nil
+}
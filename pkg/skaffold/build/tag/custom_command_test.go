@@ -0,0 +1,67 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestCustomCommandTagger_GenerateFullyQualifiedImageName(t *testing.T) {
+	tests := []struct {
+		name      string
+		stdout    string
+		err       error
+		want      string
+		shouldErr bool
+	}{
+		{
+			name:   "trimmed output",
+			stdout: "2018.07.1\n",
+			want:   "gcr.io/project/web:2018.07.1",
+		},
+		{
+			name:      "failing command",
+			err:       fmt.Errorf("exit status 1"),
+			shouldErr: true,
+		},
+		{
+			name:      "no output",
+			stdout:    "\n",
+			shouldErr: true,
+		},
+		{
+			name:      "several words",
+			stdout:    "not a tag",
+			shouldErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = testutil.NewFakeCmdOut("sh -c ./version.sh", tt.stdout, tt.err)
+
+			c := &CustomCommandTagger{Command: "./version.sh"}
+			got, err := c.GenerateFullyQualifiedImageName(".", &TagOptions{ImageName: "gcr.io/project/web"})
+
+			testutil.CheckErrorAndDeepEqual(t, tt.shouldErr, err, tt.want, got)
+		})
+	}
+}
@@ -22,10 +22,12 @@ import (
 	"os"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
 )
 
 // EnvTemplateTagger implements Tag
@@ -34,11 +36,27 @@ type EnvTemplateTagger struct {
 }
 
 // For testing
-var environ = os.Environ
+var (
+	environ   = os.Environ
+	now       = time.Now
+	gitCommit = headCommit
+)
+
+// templateFuncs can be used in templates, e.g. {{.IMAGE_NAME | replace "/" "-" | lower}}.
+var templateFuncs = template.FuncMap{
+	"lower":   strings.ToLower,
+	"replace": func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+	"trunc": func(n int, s string) string {
+		if len(s) <= n {
+			return s
+		}
+		return s[:n]
+	},
+}
 
 // NewEnvTemplateTagger creates a new EnvTemplateTagger
 func NewEnvTemplateTagger(t string) (*EnvTemplateTagger, error) {
-	tmpl, err := template.New("envTemplate").Parse(t)
+	tmpl, err := template.New("envTemplate").Funcs(templateFuncs).Parse(t)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing template")
 	}
@@ -60,6 +78,12 @@ func (c *EnvTemplateTagger) GenerateFullyQualifiedImageName(workingDir string, o
 	}
 
 	envMap["IMAGE_NAME"] = opts.ImageName
+	envMap["TIMESTAMP"] = now().UTC().Format("20060102150405")
+	if commit, err := gitCommit(workingDir); err == nil {
+		envMap["GIT_COMMIT"] = commit
+	} else {
+		logrus.Debugf("No git commit for %s: %s", workingDir, err)
+	}
 	digest := opts.Digest
 	envMap["DIGEST"] = digest
 	if digest != "" {
@@ -76,3 +100,18 @@ func (c *EnvTemplateTagger) GenerateFullyQualifiedImageName(workingDir string, o
 	}
 	return buf.String(), nil
 }
+
+// headCommit returns the sha of the current commit of the repository that
+// contains workingDir.
+func headCommit(workingDir string) (string, error) {
+	repo, err := git.PlainOpenWithOptions(workingDir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", errors.Wrap(err, "opening git repo")
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "determining current git commit")
+	}
+	return head.Hash().String(), nil
+}
@@ -19,6 +19,7 @@ package tag
 import (
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/GoogleContainerTools/skaffold/testutil"
 )
@@ -86,6 +87,49 @@ func TestEnvTemplateTagger_GenerateFullyQualifiedImageName(t *testing.T) {
 	}
 }
 
+func TestEnvTemplateTagger_MetadataAndFunctions(t *testing.T) {
+	defer func(e func() []string) { environ = e }(environ)
+	defer func(n func() time.Time) { now = n }(now)
+	defer func(g func(string) (string, error)) { gitCommit = g }(gitCommit)
+
+	environ = func() []string { return []string{"BRANCH=Feature/Login"} }
+	now = func() time.Time { return time.Date(2018, 7, 14, 15, 4, 5, 0, time.UTC) }
+	gitCommit = func(string) (string, error) { return "eefe1b9c44eb0aa87199c9a079f2d48d8eb8baed", nil }
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name:     "git commit and timestamp",
+			template: "{{.IMAGE_NAME}}:{{.GIT_COMMIT | trunc 7}}-{{.TIMESTAMP}}",
+			want:     "foo:eefe1b9-20180714150405",
+		},
+		{
+			name:     "string functions",
+			template: `{{.IMAGE_NAME}}:{{.BRANCH | replace "/" "-" | lower}}`,
+			want:     "foo:feature-login",
+		},
+		{
+			name:     "trunc shorter string",
+			template: "{{.IMAGE_NAME}}:{{.BRANCH | trunc 50}}",
+			want:     "foo:Feature/Login",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := NewEnvTemplateTagger(test.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := c.GenerateFullyQualifiedImageName("", &TagOptions{ImageName: "foo"})
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.want, got)
+		})
+	}
+}
+
 func TestNewEnvTemplateTagger(t *testing.T) {
 	tests := []struct {
 		name      string
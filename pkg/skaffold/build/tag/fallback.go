@@ -0,0 +1,65 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// FallbackTagger tries a list of taggers in order and uses the first one
+// that manages to tag the image.
+type FallbackTagger struct {
+	Taggers []Tagger
+}
+
+// GenerateFullyQualifiedImageName tags the image with the first tagger that
+// succeeds, warning about each one that fails.
+func (f *FallbackTagger) GenerateFullyQualifiedImageName(workingDir string, opts *TagOptions) (string, error) {
+	fqn, _, err := f.generateReproducible(workingDir, opts)
+	return fqn, err
+}
+
+// generateReproducible tells if the tagger that tagged the image is reproducible.
+func (f *FallbackTagger) generateReproducible(workingDir string, opts *TagOptions) (string, bool, error) {
+	if opts == nil {
+		return "", false, fmt.Errorf("Tag options not provided")
+	}
+	if len(f.Taggers) == 0 {
+		return "", false, errors.New("no tagger configured")
+	}
+
+	var err error
+	for i, tagger := range f.Taggers {
+		var (
+			tag          string
+			reproducible bool
+		)
+		tag, reproducible, err = GenerateReproducible(tagger, workingDir, opts)
+		if err == nil {
+			return tag, reproducible, nil
+		}
+
+		if i < len(f.Taggers)-1 {
+			logrus.Warnf("Unable to tag %s, falling back to the next tag policy: %s", opts.ImageName, err)
+		}
+	}
+
+	return "", false, errors.Wrapf(err, "every tag policy failed to tag %s", opts.ImageName)
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+type failingTagger struct{}
+
+func (f *failingTagger) GenerateFullyQualifiedImageName(workingDir string, opts *TagOptions) (string, error) {
+	return "", errors.New("not a git repository")
+}
+
+func TestFallbackTagger(t *testing.T) {
+	var tests = []struct {
+		description string
+		taggers     []Tagger
+		opts        *TagOptions
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "first tagger succeeds",
+			taggers:     []Tagger{&CustomTag{Tag: "first"}, &CustomTag{Tag: "second"}},
+			opts:        &TagOptions{ImageName: "test"},
+			expected:    "test:first",
+		},
+		{
+			description: "fall back to the next tagger",
+			taggers:     []Tagger{&failingTagger{}, &ChecksumTagger{}},
+			opts:        &TagOptions{ImageName: "test", Digest: "sha256:12345abcde"},
+			expected:    "test:12345abcde",
+		},
+		{
+			description: "every tagger fails",
+			taggers:     []Tagger{&failingTagger{}, &failingTagger{}},
+			opts:        &TagOptions{ImageName: "test"},
+			shouldErr:   true,
+		},
+		{
+			description: "no tagger",
+			opts:        &TagOptions{ImageName: "test"},
+			shouldErr:   true,
+		},
+		{
+			description: "no tag options",
+			taggers:     []Tagger{&CustomTag{Tag: "first"}},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			f := &FallbackTagger{Taggers: test.taggers}
+
+			tag, err := f.GenerateFullyQualifiedImageName(".", test.opts)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, tag)
+		})
+	}
+}
@@ -22,44 +22,113 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
 	git "gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// Variants of the git tagger.
+const (
+	// CommitSha tags with the full sha of the current commit.
+	CommitSha = "commitSha"
+	// AbbrevCommitSha tags with the abbreviated sha of the current commit.
+	AbbrevCommitSha = "abbrevCommitSha"
+	// Tags tags with the closest git tag, like `git describe --tags` does.
+	Tags = "tags"
+	// BranchName tags with the name of the current branch.
+	BranchName = "branchName"
 )
 
 // GitCommit tags an image by the git commit it was built at.
+// Without a variant, the image is tagged with the git tag of the current
+// commit, or its abbreviated sha. A unique suffix is added when the
+// worktree is dirty. Other variants only get a -dirty suffix.
 type GitCommit struct {
+	Variant string
+	Prefix  string
+}
+
+// NewGitCommit creates a git tagger for a variant.
+func NewGitCommit(variant, prefix string) (*GitCommit, error) {
+	switch variant {
+	case "", CommitSha, AbbrevCommitSha, Tags, BranchName:
+		return &GitCommit{
+			Variant: variant,
+			Prefix:  prefix,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown git tagger variant %q, expected %s, %s, %s or %s", variant, CommitSha, AbbrevCommitSha, Tags, BranchName)
+	}
 }
 
 // GenerateFullyQualifiedImageName tags an image with the supplied image name and the git commit.
 func (c *GitCommit) GenerateFullyQualifiedImageName(workingDir string, opts *TagOptions) (string, error) {
+	fqn, _, err := c.generateReproducible(workingDir, opts)
+	return fqn, err
+}
+
+// generateReproducible tells if the worktree is clean, so that the tag
+// identifies a commit. Branches move from commit to commit so their names
+// never do.
+func (c *GitCommit) generateReproducible(workingDir string, opts *TagOptions) (string, bool, error) {
 	repo, err := git.PlainOpenWithOptions(workingDir, &git.PlainOpenOptions{DetectDotGit: true})
 	if err != nil {
-		return "", errors.Wrap(err, "opening git repo")
+		return "", false, errors.Wrap(err, "opening git repo")
 	}
 
 	w, err := repo.Worktree()
 	if err != nil {
-		return "", errors.Wrap(err, "reading worktree")
+		return "", false, errors.Wrap(err, "reading worktree")
 	}
 
 	status, err := w.Status()
 	if err != nil {
-		return "", errors.Wrap(err, "reading status")
+		return "", false, errors.Wrap(err, "reading status")
 	}
 
 	head, err := repo.Head()
 	if err != nil {
-		return "", errors.Wrap(err, "determining current git commit")
+		return "", false, errors.Wrap(err, "determining current git commit")
 	}
+	reproducible := status.IsClean() && c.Variant != BranchName
+
+	var currentTag string
+	switch c.Variant {
+	case CommitSha:
+		currentTag = head.Hash().String()
+	case AbbrevCommitSha:
+		currentTag = head.Hash().String()[0:7]
+	case Tags:
+		currentTag, err = describe(repo, head)
+	case BranchName:
+		currentTag, err = branchName(head)
+	default:
+		fqn, err := defaultTag(repo, w, status, head, opts.ImageName, c.Prefix)
+		return fqn, reproducible, err
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	if !status.IsClean() {
+		currentTag += "-dirty"
+	}
+	return fmt.Sprintf("%s:%s%s", opts.ImageName, c.Prefix, currentTag), reproducible, nil
+}
 
+// defaultTag uses the git tag of the current commit or its abbreviated sha,
+// with a suffix that's unique to the changes of a dirty worktree.
+func defaultTag(repo *git.Repository, w *git.Worktree, status git.Status, head *plumbing.Reference, imageName, prefix string) (string, error) {
 	commitHash := head.Hash().String()
 	currentTag := commitHash[0:7]
 
 	if status.IsClean() {
 		tagrefs, _ := repo.Tags()
-		err = tagrefs.ForEach(func(t *plumbing.Reference) error {
+		err := tagrefs.ForEach(func(t *plumbing.Reference) error {
 			if t.Hash() == head.Hash() {
 				currentTag = t.Name().Short()
 			}
@@ -69,7 +138,7 @@ func (c *GitCommit) GenerateFullyQualifiedImageName(workingDir string, opts *Tag
 			return "", errors.Wrap(err, "determining git tag")
 		}
 
-		fqn := fmt.Sprintf("%s:%s", opts.ImageName, currentTag)
+		fqn := fmt.Sprintf("%s:%s%s", imageName, prefix, currentTag)
 		return fqn, nil
 	}
 
@@ -103,10 +172,70 @@ func (c *GitCommit) GenerateFullyQualifiedImageName(workingDir string, opts *Tag
 
 	sha := h.Sum(nil)
 	shaStr := hex.EncodeToString(sha[:])[:16]
-	fqn := fmt.Sprintf("%s:%s-dirty-%s", opts.ImageName, currentTag, shaStr)
+	fqn := fmt.Sprintf("%s:%s%s-dirty-%s", imageName, prefix, currentTag, shaStr)
 	return fqn, nil
 }
 
+// describe finds the closest tag among the ancestors of the current commit.
+// Like `git describe --tags`, a tag that's not on the current commit is
+// followed by the number of commits since the tag and the abbreviated sha.
+func describe(repo *git.Repository, head *plumbing.Reference) (string, error) {
+	tags := map[plumbing.Hash]string{}
+	tagrefs, err := repo.Tags()
+	if err != nil {
+		return "", errors.Wrap(err, "listing git tags")
+	}
+	err = tagrefs.ForEach(func(t *plumbing.Reference) error {
+		commit := t.Hash()
+		if annotated, err := repo.TagObject(t.Hash()); err == nil {
+			commit = annotated.Target
+		}
+		tags[commit] = t.Name().Short()
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "listing git tags")
+	}
+
+	commits, err := repo.Log(&git.LogOptions{From: head.Hash(), Order: git.LogOrderBSF})
+	if err != nil {
+		return "", errors.Wrap(err, "reading git log")
+	}
+
+	var (
+		closest string
+		depth   int
+	)
+	err = commits.ForEach(func(commit *object.Commit) error {
+		if tag, present := tags[commit.Hash]; present {
+			closest = tag
+			return storer.ErrStop
+		}
+		depth++
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "reading git log")
+	}
+
+	if closest == "" {
+		return "", errors.New("no git tag found on the current commit or its ancestors")
+	}
+	if depth == 0 {
+		return closest, nil
+	}
+	return fmt.Sprintf("%s-%d-g%s", closest, depth, head.Hash().String()[0:7]), nil
+}
+
+// branchName returns the name of the current branch, with slashes replaced
+// to make it a valid docker tag.
+func branchName(head *plumbing.Reference) (string, error) {
+	if !head.Name().IsBranch() {
+		return "", errors.New("not on a git branch")
+	}
+	return strings.Replace(head.Name().Short(), "/", "-", -1), nil
+}
+
 // changedPaths returns the changed paths in a consistent order.
 // The order is important because we generate a sha256 out of it.
 func changedPaths(status git.Status) []string {
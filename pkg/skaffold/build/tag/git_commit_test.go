@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/GoogleContainerTools/skaffold/testutil"
 	git "gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
@@ -32,6 +33,8 @@ import (
 func TestGitCommit_GenerateFullyQualifiedImageName(t *testing.T) {
 	tests := []struct {
 		description   string
+		variant       string
+		prefix        string
 		expectedName  string
 		createGitRepo func(string)
 		opts          *TagOptions
@@ -177,6 +180,98 @@ func TestGitCommit_GenerateFullyQualifiedImageName(t *testing.T) {
 			createGitRepo: func(dir string) {},
 			shouldErr:     true,
 		},
+		{
+			description:  "commit sha",
+			variant:      CommitSha,
+			opts:         &TagOptions{ImageName: "test"},
+			expectedName: "test:eefe1b9c44eb0aa87199c9a079f2d48d8eb8baed",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					tag("v1")
+			},
+		},
+		{
+			description:  "dirty abbreviated commit sha",
+			variant:      AbbrevCommitSha,
+			opts:         &TagOptions{ImageName: "test"},
+			expectedName: "test:eefe1b9-dirty",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					write("source.go", []byte("updated code"))
+			},
+		},
+		{
+			description:  "closest tag",
+			variant:      Tags,
+			opts:         &TagOptions{ImageName: "test"},
+			expectedName: "test:v1-1-gaea33bc",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					tag("v1").
+					write("other.go", []byte("other")).
+					add("other.go").
+					commit("second commit")
+			},
+		},
+		{
+			description:  "exact tag",
+			variant:      Tags,
+			opts:         &TagOptions{ImageName: "test"},
+			expectedName: "test:v1",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					tag("v1")
+			},
+		},
+		{
+			description: "no tag",
+			variant:     Tags,
+			opts:        &TagOptions{ImageName: "test"},
+			shouldErr:   true,
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial")
+			},
+		},
+		{
+			description:  "branch name with prefix",
+			variant:      BranchName,
+			prefix:       "dev-",
+			opts:         &TagOptions{ImageName: "test"},
+			expectedName: "test:dev-master",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial")
+			},
+		},
+		{
+			description:  "default variant with prefix",
+			prefix:       "dev-",
+			opts:         &TagOptions{ImageName: "test"},
+			expectedName: "test:dev-eefe1b9",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial")
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -186,7 +281,7 @@ func TestGitCommit_GenerateFullyQualifiedImageName(t *testing.T) {
 
 			tt.createGitRepo(tmpDir)
 
-			c := &GitCommit{}
+			c := &GitCommit{Variant: tt.variant, Prefix: tt.prefix}
 			name, err := c.GenerateFullyQualifiedImageName(tmpDir, tt.opts)
 
 			testutil.CheckErrorAndDeepEqual(t, tt.shouldErr, err, tt.expectedName, name)
@@ -217,6 +312,78 @@ func TestGenerateFullyQualifiedImageNameFromSubDirectory(t *testing.T) {
 	}
 }
 
+func TestGenerateReproducible(t *testing.T) {
+	var tests = []struct {
+		description string
+		tagger      Tagger
+		dirty       bool
+		expected    bool
+	}{
+		{
+			description: "git tag of a clean tree",
+			tagger:      &GitCommit{},
+			expected:    true,
+		},
+		{
+			description: "git tag of a dirty tree",
+			tagger:      &GitCommit{Variant: AbbrevCommitSha},
+			dirty:       true,
+		},
+		{
+			description: "branch name",
+			tagger:      &GitCommit{Variant: BranchName},
+		},
+		{
+			description: "sanitized git tag",
+			tagger:      WithSanitization(&GitCommit{Variant: CommitSha}),
+			expected:    true,
+		},
+		{
+			description: "fallback to a git tag",
+			tagger:      &FallbackTagger{Taggers: []Tagger{&failingTagger{}, &GitCommit{}}},
+			expected:    true,
+		},
+		{
+			description: "input digest",
+			tagger: NewInputDigestTagger([]*v1alpha2.Artifact{{ImageName: "test"}}, func(*v1alpha2.Artifact) (string, error) {
+				return "v1", nil
+			}),
+			expected: true,
+		},
+		{
+			description: "checksum",
+			tagger:      &ChecksumTagger{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			repo := gitInit(t, tmpDir).
+				write("source.go", []byte("code")).
+				add("source.go").
+				commit("initial")
+			if test.dirty {
+				repo.write("source.go", []byte("changed"))
+			}
+
+			_, reproducible, err := GenerateReproducible(test.tagger, tmpDir, &TagOptions{ImageName: "test", Digest: "sha256:12345abcde"})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, reproducible)
+		})
+	}
+}
+
+func TestNewGitCommit(t *testing.T) {
+	_, err := NewGitCommit(BranchName, "")
+	testutil.CheckError(t, false, err)
+
+	_, err = NewGitCommit("unknown", "")
+	testutil.CheckError(t, true, err)
+}
+
 // gitRepo deals with test git repositories
 type gitRepo struct {
 	dir      string
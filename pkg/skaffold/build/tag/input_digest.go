@@ -0,0 +1,70 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+)
+
+// InputDigestTagger tags an image with a digest of the inputs of its build.
+type InputDigestTagger struct {
+	artifacts map[string]*v1alpha2.Artifact
+	digest    func(*v1alpha2.Artifact) (string, error)
+}
+
+// NewInputDigestTagger creates a tagger for the given artifacts. digest
+// computes the digest of an artifact's inputs.
+func NewInputDigestTagger(artifacts []*v1alpha2.Artifact, digest func(*v1alpha2.Artifact) (string, error)) *InputDigestTagger {
+	byName := map[string]*v1alpha2.Artifact{}
+	for _, a := range artifacts {
+		byName[a.ImageName] = a
+	}
+
+	return &InputDigestTagger{
+		artifacts: byName,
+		digest:    digest,
+	}
+}
+
+// GenerateFullyQualifiedImageName tags an image with the digest of its inputs.
+func (t *InputDigestTagger) GenerateFullyQualifiedImageName(workingDir string, opts *TagOptions) (string, error) {
+	if opts == nil {
+		return "", fmt.Errorf("Tag options not provided")
+	}
+
+	a, present := t.artifacts[opts.ImageName]
+	if !present {
+		return "", fmt.Errorf("unknown artifact %s", opts.ImageName)
+	}
+
+	digest, err := t.digest(a)
+	if err != nil {
+		return "", errors.Wrapf(err, "computing the input digest of %s", opts.ImageName)
+	}
+
+	return fmt.Sprintf("%s:%s", opts.ImageName, digest), nil
+}
+
+// generateReproducible always tells the tag is reproducible: it's a digest
+// of the inputs.
+func (t *InputDigestTagger) generateReproducible(workingDir string, opts *TagOptions) (string, bool, error) {
+	fqn, err := t.GenerateFullyQualifiedImageName(workingDir, opts)
+	return fqn, true, err
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestInputDigestTagger_GenerateFullyQualifiedImageName(t *testing.T) {
+	digest := func(a *v1alpha2.Artifact) (string, error) {
+		if a.Workspace == "broken" {
+			return "", fmt.Errorf("unreadable dependency")
+		}
+		return "d1g35t", nil
+	}
+	tagger := NewInputDigestTagger([]*v1alpha2.Artifact{
+		{ImageName: "gcr.io/project/web", Workspace: "web"},
+		{ImageName: "gcr.io/project/broken", Workspace: "broken"},
+	}, digest)
+
+	tests := []struct {
+		name      string
+		imageName string
+		want      string
+		shouldErr bool
+	}{
+		{
+			name:      "digest",
+			imageName: "gcr.io/project/web",
+			want:      "gcr.io/project/web:d1g35t",
+		},
+		{
+			name:      "failing digest",
+			imageName: "gcr.io/project/broken",
+			shouldErr: true,
+		},
+		{
+			name:      "unknown artifact",
+			imageName: "gcr.io/project/unknown",
+			shouldErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tagger.GenerateFullyQualifiedImageName(".", &TagOptions{ImageName: tt.imageName})
+
+			testutil.CheckErrorAndDeepEqual(t, tt.shouldErr, err, tt.want, got)
+		})
+	}
+}
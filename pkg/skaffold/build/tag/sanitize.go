@@ -0,0 +1,136 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/warnings"
+	"github.com/docker/distribution/reference"
+)
+
+// maxTagLength is the longest tag a registry accepts.
+const maxTagLength = 128
+
+var (
+	invalidTagChars = regexp.MustCompile(`[^\w.-]+`)
+	registryPort    = regexp.MustCompile(`^[^/:]+:[0-9]+/`)
+)
+
+// InvalidTagError is returned for a generated image name that can't be
+// turned into a valid image reference.
+type InvalidTagError struct {
+	ImageName string
+	Reason    string
+}
+
+func (e *InvalidTagError) Error() string {
+	return fmt.Sprintf("invalid image name %q: %s", e.ImageName, e.Reason)
+}
+
+// Sanitize normalizes the tag of a fully qualified image name so that
+// registries accept it: invalid characters are replaced with dashes,
+// leading dots and dashes are dropped and the tag is truncated to 128
+// characters. It fails if the result still isn't a valid image reference.
+func Sanitize(fqn string) (string, error) {
+	name, tag := splitTag(fqn)
+	if tag == "" {
+		return "", &InvalidTagError{ImageName: fqn, Reason: "the tag is empty"}
+	}
+
+	sanitized := invalidTagChars.ReplaceAllString(tag, "-")
+	sanitized = strings.TrimLeft(sanitized, ".-")
+	if len(sanitized) > maxTagLength {
+		sanitized = sanitized[:maxTagLength]
+	}
+	if sanitized == "" {
+		return "", &InvalidTagError{ImageName: fqn, Reason: "the tag has no valid characters"}
+	}
+
+	normalized := name + ":" + sanitized
+	if _, err := reference.ParseNormalizedNamed(normalized); err != nil {
+		return "", &InvalidTagError{ImageName: fqn, Reason: err.Error()}
+	}
+
+	if sanitized != tag {
+		warnings.Warnf(warnings.InvalidTag, "%s isn't a valid image tag, using %s instead", tag, sanitized)
+	}
+	return normalized, nil
+}
+
+// splitTag splits an image name from its tag. The tag follows the first
+// colon that isn't the port of a registry, so that it can contain slashes.
+func splitTag(fqn string) (string, string) {
+	start := 0
+	if port := registryPort.FindStringIndex(fqn); port != nil {
+		start = port[1]
+	}
+
+	i := strings.Index(fqn[start:], ":")
+	if i < 0 {
+		return fqn, ""
+	}
+	return fqn[:start+i], fqn[start+i+1:]
+}
+
+type sanitizingTagger struct {
+	Tagger
+}
+
+// WithSanitization sanitizes every image name generated by a tagger. Tags
+// that can't be sanitized fail with an InvalidTagError, when the image is
+// tagged or beforehand with CheckTag.
+func WithSanitization(tagger Tagger) Tagger {
+	return &sanitizingTagger{Tagger: tagger}
+}
+
+func (s *sanitizingTagger) GenerateFullyQualifiedImageName(workingDir string, opts *TagOptions) (string, error) {
+	fqn, _, err := s.generateReproducible(workingDir, opts)
+	return fqn, err
+}
+
+func (s *sanitizingTagger) generateReproducible(workingDir string, opts *TagOptions) (string, bool, error) {
+	fqn, reproducible, err := GenerateReproducible(s.Tagger, workingDir, opts)
+	if err != nil {
+		return "", false, err
+	}
+
+	sanitized, err := Sanitize(fqn)
+	return sanitized, reproducible, err
+}
+
+// CheckTag fails with an InvalidTagError if the tag that a tagger will give an
+// image can't be sanitized. Only the taggers without side effects, that don't
+// depend on the built image, are run: custom tags, env templates and git
+// commits. Other taggers are left for when the image is tagged.
+func CheckTag(tagger Tagger, workingDir string, opts *TagOptions) error {
+	s, ok := tagger.(*sanitizingTagger)
+	if !ok {
+		return nil
+	}
+
+	switch s.Tagger.(type) {
+	case *CustomTag, *EnvTemplateTagger, *GitCommit:
+		_, err := s.GenerateFullyQualifiedImageName(workingDir, opts)
+		if _, invalid := err.(*InvalidTagError); invalid {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestSanitize(t *testing.T) {
+	var tests = []struct {
+		description string
+		fqn         string
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "valid tag",
+			fqn:         "gcr.io/project/image:v1.0_rc-1",
+			expected:    "gcr.io/project/image:v1.0_rc-1",
+		},
+		{
+			description: "registry with a port",
+			fqn:         "localhost:5000/image:latest",
+			expected:    "localhost:5000/image:latest",
+		},
+		{
+			description: "branch name with slashes",
+			fqn:         "image:feature/login",
+			expected:    "image:feature-login",
+		},
+		{
+			description: "registry with a port and a branch name",
+			fqn:         "localhost:5000/image:fix/typo",
+			expected:    "localhost:5000/image:fix-typo",
+		},
+		{
+			description: "leading dash and spaces",
+			fqn:         "image:-my tag",
+			expected:    "image:my-tag",
+		},
+		{
+			description: "too long",
+			fqn:         "image:" + strings.Repeat("a", 200),
+			expected:    "image:" + strings.Repeat("a", 128),
+		},
+		{
+			description: "no tag",
+			fqn:         "localhost:5000/image",
+			shouldErr:   true,
+		},
+		{
+			description: "no valid character",
+			fqn:         "image:---",
+			shouldErr:   true,
+		},
+		{
+			description: "invalid image name",
+			fqn:         "Image:v1",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			fqn, err := Sanitize(test.fqn)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, fqn)
+		})
+	}
+}
+
+func TestWithSanitization(t *testing.T) {
+	tagger := WithSanitization(&CustomTag{Tag: "feature/login"})
+
+	fqn, err := tagger.GenerateFullyQualifiedImageName(".", &TagOptions{ImageName: "image"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "image:feature-login", fqn)
+}
+
+func TestWithSanitizationInvalidTag(t *testing.T) {
+	tagger := WithSanitization(&CustomTag{Tag: "..."})
+
+	_, err := tagger.GenerateFullyQualifiedImageName(".", &TagOptions{ImageName: "image"})
+
+	_, invalid := err.(*InvalidTagError)
+	testutil.CheckErrorAndDeepEqual(t, true, err, true, invalid)
+}
+
+func TestCheckTag(t *testing.T) {
+	var tests = []struct {
+		description string
+		tagger      Tagger
+		shouldErr   bool
+	}{
+		{
+			description: "valid tag",
+			tagger:      WithSanitization(&CustomTag{Tag: "v1"}),
+		},
+		{
+			description: "tag that can't be sanitized",
+			tagger:      WithSanitization(&CustomTag{Tag: "..."}),
+			shouldErr:   true,
+		},
+		{
+			description: "tagger with side effects",
+			tagger:      WithSanitization(&CustomCommandTagger{Command: "echo ..."}),
+		},
+		{
+			description: "tag that depends on the built image",
+			tagger:      WithSanitization(&ChecksumTagger{}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			err := CheckTag(test.tagger, ".", &TagOptions{ImageName: "image"})
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
@@ -25,3 +25,22 @@ type TagOptions struct {
 	ImageName string
 	Digest    string
 }
+
+// reproducibleTagger is implemented by taggers that can tell whether the tag
+// they give an image identifies the content of that image.
+type reproducibleTagger interface {
+	generateReproducible(workingDir string, opts *TagOptions) (string, bool, error)
+}
+
+// GenerateReproducible tags an image like the tagger does, and tells if the
+// tag identifies the content of the image, like a digest of the build inputs
+// or the commit of a clean worktree do. An image that already has such a
+// tag doesn't need to be built again.
+func GenerateReproducible(tagger Tagger, workingDir string, opts *TagOptions) (string, bool, error) {
+	if r, ok := tagger.(reproducibleTagger); ok {
+		return r.generateReproducible(workingDir, opts)
+	}
+
+	fqn, err := tagger.GenerateFullyQualifiedImageName(workingDir, opts)
+	return fqn, false, err
+}
@@ -59,6 +59,14 @@ build:
     projectId: ID
 deploy:
   kubectl: {}
+`
+	fallbackConfig = `
+apiVersion: skaffold/v1alpha2
+kind: Config
+build:
+  tagPolicy:
+    fallback:
+    - sha256: {}
 `
 	badConfig = "bad config"
 )
@@ -103,6 +111,18 @@ func TestParseConfig(t *testing.T) {
 				withKubectlDeploy(),
 			),
 		},
+		{
+			description: "Fallback tag policy only",
+			config:      fallbackConfig,
+			expected: config(
+				withLocalBuild(
+					withTagPolicy(v1alpha2.TagPolicy{
+						GitTagger: &v1alpha2.GitTagger{},
+						Fallback:  []v1alpha2.TagPolicy{{ShaTagger: &v1alpha2.ShaTagger{}}},
+					}),
+				),
+			),
+		},
 		{
 			description: "Bad config",
 			config:      badConfig,
@@ -0,0 +1,73 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+)
+
+// Changes lists how the pipeline changes from one configuration to the
+// next, e.g. "artifact gcr.io/k8s-skaffold/web added". It returns nothing
+// when both configurations run the same pipeline.
+func Changes(previous, next *SkaffoldConfig) []string {
+	var changes []string
+
+	previousArtifacts := artifactsByImage(previous.Build.Artifacts)
+	nextArtifacts := artifactsByImage(next.Build.Artifacts)
+	for _, a := range previous.Build.Artifacts {
+		if _, present := nextArtifacts[a.ImageName]; !present {
+			changes = append(changes, fmt.Sprintf("artifact %s removed", a.ImageName))
+		}
+	}
+	for _, a := range next.Build.Artifacts {
+		before, present := previousArtifacts[a.ImageName]
+		switch {
+		case !present:
+			changes = append(changes, fmt.Sprintf("artifact %s added", a.ImageName))
+		case !reflect.DeepEqual(before, a):
+			changes = append(changes, fmt.Sprintf("artifact %s changed", a.ImageName))
+		}
+	}
+
+	previousBuild, nextBuild := previous.Build, next.Build
+	previousBuild.Artifacts, nextBuild.Artifacts = nil, nil
+	if !reflect.DeepEqual(previousBuild, nextBuild) {
+		changes = append(changes, "build settings changed")
+	}
+	if !reflect.DeepEqual(previous.Deploy, next.Deploy) {
+		changes = append(changes, "deploy settings changed")
+	}
+	if !reflect.DeepEqual(previous.ImageRepositories, next.ImageRepositories) {
+		changes = append(changes, "image repositories changed")
+	}
+	if !reflect.DeepEqual(previous.Profiles, next.Profiles) {
+		changes = append(changes, "profiles changed")
+	}
+
+	return changes
+}
+
+func artifactsByImage(artifacts []*v1alpha2.Artifact) map[string]*v1alpha2.Artifact {
+	byImage := map[string]*v1alpha2.Artifact{}
+	for _, a := range artifacts {
+		byImage[a.ImageName] = a
+	}
+	return byImage
+}
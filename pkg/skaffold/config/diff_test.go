@@ -0,0 +1,76 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestChanges(t *testing.T) {
+	config := func(deploy string, artifacts ...*v1alpha2.Artifact) *SkaffoldConfig {
+		return &SkaffoldConfig{
+			Build: v1alpha2.BuildConfig{
+				Artifacts: artifacts,
+				TagPolicy: v1alpha2.TagPolicy{ShaTagger: &v1alpha2.ShaTagger{}},
+			},
+			Deploy: v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{
+					KubectlDeploy: &v1alpha2.KubectlDeploy{Manifests: []string{deploy}},
+				},
+			},
+		}
+	}
+	web := &v1alpha2.Artifact{ImageName: "web", Workspace: "web"}
+	api := &v1alpha2.Artifact{ImageName: "api", Workspace: "api"}
+	movedAPI := &v1alpha2.Artifact{ImageName: "api", Workspace: "backend"}
+
+	var tests = []struct {
+		description string
+		previous    *SkaffoldConfig
+		next        *SkaffoldConfig
+		expected    []string
+	}{
+		{
+			description: "same pipeline",
+			previous:    config("k8s/*.yaml", web, api),
+			next:        config("k8s/*.yaml", &v1alpha2.Artifact{ImageName: "web", Workspace: "web"}, api),
+		},
+		{
+			description: "artifacts added, removed and changed",
+			previous:    config("k8s/*.yaml", web, api),
+			next:        config("k8s/*.yaml", movedAPI, &v1alpha2.Artifact{ImageName: "worker"}),
+			expected:    []string{"artifact web removed", "artifact api changed", "artifact worker added"},
+		},
+		{
+			description: "deploy changed",
+			previous:    config("k8s/*.yaml", web),
+			next:        config("manifests/*.yaml", web),
+			expected:    []string{"deploy settings changed"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			changes := Changes(test.previous, test.next)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, changes)
+		})
+	}
+}
@@ -16,11 +16,49 @@ limitations under the License.
 
 package config
 
+import "time"
+
 // SkaffoldOptions are options that are set by command line arguments not included
 // in the config file itself
 type SkaffoldOptions struct {
 	Cleanup      bool
+	DevMode      bool
 	Notification bool
 	Profiles     []string
 	CustomTag    string
+	CacheFile    string
+	// CacheArtifacts skips building artifacts whose inputs haven't changed
+	CacheArtifacts bool
+	// RecordFile is where the changes of a dev session are recorded
+	RecordFile string
+	// ReplayFile holds recorded changes to replay instead of watching files
+	ReplayFile string
+	// Trigger is how changes are noticed: notify, polling or manual
+	Trigger           string
+	WatchPollInterval time.Duration
+	// StubBuilds skips the builds, to measure the rest of the dev loop
+	StubBuilds bool
+	// Resume skips building and deploying what an interrupted dev session left unchanged
+	Resume      bool
+	SessionFile string
+	// Simulate injects faults in the pipeline, to test scripts that wrap skaffold
+	Simulate string
+	// BuildOutputFile is where `skaffold build` writes what it built, as json
+	BuildOutputFile string
+	// PreviewBranch deploys the preview environment of a git branch
+	PreviewBranch string
+	// Namespace is where manifests are deployed. kubectl rejects manifests
+	// that set a different metadata.namespace
+	Namespace string
+	// EventAPIAddress is where the event API listens, if enabled
+	EventAPIAddress string
+	// Debug configures the deployed containers so that debuggers can attach
+	Debug bool
+	// Tail streams the logs of the deployed pods after `skaffold run`
+	Tail bool
+	// PortForward forwards the container ports of the deployed pods in dev mode
+	PortForward bool
+	// SkipRunLabels leaves out the run id and managed-by labels, e.g. to render
+	// manifests that don't change from one run to the next
+	SkipRunLabels bool
 }
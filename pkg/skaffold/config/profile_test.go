@@ -215,6 +215,42 @@ func TestApplyProfiles(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "image repositories",
+			profile:     "prod",
+			config: SkaffoldConfig{
+				Build: v1alpha2.BuildConfig{
+					TagPolicy: v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}},
+					BuildType: v1alpha2.BuildType{
+						LocalBuild: &v1alpha2.LocalBuild{},
+					},
+				},
+				ImageRepositories: map[string]string{
+					"gcr.io/dev/web": "gcr.io/mirror/web",
+					"gcr.io/dev/api": "gcr.io/mirror/api",
+				},
+				Profiles: []v1alpha2.Profile{
+					{
+						Name: "prod",
+						ImageRepositories: map[string]string{
+							"gcr.io/dev/web": "eu.gcr.io/prod/web",
+						},
+					},
+				},
+			},
+			expected: SkaffoldConfig{
+				Build: v1alpha2.BuildConfig{
+					TagPolicy: v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}},
+					BuildType: v1alpha2.BuildType{
+						LocalBuild: &v1alpha2.LocalBuild{},
+					},
+				},
+				ImageRepositories: map[string]string{
+					"gcr.io/dev/web": "eu.gcr.io/prod/web",
+					"gcr.io/dev/api": "gcr.io/mirror/api",
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
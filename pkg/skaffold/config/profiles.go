@@ -0,0 +1,79 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+// SplitProfiles accepts profiles given as separate flags or separated by
+// commas. Profiles are applied in order, so later ones take precedence.
+func SplitProfiles(profiles []string) []string {
+	var split []string
+	for _, p := range profiles {
+		for _, name := range strings.Split(p, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				split = append(split, name)
+			}
+		}
+	}
+	return split
+}
+
+// ActiveProfilesFile returns the file through which the dev session running
+// in a directory is told to switch profiles.
+func ActiveProfilesFile(dir string) (string, error) {
+	profilesDir, err := homedir.Expand(constants.DefaultActiveProfilesDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "expanding %s", constants.DefaultActiveProfilesDir)
+	}
+
+	sum := sha256.Sum256([]byte(dir))
+	return filepath.Join(profilesDir, hex.EncodeToString(sum[:8])), nil
+}
+
+// WriteActiveProfiles writes a list of profiles, one per line.
+func WriteActiveProfiles(path string, profiles []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "creating profiles directory")
+	}
+
+	var content string
+	for _, p := range profiles {
+		content += p + "\n"
+	}
+	return ioutil.WriteFile(path, []byte(content), 0644)
+}
+
+// ReadActiveProfiles reads a list of profiles written by WriteActiveProfiles.
+func ReadActiveProfiles(path string) ([]string, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return SplitProfiles(strings.Split(string(buf), "\n")), nil
+}
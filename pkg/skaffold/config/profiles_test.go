@@ -0,0 +1,54 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestSplitProfiles(t *testing.T) {
+	profiles := SplitProfiles([]string{"dev,gpu", " staging ", "", "a,,b"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"dev", "gpu", "staging", "a", "b"}, profiles)
+}
+
+func TestActiveProfiles(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	path := filepath.Join(dir, "profiles", "session")
+
+	err := WriteActiveProfiles(path, []string{"dev", "gpu"})
+	testutil.CheckError(t, false, err)
+
+	profiles, err := ReadActiveProfiles(path)
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"dev", "gpu"}, profiles)
+}
+
+func TestActiveProfilesFile(t *testing.T) {
+	first, err := ActiveProfilesFile("/projects/first")
+	testutil.CheckError(t, false, err)
+	again, _ := ActiveProfilesFile("/projects/first")
+	second, _ := ActiveProfilesFile("/projects/second")
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, first, again)
+	if first == second {
+		t.Errorf("expected different files for different directories, got %s", first)
+	}
+}
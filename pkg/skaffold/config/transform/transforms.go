@@ -23,7 +23,7 @@ import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/util"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha1"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
-	"github.com/sirupsen/logrus"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/warnings"
 )
 
 func ToV1Alpha2(vc util.VersionedConfig) (util.VersionedConfig, error) {
@@ -63,7 +63,7 @@ func ToV1Alpha2(vc util.VersionedConfig) (util.VersionedConfig, error) {
 	var newKubectlDeploy *v1alpha2.KubectlDeploy
 	if oldConfig.Deploy.DeployType.KubectlDeploy != nil {
 		newManifests := make([]string, 0)
-		logrus.Warn("Ignoring manifest parameters when transforming v1alpha1 config; check kubernetes yaml before running skaffold")
+		warnings.Warnf(warnings.DeprecatedField, "Ignoring manifest parameters when transforming v1alpha1 config; check kubernetes yaml before running skaffold")
 		for _, manifest := range oldConfig.Deploy.DeployType.KubectlDeploy.Manifests {
 			newManifests = append(newManifests, manifest.Paths...)
 		}
@@ -38,7 +38,72 @@ const (
 	DefaultDockerForDesktopContext = "docker-for-desktop"
 	GCSBucketSuffix                = "_cloudbuild"
 
-	DefaultKanikoImage = "gcr.io/kaniko-project/executor:latest"
+	// KindContextPrefix starts the names of the contexts created by kind,
+	// followed by the cluster name. Older versions of kind used LegacyKindContext.
+	KindContextPrefix = "kind-"
+	LegacyKindContext = "kubernetes-admin@kind"
+
+	// PushAuto, PushAlways and PushNever are the values of local.push.
+	// With PushAuto, images are only pushed for remote clusters.
+	PushAuto   = "auto"
+	PushAlways = "true"
+	PushNever  = "false"
+
+	// DefaultCloudBuildDockerImage runs the docker build step on Google Cloud Build.
+	DefaultCloudBuildDockerImage = "gcr.io/cloud-builders/docker"
+
+	DefaultKanikoImage     = "gcr.io/kaniko-project/executor:latest"
+	DefaultKanikoNamespace = "default"
+	DefaultKanikoTimeout   = "10m"
+
+	// DefaultKanikoStartTimeout is how long the kaniko pod can wait to be scheduled.
+	DefaultKanikoStartTimeout = "3m"
+
+	// KanikoFallbackLocal builds the artifact locally when the kaniko pod can't start.
+	KanikoFallbackLocal = "local"
+
+	// KanikoContextGCS and KanikoContextLocal are the ways the build context
+	// can be sent to kaniko: uploaded to a bucket or copied into the build pod.
+	KanikoContextGCS   = "gcs"
+	KanikoContextLocal = "local"
+
+	// DefaultKanikoContextImage runs the init container that receives a local context.
+	DefaultKanikoContextImage = "busybox"
+
+	// DefaultKanikoDecryptImage is used by the init container that decrypts
+	// an encrypted kaniko build context.
+	DefaultKanikoDecryptImage = "google/cloud-sdk:alpine"
+
+	// RoleTest is the role of artifacts that are run as Jobs, after each deploy,
+	// to test the deployed application.
+	RoleTest = "test"
+
+	DefaultTestNamespace = "default"
+	DefaultTestTimeout   = "10m"
+
+	// DefaultStatusCheckDeadline is how long deployed workloads have to roll out,
+	// like the default progress deadline of Deployments.
+	DefaultStatusCheckDeadline = "10m"
+
+	// DefaultCacheFile is where built artifacts are cached, relative to the home directory.
+	DefaultCacheFile = "~/.skaffold/cache"
+
+	// DefaultLockFile is where `skaffold lock update` records the digests of base images.
+	DefaultLockFile = "skaffold.lock"
+
+	// DefaultSessionFile is where dev sessions record what they deployed, relative to the home directory.
+	DefaultSessionFile = "~/.skaffold/sessions"
+
+	// DefaultDeployedDir records the manifests applied by kubectl, so that they
+	// can all be deleted, relative to the home directory.
+	DefaultDeployedDir = "~/.skaffold/deployed"
+
+	// DefaultActiveProfilesDir holds the files through which running dev
+	// sessions are told to switch profiles, relative to the home directory.
+	DefaultActiveProfilesDir = "~/.skaffold/profiles"
+
+	// DefaultGlobalConfigFile holds the settings that apply to every project, like feature flags.
+	DefaultGlobalConfigFile = "~/.skaffold/config"
 
 	// TerminalBell is the sequence that triggers a beep in the terminal
 	TerminalBell = "\007"
@@ -0,0 +1,210 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ConfigChecksumAnnotation is set on pod templates to the checksum of the
+// ConfigMaps and Secrets they reference, so that pods are restarted when those change.
+const ConfigChecksumAnnotation = "skaffold.dev/config-checksum"
+
+// injectChecksums annotates the pod templates with the checksum of the
+// ConfigMaps and Secrets that they reference and that are part of the manifests,
+// in the same namespace. Resources without a namespace are in the given default
+// namespace.
+func (l *manifestList) injectChecksums(defaultNamespace string) (manifestList, error) {
+	var parsed []map[interface{}]interface{}
+	for _, manifest := range *l {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			return nil, errors.Wrap(err, "reading kubernetes YAML")
+		}
+		if len(m) == 0 {
+			continue
+		}
+		parsed = append(parsed, m)
+	}
+
+	checksums := map[string]string{}
+	for _, m := range parsed {
+		kind, name := m["kind"], nestedString(m, "metadata", "name")
+		if kind != "ConfigMap" && kind != "Secret" {
+			continue
+		}
+
+		content, err := yaml.Marshal(map[string]interface{}{
+			"data":       m["data"],
+			"binaryData": m["binaryData"],
+			"stringData": m["stringData"],
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshalling %s %s", kind, name)
+		}
+		checksums[configKey(kind, namespaceOf(m, defaultNamespace), name)] = checksum(content)
+	}
+
+	var updatedManifests manifestList
+	for _, m := range parsed {
+		if template := podTemplate(m); template != nil {
+			if sum := referencedChecksum(template["spec"], namespaceOf(m, defaultNamespace), checksums); sum != "" {
+				logrus.Debugf("Annotating %s %s with config checksum %s", m["kind"], nestedString(m, "metadata", "name"), sum)
+				setAnnotation(template, ConfigChecksumAnnotation, sum)
+			}
+		}
+
+		updatedManifest, err := yaml.Marshal(m)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling yaml")
+		}
+		updatedManifests = append(updatedManifests, updatedManifest)
+	}
+
+	return updatedManifests, nil
+}
+
+// podTemplate returns the pod template of a workload, or nil.
+func podTemplate(m map[interface{}]interface{}) map[interface{}]interface{} {
+	var path []string
+	switch m["kind"] {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		path = []string{"spec", "template"}
+	case "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template"}
+	default:
+		return nil
+	}
+
+	var current interface{} = m
+	for _, key := range path {
+		asMap, ok := current.(map[interface{}]interface{})
+		if !ok {
+			return nil
+		}
+		current = asMap[key]
+	}
+
+	template, _ := current.(map[interface{}]interface{})
+	return template
+}
+
+// namespaceOf returns the namespace of a resource, or the default namespace.
+func namespaceOf(m map[interface{}]interface{}, defaultNamespace string) string {
+	if namespace := nestedString(m, "metadata", "namespace"); namespace != "" {
+		return namespace
+	}
+	return defaultNamespace
+}
+
+// configKey identifies a ConfigMap or a Secret.
+func configKey(kind interface{}, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+// referencedChecksum combines the checksums of the ConfigMaps and Secrets referenced
+// by a pod spec through volumes, env or envFrom. They're in the pod's namespace.
+func referencedChecksum(podSpec interface{}, namespace string, checksums map[string]string) string {
+	referenced := map[string]bool{}
+	collectReferences(podSpec, namespace, referenced)
+
+	var keys []string
+	for key := range referenced {
+		if _, present := checksums[key]; present {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Strings(keys)
+
+	var content []byte
+	for _, key := range keys {
+		content = append(content, []byte(key+"="+checksums[key]+"\n")...)
+	}
+	return checksum(content)
+}
+
+func collectReferences(i interface{}, namespace string, referenced map[string]bool) {
+	switch t := i.(type) {
+	case []interface{}:
+		for _, v := range t {
+			collectReferences(v, namespace, referenced)
+		}
+	case map[interface{}]interface{}:
+		for k, v := range t {
+			switch k {
+			case "configMap", "configMapRef", "configMapKeyRef":
+				if name := nestedString(v, "name"); name != "" {
+					referenced[configKey("ConfigMap", namespace, name)] = true
+				}
+			case "secret", "secretRef", "secretKeyRef":
+				name := nestedString(v, "secretName")
+				if name == "" {
+					name = nestedString(v, "name")
+				}
+				if name != "" {
+					referenced[configKey("Secret", namespace, name)] = true
+				}
+			default:
+				collectReferences(v, namespace, referenced)
+			}
+		}
+	}
+}
+
+func setAnnotation(template map[interface{}]interface{}, key, value string) {
+	metadata, ok := template["metadata"].(map[interface{}]interface{})
+	if !ok {
+		metadata = map[interface{}]interface{}{}
+		template["metadata"] = metadata
+	}
+
+	annotations, ok := metadata["annotations"].(map[interface{}]interface{})
+	if !ok {
+		annotations = map[interface{}]interface{}{}
+		metadata["annotations"] = annotations
+	}
+
+	annotations[key] = value
+}
+
+func nestedString(i interface{}, keys ...string) string {
+	for _, key := range keys {
+		asMap, ok := i.(map[interface{}]interface{})
+		if !ok {
+			return ""
+		}
+		i = asMap[key]
+	}
+
+	s, _ := i.(string)
+	return s
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
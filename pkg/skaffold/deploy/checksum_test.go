@@ -0,0 +1,73 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const checksumDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        envFrom:
+        - configMapRef:
+            name: app-config
+      volumes:
+      - name: certs
+        secret:
+          secretName: app-certs`
+
+func annotationOf(t *testing.T, manifests manifestList, index int) string {
+	m := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(manifests[index], &m); err != nil {
+		t.Fatal(err)
+	}
+	return nestedString(m, "spec", "template", "metadata", "annotations", ConfigChecksumAnnotation)
+}
+
+func TestInjectChecksums(t *testing.T) {
+	configV1 := manifestList{[]byte(checksumDeployment), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-config\ndata:\n  key: v1")}
+	configV2 := manifestList{[]byte(checksumDeployment), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-config\ndata:\n  key: v2")}
+	unrelated := manifestList{[]byte(checksumDeployment), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\ndata:\n  key: v1")}
+	otherNamespace := manifestList{[]byte(checksumDeployment), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-config\n  namespace: other\ndata:\n  key: v1")}
+	explicitNamespace := manifestList{[]byte(checksumDeployment), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-config\n  namespace: apps\ndata:\n  key: v1")}
+	withSecret := manifestList{[]byte(checksumDeployment), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-config\ndata:\n  key: v1"), []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: app-certs\ndata:\n  cert: Y2VydA==")}
+
+	checksums := map[string]string{}
+	for name, manifests := range map[string]manifestList{"v1": configV1, "v2": configV2, "unrelated": unrelated, "other namespace": otherNamespace, "explicit namespace": explicitNamespace, "secret": withSecret} {
+		injected, err := manifests.injectChecksums("apps")
+		testutil.CheckErrorAndDeepEqual(t, false, err, len(manifests), len(injected))
+		checksums[name] = annotationOf(t, injected, 0)
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 64, len(checksums["v1"]))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "", checksums["unrelated"])
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "", checksums["other namespace"])
+	testutil.CheckErrorAndDeepEqual(t, false, nil, checksums["v1"], checksums["explicit namespace"])
+	if checksums["v1"] == checksums["v2"] || checksums["v1"] == checksums["secret"] {
+		t.Errorf("expected checksums to change with the referenced config, got %v", checksums)
+	}
+}
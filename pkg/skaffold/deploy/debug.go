@@ -0,0 +1,293 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/google/go-containerregistry/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// DebugAnnotation is set on the pod templates configured for debugging.
+// Its value maps the name of each debuggable container to its runtime and
+// debug ports, as json, for IDEs to know where to attach.
+const DebugAnnotation = "debug.skaffold.dev/config"
+
+const (
+	jvmRuntime    = "jvm"
+	nodeJSRuntime = "nodejs"
+
+	jdwpPort     = 5005
+	devtoolsPort = 9229
+)
+
+// ContainerDebugConfig is how a container was configured for debugging.
+type ContainerDebugConfig struct {
+	Runtime string         `json:"runtime"`
+	Ports   map[string]int `json:"ports"`
+}
+
+// injectDebug configures the containers running built images so that a
+// debugger can attach: the jdwp agent is enabled for JVMs and the inspector
+// for Node.js. The runtime is guessed from the command and the environment of
+// each container and of its image, and containers with other runtimes are left
+// untouched.
+func (l *manifestList) injectDebug(builds []build.Build) (manifestList, error) {
+	tags := map[string]bool{}
+	for _, b := range builds {
+		tags[b.Tag] = true
+	}
+
+	var updatedManifests manifestList
+
+	for _, manifest := range *l {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			return nil, errors.Wrap(err, "reading kubernetes YAML")
+		}
+		if len(m) == 0 {
+			continue
+		}
+
+		template := podTemplate(m)
+		if m["kind"] == "Pod" {
+			template = m
+		}
+		if template != nil {
+			if err := debugPodSpec(template, tags); err != nil {
+				return nil, errors.Wrapf(err, "configuring %s %s for debugging", m["kind"], nestedString(m, "metadata", "name"))
+			}
+		}
+
+		updatedManifest, err := yaml.Marshal(m)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling yaml")
+		}
+		updatedManifests = append(updatedManifests, updatedManifest)
+	}
+
+	return updatedManifests, nil
+}
+
+func debugPodSpec(template map[interface{}]interface{}, tags map[string]bool) error {
+	containers, _ := nestedValue(template, "spec", "containers").([]interface{})
+
+	configs := map[string]ContainerDebugConfig{}
+	for _, c := range containers {
+		container, ok := c.(map[interface{}]interface{})
+		if !ok || !tags[nestedString(container, "image")] {
+			continue
+		}
+
+		image := imageConfig(nestedString(container, "image"))
+
+		var config ContainerDebugConfig
+		switch runtime := containerRuntime(container, image); runtime {
+		case jvmRuntime:
+			appendEnv(container, image, "JAVA_TOOL_OPTIONS", "-agentlib:jdwp=transport=dt_socket,server=y,suspend=n,address=5005")
+			addPort(container, "jdwp", jdwpPort)
+			config = ContainerDebugConfig{Runtime: runtime, Ports: map[string]int{"jdwp": jdwpPort}}
+		case nodeJSRuntime:
+			enableInspector(container, image)
+			addPort(container, "devtools", devtoolsPort)
+			config = ContainerDebugConfig{Runtime: runtime, Ports: map[string]int{"devtools": devtoolsPort}}
+		default:
+			logrus.Debugf("Unknown runtime for container %s, it can't be debugged", nestedString(container, "name"))
+			continue
+		}
+		configs[nestedString(container, "name")] = config
+	}
+
+	if len(configs) == 0 {
+		return nil
+	}
+
+	annotation, err := json.Marshal(configs)
+	if err != nil {
+		return errors.Wrap(err, "marshalling debug configuration")
+	}
+	setAnnotation(template, DebugAnnotation, string(annotation))
+	return nil
+}
+
+// imageConfig reads the configuration of a built image. Most manifests leave
+// the command and the environment to the image. If the image can't be
+// inspected, the runtime is guessed from the manifest alone.
+func imageConfig(image string) v1.Config {
+	cfg, err := docker.RetrieveImage(image)
+	if err != nil {
+		logrus.Debugf("Couldn't inspect image %s: %s", image, err)
+		return v1.Config{}
+	}
+	return cfg.Config
+}
+
+// containerRuntime guesses the runtime of a container from its command
+// and its environment variables.
+func containerRuntime(container map[interface{}]interface{}, image v1.Config) string {
+	if command := commandLine(container, image); len(command) > 0 {
+		switch path.Base(command[0]) {
+		case "java":
+			return jvmRuntime
+		case "node", "nodejs", "nodemon":
+			return nodeJSRuntime
+		}
+	}
+
+	var names []string
+	for _, e := range env(container) {
+		names = append(names, nestedString(e, "name"))
+	}
+	for _, e := range image.Env {
+		names = append(names, strings.SplitN(e, "=", 2)[0])
+	}
+
+	for _, name := range names {
+		switch name {
+		case "JAVA_TOOL_OPTIONS", "JAVA_VERSION", "JAVA_HOME":
+			return jvmRuntime
+		case "NODE_VERSION", "NODE_ENV", "NODE_OPTIONS":
+			return nodeJSRuntime
+		}
+	}
+
+	return ""
+}
+
+// commandLine returns the command line that a container runs: its command,
+// or else the image's entrypoint, followed by its args, or else the image's
+// cmd when the command isn't overridden.
+func commandLine(container map[interface{}]interface{}, image v1.Config) []string {
+	command := toStrings(container["command"])
+	args := toStrings(container["args"])
+
+	if len(command) == 0 {
+		command = image.Entrypoint
+		if len(args) == 0 {
+			args = image.Cmd
+		}
+	}
+
+	return append(append([]string{}, command...), args...)
+}
+
+// enableInspector adds --inspect to the node command line of the container,
+// or to NODE_OPTIONS when node isn't started explicitly.
+func enableInspector(container map[interface{}]interface{}, image v1.Config) {
+	inspect := "--inspect=0.0.0.0:9229"
+
+	for _, key := range []string{"command", "args"} {
+		command, ok := container[key].([]interface{})
+		if !ok || len(command) == 0 {
+			continue
+		}
+
+		if base := path.Base(toString(command[0])); base == "node" || base == "nodejs" {
+			container[key] = append([]interface{}{command[0], inspect}, command[1:]...)
+			return
+		}
+		break
+	}
+
+	appendEnv(container, image, "NODE_OPTIONS", inspect)
+}
+
+func env(container map[interface{}]interface{}) []interface{} {
+	env, _ := container["env"].([]interface{})
+	return env
+}
+
+// appendEnv appends a value to an environment variable of a container,
+// separated by a space, or creates the variable, keeping the value that
+// the image gives it.
+func appendEnv(container map[interface{}]interface{}, image v1.Config, name, value string) {
+	vars := env(container)
+	for _, e := range vars {
+		if nestedString(e, "name") != name {
+			continue
+		}
+		v := e.(map[interface{}]interface{})
+		if _, present := v["valueFrom"]; present {
+			logrus.Warnf("%s is set from a reference, it's left as is", name)
+			return
+		}
+		if existing := strings.TrimSpace(nestedString(v, "value")); existing != "" {
+			value = existing + " " + value
+		}
+		v["value"] = value
+		return
+	}
+
+	for _, e := range image.Env {
+		if kv := strings.SplitN(e, "=", 2); len(kv) == 2 && kv[0] == name && strings.TrimSpace(kv[1]) != "" {
+			value = strings.TrimSpace(kv[1]) + " " + value
+		}
+	}
+
+	container["env"] = append(vars, map[interface{}]interface{}{
+		"name":  name,
+		"value": value,
+	})
+}
+
+// addPort declares a port of a container, unless it's already declared.
+func addPort(container map[interface{}]interface{}, name string, port int) {
+	ports, _ := container["ports"].([]interface{})
+	for _, p := range ports {
+		if declared, ok := nestedValue(p, "containerPort").(int); ok && declared == port {
+			return
+		}
+	}
+
+	container["ports"] = append(ports, map[interface{}]interface{}{
+		"name":          name,
+		"containerPort": port,
+	})
+}
+
+func nestedValue(i interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		asMap, ok := i.(map[interface{}]interface{})
+		if !ok {
+			return nil
+		}
+		i = asMap[key]
+	}
+	return i
+}
+
+func toString(i interface{}) string {
+	s, _ := i.(string)
+	return s
+}
+
+func toStrings(i interface{}) []string {
+	list, _ := i.([]interface{})
+
+	var values []string
+	for _, s := range list {
+		values = append(values, toString(s))
+	}
+	return values
+}
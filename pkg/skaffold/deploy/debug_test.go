@@ -0,0 +1,253 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/google/go-containerregistry/v1"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestInjectDebug(t *testing.T) {
+	var tests = []struct {
+		description string
+		manifest    string
+		expected    string
+	}{
+		{
+			description: "jvm",
+			manifest: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  template:
+    spec:
+      containers:
+      - name: api
+        image: api:123
+        command: [java, -jar, app.jar]
+        env:
+        - name: JAVA_TOOL_OPTIONS
+          value: -Xmx512m`,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  template:
+    metadata:
+      annotations:
+        debug.skaffold.dev/config: '{"api":{"runtime":"jvm","ports":{"jdwp":5005}}}'
+    spec:
+      containers:
+      - name: api
+        image: api:123
+        command: [java, -jar, app.jar]
+        env:
+        - name: JAVA_TOOL_OPTIONS
+          value: -Xmx512m -agentlib:jdwp=transport=dt_socket,server=y,suspend=n,address=5005
+        ports:
+        - name: jdwp
+          containerPort: 5005`,
+		},
+		{
+			description: "node command",
+			manifest: `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: web:123
+    command: [node, server.js]
+    ports:
+    - containerPort: 8080`,
+			expected: `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  annotations:
+    debug.skaffold.dev/config: '{"web":{"runtime":"nodejs","ports":{"devtools":9229}}}'
+spec:
+  containers:
+  - name: web
+    image: web:123
+    command: [node, --inspect=0.0.0.0:9229, server.js]
+    ports:
+    - containerPort: 8080
+    - name: devtools
+      containerPort: 9229`,
+		},
+		{
+			description: "node environment",
+			manifest: `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: web:123
+    env:
+    - name: NODE_ENV
+      value: development`,
+			expected: `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  annotations:
+    debug.skaffold.dev/config: '{"web":{"runtime":"nodejs","ports":{"devtools":9229}}}'
+spec:
+  containers:
+  - name: web
+    image: web:123
+    env:
+    - name: NODE_ENV
+      value: development
+    - name: NODE_OPTIONS
+      value: --inspect=0.0.0.0:9229
+    ports:
+    - name: devtools
+      containerPort: 9229`,
+		},
+		{
+			description: "jvm image",
+			manifest: `apiVersion: v1
+kind: Pod
+metadata:
+  name: api
+spec:
+  containers:
+  - name: api
+    image: java-app:123`,
+			expected: `apiVersion: v1
+kind: Pod
+metadata:
+  name: api
+  annotations:
+    debug.skaffold.dev/config: '{"api":{"runtime":"jvm","ports":{"jdwp":5005}}}'
+spec:
+  containers:
+  - name: api
+    image: java-app:123
+    env:
+    - name: JAVA_TOOL_OPTIONS
+      value: -Xmx512m -agentlib:jdwp=transport=dt_socket,server=y,suspend=n,address=5005
+    ports:
+    - name: jdwp
+      containerPort: 5005`,
+		},
+		{
+			description: "node image",
+			manifest: `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: node-app:123
+    args: [server.js]`,
+			expected: `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  annotations:
+    debug.skaffold.dev/config: '{"web":{"runtime":"nodejs","ports":{"devtools":9229}}}'
+spec:
+  containers:
+  - name: web
+    image: node-app:123
+    args: [server.js]
+    env:
+    - name: NODE_OPTIONS
+      value: --inspect=0.0.0.0:9229
+    ports:
+    - name: devtools
+      containerPort: 9229`,
+		},
+		{
+			description: "unknown runtime and image not built",
+			manifest: `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: web:123
+    command: [./server]
+  - name: redis
+    image: redis
+    command: [java]`,
+			expected: `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: web:123
+    command: [./server]
+  - name: redis
+    image: redis
+    command: [java]`,
+		},
+	}
+
+	defer func(r func(string) (*v1.ConfigFile, error)) { docker.RetrieveImage = r }(docker.RetrieveImage)
+	docker.RetrieveImage = func(image string) (*v1.ConfigFile, error) {
+		switch image {
+		case "java-app:123":
+			return &v1.ConfigFile{Config: v1.Config{Entrypoint: []string{"java", "-jar", "app.jar"}, Env: []string{"JAVA_TOOL_OPTIONS=-Xmx512m"}}}, nil
+		case "node-app:123":
+			return &v1.ConfigFile{Config: v1.Config{Entrypoint: []string{"/usr/local/bin/node"}, Env: []string{"NODE_VERSION=10.12.0"}}}, nil
+		default:
+			return nil, fmt.Errorf("image %s not found", image)
+		}
+	}
+
+	builds := []build.Build{
+		{ImageName: "api", Tag: "api:123"},
+		{ImageName: "web", Tag: "web:123"},
+		{ImageName: "java-app", Tag: "java-app:123"},
+		{ImageName: "node-app", Tag: "node-app:123"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			manifests := manifestList{[]byte(test.manifest)}
+
+			injected, err := manifests.injectDebug(builds)
+			testutil.CheckError(t, false, err)
+
+			actual := make(map[interface{}]interface{})
+			expected := make(map[interface{}]interface{})
+			yaml.Unmarshal(injected[0], &actual)
+			yaml.Unmarshal([]byte(test.expected), &expected)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, expected, actual)
+		})
+	}
+}
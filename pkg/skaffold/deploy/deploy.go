@@ -22,11 +22,14 @@ import (
 	"io"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 )
 
-// Result is currently unused, but a stub for results that might be returned
-// from a Deployer.Run()
-type Result struct{}
+// Result is what a Deployer.Deploy() returns: the workloads that were
+// deployed, whose rollout can be waited for.
+type Result struct {
+	Workloads []kubernetes.Workload
+}
 
 // Deployer is the Deploy API of skaffold and responsible for deploying
 // the build results to a Kubernetes cluster
@@ -41,6 +44,10 @@ type Deployer interface {
 
 	// Cleanup deletes what was deployed by calling Deploy.
 	Cleanup(context.Context, io.Writer) error
+
+	// Render writes the manifests that Deploy would apply, with the images
+	// of the build results, without deploying them.
+	Render(context.Context, io.Writer, *build.BuildResult) error
 }
 
 func JoinTagsToBuildResult(b []build.Build, params map[string]string) (map[string]build.Build, error) {
@@ -0,0 +1,156 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// deployedDir holds the manifests applied by each project, so that they can
+// be deleted even if they were since removed from the project.
+var deployedDir = constants.DefaultDeployedDir
+
+// deployedFile is where the manifests applied by a deployer, from the current
+// directory to a given context and namespace, are recorded.
+func deployedFile(deployer, kubeContext, namespace string) (string, error) {
+	dir, err := homedir.Expand(deployedDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "expanding %s", deployedDir)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", errors.Wrap(err, "getting current directory")
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%s@%s/%s", deployer, cwd, kubeContext, namespace)))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".yaml"), nil
+}
+
+// recordDeployed adds the manifests to those already recorded in a file.
+// A resource that was already recorded is replaced by its latest version.
+// Resources without a namespace are in the given default namespace.
+func recordDeployed(path, namespace string, manifests manifestList) error {
+	recorded, err := readDeployed(path)
+	if err != nil {
+		return err
+	}
+
+	merged := manifestList{}
+	index := map[string]int{}
+	for _, manifest := range append(recorded, manifests...) {
+		key := resourceKey(manifest, namespace)
+		if key == "" {
+			continue
+		}
+		if i, found := index[key]; found {
+			merged[i] = manifest
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, manifest)
+	}
+
+	return writeDeployed(path, merged)
+}
+
+// writeDeployed replaces what's recorded in a file.
+func writeDeployed(path string, manifests manifestList) error {
+	if err := util.Fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "creating directory")
+	}
+	return afero.WriteFile(util.Fs, path, []byte(manifests.String()), 0644)
+}
+
+// orphans lists the resources that were deployed but that aren't part of
+// the current manifests anymore. Resources without a namespace are in the
+// given default namespace.
+func orphans(deployed, current manifestList, namespace string) manifestList {
+	keys := map[string]bool{}
+	for _, manifest := range current {
+		keys[resourceKey(manifest, namespace)] = true
+	}
+
+	var orphans manifestList
+	for _, manifest := range deployed {
+		if key := resourceKey(manifest, namespace); key != "" && !keys[key] {
+			orphans = append(orphans, manifest)
+		}
+	}
+	return orphans
+}
+
+// readDeployed reads the manifests recorded by recordDeployed. Nothing was
+// recorded if the file doesn't exist.
+func readDeployed(path string) (manifestList, error) {
+	buf, err := afero.ReadFile(util.Fs, path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading deployed manifests")
+	}
+
+	var manifests manifestList
+	for _, part := range bytes.Split(buf, []byte("\n---")) {
+		if len(bytes.TrimSpace(part)) > 0 {
+			manifests = append(manifests, part)
+		}
+	}
+	return manifests, nil
+}
+
+// forgetDeployed removes the record of what was deployed.
+func forgetDeployed(path string) error {
+	if err := util.Fs.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing deployed manifests")
+	}
+	return nil
+}
+
+// resourceKey identifies a resource by its API group, kind, namespace and
+// name. The version is left out since moving a resource to a newer apiVersion
+// doesn't make it a different resource.
+func resourceKey(manifest []byte, defaultNamespace string) string {
+	m := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(manifest, &m); err != nil || len(m) == 0 {
+		return ""
+	}
+
+	group := ""
+	if apiVersion := nestedString(m, "apiVersion"); strings.Contains(apiVersion, "/") {
+		group = apiVersion[:strings.LastIndex(apiVersion, "/")]
+	}
+	namespace := nestedString(m, "metadata", "namespace")
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	return fmt.Sprintf("%s/%s/%s/%s", group, nestedString(m, "kind"), namespace, nestedString(m, "metadata", "name"))
+}
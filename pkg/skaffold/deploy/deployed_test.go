@@ -0,0 +1,108 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/spf13/afero"
+)
+
+func TestRecordDeployed(t *testing.T) {
+	defer func(fs afero.Fs) { util.Fs = fs }(util.Fs)
+	util.Fs = afero.NewMemMapFs()
+
+	web := []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: web")
+	webV2 := []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n  labels:\n    version: v2")
+	db := []byte("apiVersion: apps/v1\nkind: StatefulSet\nmetadata:\n  name: db")
+
+	err := recordDeployed("deployed/project.yaml", "", manifestList{web, db})
+	testutil.CheckError(t, false, err)
+
+	err = recordDeployed("deployed/project.yaml", "", manifestList{webV2, []byte("  ")})
+	testutil.CheckError(t, false, err)
+
+	manifests, err := readDeployed("deployed/project.yaml")
+	testutil.CheckErrorAndDeepEqual(t, false, err, (&manifestList{webV2, db}).String(), manifests.String())
+
+	err = forgetDeployed("deployed/project.yaml")
+	testutil.CheckError(t, false, err)
+
+	manifests, err = readDeployed("deployed/project.yaml")
+	testutil.CheckErrorAndDeepEqual(t, false, err, 0, len(manifests))
+}
+
+func TestForgetNothingDeployed(t *testing.T) {
+	defer func(fs afero.Fs) { util.Fs = fs }(util.Fs)
+	util.Fs = afero.NewMemMapFs()
+
+	err := forgetDeployed("deployed/project.yaml")
+
+	testutil.CheckError(t, false, err)
+}
+
+func TestOrphans(t *testing.T) {
+	web := []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: web")
+	webV2 := []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n  labels:\n    version: v2")
+	db := []byte("apiVersion: apps/v1\nkind: StatefulSet\nmetadata:\n  name: db")
+
+	removed := orphans(manifestList{web, db}, manifestList{webV2}, "")
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, manifestList{db}, removed)
+}
+
+func TestOrphansResourceIdentity(t *testing.T) {
+	deployment := []byte("apiVersion: extensions/v1beta1\nkind: Deployment\nmetadata:\n  name: web")
+	deploymentV1 := []byte("apiVersion: extensions/v1\nkind: Deployment\nmetadata:\n  name: web")
+	inNamespace := []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n  namespace: apps")
+	withoutNamespace := []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: web")
+	otherGroup := []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web")
+
+	var tests = []struct {
+		description string
+		deployed    manifestList
+		current     manifestList
+		expected    manifestList
+	}{
+		{
+			description: "apiVersion change",
+			deployed:    manifestList{deployment},
+			current:     manifestList{deploymentV1},
+		},
+		{
+			description: "explicit default namespace",
+			deployed:    manifestList{withoutNamespace},
+			current:     manifestList{inNamespace},
+		},
+		{
+			description: "other API group",
+			deployed:    manifestList{deployment},
+			current:     manifestList{otherGroup},
+			expected:    manifestList{deployment},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			removed := orphans(test.deployed, test.current, "apps")
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, removed)
+		})
+	}
+}
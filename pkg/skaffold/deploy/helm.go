@@ -17,21 +17,50 @@ limitations under the License.
 package deploy
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/docker/distribution/reference"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// Strategies to write built images into the values of a chart.
+const (
+	// ImageStrategyFQN sets a value to the image name and its tag.
+	ImageStrategyFQN = "fqn"
+	// ImageStrategyRepoTag sets `<value>.repository` and `<value>.tag`.
+	ImageStrategyRepoTag = "repoTag"
+	// ImageStrategyDigest sets a value to the image name and its digest.
+	ImageStrategyDigest = "digest"
 )
 
 type HelmDeployer struct {
 	*v1alpha2.DeployConfig
 	kubeContext string
+
+	lookupDigest func(string) (string, error)
+
+	// majorVersion is the version of helm, once it's known.
+	majorVersion int
 }
 
 // NewHelmDeployer returns a new HelmDeployer for a DeployConfig filled
@@ -40,97 +69,546 @@ func NewHelmDeployer(cfg *v1alpha2.DeployConfig, kubeContext string) *HelmDeploy
 	return &HelmDeployer{
 		DeployConfig: cfg,
 		kubeContext:  kubeContext,
+		lookupDigest: func(image string) (string, error) {
+			return docker.DefaultDigestResolver(false).Lookup(image)
+		},
 	}
 }
 
 func (h *HelmDeployer) Deploy(ctx context.Context, out io.Writer, b *build.BuildResult) (*Result, error) {
+	if err := h.detectVersion(ctx); err != nil {
+		return nil, err
+	}
+
+	// The workloads are only listed when something waits for them.
+	var workloads []kubernetes.Workload
 	for _, r := range h.HelmDeploy.Releases {
-		if err := h.deployRelease(out, r, b); err != nil {
+		if err := h.deployRelease(ctx, out, r, b); err != nil {
 			return nil, errors.Wrapf(err, "deploying %s", r.Name)
 		}
+
+		if !WaitsForRollouts(h.DeployConfig) {
+			continue
+		}
+		released, err := h.releaseWorkloads(ctx, r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the workloads of %s", r.Name)
+		}
+		workloads = append(workloads, released...)
 	}
-	return nil, nil
+	return &Result{Workloads: workloads}, nil
 }
 
-// Not implemented
-func (k *HelmDeployer) Dependencies() ([]string, error) {
-	return nil, nil
+// releaseWorkloads lists the workloads of a release, from the manifest
+// that helm installed.
+func (h *HelmDeployer) releaseWorkloads(ctx context.Context, r v1alpha2.HelmRelease) ([]kubernetes.Workload, error) {
+	args := []string{"--kube-context", h.kubeContext, "get", "manifest", r.Name}
+	if h.majorVersion >= 3 && r.Namespace != "" {
+		args = append(args, "--namespace", r.Namespace)
+	}
+
+	buf, err := util.RunCmdOut(exec.CommandContext(ctx, "helm", args...))
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the release manifest")
+	}
+
+	var manifests manifestList
+	for _, part := range bytes.Split(buf, []byte("\n---")) {
+		manifests = append(manifests, part)
+	}
+	return manifests.workloads(r.Namespace)
+}
+
+// Dependencies lists the files of the local charts and the values files.
+// The dependencies that `helm dep build` downloads are left out since
+// they're rewritten by each deploy.
+func (h *HelmDeployer) Dependencies() ([]string, error) {
+	var deps []string
+	for _, r := range h.HelmDeploy.Releases {
+		if r.ChartPath != "" && r.RemoteChart == "" {
+			downloaded := filepath.Join(r.ChartPath, "charts")
+			err := afero.Walk(util.Fs, r.ChartPath, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				switch {
+				case info.IsDir() && path == downloaded:
+					return filepath.SkipDir
+				case info.IsDir():
+					return nil
+				case filepath.Dir(path) == filepath.Clean(r.ChartPath) && (info.Name() == "requirements.lock" || info.Name() == "Chart.lock"):
+					return nil
+				}
+				deps = append(deps, path)
+				return nil
+			})
+			if err != nil {
+				return nil, errors.Wrapf(err, "listing the files of chart %s", r.ChartPath)
+			}
+		}
+		deps = append(deps, valuesFiles(r)...)
+	}
+	return deps, nil
 }
 
 // Cleanup deletes what was deployed by calling Deploy.
 func (h *HelmDeployer) Cleanup(ctx context.Context, out io.Writer) error {
+	if err := h.detectVersion(ctx); err != nil {
+		return err
+	}
+
 	for _, r := range h.HelmDeploy.Releases {
-		if err := h.deleteRelease(out, r); err != nil {
+		if err := h.deleteRelease(ctx, out, r); err != nil {
 			return errors.Wrapf(err, "deploying %s", r.Name)
 		}
 	}
 	return nil
 }
 
-func (h *HelmDeployer) helm(out io.Writer, arg ...string) error {
+// Render writes the manifests of the releases, as rendered by `helm template`.
+func (h *HelmDeployer) Render(ctx context.Context, out io.Writer, b *build.BuildResult) error {
+	if err := h.detectVersion(ctx); err != nil {
+		return err
+	}
+
+	for _, r := range h.HelmDeploy.Releases {
+		if err := h.renderRelease(ctx, out, r, b); err != nil {
+			return errors.Wrapf(err, "rendering %s", r.Name)
+		}
+	}
+	return nil
+}
+
+// detectVersion finds out which major version of helm to use, unless
+// it's already known.
+func (h *HelmDeployer) detectVersion(ctx context.Context) error {
+	if h.majorVersion != 0 {
+		return nil
+	}
+
+	major, err := helmMajorVersion(h.HelmDeploy.HelmVersion, func() ([]byte, error) {
+		var buf bytes.Buffer
+		err := h.helm(ctx, &buf, "version", "--client", "--short")
+		return buf.Bytes(), err
+	})
+	if err != nil {
+		return err
+	}
+
+	h.majorVersion = major
+	return nil
+}
+
+func (h *HelmDeployer) helm(ctx context.Context, out io.Writer, arg ...string) error {
+	return h.helmSupervised(ctx, out, util.DefaultSupervision(out), arg...)
+}
+
+func (h *HelmDeployer) helmSupervised(ctx context.Context, out io.Writer, s util.Supervision, arg ...string) error {
 	args := append([]string{"--kube-context", h.kubeContext}, arg...)
 
 	cmd := exec.Command("helm", args...)
 	cmd.Stdout = out
 	cmd.Stderr = out
 
-	return util.RunCmd(cmd)
+	return util.RunCmdSupervised(ctx, cmd, s)
 }
 
-func (h *HelmDeployer) deployRelease(out io.Writer, r v1alpha2.HelmRelease, b *build.BuildResult) error {
+func (h *HelmDeployer) deployRelease(ctx context.Context, out io.Writer, r v1alpha2.HelmRelease, b *build.BuildResult) error {
+	// helm 3 installs or upgrades releases in one go.
+	helm3 := h.majorVersion >= 3
 	isInstalled := true
-	if err := h.helm(out, "get", r.Name); err != nil {
-		fmt.Fprintf(out, "Helm release %s not installed. Installing...\n", r.Name)
-		isInstalled = false
+	if !helm3 {
+		if err := h.helm(ctx, out, "get", r.Name); err != nil {
+			fmt.Fprintf(out, "Helm release %s not installed. Installing...\n", r.Name)
+			isInstalled = false
+		}
+	} else if r.RecreatePods {
+		logrus.Warnf("recreatePods isn't supported by helm 3, ignoring it for release %s", r.Name)
+		r.RecreatePods = false
 	}
 
-	params, err := JoinTagsToBuildResult(b.Builds, r.Values)
+	chart, valueFlags, cleanup, err := h.releaseChart(ctx, out, r, b)
 	if err != nil {
-		return errors.Wrap(err, "matching build results to chart values")
+		return err
 	}
+	defer cleanup()
 
-	var setOpts []string
-	for k, v := range params {
-		setOpts = append(setOpts, "--set")
-		setOpts = append(setOpts, fmt.Sprintf("%s=%s", k, v.Tag))
+	var args []string
+	switch {
+	case helm3:
+		args = append(args, "upgrade", "--install", r.Name, chart)
+	case !isInstalled:
+		args = append(args, "install", "--name", r.Name, chart)
+	default:
+		args = append(args, "upgrade", r.Name, chart)
 	}
 
-	// First build dependencies.
-	logrus.Infof("Building helm dependencies...")
-	if err := h.helm(out, "dep", "build", r.ChartPath); err != nil {
-		return errors.Wrap(err, "building helm dependencies")
+	if r.Repo != "" {
+		args = append(args, "--repo", r.Repo)
 	}
+	args = append(args, releaseFlags(r, isInstalled, helm3)...)
+
+	if r.Namespace != "" {
+		args = append(args, "--namespace", r.Namespace)
+	}
+	args = append(args, valueFlags...)
+
+	// helm prints nothing while it waits for the release to be ready.
+	s := util.DefaultSupervision(out)
+	if wait := time.Duration(r.Timeout) * time.Second; r.Wait && wait >= s.InactivityTimeout {
+		s.InactivityTimeout = wait + time.Minute
+	}
+
+	return h.helmSupervised(ctx, out, s, args...)
+}
+
+func (h *HelmDeployer) renderRelease(ctx context.Context, out io.Writer, r v1alpha2.HelmRelease, b *build.BuildResult) error {
+	// What's printed while the chart is prepared mustn't end up with the manifests.
+	chart, valueFlags, cleanup, err := h.releaseChart(ctx, os.Stderr, r, b)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
 
 	var args []string
-	if !isInstalled {
-		args = append(args, "install", "--name", r.Name, r.ChartPath)
+	if h.majorVersion >= 3 {
+		args = append(args, "template", r.Name, chart)
 	} else {
-		args = append(args, "upgrade", r.Name, r.ChartPath)
+		if r.RemoteChart != "" && r.Packaged == nil {
+			return fmt.Errorf("rendering the remote chart %s requires helm 3", r.RemoteChart)
+		}
+		args = append(args, "template", chart, "--name", r.Name)
 	}
 
+	if r.Repo != "" {
+		args = append(args, "--repo", r.Repo)
+	}
 	if r.Namespace != "" {
 		args = append(args, "--namespace", r.Namespace)
 	}
-	if r.ValuesFilePath != "" {
-		args = append(args, "-f", r.ValuesFilePath)
+	args = append(args, valueFlags...)
+
+	cmd := exec.Command("helm", append([]string{"--kube-context", h.kubeContext}, args...)...)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+
+	return util.RunCmd(cmd)
+}
+
+// releaseChart prepares the chart of a release and lists the flags that set
+// its values, including the built images. The returned function removes the
+// packaged chart, if any.
+func (h *HelmDeployer) releaseChart(ctx context.Context, out io.Writer, r v1alpha2.HelmRelease, b *build.BuildResult) (string, []string, func(), error) {
+	noop := func() {}
+
+	data := templateData(b.Builds)
+	values, err := expandValues(r.Values, data)
+	if err != nil {
+		return "", nil, noop, errors.Wrap(err, "expanding chart values")
+	}
+
+	params, err := JoinTagsToBuildResult(b.Builds, values)
+	if err != nil {
+		return "", nil, noop, errors.Wrap(err, "matching build results to chart values")
+	}
+	data["TAG"] = releaseTag(params)
+
+	var setOpts []string
+	for _, k := range sortedBuildKeys(params) {
+		values, err := h.imageValues(r.ImageStrategy, k, params[k])
+		if err != nil {
+			return "", nil, noop, errors.Wrapf(err, "setting value %s", k)
+		}
+		for _, v := range values {
+			setOpts = append(setOpts, "--set", v)
+		}
+	}
+
+	chart, err := h.prepareChart(ctx, out, r)
+	if err != nil {
+		return "", nil, noop, err
+	}
+
+	cleanup := noop
+	if r.Packaged != nil {
+		dir, err := ioutil.TempDir("", "skaffold-helm")
+		if err != nil {
+			return "", nil, noop, errors.Wrap(err, "creating a directory for the chart package")
+		}
+		cleanup = func() { os.RemoveAll(dir) }
+
+		chart, err = h.packageChart(ctx, out, r, data, dir)
+		if err != nil {
+			cleanup()
+			return "", nil, noop, errors.Wrap(err, "packaging chart")
+		}
+	}
+
+	var flags []string
+	for _, f := range valuesFiles(r) {
+		flags = append(flags, "-f", f)
 	}
 	if r.Version != "" {
-		args = append(args, "--version", r.Version)
+		flags = append(flags, "--version", r.Version)
+	}
+
+	setValues, err := expandValues(r.SetValues, data)
+	if err != nil {
+		cleanup()
+		return "", nil, noop, errors.Wrap(err, "expanding setValues")
+	}
+	for _, k := range sortedKeys(setValues) {
+		setOpts = append(setOpts, "--set", fmt.Sprintf("%s=%s", k, setValues[k]))
+	}
+
+	return chart, append(flags, setOpts...), cleanup, nil
+}
+
+// prepareChart returns the chart to install. The dependencies of local charts
+// are built first. Remote charts are looked up in the repository given by its
+// url or else in the local repositories, whose indexes are updated.
+func (h *HelmDeployer) prepareChart(ctx context.Context, out io.Writer, r v1alpha2.HelmRelease) (string, error) {
+	switch {
+	case r.ChartPath != "" && r.RemoteChart != "":
+		return "", fmt.Errorf("release %s can't have both a chartPath and a remoteChart", r.Name)
+
+	case r.RemoteChart != "":
+		if r.Repo == "" {
+			logrus.Infof("Updating helm repositories...")
+			if err := h.helm(ctx, out, "repo", "update"); err != nil {
+				return "", errors.Wrap(err, "updating helm repositories")
+			}
+		}
+		return r.RemoteChart, nil
+
+	case r.ChartPath != "" && r.SkipBuildDependencies:
+		return r.ChartPath, nil
+
+	case r.ChartPath != "":
+		logrus.Infof("Building helm dependencies...")
+		if err := h.helm(ctx, out, "dep", "build", r.ChartPath); err != nil {
+			return "", errors.Wrap(err, "building helm dependencies")
+		}
+		return r.ChartPath, nil
+	}
+
+	return "", fmt.Errorf("release %s needs either a chartPath or a remoteChart", r.Name)
+}
+
+// imageValues lists the `key=value` pairs that set a built image into
+// a chart value, according to the release's image strategy.
+func (h *HelmDeployer) imageValues(strategy string, key string, b build.Build) ([]string, error) {
+	if strategy == "" || strategy == ImageStrategyFQN {
+		return []string{fmt.Sprintf("%s=%s", key, b.Tag)}, nil
+	}
+
+	r, err := reference.Parse(b.Tag)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", b.Tag)
+	}
+	named, isNamed := r.(reference.Named)
+	if !isNamed {
+		return nil, fmt.Errorf("%s has no name", b.Tag)
+	}
+
+	switch strategy {
+	case ImageStrategyRepoTag:
+		tagged, isTagged := r.(reference.Tagged)
+		if !isTagged {
+			return nil, fmt.Errorf("%s has no tag", b.Tag)
+		}
+		return []string{
+			fmt.Sprintf("%s.repository=%s", key, named.Name()),
+			fmt.Sprintf("%s.tag=%s", key, tagged.Tag()),
+		}, nil
+
+	case ImageStrategyDigest:
+		digest, err := h.lookupDigest(b.Tag)
+		if err != nil {
+			return nil, errors.Wrapf(err, "looking up the digest of %s", b.Tag)
+		}
+		return []string{fmt.Sprintf("%s=%s@%s", key, named.Name(), digest)}, nil
+	}
+
+	return nil, fmt.Errorf("unknown image strategy %s", strategy)
+}
+
+func sortedBuildKeys(m map[string]build.Build) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// releaseFlags lists the flags of `helm install` or `helm upgrade` that
+// control how the release is rolled out.
+func releaseFlags(r v1alpha2.HelmRelease, upgrade, helm3 bool) []string {
+	var flags []string
+	if r.Wait {
+		flags = append(flags, "--wait")
+	}
+	if r.Timeout > 0 {
+		// helm 3 expects a duration rather than a number of seconds.
+		timeout := strconv.Itoa(r.Timeout)
+		if helm3 {
+			timeout = fmt.Sprintf("%ds", r.Timeout)
+		}
+		flags = append(flags, "--timeout", timeout)
+	}
+	if r.Atomic {
+		flags = append(flags, "--atomic")
+	}
+	if upgrade && r.Force {
+		flags = append(flags, "--force")
+	}
+	if upgrade && r.RecreatePods {
+		flags = append(flags, "--recreate-pods")
+	}
+	return flags
+}
+
+var packagedChart = regexp.MustCompile(`saved it to: (.+\.tgz)`)
+
+// packageChart runs `helm package` on a local chart and returns the path
+// of the package.
+func (h *HelmDeployer) packageChart(ctx context.Context, out io.Writer, r v1alpha2.HelmRelease, data map[string]interface{}, dir string) (string, error) {
+	if r.ChartPath == "" {
+		return "", fmt.Errorf("only local charts can be packaged")
+	}
+
+	args := []string{"package", r.ChartPath, "--destination", dir}
+	if r.Packaged.Version != "" {
+		version, err := executeTemplate(r.Packaged.Version, data)
+		if err != nil {
+			return "", errors.Wrap(err, "templating chart version")
+		}
+		args = append(args, "--version", version)
+	}
+	if r.Packaged.AppVersion != "" {
+		appVersion, err := executeTemplate(r.Packaged.AppVersion, data)
+		if err != nil {
+			return "", errors.Wrap(err, "templating chart appVersion")
+		}
+		args = append(args, "--app-version", appVersion)
+	}
+
+	var buf bytes.Buffer
+	if err := h.helm(ctx, io.MultiWriter(out, &buf), args...); err != nil {
+		return "", err
+	}
+
+	match := packagedChart.FindStringSubmatch(buf.String())
+	if match == nil {
+		return "", fmt.Errorf("unable to find the package in helm's output: %s", buf.String())
+	}
+	return match[1], nil
+}
+
+// releaseTag is the tag of the image set in the release's values, or of the
+// first one by key.
+func releaseTag(params map[string]build.Build) string {
+	keys := sortedBuildKeys(params)
+	if len(keys) == 0 {
+		return ""
+	}
+
+	r, err := reference.ParseNormalizedNamed(params[keys[0]].Tag)
+	if err != nil {
+		return ""
+	}
+	if tagged, ok := r.(reference.Tagged); ok {
+		return tagged.Tag()
+	}
+	return ""
+}
+
+// valuesFiles lists the values files of a release, in the order
+// they're given to helm.
+func valuesFiles(r v1alpha2.HelmRelease) []string {
+	var files []string
+	if r.ValuesFilePath != "" {
+		files = append(files, r.ValuesFilePath)
+	}
+	return append(files, r.ValuesFiles...)
+}
+
+// ImageMetadata describes a built image in the templates of helm releases.
+type ImageMetadata struct {
+	FQN        string
+	Repository string
+	Tag        string
+}
+
+// templateData is what the templates of helm releases are executed with:
+// the environment variables and, in IMAGES, the built images by name.
+func templateData(builds []build.Build) map[string]interface{} {
+	data := map[string]interface{}{}
+	for _, env := range os.Environ() {
+		if kv := strings.SplitN(env, "=", 2); len(kv) == 2 {
+			data[kv[0]] = kv[1]
+		}
 	}
 
-	if len(r.SetValues) != 0 {
-		for k, v := range r.SetValues {
-			setOpts = append(setOpts, "--set")
-			setOpts = append(setOpts, fmt.Sprintf("%s=%s", k, v))
+	images := map[string]ImageMetadata{}
+	for _, b := range builds {
+		image := ImageMetadata{FQN: b.Tag, Repository: b.ImageName}
+		if r, err := reference.Parse(b.Tag); err == nil {
+			if named, ok := r.(reference.Named); ok {
+				image.Repository = named.Name()
+			}
+			if tagged, ok := r.(reference.Tagged); ok {
+				image.Tag = tagged.Tag()
+			}
 		}
+		images[b.ImageName] = image
 	}
-	args = append(args, setOpts...)
+	data["IMAGES"] = images
 
-	return h.helm(out, args...)
+	return data
 }
 
-func (h *HelmDeployer) deleteRelease(out io.Writer, r v1alpha2.HelmRelease) error {
-	if err := h.helm(out, "delete", r.Name, "--purge"); err != nil {
+// expandValues executes the templates of a map's values.
+func expandValues(values map[string]string, data interface{}) (map[string]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+
+	expanded := map[string]string{}
+	for k, v := range values {
+		value, err := executeTemplate(v, data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "expanding %s", k)
+		}
+		expanded[k] = value
+	}
+	return expanded, nil
+}
+
+func executeTemplate(text string, data interface{}) (string, error) {
+	tmpl, err := template.New("helm").Parse(text)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing template")
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", errors.Wrap(err, "executing template")
+	}
+	return buf.String(), nil
+}
+
+func (h *HelmDeployer) deleteRelease(ctx context.Context, out io.Writer, r v1alpha2.HelmRelease) error {
+	args := []string{"delete", r.Name, "--purge"}
+	if h.majorVersion >= 3 {
+		args = []string{"uninstall", r.Name}
+		if r.Namespace != "" {
+			args = append(args, "--namespace", r.Namespace)
+		}
+	}
+
+	if err := h.helm(ctx, out, args...); err != nil {
 		logrus.Debugf("deleting release %s: %v\n", r.Name, err)
 	}
 
@@ -20,13 +20,19 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/spf13/afero"
 )
 
 var testBuildResult = &build.BuildResult{
@@ -148,11 +154,629 @@ func TestHelmDeploy(t *testing.T) {
 
 }
 
+func TestHelmDeployWorkloads(t *testing.T) {
+	manifest := `
+---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+---
+# Source: app/templates/statefulset.yaml
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+  namespace: storage
+`
+
+	var tests = []struct {
+		description string
+		statusCheck *v1alpha2.StatusCheck
+		version     string
+		expected    []kubernetes.Workload
+		commands    []string
+	}{
+		{
+			description: "no status check",
+			commands: []string{
+				"version --client --short",
+				"get app",
+				"dep build chart",
+				"upgrade app chart --namespace apps",
+			},
+		},
+		{
+			description: "helm 2",
+			statusCheck: &v1alpha2.StatusCheck{Deadline: "1m"},
+			expected: []kubernetes.Workload{
+				{Kind: "Deployment", Namespace: "apps", Name: "app"},
+				{Kind: "StatefulSet", Namespace: "storage", Name: "db"},
+			},
+			commands: []string{
+				"version --client --short",
+				"get app",
+				"dep build chart",
+				"upgrade app chart --namespace apps",
+				"get manifest app",
+			},
+		},
+		{
+			description: "helm 3",
+			statusCheck: &v1alpha2.StatusCheck{Deadline: "1m"},
+			version:     "v3.0.0+ge29ce2a",
+			expected: []kubernetes.Workload{
+				{Kind: "Deployment", Namespace: "apps", Name: "app"},
+				{Kind: "StatefulSet", Namespace: "storage", Name: "db"},
+			},
+			commands: []string{
+				"version --client --short",
+				"dep build chart",
+				"upgrade --install app chart --namespace apps",
+				"get manifest app --namespace apps",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cmd := &MockHelm{t: t, manifestResult: cmdOutput{manifest, nil}}
+			if test.version != "" {
+				cmd.versionResult = cmdOutput{test.version, nil}
+			}
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = cmd
+
+			deployer := NewHelmDeployer(&v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{
+					HelmDeploy: &v1alpha2.HelmDeploy{
+						Releases: []v1alpha2.HelmRelease{{Name: "app", ChartPath: "chart", Namespace: "apps"}},
+					},
+				},
+				StatusCheck: test.statusCheck,
+			}, testKubeContext)
+			res, err := deployer.Deploy(context.Background(), ioutil.Discard, &build.BuildResult{})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, res.Workloads)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.commands, cmd.commands)
+		})
+	}
+}
+
+func TestHelmDeployRemoteChart(t *testing.T) {
+	var tests = []struct {
+		description string
+		release     v1alpha2.HelmRelease
+		cmd         *MockHelm
+		expected    []string
+		shouldErr   bool
+	}{
+		{
+			description: "chart from a repository url",
+			release: v1alpha2.HelmRelease{
+				Name:        "redis",
+				RemoteChart: "redis",
+				Repo:        "https://charts.example.com",
+				Version:     "3.7.2",
+			},
+			cmd: &MockHelm{
+				t:         t,
+				getResult: cmdOutput{"", fmt.Errorf("not found")},
+			},
+			expected: []string{
+				"version --client --short",
+				"get redis",
+				"install --name redis redis --repo https://charts.example.com --version 3.7.2",
+			},
+		},
+		{
+			description: "chart from a local repository",
+			release: v1alpha2.HelmRelease{
+				Name:        "redis",
+				RemoteChart: "stable/redis",
+			},
+			cmd: &MockHelm{t: t},
+			expected: []string{
+				"version --client --short",
+				"get redis",
+				"repo update",
+				"upgrade redis stable/redis",
+			},
+		},
+		{
+			description: "repo update error",
+			release: v1alpha2.HelmRelease{
+				Name:        "redis",
+				RemoteChart: "stable/redis",
+			},
+			cmd: &MockHelm{
+				t:          t,
+				repoResult: cmdOutput{"", fmt.Errorf("unexpected error")},
+			},
+			expected: []string{
+				"version --client --short",
+				"get redis",
+				"repo update",
+			},
+			shouldErr: true,
+		},
+		{
+			description: "both a local and a remote chart",
+			release: v1alpha2.HelmRelease{
+				Name:        "redis",
+				ChartPath:   "charts/redis",
+				RemoteChart: "stable/redis",
+			},
+			cmd:       &MockHelm{t: t},
+			expected:  []string{"version --client --short", "get redis"},
+			shouldErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = tt.cmd
+
+			deployer := NewHelmDeployer(&v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{
+					HelmDeploy: &v1alpha2.HelmDeploy{
+						Releases: []v1alpha2.HelmRelease{tt.release},
+					},
+				},
+			}, testKubeContext)
+			_, err := deployer.Deploy(context.Background(), &bytes.Buffer{}, &build.BuildResult{})
+
+			testutil.CheckErrorAndDeepEqual(t, tt.shouldErr, err, tt.expected, tt.cmd.commands)
+		})
+	}
+}
+
+func TestHelmImageValues(t *testing.T) {
+	var tests = []struct {
+		description string
+		strategy    string
+		tag         string
+		expected    []string
+		shouldErr   bool
+	}{
+		{
+			description: "default",
+			tag:         "gcr.io/project/image:v1",
+			expected:    []string{"image=gcr.io/project/image:v1"},
+		},
+		{
+			description: "fqn",
+			strategy:    ImageStrategyFQN,
+			tag:         "gcr.io/project/image:v1",
+			expected:    []string{"image=gcr.io/project/image:v1"},
+		},
+		{
+			description: "repository and tag",
+			strategy:    ImageStrategyRepoTag,
+			tag:         "localhost:5000/image:v1",
+			expected:    []string{"image.repository=localhost:5000/image", "image.tag=v1"},
+		},
+		{
+			description: "digest",
+			strategy:    ImageStrategyDigest,
+			tag:         "gcr.io/project/image:v1",
+			expected:    []string{"image=gcr.io/project/image@sha256:abac"},
+		},
+		{
+			description: "no tag",
+			strategy:    ImageStrategyRepoTag,
+			tag:         "gcr.io/project/image",
+			shouldErr:   true,
+		},
+		{
+			description: "unknown strategy",
+			strategy:    "unknown",
+			tag:         "gcr.io/project/image:v1",
+			shouldErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			deployer := NewHelmDeployer(testDeployConfig, testKubeContext)
+			deployer.lookupDigest = func(string) (string, error) { return "sha256:abac", nil }
+
+			values, err := deployer.imageValues(tt.strategy, "image", build.Build{ImageName: "image", Tag: tt.tag})
+
+			testutil.CheckErrorAndDeepEqual(t, tt.shouldErr, err, tt.expected, values)
+		})
+	}
+}
+
+func TestHelmDeployTemplatedValues(t *testing.T) {
+	defer os.Unsetenv("SKAFFOLD_TEST_ENV")
+	os.Setenv("SKAFFOLD_TEST_ENV", "staging")
+
+	cmd := &MockHelm{t: t}
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = cmd
+
+	deployer := NewHelmDeployer(&v1alpha2.DeployConfig{
+		DeployType: v1alpha2.DeployType{
+			HelmDeploy: &v1alpha2.HelmDeploy{
+				Releases: []v1alpha2.HelmRelease{{
+					Name:           "skaffold-helm",
+					ChartPath:      "examples/test",
+					ValuesFilePath: "values.yaml",
+					ValuesFiles:    []string{"values-staging.yaml", "values-local.yaml"},
+					Values:         map[string]string{"image": "{{.SKAFFOLD_TEST_ENV}}/app"},
+					SetValues: map[string]string{
+						"env":     "{{.SKAFFOLD_TEST_ENV}}",
+						"version": `{{(index .IMAGES "staging/app").Tag}}`,
+					},
+					SkipBuildDependencies: true,
+				}},
+			},
+		},
+	}, testKubeContext)
+	_, err := deployer.Deploy(context.Background(), &bytes.Buffer{}, &build.BuildResult{
+		Builds: []build.Build{{ImageName: "staging/app", Tag: "staging/app:v1"}},
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{
+		"version --client --short",
+		"get skaffold-helm",
+		"upgrade skaffold-helm examples/test -f values.yaml -f values-staging.yaml -f values-local.yaml --set image=staging/app:v1 --set env=staging --set version=v1",
+	}, cmd.commands)
+}
+
+func TestHelmMajorVersion(t *testing.T) {
+	var tests = []struct {
+		description   string
+		configured    string
+		clientVersion string
+		expected      int
+		shouldErr     bool
+	}{
+		{
+			description: "configured",
+			configured:  "3",
+			expected:    3,
+		},
+		{
+			description: "configured with a v",
+			configured:  "v2",
+			expected:    2,
+		},
+		{
+			description: "unsupported",
+			configured:  "4",
+			shouldErr:   true,
+		},
+		{
+			description:   "helm 2",
+			clientVersion: "Client: v2.16.1+gbbdfe5e",
+			expected:      2,
+		},
+		{
+			description:   "helm 3",
+			clientVersion: "v3.0.2+g19e47ee",
+			expected:      3,
+		},
+		{
+			description:   "unknown output",
+			clientVersion: "helm",
+			shouldErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			major, err := helmMajorVersion(tt.configured, func() ([]byte, error) {
+				return []byte(tt.clientVersion), nil
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, tt.shouldErr, err, tt.expected, major)
+		})
+	}
+}
+
+func TestHelm3(t *testing.T) {
+	cmd := &MockHelm{
+		t:             t,
+		versionResult: cmdOutput{"v3.0.2+g19e47ee", nil},
+	}
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = cmd
+
+	deployer := NewHelmDeployer(&v1alpha2.DeployConfig{
+		DeployType: v1alpha2.DeployType{
+			HelmDeploy: &v1alpha2.HelmDeploy{
+				Releases: []v1alpha2.HelmRelease{{
+					Name:         "skaffold-helm",
+					ChartPath:    "examples/test",
+					Namespace:    "staging",
+					Values:       map[string]string{"image": "skaffold-helm"},
+					RecreatePods: true,
+				}},
+			},
+		},
+	}, testKubeContext)
+	_, err := deployer.Deploy(context.Background(), &bytes.Buffer{}, testBuildResult)
+	testutil.CheckError(t, false, err)
+
+	err = deployer.Cleanup(context.Background(), &bytes.Buffer{})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{
+		"version --client --short",
+		"dep build examples/test",
+		"upgrade --install skaffold-helm examples/test --namespace staging --set image=skaffold-helm:3605e7bc17cf46e53f4d81c4cbc24e5b4c495184",
+		"uninstall skaffold-helm --namespace staging",
+	}, cmd.commands)
+}
+
+func TestHelmRender(t *testing.T) {
+	var tests = []struct {
+		description string
+		version     string
+		release     v1alpha2.HelmRelease
+		expected    []string
+		shouldErr   bool
+	}{
+		{
+			description: "helm 2",
+			version:     "v2.16.1+gbbdfe5e",
+			release: v1alpha2.HelmRelease{
+				Name:      "skaffold-helm",
+				ChartPath: "examples/test",
+				Namespace: "staging",
+				Values:    map[string]string{"image": "skaffold-helm"},
+			},
+			expected: []string{
+				"version --client --short",
+				"dep build examples/test",
+				"template examples/test --name skaffold-helm --namespace staging --set image=skaffold-helm:3605e7bc17cf46e53f4d81c4cbc24e5b4c495184",
+			},
+		},
+		{
+			description: "helm 3",
+			version:     "v3.0.2+g19e47ee",
+			release: v1alpha2.HelmRelease{
+				Name:        "skaffold-helm",
+				RemoteChart: "stable/chart",
+				Repo:        "https://charts.example.com",
+				Values:      map[string]string{"image": "skaffold-helm"},
+			},
+			expected: []string{
+				"version --client --short",
+				"template skaffold-helm stable/chart --repo https://charts.example.com --set image=skaffold-helm:3605e7bc17cf46e53f4d81c4cbc24e5b4c495184",
+			},
+		},
+		{
+			description: "remote chart with helm 2",
+			version:     "v2.16.1+gbbdfe5e",
+			release: v1alpha2.HelmRelease{
+				Name:        "skaffold-helm",
+				RemoteChart: "stable/chart",
+				Repo:        "https://charts.example.com",
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cmd := &MockHelm{
+				t:              t,
+				versionResult:  cmdOutput{test.version, nil},
+				templateResult: cmdOutput{"kind: Deployment\n", nil},
+			}
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = cmd
+
+			deployer := NewHelmDeployer(&v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{
+					HelmDeploy: &v1alpha2.HelmDeploy{Releases: []v1alpha2.HelmRelease{test.release}},
+				},
+			}, testKubeContext)
+
+			out := &bytes.Buffer{}
+			err := deployer.Render(context.Background(), out, testBuildResult)
+
+			testutil.CheckError(t, test.shouldErr, err)
+			if !test.shouldErr {
+				testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, cmd.commands)
+				testutil.CheckErrorAndDeepEqual(t, false, nil, "kind: Deployment\n", out.String())
+			}
+		})
+	}
+}
+
+func TestHelmReleaseFlags(t *testing.T) {
+	var tests = []struct {
+		description string
+		release     v1alpha2.HelmRelease
+		upgrade     bool
+		helm3       bool
+		expected    []string
+	}{
+		{
+			description: "no flags",
+		},
+		{
+			description: "install",
+			release: v1alpha2.HelmRelease{
+				Wait:         true,
+				Timeout:      120,
+				Atomic:       true,
+				Force:        true,
+				RecreatePods: true,
+			},
+			expected: []string{"--wait", "--timeout", "120", "--atomic"},
+		},
+		{
+			description: "upgrade",
+			release: v1alpha2.HelmRelease{
+				Wait:         true,
+				Timeout:      120,
+				Atomic:       true,
+				Force:        true,
+				RecreatePods: true,
+			},
+			upgrade:  true,
+			expected: []string{"--wait", "--timeout", "120", "--atomic", "--force", "--recreate-pods"},
+		},
+		{
+			description: "helm 3",
+			release: v1alpha2.HelmRelease{
+				Wait:    true,
+				Timeout: 120,
+				Atomic:  true,
+			},
+			upgrade:  true,
+			helm3:    true,
+			expected: []string{"--wait", "--timeout", "120s", "--atomic"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			flags := releaseFlags(tt.release, tt.upgrade, tt.helm3)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, tt.expected, flags)
+		})
+	}
+}
+
+func TestHelmDeployPackaged(t *testing.T) {
+	var tests = []struct {
+		description string
+		release     v1alpha2.HelmRelease
+		cmd         *MockHelm
+		expected    []string
+		shouldErr   bool
+	}{
+		{
+			description: "package with templated versions",
+			release: v1alpha2.HelmRelease{
+				Name:      "skaffold-helm",
+				ChartPath: "examples/test",
+				Values:    map[string]string{"image": "skaffold-helm"},
+				Packaged: &v1alpha2.HelmPackaged{
+					Version:    "1.0.0-{{.TAG}}",
+					AppVersion: "{{.TAG}}",
+				},
+			},
+			cmd: &MockHelm{
+				t:             t,
+				packageResult: cmdOutput{"Successfully packaged chart and saved it to: /tmp/skaffold-helm-1.0.0.tgz\n", nil},
+			},
+			expected: []string{
+				"version --client --short",
+				"get skaffold-helm",
+				"dep build examples/test",
+				"package examples/test --destination DIR --version 1.0.0-3605e7bc --app-version 3605e7bc",
+				"upgrade skaffold-helm /tmp/skaffold-helm-1.0.0.tgz --set image=skaffold-helm:3605e7bc",
+			},
+		},
+		{
+			description: "skip dependencies",
+			release: v1alpha2.HelmRelease{
+				Name:                  "skaffold-helm",
+				ChartPath:             "examples/test",
+				SkipBuildDependencies: true,
+				Packaged:              &v1alpha2.HelmPackaged{},
+			},
+			cmd: &MockHelm{
+				t:             t,
+				packageResult: cmdOutput{"Successfully packaged chart and saved it to: /tmp/skaffold-helm-0.1.0.tgz\n", nil},
+			},
+			expected: []string{
+				"version --client --short",
+				"get skaffold-helm",
+				"package examples/test --destination DIR",
+				"upgrade skaffold-helm /tmp/skaffold-helm-0.1.0.tgz",
+			},
+		},
+		{
+			description: "unexpected package output",
+			release: v1alpha2.HelmRelease{
+				Name:      "skaffold-helm",
+				ChartPath: "examples/test",
+				Packaged:  &v1alpha2.HelmPackaged{},
+			},
+			cmd: &MockHelm{
+				t:             t,
+				packageResult: cmdOutput{"", nil},
+			},
+			expected: []string{
+				"version --client --short",
+				"get skaffold-helm",
+				"dep build examples/test",
+				"package examples/test --destination DIR",
+			},
+			shouldErr: true,
+		},
+		{
+			description: "remote chart",
+			release: v1alpha2.HelmRelease{
+				Name:        "redis",
+				RemoteChart: "redis",
+				Repo:        "https://charts.example.com",
+				Packaged:    &v1alpha2.HelmPackaged{},
+			},
+			cmd:       &MockHelm{t: t},
+			expected:  []string{"version --client --short", "get redis"},
+			shouldErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = tt.cmd
+
+			deployer := NewHelmDeployer(&v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{
+					HelmDeploy: &v1alpha2.HelmDeploy{
+						Releases: []v1alpha2.HelmRelease{tt.release},
+					},
+				},
+			}, testKubeContext)
+			_, err := deployer.Deploy(context.Background(), &bytes.Buffer{}, &build.BuildResult{
+				Builds: []build.Build{{ImageName: "skaffold-helm", Tag: "skaffold-helm:3605e7bc"}},
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, tt.shouldErr, err, tt.expected, withoutTempDir(tt.cmd.commands))
+		})
+	}
+}
+
+// withoutTempDir replaces the random destination of `helm package`.
+func withoutTempDir(commands []string) []string {
+	var replaced []string
+	for _, c := range commands {
+		replaced = append(replaced, regexp.MustCompile(`--destination \S+`).ReplaceAllString(c, "--destination DIR"))
+	}
+	return replaced
+}
+
 type MockHelm struct {
-	getResult     cmdOutput
-	installResult cmdOutput
-	upgradeResult cmdOutput
-	depResult     cmdOutput
+	getResult      cmdOutput
+	manifestResult cmdOutput
+	installResult  cmdOutput
+	upgradeResult  cmdOutput
+	depResult      cmdOutput
+	repoResult     cmdOutput
+	packageResult  cmdOutput
+	versionResult  cmdOutput
+	deleteResult   cmdOutput
+	templateResult cmdOutput
+
+	commands []string
 
 	t *testing.T
 }
@@ -175,8 +799,24 @@ func (m *MockHelm) RunCmdOut(c *exec.Cmd) ([]byte, error) {
 		m.t.Errorf("Invalid kubernetes context %v", c)
 	}
 
+	m.commands = append(m.commands, strings.Join(c.Args[3:], " "))
+
 	switch c.Args[3] {
+	case "version":
+		if m.versionResult == (cmdOutput{}) {
+			return []byte("Client: v2.16.1+gbbdfe5e"), nil
+		}
+		return m.versionResult.out()
+	case "uninstall", "delete":
+		return m.deleteResult.out()
+	case "package":
+		return m.packageResult.out()
+	case "repo":
+		return m.repoResult.out()
 	case "get":
+		if len(c.Args) > 4 && c.Args[4] == "manifest" {
+			return m.manifestResult.out()
+		}
 		return m.getResult.out()
 	case "install":
 		return m.installResult.out()
@@ -184,6 +824,8 @@ func (m *MockHelm) RunCmdOut(c *exec.Cmd) ([]byte, error) {
 		return m.upgradeResult.out()
 	case "dep":
 		return m.depResult.out()
+	case "template":
+		return m.templateResult.out()
 	}
 
 	m.t.Errorf("Unknown helm command: %+v", c)
@@ -191,6 +833,34 @@ func (m *MockHelm) RunCmdOut(c *exec.Cmd) ([]byte, error) {
 }
 
 func (m *MockHelm) RunCmd(c *exec.Cmd) error {
-	_, err := m.RunCmdOut(c)
+	stdout, err := m.RunCmdOut(c)
+	if c.Stdout != nil {
+		c.Stdout.Write(stdout)
+	}
 	return err
 }
+
+func TestHelmDependencies(t *testing.T) {
+	defer func(fs afero.Fs) { util.Fs = fs }(util.Fs)
+	util.Fs = afero.NewMemMapFs()
+	afero.WriteFile(util.Fs, "chart/Chart.yaml", []byte(""), 0644)
+	afero.WriteFile(util.Fs, "chart/requirements.lock", []byte(""), 0644)
+	afero.WriteFile(util.Fs, "chart/charts/redis-3.0.0.tgz", []byte(""), 0644)
+	afero.WriteFile(util.Fs, "chart/templates/deployment.yaml", []byte(""), 0644)
+	afero.WriteFile(util.Fs, "chart/templates/charts/notes.txt", []byte(""), 0644)
+
+	deployer := NewHelmDeployer(&v1alpha2.DeployConfig{
+		DeployType: v1alpha2.DeployType{
+			HelmDeploy: &v1alpha2.HelmDeploy{
+				Releases: []v1alpha2.HelmRelease{
+					{Name: "local", ChartPath: "chart", ValuesFilePath: "values.yaml", ValuesFiles: []string{"dev.yaml"}},
+					{Name: "remote", RemoteChart: "stable/redis", ValuesFiles: []string{"redis.yaml"}},
+				},
+			},
+		},
+	}, testKubeContext)
+	deps, err := deployer.Dependencies()
+
+	expected := []string{"chart/Chart.yaml", "chart/templates/charts/notes.txt", "chart/templates/deployment.yaml", "values.yaml", "dev.yaml", "redis.yaml"}
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, deps)
+}
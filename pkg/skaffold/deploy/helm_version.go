@@ -0,0 +1,62 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var helmClientVersion = regexp.MustCompile(`v(\d+)\.\d+`)
+
+// helmMajorVersion returns the major version of helm: the configured one if
+// any, or else the version printed by `helm version --client --short`.
+func helmMajorVersion(configured string, clientVersion func() ([]byte, error)) (int, error) {
+	if configured != "" {
+		major, err := strconv.Atoi(strings.TrimPrefix(configured, "v"))
+		if err != nil || (major != 2 && major != 3) {
+			return 0, fmt.Errorf("unsupported helm version %s, expected 2 or 3", configured)
+		}
+		return major, nil
+	}
+
+	out, err := clientVersion()
+	if err != nil {
+		return 0, errors.Wrap(err, "getting helm client version")
+	}
+
+	match := helmClientVersion.FindStringSubmatch(string(out))
+	if match == nil {
+		return 0, fmt.Errorf("unable to parse helm client version: %s", out)
+	}
+
+	major, _ := strconv.Atoi(match[1])
+	logrus.Debugf("Detected helm %d", major)
+	return major, nil
+}
+
+// localHelmClientVersion runs `helm version --client --short`.
+func localHelmClientVersion() ([]byte, error) {
+	return util.RunCmdOut(exec.Command("helm", "version", "--client", "--short"))
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+var invalidEnvChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// podsTemplate prints the name, deletion timestamp and images of each pod on
+// its own line, separated by tabs.
+const podsTemplate = `{range .items[*]}{.metadata.name}{"\t"}{.metadata.deletionTimestamp}{"\t"}{.spec.containers[*].image}{"\n"}{end}`
+
+// WaitsForRollouts tells if deploys wait for the deployed workloads to be
+// rolled out: either a status check is configured, or after hooks need the
+// new pods to be running.
+func WaitsForRollouts(cfg *v1alpha2.DeployConfig) bool {
+	return cfg.StatusCheck != nil || (cfg.Hooks != nil && len(cfg.Hooks.After) > 0)
+}
+
+// RunHooks runs deploy hooks in order and stops at the first failure. The
+// deployed images are passed to host commands in their environment, as
+// SKAFFOLD_IMAGE_<NAME>=<tag>, and expanded in the commands run in pods.
+func RunHooks(ctx context.Context, out io.Writer, hooks []v1alpha2.DeployHook, kubeContext, namespace string, builds []build.Build) error {
+	env := hookEnv(builds, kubeContext, namespace)
+	deployed := map[string]bool{}
+	for _, b := range builds {
+		deployed[b.Tag] = true
+	}
+
+	for _, hook := range hooks {
+		switch {
+		case hook.Command != "" && hook.Exec != nil:
+			return fmt.Errorf("a hook can't have both a command and exec")
+
+		case hook.Command != "":
+			if err := runHostHook(ctx, out, hook.Command, env); err != nil {
+				return errors.Wrapf(err, "running %s", hook.Command)
+			}
+
+		case hook.Exec != nil && len(hook.Exec.Selector) == 0:
+			return fmt.Errorf("an exec hook needs a selector, otherwise it would run in any pod")
+
+		case hook.Exec != nil:
+			if err := runExecHook(ctx, out, hook.Exec, env, deployed, kubeContext, namespace); err != nil {
+				return errors.Wrapf(err, "running %s in a pod", strings.Join(hook.Exec.Command, " "))
+			}
+
+		default:
+			return fmt.Errorf("a hook needs either a command or exec")
+		}
+	}
+
+	return nil
+}
+
+func runHostHook(ctx context.Context, out io.Writer, command string, env map[string]string) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = os.Environ()
+	for _, k := range sortedKeys(env) {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, env[k]))
+	}
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	return util.RunCmd(cmd)
+}
+
+func runExecHook(ctx context.Context, out io.Writer, hook *v1alpha2.ExecHook, env map[string]string, deployed map[string]bool, kubeContext, namespace string) error {
+	if hook.Namespace != "" {
+		namespace = hook.Namespace
+	}
+
+	podName, err := runningPod(ctx, kubeContext, namespace, hook.Selector, deployed)
+	if err != nil {
+		return err
+	}
+
+	args := append(kubectlContextArgs(kubeContext, namespace), "exec", podName)
+	if hook.Container != "" {
+		args = append(args, "-c", hook.Container)
+	}
+	args = append(args, "--")
+	for _, arg := range hook.Command {
+		args = append(args, os.Expand(arg, func(key string) string { return env[key] }))
+	}
+
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	return util.RunCmd(cmd)
+}
+
+// runningPod finds the name of a running pod that matches a selector.
+// Pods that are terminating, like those of the previous revision during a
+// rollout, are skipped, and pods running one of the deployed images are
+// preferred.
+func runningPod(ctx context.Context, kubeContext, namespace string, selector map[string]string, deployed map[string]bool) (string, error) {
+	var labels []string
+	for _, k := range sortedKeys(selector) {
+		labels = append(labels, fmt.Sprintf("%s=%s", k, selector[k]))
+	}
+
+	args := append(kubectlContextArgs(kubeContext, namespace), "get", "pods",
+		"-l", strings.Join(labels, ","),
+		"--field-selector", "status.phase=Running",
+		"-o", "jsonpath="+podsTemplate)
+
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	stdout, err := util.RunCmdOut(cmd)
+	if err != nil {
+		return "", errors.Wrap(err, "listing pods")
+	}
+
+	var running []string
+	for _, line := range strings.Split(string(stdout), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 || fields[0] == "" || fields[1] != "" {
+			continue
+		}
+
+		for _, image := range strings.Fields(fields[2]) {
+			if deployed[image] {
+				return fields[0], nil
+			}
+		}
+		running = append(running, fields[0])
+	}
+
+	if len(running) == 0 {
+		return "", fmt.Errorf("no running pod matches %s", strings.Join(labels, ","))
+	}
+	return running[0], nil
+}
+
+func kubectlContextArgs(kubeContext, namespace string) []string {
+	args := []string{"--context", kubeContext}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	return args
+}
+
+// hookEnv lists the variables given to hooks: the tag of each deployed image,
+// the kubectl context and, if set, the namespace.
+func hookEnv(builds []build.Build, kubeContext, namespace string) map[string]string {
+	env := map[string]string{
+		"SKAFFOLD_KUBE_CONTEXT": kubeContext,
+	}
+	if namespace != "" {
+		env["SKAFFOLD_NAMESPACE"] = namespace
+	}
+	for _, b := range builds {
+		env[imageEnvVariable(b.ImageName)] = b.Tag
+	}
+	return env
+}
+
+// imageEnvVariable names the variable that holds the tag of an image after
+// the last part of its name: gcr.io/project/web-app gives SKAFFOLD_IMAGE_WEB_APP.
+func imageEnvVariable(imageName string) string {
+	name := imageName[strings.LastIndex(imageName, "/")+1:]
+	return "SKAFFOLD_IMAGE_" + invalidEnvChars.ReplaceAllString(strings.ToUpper(name), "_")
+}
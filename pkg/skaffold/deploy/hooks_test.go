@@ -0,0 +1,168 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+var hookBuilds = []build.Build{
+	{ImageName: "gcr.io/project/web-app", Tag: "gcr.io/project/web-app:v1"},
+}
+
+func TestRunHostHooks(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	output := filepath.Join(dir, "output")
+
+	err := RunHooks(context.Background(), ioutil.Discard, []v1alpha2.DeployHook{
+		{Command: fmt.Sprintf("echo $SKAFFOLD_IMAGE_WEB_APP $SKAFFOLD_NAMESPACE > %s", output)},
+	}, "kubecontext", "staging", hookBuilds)
+	testutil.CheckError(t, false, err)
+
+	content, err := ioutil.ReadFile(output)
+	testutil.CheckErrorAndDeepEqual(t, false, err, "gcr.io/project/web-app:v1 staging\n", string(content))
+}
+
+// recordingKubectl records the commands it's given and answers `kubectl get pods`
+// with pod names. The commands that contain failing, if set, fail.
+type recordingKubectl struct {
+	pods     string
+	failing  string
+	commands []string
+}
+
+func (r *recordingKubectl) RunCmdOut(cmd *exec.Cmd) ([]byte, error) {
+	return []byte(r.pods), r.RunCmd(cmd)
+}
+
+func (r *recordingKubectl) RunCmd(cmd *exec.Cmd) error {
+	command := strings.Join(cmd.Args, " ")
+	r.commands = append(r.commands, command)
+	if r.failing != "" && strings.Contains(command, r.failing) {
+		return fmt.Errorf("%s failed", command)
+	}
+	return nil
+}
+
+func TestRunExecHooks(t *testing.T) {
+	var tests = []struct {
+		description string
+		hook        v1alpha2.DeployHook
+		pods        string
+		expected    []string
+		shouldErr   bool
+	}{
+		{
+			description: "exec in first running pod",
+			hook: v1alpha2.DeployHook{Exec: &v1alpha2.ExecHook{
+				Selector:  map[string]string{"app": "web", "tier": "backend"},
+				Container: "web",
+				Command:   []string{"migrate", "--image", "$SKAFFOLD_IMAGE_WEB_APP"},
+			}},
+			pods: "web-1\t\tgcr.io/project/web-app:v1\nweb-2\t\tgcr.io/project/web-app:v1\n",
+			expected: []string{
+				"kubectl --context kubecontext get pods -l app=web,tier=backend --field-selector status.phase=Running -o jsonpath=" + podsTemplate,
+				"kubectl --context kubecontext exec web-1 -c web -- migrate --image gcr.io/project/web-app:v1",
+			},
+		},
+		{
+			description: "exec in a pod of the new revision",
+			hook: v1alpha2.DeployHook{Exec: &v1alpha2.ExecHook{
+				Selector: map[string]string{"app": "web"},
+				Command:  []string{"migrate"},
+			}},
+			pods: "web-old-1\t2018-10-16T08:00:00Z\tgcr.io/project/web-app:v0\nweb-old-2\t\tgcr.io/project/web-app:v0 sidecar:1\nweb-new-1\t\tsidecar:1 gcr.io/project/web-app:v1\n",
+			expected: []string{
+				"kubectl --context kubecontext get pods -l app=web --field-selector status.phase=Running -o jsonpath=" + podsTemplate,
+				"kubectl --context kubecontext exec web-new-1 -- migrate",
+			},
+		},
+		{
+			description: "skip terminating pods",
+			hook: v1alpha2.DeployHook{Exec: &v1alpha2.ExecHook{
+				Selector: map[string]string{"app": "db"},
+				Command:  []string{"true"},
+			}},
+			pods: "db-0\t2018-10-16T08:00:00Z\tpostgres:10\ndb-1\t\tpostgres:10\n",
+			expected: []string{
+				"kubectl --context kubecontext get pods -l app=db --field-selector status.phase=Running -o jsonpath=" + podsTemplate,
+				"kubectl --context kubecontext exec db-1 -- true",
+			},
+		},
+		{
+			description: "hook namespace",
+			hook: v1alpha2.DeployHook{Exec: &v1alpha2.ExecHook{
+				Namespace: "db",
+				Selector:  map[string]string{"app": "db"},
+				Command:   []string{"true"},
+			}},
+			pods: "db-0\t\tpostgres:10\n",
+			expected: []string{
+				"kubectl --context kubecontext --namespace db get pods -l app=db --field-selector status.phase=Running -o jsonpath=" + podsTemplate,
+				"kubectl --context kubecontext --namespace db exec db-0 -- true",
+			},
+		},
+		{
+			description: "no running pod",
+			hook: v1alpha2.DeployHook{Exec: &v1alpha2.ExecHook{
+				Selector: map[string]string{"app": "web"},
+				Command:  []string{"true"},
+			}},
+			shouldErr: true,
+		},
+		{
+			description: "no selector",
+			hook: v1alpha2.DeployHook{Exec: &v1alpha2.ExecHook{
+				Command: []string{"true"},
+			}},
+			pods:      "web-1\t\tgcr.io/project/web-app:v1\n",
+			shouldErr: true,
+		},
+		{
+			description: "invalid hook",
+			hook:        v1alpha2.DeployHook{},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			kubectl := &recordingKubectl{pods: test.pods}
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = kubectl
+
+			err := RunHooks(context.Background(), ioutil.Discard, []v1alpha2.DeployHook{test.hook}, "kubecontext", "", hookBuilds)
+
+			testutil.CheckError(t, test.shouldErr, err)
+			if !test.shouldErr {
+				testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, kubectl.commands)
+			}
+		})
+	}
+}
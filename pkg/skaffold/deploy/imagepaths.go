@@ -0,0 +1,97 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"strconv"
+	"strings"
+)
+
+// replaceImagesAtPath replaces the built images found at a path of a manifest.
+// The path is a dot separated list of fields, where `[*]` selects every item of
+// a list and `[n]` a single one. JSONPath's braces and leading dot are accepted.
+func replaceImagesAtPath(m map[interface{}]interface{}, path string, replacements map[string]*replacement) {
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "{"), "}")
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return
+	}
+
+	replaceAtPath(m, strings.Split(path, "."), replacements)
+}
+
+func replaceAtPath(node interface{}, fields []string, replacements map[string]*replacement) {
+	m, ok := node.(map[interface{}]interface{})
+	if !ok {
+		return
+	}
+
+	name, index, isList := parseField(fields[0])
+	last := len(fields) == 1
+
+	if !isList {
+		if !last {
+			replaceAtPath(m[name], fields[1:], replacements)
+			return
+		}
+		if image, ok := m[name].(string); ok {
+			if tag, replaced := replaceImage(image, replacements); replaced {
+				m[name] = tag
+			}
+		}
+		return
+	}
+
+	items, ok := m[name].([]interface{})
+	if !ok {
+		return
+	}
+	for i, item := range items {
+		if index >= 0 && i != index {
+			continue
+		}
+		if !last {
+			replaceAtPath(item, fields[1:], replacements)
+			continue
+		}
+		if image, ok := item.(string); ok {
+			if tag, replaced := replaceImage(image, replacements); replaced {
+				items[i] = tag
+			}
+		}
+	}
+}
+
+// parseField splits `name[n]` or `name[*]` into the name of a field and
+// the index of the selected item, -1 selecting every item.
+func parseField(field string) (string, int, bool) {
+	open := strings.Index(field, "[")
+	if open < 0 || !strings.HasSuffix(field, "]") {
+		return field, 0, false
+	}
+
+	name, selector := field[:open], field[open+1:len(field)-1]
+	if selector == "*" {
+		return name, -1, true
+	}
+
+	index, err := strconv.Atoi(selector)
+	if err != nil {
+		return field, 0, false
+	}
+	return name, index, true
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestReplaceImagesAtPaths(t *testing.T) {
+	manifests := manifestList{[]byte(`apiVersion: batch/v1beta1
+kind: CronJob
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - image: worker
+            name: worker
+`), []byte(`apiVersion: example.com/v1
+kind: Function
+spec:
+  runtime:
+    baseImage: worker
+  sidecars:
+  - proxy
+  - worker
+  steps:
+  - ref: worker
+  - ref: worker
+`), []byte(`apiVersion: example.com/v1
+kind: Other
+spec:
+  runtime:
+    baseImage: worker
+`)}
+
+	builds := []build.Build{{
+		ImageName: "worker",
+		Tag:       "worker:TAG",
+	}}
+	paths := []v1alpha2.ImagePath{
+		{Kind: "Function", Path: "spec.runtime.baseImage"},
+		{Kind: "Function", Path: "{.spec.sidecars[*]}"},
+		{Kind: "Function", Path: ".spec.steps[1].ref"},
+		{Path: "spec.missing[*].image"},
+	}
+
+	expected := manifestList{[]byte(`apiVersion: batch/v1beta1
+kind: CronJob
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - image: worker:TAG
+            name: worker
+`), []byte(`apiVersion: example.com/v1
+kind: Function
+spec:
+  runtime:
+    baseImage: worker:TAG
+  sidecars:
+  - proxy
+  - worker:TAG
+  steps:
+  - ref: worker
+  - ref: worker:TAG
+`), []byte(`apiVersion: example.com/v1
+kind: Other
+spec:
+  runtime:
+    baseImage: worker
+`)}
+
+	resultManifest, err := manifests.replaceImages(builds, paths)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected.String(), resultManifest.String())
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+// ImageReference is an image that a manifest or a chart refers to.
+type ImageReference struct {
+	Image  string
+	Source string
+}
+
+// ManifestImages lists the images referenced by kubectl manifests.
+// Remote manifests are skipped since they only exist in the cluster.
+func ManifestImages(manifests []string) ([]ImageReference, error) {
+	files, err := manifestFiles(manifests)
+	if err != nil {
+		return nil, errors.Wrap(err, "expanding user manifest list")
+	}
+
+	var refs []ImageReference
+	for _, file := range files {
+		buf, err := afero.ReadFile(util.Fs, file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", file)
+		}
+
+		images, err := imagesInManifests(buf)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", file)
+		}
+		for _, image := range images {
+			refs = append(refs, ImageReference{Image: image, Source: file})
+		}
+	}
+	return refs, nil
+}
+
+// ChartImages renders a helm release with `helm template` and lists the images
+// it refers to. The values that receive built images are set to the image names.
+// Remote charts are skipped since `helm template` only renders local charts.
+// The version of helm is detected when helmVersion is empty.
+func ChartImages(r v1alpha2.HelmRelease, helmVersion string) ([]ImageReference, error) {
+	if r.ChartPath == "" {
+		logrus.Infof("Skipping the remote chart of release %s", r.Name)
+		return nil, nil
+	}
+
+	major, err := helmMajorVersion(helmVersion, localHelmClientVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"template", r.ChartPath, "--name", r.Name}
+	if major >= 3 {
+		args = []string{"template", r.Name, r.ChartPath}
+	}
+	if r.Namespace != "" {
+		args = append(args, "--namespace", r.Namespace)
+	}
+	for _, f := range valuesFiles(r) {
+		args = append(args, "-f", f)
+	}
+
+	data := templateData(nil)
+	values, err := expandValues(r.Values, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "expanding chart values")
+	}
+	for _, k := range sortedKeys(values) {
+		if r.ImageStrategy == ImageStrategyRepoTag {
+			args = append(args, "--set", fmt.Sprintf("%s.repository=%s", k, values[k]), "--set", k+".tag=latest")
+			continue
+		}
+		args = append(args, "--set", fmt.Sprintf("%s=%s", k, values[k]))
+	}
+
+	setValues, err := expandValues(r.SetValues, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "expanding setValues")
+	}
+	for _, k := range sortedKeys(setValues) {
+		args = append(args, "--set", fmt.Sprintf("%s=%s", k, setValues[k]))
+	}
+
+	buf, err := util.RunCmdOut(exec.Command("helm", args...))
+	if err != nil {
+		return nil, errors.Wrapf(err, "rendering chart of release %s", r.Name)
+	}
+
+	images, err := imagesInManifests(buf)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing chart of release %s", r.Name)
+	}
+
+	var refs []ImageReference
+	for _, image := range images {
+		refs = append(refs, ImageReference{Image: image, Source: "helm release " + r.Name})
+	}
+	return refs, nil
+}
+
+// imagesInManifests lists the distinct images of a multi-document yaml, in order.
+func imagesInManifests(buf []byte) ([]string, error) {
+	var images []string
+	seen := map[string]bool{}
+
+	for _, part := range bytes.Split(buf, []byte("\n---")) {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(part, &m); err != nil {
+			return nil, errors.Wrap(err, "reading kubernetes YAML")
+		}
+
+		var found []string
+		recursiveFindImages(m, &found)
+		for _, image := range found {
+			if !seen[image] {
+				seen[image] = true
+				images = append(images, image)
+			}
+		}
+	}
+	return images, nil
+}
+
+func recursiveFindImages(i interface{}, images *[]string) {
+	switch t := i.(type) {
+	case []interface{}:
+		for _, v := range t {
+			recursiveFindImages(v, images)
+		}
+	case map[interface{}]interface{}:
+		// Walk the keys in a stable order to always report images the same way.
+		var keys []interface{}
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+
+		for _, k := range keys {
+			v := t[k]
+			if image, isString := v.(string); isString && k == "image" {
+				*images = append(*images, image)
+				continue
+			}
+			recursiveFindImages(v, images)
+		}
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+const manifestsWithImages = `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  initContainers:
+  - name: init
+    image: busybox
+  containers:
+  - name: web
+    image: gcr.io/project/web
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-copy
+spec:
+  containers:
+  - name: web
+    image: gcr.io/project/web
+  - name: proxy
+    image: envoyproxy/envoy:v1.7.0`
+
+func TestImagesInManifests(t *testing.T) {
+	images, err := imagesInManifests([]byte(manifestsWithImages))
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"gcr.io/project/web", "busybox", "envoyproxy/envoy:v1.7.0"}, images)
+}
+
+func TestChartImages(t *testing.T) {
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = testutil.NewFakeCmdOut(
+		"helm template charts/web --name web -f values.yaml --set image=gcr.io/project/web --set replicas=2",
+		manifestsWithImages,
+		nil,
+	)
+
+	refs, err := ChartImages(v1alpha2.HelmRelease{
+		Name:           "web",
+		ChartPath:      "charts/web",
+		ValuesFilePath: "values.yaml",
+		Values:         map[string]string{"image": "gcr.io/project/web"},
+		SetValues:      map[string]string{"replicas": "2"},
+	}, "2")
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []ImageReference{
+		{Image: "gcr.io/project/web", Source: "helm release web"},
+		{Image: "busybox", Source: "helm release web"},
+		{Image: "envoyproxy/envoy:v1.7.0", Source: "helm release web"},
+	}, refs)
+}
+
+func TestChartImagesHelm3(t *testing.T) {
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = testutil.NewFakeCmdOut(
+		"helm template web charts/web -f values.yaml --set image=gcr.io/project/web --set replicas=2",
+		manifestsWithImages,
+		nil,
+	)
+
+	refs, err := ChartImages(v1alpha2.HelmRelease{
+		Name:           "web",
+		ChartPath:      "charts/web",
+		ValuesFilePath: "values.yaml",
+		Values:         map[string]string{"image": "gcr.io/project/web"},
+		SetValues:      map[string]string{"replicas": "2"},
+	}, "3")
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []ImageReference{
+		{Image: "gcr.io/project/web", Source: "helm release web"},
+		{Image: "busybox", Source: "helm release web"},
+		{Image: "envoyproxy/envoy:v1.7.0", Source: "helm release web"},
+	}, refs)
+}
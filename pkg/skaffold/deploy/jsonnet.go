@@ -0,0 +1,282 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// JsonnetDeployer renders jsonnet files, or a ksonnet app environment,
+// and deploys the resulting manifests with kubectl.
+type JsonnetDeployer struct {
+	*v1alpha2.DeployConfig
+	kubectl *KubectlDeployer
+
+	// Labels are added to every deployed resource.
+	Labels map[string]string
+
+	// Debug configures the containers of the built images for debugging.
+	Debug bool
+}
+
+// NewJsonnetDeployer returns a new JsonnetDeployer for a DeployConfig filled
+// with the needed configuration for rendering jsonnet.
+func NewJsonnetDeployer(cfg *v1alpha2.DeployConfig, kubeContext string, namespace string) *JsonnetDeployer {
+	kubectl := NewKubectlDeployer(cfg, kubeContext)
+	kubectl.Namespace = namespace
+
+	return &JsonnetDeployer{
+		DeployConfig: cfg,
+		kubectl:      kubectl,
+	}
+}
+
+// Deploy renders the manifests with the tags of the built images and
+// runs `kubectl apply` on them.
+func (j *JsonnetDeployer) Deploy(ctx context.Context, out io.Writer, b *build.BuildResult) (*Result, error) {
+	manifests, err := j.renderBuilt(b)
+	if err != nil {
+		return nil, err
+	}
+
+	workloads, err := manifests.workloads(j.kubectl.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing workloads")
+	}
+
+	if err := j.kubectl.kubectl(ctx, manifests.reader(), out, "apply", "-f", "-"); err != nil {
+		return nil, errors.Wrap(err, "deploying manifests")
+	}
+
+	j.kubectl.recordDeployed("jsonnet", manifests)
+	return &Result{Workloads: workloads}, nil
+}
+
+// Render writes the manifests that Deploy would apply.
+func (j *JsonnetDeployer) Render(ctx context.Context, out io.Writer, b *build.BuildResult) error {
+	manifests, err := j.renderBuilt(b)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(out, manifests.String())
+	return err
+}
+
+// renderBuilt renders the manifests with the tags of the built images.
+func (j *JsonnetDeployer) renderBuilt(b *build.BuildResult) (manifestList, error) {
+	values, err := JoinTagsToBuildResult(b.Builds, j.JsonnetDeploy.Values)
+	if err != nil {
+		return nil, errors.Wrap(err, "matching build results to jsonnet values")
+	}
+
+	extVars := map[string]string{}
+	for name, build := range values {
+		extVars[name] = build.Tag
+	}
+
+	manifests, err := j.render(extVars)
+	if err != nil {
+		return nil, errors.Wrap(err, "rendering jsonnet")
+	}
+
+	manifests, err = manifests.replaceImages(b.Builds, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "replacing images in manifests")
+	}
+
+	if j.Debug {
+		manifests, err = manifests.injectDebug(b.Builds)
+		if err != nil {
+			return nil, errors.Wrap(err, "configuring debugging")
+		}
+	}
+
+	if len(j.Labels) > 0 {
+		manifests, err = manifests.injectLabels(j.Labels)
+		if err != nil {
+			return nil, errors.Wrap(err, "injecting labels")
+		}
+	}
+
+	return manifests, nil
+}
+
+// Cleanup deletes what was deployed by calling Deploy. Without any record of
+// what was deployed, the manifests are rendered with the names of the images
+// in place of their tags.
+func (j *JsonnetDeployer) Cleanup(ctx context.Context, out io.Writer) error {
+	manifests, forget, err := j.kubectl.deployed("jsonnet")
+	if err != nil {
+		return errors.Wrap(err, "reading deployed manifests")
+	}
+
+	if len(manifests) == 0 {
+		manifests, err = j.render(j.JsonnetDeploy.Values)
+		if err != nil {
+			return errors.Wrap(err, "rendering jsonnet")
+		}
+	}
+
+	if err := j.kubectl.kubectl(ctx, manifests.reader(), out, "delete", "--ignore-not-found", "-f", "-"); err != nil {
+		return errors.Wrap(err, "deleting manifests")
+	}
+
+	return forget()
+}
+
+// Dependencies lists the jsonnet files and every jsonnet source found
+// in the library paths or in the ksonnet app.
+func (j *JsonnetDeployer) Dependencies() ([]string, error) {
+	if j.JsonnetDeploy.KsonnetEnvironment != "" {
+		return jsonnetSources([]string{j.ksonnetApp()})
+	}
+
+	files, err := util.ExpandPathsGlob(j.JsonnetDeploy.Files)
+	if err != nil {
+		return nil, errors.Wrap(err, "expanding jsonnet files")
+	}
+
+	libs, err := jsonnetSources(j.JsonnetDeploy.JPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(files, libs...), nil
+}
+
+func (j *JsonnetDeployer) ksonnetApp() string {
+	if j.JsonnetDeploy.KsonnetApp == "" {
+		return "."
+	}
+	return j.JsonnetDeploy.KsonnetApp
+}
+
+// render evaluates the jsonnet files, or shows the ksonnet environment.
+func (j *JsonnetDeployer) render(extVars map[string]string) (manifestList, error) {
+	if j.JsonnetDeploy.KsonnetEnvironment != "" {
+		cmd := exec.Command("ks", "show", j.JsonnetDeploy.KsonnetEnvironment)
+		cmd.Dir = j.ksonnetApp()
+
+		out, err := util.RunCmdOut(cmd)
+		if err != nil {
+			return nil, errors.Wrapf(err, "showing ksonnet environment %s", j.JsonnetDeploy.KsonnetEnvironment)
+		}
+
+		var manifests manifestList
+		for _, part := range bytes.Split(out, []byte("\n---")) {
+			manifests = append(manifests, part)
+		}
+		return manifests, nil
+	}
+
+	files, err := util.ExpandPathsGlob(j.JsonnetDeploy.Files)
+	if err != nil {
+		return nil, errors.Wrap(err, "expanding jsonnet files")
+	}
+
+	var manifests manifestList
+	for _, file := range files {
+		out, err := util.RunCmdOut(exec.Command("jsonnet", jsonnetArgs(file, j.JsonnetDeploy.JPath, extVars)...))
+		if err != nil {
+			return nil, errors.Wrapf(err, "evaluating %s", file)
+		}
+
+		objects, err := splitJSONObjects(out)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading the output of %s", file)
+		}
+		manifests = append(manifests, objects...)
+	}
+
+	logrus.Debugln("rendered manifests", manifests.String())
+
+	return manifests, nil
+}
+
+func jsonnetArgs(file string, jpath []string, extVars map[string]string) []string {
+	var args []string
+	for _, path := range jpath {
+		args = append(args, "-J", path)
+	}
+
+	var names []string
+	for name := range extVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, "--ext-str", fmt.Sprintf("%s=%s", name, extVars[name]))
+	}
+
+	return append(args, file)
+}
+
+// splitJSONObjects turns the output of jsonnet into a list of manifests.
+// An array is split into its elements.
+func splitJSONObjects(out []byte) (manifestList, error) {
+	var objects []json.RawMessage
+	if err := json.Unmarshal(out, &objects); err != nil {
+		var object json.RawMessage
+		if err := json.Unmarshal(out, &object); err != nil {
+			return nil, err
+		}
+		return manifestList{object}, nil
+	}
+
+	var manifests manifestList
+	for _, object := range objects {
+		manifests = append(manifests, object)
+	}
+	return manifests, nil
+}
+
+// jsonnetSources lists the jsonnet files found in a list of directories.
+func jsonnetSources(dirs []string) ([]string, error) {
+	var sources []string
+	for _, dir := range dirs {
+		err := afero.Walk(util.Fs, dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			switch filepath.Ext(path) {
+			case ".jsonnet", ".libsonnet":
+				sources = append(sources, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing jsonnet files in %s", dir)
+		}
+	}
+	return sources, nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/spf13/afero"
+)
+
+func TestJsonnetRender(t *testing.T) {
+	var tests = []struct {
+		description string
+		cfg         *v1alpha2.JsonnetDeploy
+		extVars     map[string]string
+		command     util.Command
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "single object",
+			cfg: &v1alpha2.JsonnetDeploy{
+				Files: []string{"app.jsonnet"},
+				JPath: []string{"vendor"},
+			},
+			extVars:  map[string]string{"web": "web:123", "db": "db:456"},
+			command:  testutil.NewFakeCmdOut("jsonnet -J vendor --ext-str db=db:456 --ext-str web=web:123 app.jsonnet", `{"kind": "Pod"}`, nil),
+			expected: `{"kind": "Pod"}`,
+		},
+		{
+			description: "array of objects",
+			cfg: &v1alpha2.JsonnetDeploy{
+				Files: []string{"app.jsonnet"},
+			},
+			command:  testutil.NewFakeCmdOut("jsonnet app.jsonnet", `[{"kind": "Pod"}, {"kind": "Service"}]`, nil),
+			expected: "{\"kind\": \"Pod\"}\n---\n{\"kind\": \"Service\"}",
+		},
+		{
+			description: "ksonnet environment",
+			cfg: &v1alpha2.JsonnetDeploy{
+				KsonnetEnvironment: "default",
+			},
+			command:  testutil.NewFakeCmdOut("ks show default", "kind: Pod\n---\nkind: Service\n", nil),
+			expected: "kind: Pod\n---\nkind: Service",
+		},
+		{
+			description: "invalid output",
+			cfg: &v1alpha2.JsonnetDeploy{
+				Files: []string{"app.jsonnet"},
+			},
+			command:   testutil.NewFakeCmdOut("jsonnet app.jsonnet", "not json", nil),
+			shouldErr: true,
+		},
+		{
+			description: "evaluation error",
+			cfg: &v1alpha2.JsonnetDeploy{
+				Files: []string{"app.jsonnet"},
+			},
+			command:   testutil.NewFakeCmdOut("jsonnet app.jsonnet", "", fmt.Errorf("undefined external variable: web")),
+			shouldErr: true,
+		},
+	}
+
+	defer func(fs afero.Fs) { util.Fs = fs }(util.Fs)
+	util.Fs = afero.NewMemMapFs()
+	afero.WriteFile(util.Fs, "app.jsonnet", []byte("{}"), 0644)
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = test.command
+
+			deployer := NewJsonnetDeployer(&v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{JsonnetDeploy: test.cfg},
+			}, testKubeContext, "")
+			manifests, err := deployer.render(test.extVars)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, manifests.String())
+		})
+	}
+}
+
+func TestJsonnetDependencies(t *testing.T) {
+	defer func(fs afero.Fs) { util.Fs = fs }(util.Fs)
+	util.Fs = afero.NewMemMapFs()
+	afero.WriteFile(util.Fs, "app.jsonnet", []byte("{}"), 0644)
+	afero.WriteFile(util.Fs, "vendor/lib.libsonnet", []byte("{}"), 0644)
+	afero.WriteFile(util.Fs, "vendor/README.md", []byte(""), 0644)
+
+	deployer := NewJsonnetDeployer(&v1alpha2.DeployConfig{
+		DeployType: v1alpha2.DeployType{
+			JsonnetDeploy: &v1alpha2.JsonnetDeploy{
+				Files: []string{"app.jsonnet"},
+				JPath: []string{"vendor"},
+			},
+		},
+	}, testKubeContext, "")
+	deps, err := deployer.Dependencies()
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"app.jsonnet", "vendor/lib.libsonnet"}, deps)
+}
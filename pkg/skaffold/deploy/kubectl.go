@@ -19,7 +19,9 @@ package deploy
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -28,8 +30,10 @@ import (
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/warnings"
 	"github.com/docker/distribution/reference"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -70,67 +74,229 @@ spec:
 type KubectlDeployer struct {
 	*v1alpha2.DeployConfig
 	kubeContext string
+
+	// DevMode adds the dev sidecars to the deployed workloads.
+	DevMode bool
+
+	// Debug configures the containers of the built images for debugging.
+	Debug bool
+
+	// Namespace, if set, is where the manifests are deployed.
+	Namespace string
+
+	// Labels are added to every deployed resource.
+	Labels map[string]string
 }
 
 // NewKubectlDeployer returns a new KubectlDeployer for a DeployConfig filled
 // with the needed configuration for `kubectl apply`
 func NewKubectlDeployer(cfg *v1alpha2.DeployConfig, kubeContext string) *KubectlDeployer {
-	return &KubectlDeployer{
+	k := &KubectlDeployer{
 		DeployConfig: cfg,
 		kubeContext:  kubeContext,
 	}
+	if cfg.KubectlDeploy != nil {
+		k.Namespace = cfg.KubectlDeploy.Namespace
+	}
+	return k
 }
 
 // Deploy templates the provided manifests with a simple `find and replace` and
 // runs `kubectl apply` on those manifests
 func (k *KubectlDeployer) Deploy(ctx context.Context, out io.Writer, b *build.BuildResult) (*Result, error) {
-	manifests, err := k.readOrGenerateManifests(b)
+	manifests, err := k.hydrateManifests(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	workloads, err := manifests.workloads(k.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing workloads")
+	}
+
+	if k.KubectlDeploy.CreateNamespace && k.Namespace != "" {
+		if err := k.createNamespace(ctx, out); err != nil {
+			return nil, errors.Wrapf(err, "creating namespace %s", k.Namespace)
+		}
+	}
+
+	if err := k.apply(ctx, out, manifests); err != nil {
+		return nil, errors.Wrap(err, "deploying manifests")
+	}
+
+	if k.KubectlDeploy.Prune {
+		if err := k.prune(ctx, out, manifests); err != nil {
+			return nil, errors.Wrap(err, "pruning resources")
+		}
+	} else {
+		k.recordDeployed("kubectl", manifests)
+	}
+	return &Result{Workloads: workloads}, nil
+}
+
+// prune deletes the resources that were deployed before but that were since
+// removed from the manifests. Only the current manifests stay recorded.
+func (k *KubectlDeployer) prune(ctx context.Context, out io.Writer, manifests manifestList) error {
+	path, err := deployedFile("kubectl", k.kubeContext, k.Namespace)
+	if err != nil {
+		return err
+	}
+	deployed, err := readDeployed(path)
+	if err != nil {
+		return err
+	}
+
+	if removed := orphans(deployed, manifests, k.Namespace); len(removed) > 0 {
+		flags := append([]string{"--ignore-not-found"}, k.KubectlDeploy.Flags.Delete...)
+		if err := k.kubectl(ctx, removed.reader(), out, k.commandArgs("delete", flags)...); err != nil {
+			return errors.Wrap(err, "deleting removed resources")
+		}
+	}
+
+	return writeDeployed(path, manifests)
+}
+
+// Render writes the manifests that Deploy would apply.
+func (k *KubectlDeployer) Render(ctx context.Context, out io.Writer, b *build.BuildResult) error {
+	manifests, err := k.hydrateManifests(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(out, manifests.String())
+	return err
+}
+
+// hydrateManifests reads the manifests and transforms them into what's
+// applied: with the built images, the dev sidecars, the debug settings, the
+// pull secret, the checksum annotations and the labels.
+func (k *KubectlDeployer) hydrateManifests(ctx context.Context, b *build.BuildResult) (manifestList, error) {
+	manifests, err := k.readOrGenerateManifests(ctx, b)
 	if err != nil {
 		return nil, errors.Wrap(err, "reading manifests")
 	}
 
-	manifests, err = manifests.replaceImages(b.Builds)
+	if k.DevMode && len(k.KubectlDeploy.DevSidecars) > 0 {
+		manifests, err = manifests.injectSidecars(k.KubectlDeploy.DevSidecars)
+		if err != nil {
+			return nil, errors.Wrap(err, "injecting dev sidecars")
+		}
+	}
+
+	manifests, err = manifests.replaceImages(b.Builds, k.KubectlDeploy.ImagePaths)
 	if err != nil {
 		return nil, errors.Wrap(err, "replacing images in manifests")
 	}
 
-	err = k.kubectl(manifests.reader(), out, "apply", "-f", "-")
-	if err != nil {
-		return nil, errors.Wrap(err, "deploying manifests")
+	if k.Debug {
+		manifests, err = manifests.injectDebug(b.Builds)
+		if err != nil {
+			return nil, errors.Wrap(err, "configuring debugging")
+		}
+	}
+
+	if k.KubectlDeploy.ImagePullSecret != "" {
+		manifests, err = manifests.injectPullSecret(k.KubectlDeploy.ImagePullSecret)
+		if err != nil {
+			return nil, errors.Wrap(err, "injecting image pull secret")
+		}
+	}
+
+	if k.KubectlDeploy.ChecksumAnnotations {
+		manifests, err = manifests.injectChecksums(k.Namespace)
+		if err != nil {
+			return nil, errors.Wrap(err, "injecting config checksums")
+		}
+	}
+
+	if len(k.Labels) > 0 {
+		manifests, err = manifests.injectLabels(k.Labels)
+		if err != nil {
+			return nil, errors.Wrap(err, "injecting labels")
+		}
 	}
 
-	return &Result{}, nil
+	return manifests, nil
 }
 
-// Cleanup deletes what was deployed by calling Deploy.
+// Cleanup deletes what was deployed by calling Deploy, including the resources
+// that were since removed from the manifests. Without any record of what was
+// deployed, the current manifests are deleted.
 func (k *KubectlDeployer) Cleanup(ctx context.Context, out io.Writer) error {
-	if len(k.KubectlDeploy.Manifests) == 0 {
-		return k.kubectl(nil, out, "delete", "deployment", "skaffold")
+	manifests, forget, err := k.deployed("kubectl")
+	if err != nil {
+		return errors.Wrap(err, "reading deployed manifests")
 	}
 
-	manifests, err := k.readManifests()
-	if err != nil {
-		return errors.Wrap(err, "reading manifests")
+	if len(manifests) == 0 {
+		if len(k.KubectlDeploy.Manifests) == 0 {
+			args := append([]string{"delete"}, k.KubectlDeploy.Flags.Delete...)
+			return k.kubectl(ctx, nil, out, append(args, "deployment", "skaffold")...)
+		}
+
+		manifests, err = k.readManifests(ctx)
+		if err != nil {
+			return errors.Wrap(err, "reading manifests")
+		}
 	}
 
-	err = k.kubectl(manifests.reader(), out, "delete", "-f", "-")
+	err = k.kubectl(ctx, manifests.reader(), out, k.commandArgs("delete", k.KubectlDeploy.Flags.Delete)...)
 	if err != nil {
 		return errors.Wrap(err, "deleting manifests")
 	}
 
-	return nil
+	return forget()
+}
+
+// recordDeployed keeps track of the applied manifests. Failing to do so
+// doesn't fail the deploy, it only makes the cleanup less thorough.
+func (k *KubectlDeployer) recordDeployed(deployer string, manifests manifestList) {
+	path, err := deployedFile(deployer, k.kubeContext, k.Namespace)
+	if err == nil {
+		err = recordDeployed(path, k.Namespace, manifests)
+	}
+	if err != nil {
+		logrus.Warnf("recording deployed manifests: %s", err)
+	}
 }
 
-// Not implemented
+// deployed returns the manifests applied since the last cleanup, and a
+// function that forgets them once they're deleted.
+func (k *KubectlDeployer) deployed(deployer string) (manifestList, func() error, error) {
+	path, err := deployedFile(deployer, k.kubeContext, k.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifests, err := readDeployed(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return manifests, func() error { return forgetDeployed(path) }, nil
+}
+
+// createNamespace creates the namespace where the manifests are deployed,
+// unless it already exists.
+func (k *KubectlDeployer) createNamespace(ctx context.Context, out io.Writer) error {
+	if err := k.kubectl(ctx, nil, ioutil.Discard, "get", "namespace", k.Namespace); err == nil {
+		return nil
+	}
+
+	return k.kubectl(ctx, nil, out, "create", "namespace", k.Namespace)
+}
+
+// Dependencies lists the local manifests. Manifests that are downloaded
+// aren't watched.
 func (k *KubectlDeployer) Dependencies() ([]string, error) {
 	return manifestFiles(k.KubectlDeploy.Manifests)
 }
 
 // readOrGenerateManifests reads the manifests to deploy/delete. If no manifest exists, try to
 // generate it with the information we have.
-func (k *KubectlDeployer) readOrGenerateManifests(b *build.BuildResult) (manifestList, error) {
+func (k *KubectlDeployer) readOrGenerateManifests(ctx context.Context, b *build.BuildResult) (manifestList, error) {
 	if len(k.KubectlDeploy.Manifests) > 0 {
-		return k.readManifests()
+		return k.readManifests(ctx)
 	}
 
 	if len(b.Builds) != 1 {
@@ -145,18 +311,56 @@ func (k *KubectlDeployer) readOrGenerateManifests(b *build.BuildResult) (manifes
 	return manifestList{yaml}, nil
 }
 
-func (k *KubectlDeployer) kubectl(in io.Reader, out io.Writer, arg ...string) error {
-	args := append([]string{"--context", k.kubeContext}, arg...)
+func (k *KubectlDeployer) kubectl(ctx context.Context, in io.Reader, out io.Writer, arg ...string) error {
+	args := []string{"--context", k.kubeContext}
+	if k.Namespace != "" {
+		args = append(args, "--namespace", k.Namespace)
+	}
+	if k.KubectlDeploy != nil {
+		args = append(args, k.KubectlDeploy.Flags.Global...)
+	}
+	args = append(args, arg...)
 
 	cmd := exec.Command("kubectl", args...)
 	cmd.Stdin = in
 	cmd.Stdout = out
 	cmd.Stderr = out
 
-	return util.RunCmd(cmd)
+	// out might be capturing a manifest, so heartbeats are only logged.
+	return util.RunCmdSupervised(ctx, cmd, util.DefaultSupervision(nil))
+}
+
+// commandArgs are the arguments of a kubectl command that reads
+// manifests from stdin.
+func (k *KubectlDeployer) commandArgs(command string, flags []string) []string {
+	args := append([]string{command}, flags...)
+	return append(args, "-f", "-")
+}
+
+// manifestURLs lists the manifests that are downloaded.
+func manifestURLs(manifests []string) []string {
+	var urls []string
+	for _, m := range manifests {
+		if util.IsURL(m) {
+			urls = append(urls, m)
+		}
+	}
+	return urls
 }
 
+// manifestFiles expands the paths and the glob patterns of local manifests.
 func manifestFiles(manifests []string) ([]string, error) {
+	var local []string
+	for _, m := range manifests {
+		if !util.IsURL(m) {
+			local = append(local, m)
+		}
+	}
+	if len(local) == 0 {
+		return nil, nil
+	}
+	manifests = local
+
 	list, err := util.ExpandPathsGlob(manifests)
 	if err != nil {
 		return nil, errors.Wrap(err, "expanding kubectl manifest paths")
@@ -178,7 +382,7 @@ func manifestFiles(manifests []string) ([]string, error) {
 }
 
 // readManifests reads the manifests to deploy/delete.
-func (k *KubectlDeployer) readManifests() (manifestList, error) {
+func (k *KubectlDeployer) readManifests(ctx context.Context) (manifestList, error) {
 	files, err := manifestFiles(k.KubectlDeploy.Manifests)
 	if err != nil {
 		return nil, errors.Wrap(err, "expanding user manifest list")
@@ -197,8 +401,20 @@ func (k *KubectlDeployer) readManifests() (manifestList, error) {
 		}
 	}
 
+	for _, url := range manifestURLs(k.KubectlDeploy.Manifests) {
+		buf, err := util.Download(url)
+		if err != nil {
+			return nil, errors.Wrap(err, "downloading manifest")
+		}
+
+		parts := bytes.Split(buf, []byte("\n---"))
+		for _, part := range parts {
+			manifests = append(manifests, part)
+		}
+	}
+
 	for _, m := range k.KubectlDeploy.RemoteManifests {
-		manifest, err := k.readRemoteManifest(m)
+		manifest, err := k.readRemoteManifest(ctx, m)
 		if err != nil {
 			return nil, errors.Wrap(err, "get remote manifests")
 		}
@@ -211,7 +427,7 @@ func (k *KubectlDeployer) readManifests() (manifestList, error) {
 	return manifests, nil
 }
 
-func (k *KubectlDeployer) readRemoteManifest(name string) ([]byte, error) {
+func (k *KubectlDeployer) readRemoteManifest(ctx context.Context, name string) ([]byte, error) {
 	var args []string
 	if parts := strings.Split(name, ":"); len(parts) > 1 {
 		args = append(args, "--namespace", parts[0])
@@ -220,7 +436,7 @@ func (k *KubectlDeployer) readRemoteManifest(name string) ([]byte, error) {
 	args = append(args, "get", name, "-o", "yaml")
 
 	var manifest bytes.Buffer
-	err := k.kubectl(nil, &manifest, args...)
+	err := k.kubectl(ctx, nil, &manifest, args...)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting manifest")
 	}
@@ -256,6 +472,7 @@ func generateManifest(b build.Build) ([]byte, error) {
 }
 
 type replacement struct {
+	name  string
 	tag   string
 	found bool
 }
@@ -277,11 +494,44 @@ func (l *manifestList) reader() io.Reader {
 	return strings.NewReader(l.String())
 }
 
-func (l *manifestList) replaceImages(b []build.Build) (manifestList, error) {
+// workloads lists the Deployments, StatefulSets and DaemonSets of the manifests.
+// Those without a namespace are deployed to the given namespace.
+func (l *manifestList) workloads(namespace string) ([]kubernetes.Workload, error) {
+	var workloads []kubernetes.Workload
+
+	for _, manifest := range *l {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			return nil, errors.Wrap(err, "reading kubernetes YAML")
+		}
+
+		kind := nestedString(m, "kind")
+		if !kubernetes.IsWorkloadKind(kind) {
+			continue
+		}
+
+		ns := nestedString(m, "metadata", "namespace")
+		if ns == "" {
+			ns = namespace
+		}
+		workloads = append(workloads, kubernetes.Workload{
+			Kind:      kind,
+			Namespace: ns,
+			Name:      nestedString(m, "metadata", "name"),
+		})
+	}
+
+	return workloads, nil
+}
+
+// replaceImages replaces the built images in the `image` fields of the manifests
+// and at the given paths of custom resources.
+func (l *manifestList) replaceImages(b []build.Build, paths []v1alpha2.ImagePath) (manifestList, error) {
 	replacements := map[string]*replacement{}
 	for _, build := range b {
-		replacements[build.ImageName] = &replacement{
-			tag: build.Tag,
+		replacements[normalizedName(build.ImageName)] = &replacement{
+			name: build.ImageName,
+			tag:  build.Tag,
 		}
 	}
 
@@ -298,6 +548,11 @@ func (l *manifestList) replaceImages(b []build.Build) (manifestList, error) {
 		}
 
 		recursiveReplaceImage(m, replacements)
+		for _, path := range paths {
+			if path.Kind == "" || path.Kind == m["kind"] {
+				replaceImagesAtPath(m, path.Path, replacements)
+			}
+		}
 
 		updatedManifest, err := yaml.Marshal(m)
 		if err != nil {
@@ -307,9 +562,9 @@ func (l *manifestList) replaceImages(b []build.Build) (manifestList, error) {
 		updatedManifests = append(updatedManifests, updatedManifest)
 	}
 
-	for name, replacement := range replacements {
+	for _, replacement := range replacements {
 		if !replacement.found {
-			logrus.Warnf("image [%s] is not used by the deployment", name)
+			warnings.Warnf(warnings.UnusedArtifact, "image [%s] is not used by the deployment", replacement.name)
 		}
 	}
 
@@ -331,30 +586,53 @@ func recursiveReplaceImage(i interface{}, replacements map[string]*replacement)
 				continue
 			}
 
-			image := v.(string)
-			parsed, err := parseReference(image)
-			if err != nil {
-				logrus.Warnf("Couldn't parse image: %s", v)
-				continue
-			}
-
-			if parsed.fullyQualified {
-				// TODO(1.0.0): Remove this warning.
-				logrus.Infof("Not replacing fully qualified image: %s (see #565)", v)
+			image, ok := v.(string)
+			if !ok {
 				continue
 			}
-
-			if img, present := replacements[parsed.baseName]; present {
-				t[k] = img.tag
-				img.found = true
+			if tag, replaced := replaceImage(image, replacements); replaced {
+				t[k] = tag
 			}
 		}
 	}
 }
 
+// replaceImage returns the new tag of an image if it was built, whatever
+// the tag it has in the manifest. Images pinned by digest are left as is.
+func replaceImage(image string, replacements map[string]*replacement) (string, bool) {
+	parsed, err := parseReference(image)
+	if err != nil {
+		logrus.Warnf("Couldn't parse image: %s", image)
+		return "", false
+	}
+
+	if parsed.digested {
+		logrus.Infof("Not replacing image pinned by digest: %s", image)
+		return "", false
+	}
+
+	img, present := replacements[normalizedName(parsed.baseName)]
+	if !present {
+		return "", false
+	}
+
+	img.found = true
+	return img.tag, true
+}
+
 type imageReference struct {
-	baseName       string
-	fullyQualified bool
+	baseName string
+	digested bool
+}
+
+// normalizedName is the canonical form of an image name, so that `nginx` and
+// `docker.io/library/nginx` refer to the same image.
+func normalizedName(name string) string {
+	n, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return name
+	}
+	return n.Name()
 }
 
 func parseReference(image string) (*imageReference, error) {
@@ -368,16 +646,10 @@ func parseReference(image string) (*imageReference, error) {
 		baseName = n.Name()
 	}
 
-	fullyQualified := false
-	switch n := r.(type) {
-	case reference.Tagged:
-		fullyQualified = n.Tag() != "latest"
-	case reference.Digested:
-		fullyQualified = true
-	}
+	_, digested := r.(reference.Digested)
 
 	return &imageReference{
-		baseName:       baseName,
-		fullyQualified: fullyQualified,
+		baseName: baseName,
+		digested: digested,
 	}, nil
 }
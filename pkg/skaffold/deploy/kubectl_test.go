@@ -20,10 +20,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/GoogleContainerTools/skaffold/testutil"
@@ -120,7 +124,36 @@ func TestKubectlDeploy(t *testing.T) {
 					},
 				},
 			},
-			expected: &Result{},
+			expected: &Result{
+				Workloads: []kubernetes.Workload{{Kind: "Deployment", Name: "leeroy-web"}},
+			},
+		},
+		{
+			description: "deploy with additional flags",
+			cfg: &v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{
+					KubectlDeploy: &v1alpha2.KubectlDeploy{
+						Manifests: []string{"test/deployment.yaml"},
+						Flags: v1alpha2.KubectlFlags{
+							Global: []string{"--kubeconfig", "config"},
+							Apply:  []string{"--validate=false"},
+							Delete: []string{"--grace-period=0"},
+						},
+					},
+				},
+			},
+			command: testutil.NewFakeCmd("kubectl --context kubecontext --kubeconfig config apply --validate=false -f -", nil),
+			b: &build.BuildResult{
+				Builds: []build.Build{
+					{
+						ImageName: "leeroy-web",
+						Tag:       "leeroy-web:123",
+					},
+				},
+			},
+			expected: &Result{
+				Workloads: []kubernetes.Workload{{Kind: "Deployment", Name: "leeroy-web"}},
+			},
 		},
 		{
 			description: "deploy command error",
@@ -165,6 +198,66 @@ func TestKubectlDeploy(t *testing.T) {
 	}
 }
 
+func TestKubectlReadManifests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/service.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("kind: Service\n---\nkind: Ingress"))
+	}))
+	defer server.Close()
+
+	defer func(fs afero.Fs) { util.Fs = fs }(util.Fs)
+	util.Fs = afero.NewMemMapFs()
+	afero.WriteFile(util.Fs, "k8s/web/deployment.yaml", []byte("kind: Deployment"), 0644)
+	afero.WriteFile(util.Fs, "k8s/db/statefulset.yaml", []byte("kind: StatefulSet"), 0644)
+
+	var tests = []struct {
+		description  string
+		manifests    []string
+		expected     string
+		dependencies []string
+		shouldErr    bool
+	}{
+		{
+			description:  "glob and url",
+			manifests:    []string{"k8s/**.yaml", server.URL + "/service.yaml"},
+			expected:     "kind: StatefulSet\n---\nkind: Deployment\n---\nkind: Service\n---\nkind: Ingress",
+			dependencies: []string{"k8s/db/statefulset.yaml", "k8s/web/deployment.yaml"},
+		},
+		{
+			description:  "directory",
+			manifests:    []string{"k8s/web"},
+			expected:     "kind: Deployment",
+			dependencies: []string{"k8s/web/deployment.yaml"},
+		},
+		{
+			description: "missing url",
+			manifests:   []string{server.URL + "/missing.yaml"},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			k := NewKubectlDeployer(&v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{
+					KubectlDeploy: &v1alpha2.KubectlDeploy{
+						Manifests: test.manifests,
+					},
+				},
+			}, testKubeContext)
+
+			manifests, err := k.readManifests(context.Background())
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, manifests.String())
+
+			dependencies, err := k.Dependencies()
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.dependencies, dependencies)
+		})
+	}
+}
+
 func TestKubectlCleanup(t *testing.T) {
 	var tests = []struct {
 		description string
@@ -183,6 +276,22 @@ func TestKubectlCleanup(t *testing.T) {
 			},
 			command: testutil.NewFakeCmd("kubectl --context kubecontext delete -f -", nil),
 		},
+		{
+			description: "cleanup with additional flags",
+			cfg: &v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{
+					KubectlDeploy: &v1alpha2.KubectlDeploy{
+						Manifests: []string{"test/deployment.yaml"},
+						Flags: v1alpha2.KubectlFlags{
+							Global: []string{"--kubeconfig", "config"},
+							Apply:  []string{"--validate=false"},
+							Delete: []string{"--grace-period=0"},
+						},
+					},
+				},
+			},
+			command: testutil.NewFakeCmd("kubectl --context kubecontext --kubeconfig config delete --grace-period=0 -f -", nil),
+		},
 		{
 			description: "cleanup error",
 			cfg: &v1alpha2.DeployConfig{
@@ -231,11 +340,15 @@ spec:
   - image: gcr.io/k8s-skaffold/example:latest
     name: latest
   - image: gcr.io/k8s-skaffold/example:v1
-    name: fully-qualified
+    name: tagged
   - image: skaffold/other
     name: other
   - image: gcr.io/k8s-skaffold/example@sha256:81daf011d63b68cfa514ddab7741a1adddd59d3264118dfb0fd9266328bb8883
     name: digest
+  - image: docker.io/skaffold/other
+    name: normalized
+  - image: docker.io/library/busybox:latest
+    name: library
 `)}
 
 	builds := []build.Build{{
@@ -244,6 +357,9 @@ spec:
 	}, {
 		ImageName: "skaffold/other",
 		Tag:       "skaffold/other:OTHER_TAG",
+	}, {
+		ImageName: "busybox",
+		Tag:       "busybox:BUSYBOX_TAG",
 	}}
 
 	expected := manifestList{[]byte(`
@@ -257,15 +373,19 @@ spec:
     name: not-tagged
   - image: gcr.io/k8s-skaffold/example:TAG
     name: latest
-  - image: gcr.io/k8s-skaffold/example:v1
-    name: fully-qualified
+  - image: gcr.io/k8s-skaffold/example:TAG
+    name: tagged
   - image: skaffold/other:OTHER_TAG
     name: other
   - image: gcr.io/k8s-skaffold/example@sha256:81daf011d63b68cfa514ddab7741a1adddd59d3264118dfb0fd9266328bb8883
     name: digest
+  - image: skaffold/other:OTHER_TAG
+    name: normalized
+  - image: busybox:BUSYBOX_TAG
+    name: library
 `)}
 
-	resultManifest, err := manifests.replaceImages(builds)
+	resultManifest, err := manifests.replaceImages(builds, nil)
 
 	testutil.CheckErrorAndDeepEqual(t, false, err, expected.String(), resultManifest.String())
 }
@@ -274,7 +394,7 @@ func TestReplaceEmptyManifest(t *testing.T) {
 	manifests := manifestList{[]byte(""), []byte("  ")}
 	expected := manifestList{}
 
-	resultManifest, err := manifests.replaceImages(nil)
+	resultManifest, err := manifests.replaceImages(nil, nil)
 
 	testutil.CheckErrorAndDeepEqual(t, false, err, expected.String(), resultManifest.String())
 }
@@ -282,11 +402,120 @@ func TestReplaceEmptyManifest(t *testing.T) {
 func TestReplaceInvalidManifest(t *testing.T) {
 	manifests := manifestList{[]byte("INVALID")}
 
-	_, err := manifests.replaceImages(nil)
+	_, err := manifests.replaceImages(nil, nil)
 
 	testutil.CheckError(t, true, err)
 }
 
+func TestKubectlDeployNamespace(t *testing.T) {
+	var tests = []struct {
+		description     string
+		createNamespace bool
+		existing        bool
+		expected        []string
+	}{
+		{
+			description: "namespace",
+			expected: []string{
+				"kubectl --context kubecontext --namespace staging apply -f -",
+			},
+		},
+		{
+			description:     "create missing namespace",
+			createNamespace: true,
+			expected: []string{
+				"kubectl --context kubecontext --namespace staging get namespace staging",
+				"kubectl --context kubecontext --namespace staging create namespace staging",
+				"kubectl --context kubecontext --namespace staging apply -f -",
+			},
+		},
+		{
+			description:     "existing namespace",
+			createNamespace: true,
+			existing:        true,
+			expected: []string{
+				"kubectl --context kubecontext --namespace staging get namespace staging",
+				"kubectl --context kubecontext --namespace staging apply -f -",
+			},
+		},
+	}
+
+	defer func(fs afero.Fs) { util.Fs = fs }(util.Fs)
+	util.Fs = afero.NewMemMapFs()
+	afero.WriteFile(util.Fs, "test/deployment.yaml", []byte(deploymentYAML), 0644)
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			kubectl := &recordingKubectl{}
+			if !test.existing {
+				kubectl.failing = "get namespace"
+			}
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = kubectl
+
+			k := NewKubectlDeployer(&v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{
+					KubectlDeploy: &v1alpha2.KubectlDeploy{
+						Manifests:       []string{"test/deployment.yaml"},
+						Namespace:       "staging",
+						CreateNamespace: test.createNamespace,
+					},
+				},
+			}, testKubeContext)
+			res, err := k.Deploy(context.Background(), &bytes.Buffer{}, &build.BuildResult{
+				Builds: []build.Build{{ImageName: "leeroy-web", Tag: "leeroy-web:123"}},
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, kubectl.commands)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, []kubernetes.Workload{{Kind: "Deployment", Namespace: "staging", Name: "leeroy-web"}}, res.Workloads)
+		})
+	}
+}
+
+func TestKubectlRender(t *testing.T) {
+	defer func(fs afero.Fs) { util.Fs = fs }(util.Fs)
+	util.Fs = afero.NewMemMapFs()
+	afero.WriteFile(util.Fs, "test/deployment.yaml", []byte(deploymentYAML), 0644)
+
+	k := NewKubectlDeployer(&v1alpha2.DeployConfig{
+		DeployType: v1alpha2.DeployType{
+			KubectlDeploy: &v1alpha2.KubectlDeploy{
+				Manifests: []string{"test/deployment.yaml"},
+			},
+		},
+	}, testKubeContext)
+
+	out := &bytes.Buffer{}
+	err := k.Render(context.Background(), out, &build.BuildResult{
+		Builds: []build.Build{{ImageName: "leeroy-web", Tag: "leeroy-web:123"}},
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, true, strings.Contains(out.String(), "image: leeroy-web:123"))
+}
+
+func TestWorkloads(t *testing.T) {
+	manifests := manifestList{[]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web`), []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: web`), []byte(`apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+  namespace: storage`)}
+
+	expected := []kubernetes.Workload{
+		{Kind: "Deployment", Namespace: "ns", Name: "web"},
+		{Kind: "StatefulSet", Namespace: "storage", Name: "db"},
+	}
+
+	workloads, err := manifests.workloads("ns")
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, workloads)
+}
+
 func TestGenerateManifest(t *testing.T) {
 	dockerfile, cleanup := testutil.TempFile(t, "Dockerfile", []byte("FROM scratch\nEXPOSE 80"))
 	defer cleanup()
@@ -313,10 +542,10 @@ func TestGenerateManifest(t *testing.T) {
 			},
 		},
 	}
-	manifests, err := deployer.readOrGenerateManifests(bRes)
+	manifests, err := deployer.readOrGenerateManifests(context.Background(), bRes)
 	testutil.CheckError(t, false, err)
 
-	manifests, err = manifests.replaceImages(bRes.Builds)
+	manifests, err = manifests.replaceImages(bRes.Builds, nil)
 
 	testutil.CheckErrorAndDeepEqual(t, false, err, `apiVersion: extensions/v1beta1
 kind: Deployment
@@ -344,40 +573,40 @@ spec:
 
 func TestSplitTag(t *testing.T) {
 	var tests = []struct {
-		description            string
-		image                  string
-		expectedName           string
-		expectedFullyQualified bool
+		description      string
+		image            string
+		expectedName     string
+		expectedDigested bool
 	}{
 		{
-			description:            "port and tag",
-			image:                  "host:1234/user/container:tag",
-			expectedName:           "host:1234/user/container",
-			expectedFullyQualified: true,
+			description:      "port and tag",
+			image:            "host:1234/user/container:tag",
+			expectedName:     "host:1234/user/container",
+			expectedDigested: false,
 		},
 		{
-			description:            "port",
-			image:                  "host:1234/user/container",
-			expectedName:           "host:1234/user/container",
-			expectedFullyQualified: false,
+			description:      "port",
+			image:            "host:1234/user/container",
+			expectedName:     "host:1234/user/container",
+			expectedDigested: false,
 		},
 		{
-			description:            "tag",
-			image:                  "host/user/container:tag",
-			expectedName:           "host/user/container",
-			expectedFullyQualified: true,
+			description:      "tag",
+			image:            "host/user/container:tag",
+			expectedName:     "host/user/container",
+			expectedDigested: false,
 		},
 		{
-			description:            "latest",
-			image:                  "host/user/container:latest",
-			expectedName:           "host/user/container",
-			expectedFullyQualified: false,
+			description:      "latest",
+			image:            "host/user/container:latest",
+			expectedName:     "host/user/container",
+			expectedDigested: false,
 		},
 		{
-			description:            "digest",
-			image:                  "gcr.io/k8s-skaffold/example@sha256:81daf011d63b68cfa514ddab7741a1adddd59d3264118dfb0fd9266328bb8883",
-			expectedName:           "gcr.io/k8s-skaffold/example",
-			expectedFullyQualified: true,
+			description:      "digest",
+			image:            "gcr.io/k8s-skaffold/example@sha256:81daf011d63b68cfa514ddab7741a1adddd59d3264118dfb0fd9266328bb8883",
+			expectedName:     "gcr.io/k8s-skaffold/example",
+			expectedDigested: true,
 		},
 	}
 
@@ -386,7 +615,47 @@ func TestSplitTag(t *testing.T) {
 			parsed, err := parseReference(test.image)
 
 			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectedName, parsed.baseName)
-			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectedFullyQualified, parsed.fullyQualified)
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectedDigested, parsed.digested)
 		})
 	}
 }
+
+func TestKubectlPrune(t *testing.T) {
+	defer func(fs afero.Fs) { util.Fs = fs }(util.Fs)
+	util.Fs = afero.NewMemMapFs()
+	afero.WriteFile(util.Fs, "test/deployment.yaml", []byte(deploymentYAML+"\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: leeroy-web"), 0644)
+
+	kubectl := &recordingKubectl{}
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = kubectl
+
+	k := NewKubectlDeployer(&v1alpha2.DeployConfig{
+		DeployType: v1alpha2.DeployType{
+			KubectlDeploy: &v1alpha2.KubectlDeploy{
+				Manifests: []string{"test/deployment.yaml"},
+				Prune:     true,
+			},
+		},
+	}, testKubeContext)
+	b := &build.BuildResult{
+		Builds: []build.Build{{ImageName: "leeroy-web", Tag: "leeroy-web:123"}},
+	}
+
+	// The service is deployed, then removed from the manifests.
+	_, err := k.Deploy(context.Background(), &bytes.Buffer{}, b)
+	testutil.CheckError(t, false, err)
+
+	afero.WriteFile(util.Fs, "test/deployment.yaml", []byte(deploymentYAML), 0644)
+	_, err = k.Deploy(context.Background(), &bytes.Buffer{}, b)
+	testutil.CheckError(t, false, err)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{
+		"kubectl --context kubecontext apply -f -",
+		"kubectl --context kubecontext apply -f -",
+		"kubectl --context kubecontext delete --ignore-not-found -f -",
+	}, kubectl.commands)
+
+	path, _ := deployedFile("kubectl", testKubeContext, "")
+	deployed, err := readDeployed(path)
+	testutil.CheckErrorAndDeepEqual(t, false, err, 1, len(deployed))
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// RunIDLabel is set to the id of the skaffold run that deployed a resource.
+	RunIDLabel = "skaffold.dev/run-id"
+
+	// ManagedByLabel marks the resources deployed by skaffold.
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+)
+
+// SkaffoldLabels are the labels that identify the resources deployed by a run.
+func SkaffoldLabels(runID string) map[string]string {
+	return map[string]string{
+		RunIDLabel:     runID,
+		ManagedByLabel: "skaffold",
+	}
+}
+
+// injectLabels adds labels to every resource and to the pod templates of the
+// workloads. The labels already set by the manifests are kept.
+func (l *manifestList) injectLabels(labels map[string]string) (manifestList, error) {
+	var updatedManifests manifestList
+
+	for _, manifest := range *l {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			return nil, errors.Wrap(err, "reading kubernetes YAML")
+		}
+		if len(m) == 0 {
+			continue
+		}
+
+		addLabels(m, labels)
+		if template := podTemplate(m); template != nil {
+			addLabels(template, labels)
+		}
+
+		updatedManifest, err := yaml.Marshal(m)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling yaml")
+		}
+		updatedManifests = append(updatedManifests, updatedManifest)
+	}
+
+	return updatedManifests, nil
+}
+
+func addLabels(object map[interface{}]interface{}, labels map[string]string) {
+	metadata, ok := object["metadata"].(map[interface{}]interface{})
+	if !ok {
+		metadata = map[interface{}]interface{}{}
+		object["metadata"] = metadata
+	}
+
+	existing, ok := metadata["labels"].(map[interface{}]interface{})
+	if !ok {
+		existing = map[interface{}]interface{}{}
+		metadata["labels"] = existing
+	}
+
+	for _, key := range sortedKeys(labels) {
+		if value, found := existing[key]; found {
+			logrus.Debugf("Keeping label %s=%v of %s %s", key, value, object["kind"], nestedString(object, "metadata", "name"))
+			continue
+		}
+		existing[key] = labels[key]
+	}
+}
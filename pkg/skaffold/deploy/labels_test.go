@@ -0,0 +1,92 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestInjectLabels(t *testing.T) {
+	var tests = []struct {
+		description string
+		manifest    string
+		expected    string
+	}{
+		{
+			description: "service",
+			manifest: `apiVersion: v1
+kind: Service
+metadata:
+  name: web`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app.kubernetes.io/managed-by: skaffold
+    skaffold.dev/run-id: "1234"
+  name: web
+`,
+		},
+		{
+			description: "deployment and its pod template",
+			manifest: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app.kubernetes.io/managed-by: helm
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - image: web`,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app.kubernetes.io/managed-by: helm
+    skaffold.dev/run-id: "1234"
+  name: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+        app.kubernetes.io/managed-by: skaffold
+        skaffold.dev/run-id: "1234"
+    spec:
+      containers:
+      - image: web
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			manifests := manifestList{[]byte(test.manifest)}
+
+			result, err := manifests.injectLabels(SkaffoldLabels("1234"))
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, result.String()+"\n")
+		})
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/pkg/errors"
+)
+
+// NamedDeployer is a Deployer with a name used to report its results.
+type NamedDeployer struct {
+	Name string
+	Deployer
+}
+
+// DeployerMux runs several deployers, one after the other.
+type DeployerMux []NamedDeployer
+
+// Deploy runs the deployers in order and stops at the first failure.
+// The workloads deployed by each deployer are returned together.
+func (m DeployerMux) Deploy(ctx context.Context, out io.Writer, b *build.BuildResult) (*Result, error) {
+	result := &Result{}
+	for _, d := range m {
+		fmt.Fprintf(out, "Deploying with %s...\n", d.Name)
+		res, err := d.Deploy(ctx, out, b)
+		if err != nil {
+			return nil, errors.Wrapf(err, "deploying with %s", d.Name)
+		}
+		if res != nil {
+			result.Workloads = append(result.Workloads, res.Workloads...)
+		}
+	}
+
+	return result, nil
+}
+
+// Render writes the manifests of all the deployers, one after the other.
+func (m DeployerMux) Render(ctx context.Context, out io.Writer, b *build.BuildResult) error {
+	for i, d := range m {
+		if i > 0 {
+			fmt.Fprintln(out, "---")
+		}
+		if err := d.Render(ctx, out, b); err != nil {
+			return errors.Wrapf(err, "rendering with %s", d.Name)
+		}
+	}
+
+	return nil
+}
+
+// Dependencies returns the dependencies of all the deployers.
+func (m DeployerMux) Dependencies() ([]string, error) {
+	var deps []string
+	for _, d := range m {
+		result, err := d.Dependencies()
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting dependencies of %s", d.Name)
+		}
+		deps = append(deps, result...)
+	}
+
+	return deps, nil
+}
+
+// Cleanup runs the cleanup of all the deployers in reverse order, even if some of them fail.
+func (m DeployerMux) Cleanup(ctx context.Context, out io.Writer) error {
+	var errs []string
+	for i := len(m) - 1; i >= 0; i-- {
+		if err := m[i].Cleanup(ctx, out); err != nil {
+			errs = append(errs, fmt.Sprintf("cleaning up %s: %s", m[i].Name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+type fakeDeployer struct {
+	name  string
+	err   error
+	calls *[]string
+}
+
+func (f *fakeDeployer) Deploy(context.Context, io.Writer, *build.BuildResult) (*Result, error) {
+	*f.calls = append(*f.calls, "deploy "+f.name)
+	return &Result{}, f.err
+}
+
+func (f *fakeDeployer) Dependencies() ([]string, error) {
+	return []string{f.name + ".yaml"}, nil
+}
+
+func (f *fakeDeployer) Cleanup(context.Context, io.Writer) error {
+	*f.calls = append(*f.calls, "cleanup "+f.name)
+	return f.err
+}
+
+func (f *fakeDeployer) Render(_ context.Context, out io.Writer, _ *build.BuildResult) error {
+	*f.calls = append(*f.calls, "render "+f.name)
+	fmt.Fprintf(out, "name: %s\n", f.name)
+	return f.err
+}
+
+func TestDeployerMux(t *testing.T) {
+	var tests = []struct {
+		description   string
+		kubectlErr    error
+		expectedCalls []string
+		shouldErr     bool
+	}{
+		{
+			description:   "success",
+			expectedCalls: []string{"deploy kubectl", "deploy helm", "cleanup helm", "cleanup kubectl"},
+		},
+		{
+			description:   "first deployer fails",
+			kubectlErr:    fmt.Errorf("kubectl failed"),
+			expectedCalls: []string{"deploy kubectl", "cleanup helm", "cleanup kubectl"},
+			shouldErr:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var calls []string
+			mux := DeployerMux{
+				{Name: "kubectl", Deployer: &fakeDeployer{name: "kubectl", err: test.kubectlErr, calls: &calls}},
+				{Name: "helm", Deployer: &fakeDeployer{name: "helm", calls: &calls}},
+			}
+
+			_, deployErr := mux.Deploy(context.Background(), &bytes.Buffer{}, &build.BuildResult{})
+			cleanupErr := mux.Cleanup(context.Background(), &bytes.Buffer{})
+			deps, err := mux.Dependencies()
+
+			testutil.CheckError(t, test.shouldErr, deployErr)
+			testutil.CheckError(t, test.shouldErr, cleanupErr)
+			testutil.CheckErrorAndDeepEqual(t, false, err, []string{"kubectl.yaml", "helm.yaml"}, deps)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedCalls, calls)
+		})
+	}
+}
+
+func TestDeployerMuxRender(t *testing.T) {
+	var calls []string
+	mux := DeployerMux{
+		{Name: "kubectl", Deployer: &fakeDeployer{name: "kubectl", calls: &calls}},
+		{Name: "helm", Deployer: &fakeDeployer{name: "helm", calls: &calls}},
+	}
+
+	out := &bytes.Buffer{}
+	err := mux.Render(context.Background(), out, &build.BuildResult{})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "name: kubectl\n---\nname: helm\n", out.String())
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"render kubectl", "render helm"}, calls)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	// applyRetries is how many times an apply is retried when the kind of a
+	// resource isn't known yet, typically because its CRD was just created.
+	applyRetries    = 5
+	applyRetryDelay = 2 * time.Second
+
+	// crdEstablishedTimeout is how long CRDs have to be established before
+	// the resources that use them are applied.
+	crdEstablishedTimeout = "60s"
+)
+
+// apply runs `kubectl apply` on the manifests. CRDs, along with namespaces,
+// are applied first and waited for, so that custom resources can be created.
+// Webhook configurations are applied last, so that they don't intercept the
+// creation of the workloads that serve them.
+func (k *KubectlDeployer) apply(ctx context.Context, out io.Writer, manifests manifestList) error {
+	first, rest, last, err := applyPhases(manifests)
+	if err != nil {
+		return err
+	}
+	if len(first) == 0 && len(last) == 0 {
+		return k.applyWithRetries(ctx, out, manifests)
+	}
+
+	if len(first) > 0 {
+		if err := k.applyWithRetries(ctx, out, first); err != nil {
+			return errors.Wrap(err, "applying CRDs and namespaces")
+		}
+		if crds := crdNames(first); len(crds) > 0 {
+			args := append([]string{"wait", "--for", "condition=established", "--timeout", crdEstablishedTimeout}, crds...)
+			if err := k.kubectl(ctx, nil, out, args...); err != nil {
+				return errors.Wrap(err, "waiting for CRDs to be established")
+			}
+		}
+	}
+	if len(rest) > 0 {
+		if err := k.applyWithRetries(ctx, out, rest); err != nil {
+			return err
+		}
+	}
+	if len(last) > 0 {
+		if err := k.applyWithRetries(ctx, out, last); err != nil {
+			return errors.Wrap(err, "applying webhook configurations")
+		}
+	}
+
+	return nil
+}
+
+// applyWithRetries retries `kubectl apply` while it fails because a kind
+// of resource isn't known yet.
+func (k *KubectlDeployer) applyWithRetries(ctx context.Context, out io.Writer, manifests manifestList) error {
+	for attempt := 1; ; attempt++ {
+		var output bytes.Buffer
+		err := k.kubectl(ctx, manifests.reader(), io.MultiWriter(out, &output), k.commandArgs("apply", k.KubectlDeploy.Flags.Apply)...)
+		if err == nil || attempt >= applyRetries || !isMissingKind(output.String()) {
+			return err
+		}
+
+		logrus.Infof("Some kinds of resources aren't known yet, retrying in %s", applyRetryDelay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(applyRetryDelay):
+		}
+	}
+}
+
+// isMissingKind tells if kubectl failed because a kind of resource is unknown.
+func isMissingKind(output string) bool {
+	return strings.Contains(output, "no matches for kind") || strings.Contains(output, "ensure CRDs are installed first")
+}
+
+// applyPhases splits the manifests into the CRDs and namespaces, the other
+// resources and the webhook configurations.
+func applyPhases(manifests manifestList) (manifestList, manifestList, manifestList, error) {
+	var first, rest, last manifestList
+
+	for _, manifest := range manifests {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			return nil, nil, nil, errors.Wrap(err, "reading kubernetes YAML")
+		}
+
+		switch nestedString(m, "kind") {
+		case "CustomResourceDefinition", "Namespace":
+			first = append(first, manifest)
+		case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+			last = append(last, manifest)
+		default:
+			rest = append(rest, manifest)
+		}
+	}
+
+	return first, rest, last, nil
+}
+
+// crdNames lists the CRDs of the manifests, as arguments to kubectl.
+func crdNames(manifests manifestList) []string {
+	var names []string
+	for _, manifest := range manifests {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			continue
+		}
+		if nestedString(m, "kind") == "CustomResourceDefinition" {
+			names = append(names, "crd/"+nestedString(m, "metadata", "name"))
+		}
+	}
+	return names
+}
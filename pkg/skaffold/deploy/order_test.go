@@ -0,0 +1,115 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/spf13/afero"
+)
+
+const crdYAML = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: crontabs.stable.example.com`
+
+const webhookYAML = `apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: validator`
+
+func TestApplyPhases(t *testing.T) {
+	manifests := manifestList{
+		[]byte(webhookYAML),
+		[]byte(deploymentYAML),
+		[]byte(crdYAML),
+		[]byte("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: ns"),
+	}
+
+	first, rest, last, err := applyPhases(manifests)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, manifestList{manifests[2], manifests[3]}, first)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, manifestList{manifests[1]}, rest)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, manifestList{manifests[0]}, last)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"crd/crontabs.stable.example.com"}, crdNames(first))
+}
+
+func TestIsMissingKind(t *testing.T) {
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, isMissingKind(`error: unable to recognize "STDIN": no matches for kind "CronTab" in version "stable.example.com/v1"`))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, isMissingKind(`resource mapping not found for name: "tab": ensure CRDs are installed first`))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, isMissingKind(`error: the server doesn't have a resource type "pods"`))
+}
+
+func TestKubectlOrderedApply(t *testing.T) {
+	defer func(fs afero.Fs) { util.Fs = fs }(util.Fs)
+	util.Fs = afero.NewMemMapFs()
+	afero.WriteFile(util.Fs, "test/deployment.yaml", []byte(webhookYAML+"\n---\n"+deploymentYAML+"\n---\n"+crdYAML), 0644)
+
+	var tests = []struct {
+		description string
+		failing     string
+		expected    []string
+		shouldErr   bool
+	}{
+		{
+			description: "crds, then resources, then webhooks",
+			expected: []string{
+				"kubectl --context kubecontext apply -f -",
+				"kubectl --context kubecontext wait --for condition=established --timeout 60s crd/crontabs.stable.example.com",
+				"kubectl --context kubecontext apply -f -",
+				"kubectl --context kubecontext apply -f -",
+			},
+		},
+		{
+			description: "crds not established",
+			failing:     "wait",
+			expected: []string{
+				"kubectl --context kubecontext apply -f -",
+				"kubectl --context kubecontext wait --for condition=established --timeout 60s crd/crontabs.stable.example.com",
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			kubectl := &recordingKubectl{failing: test.failing}
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = kubectl
+
+			k := NewKubectlDeployer(&v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{
+					KubectlDeploy: &v1alpha2.KubectlDeploy{
+						Manifests: []string{"test/deployment.yaml"},
+					},
+				},
+			}, testKubeContext)
+
+			_, err := k.Deploy(context.Background(), &bytes.Buffer{}, &build.BuildResult{
+				Builds: []build.Build{{ImageName: "leeroy-web", Tag: "leeroy-web:123"}},
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, kubectl.commands)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// injectPullSecret references the given secret in the imagePullSecrets of
+// every pod and pod template, so that private images can be pulled in
+// namespaces where the default service account doesn't know about it.
+func (l *manifestList) injectPullSecret(secret string) (manifestList, error) {
+	var updatedManifests manifestList
+
+	for _, manifest := range *l {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			return nil, errors.Wrap(err, "reading kubernetes YAML")
+		}
+		if len(m) == 0 {
+			continue
+		}
+
+		template := podTemplate(m)
+		if m["kind"] == "Pod" {
+			template = m
+		}
+		if template != nil && addPullSecret(template, secret) {
+			logrus.Debugf("Adding image pull secret %s to %s %s", secret, m["kind"], nestedString(m, "metadata", "name"))
+		}
+
+		updatedManifest, err := yaml.Marshal(m)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling yaml")
+		}
+		updatedManifests = append(updatedManifests, updatedManifest)
+	}
+
+	return updatedManifests, nil
+}
+
+// addPullSecret appends a secret to the imagePullSecrets of a pod spec,
+// unless it's already referenced.
+func addPullSecret(template map[interface{}]interface{}, secret string) bool {
+	spec, ok := template["spec"].(map[interface{}]interface{})
+	if !ok {
+		return false
+	}
+
+	secrets, _ := spec["imagePullSecrets"].([]interface{})
+	for _, s := range secrets {
+		if nestedString(s, "name") == secret {
+			return false
+		}
+	}
+
+	spec["imagePullSecrets"] = append(secrets, map[interface{}]interface{}{"name": secret})
+	return true
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const podWithPullSecret = `apiVersion: v1
+kind: Pod
+metadata:
+  name: worker
+spec:
+  imagePullSecrets:
+  - name: other
+  - name: registry
+  containers:
+  - name: worker
+    image: gcr.io/k8s-skaffold/worker`
+
+const service = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80`
+
+func TestInjectPullSecret(t *testing.T) {
+	manifests := manifestList{[]byte(sidecarDeployment), []byte(sidecarPod), []byte(podWithPullSecret), []byte(service)}
+
+	injected, err := manifests.injectPullSecret("registry")
+
+	expected := [][]string{{"registry"}, {"registry"}, {"other", "registry"}, nil}
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, pullSecretNames(t, injected))
+}
+
+func pullSecretNames(t *testing.T, manifests manifestList) [][]string {
+	var names [][]string
+	for _, manifest := range manifests {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			t.Fatal(err)
+		}
+
+		template := podTemplate(m)
+		if m["kind"] == "Pod" {
+			template = m
+		}
+
+		var secretNames []string
+		spec, _ := template["spec"].(map[interface{}]interface{})
+		secrets, _ := spec["imagePullSecrets"].([]interface{})
+		for _, s := range secrets {
+			secretNames = append(secretNames, nestedString(s, "name"))
+		}
+		names = append(names, secretNames)
+	}
+	return names
+}
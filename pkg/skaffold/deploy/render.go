@@ -0,0 +1,64 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// WriteManifests writes rendered manifests to a directory, one file per
+// resource, named after its namespace, kind and name.
+func WriteManifests(dir string, rendered []byte) error {
+	if err := util.Fs.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "creating %s", dir)
+	}
+
+	for _, manifest := range bytes.Split(rendered, []byte("\n---")) {
+		manifest = bytes.TrimPrefix(bytes.TrimSpace(manifest), []byte("---"))
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			return errors.Wrap(err, "reading kubernetes YAML")
+		}
+		if len(m) == 0 {
+			continue
+		}
+
+		path := filepath.Join(dir, manifestFileName(m))
+		if err := afero.WriteFile(util.Fs, path, append(bytes.TrimSpace(manifest), '\n'), 0644); err != nil {
+			return errors.Wrapf(err, "writing %s", path)
+		}
+	}
+
+	return nil
+}
+
+func manifestFileName(m map[interface{}]interface{}) string {
+	parts := []string{nestedString(m, "kind"), nestedString(m, "metadata", "name")}
+	if namespace := nestedString(m, "metadata", "namespace"); namespace != "" {
+		parts = append([]string{namespace}, parts...)
+	}
+
+	return fmt.Sprintf("%s.yaml", strings.ToLower(strings.Join(parts, "-")))
+}
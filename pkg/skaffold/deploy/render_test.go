@@ -0,0 +1,53 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/spf13/afero"
+)
+
+func TestWriteManifests(t *testing.T) {
+	defer func(fs afero.Fs) { util.Fs = fs }(util.Fs)
+	util.Fs = afero.NewMemMapFs()
+
+	rendered := `---
+# Source: chart/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: staging
+`
+
+	err := WriteManifests("out", []byte(rendered))
+	testutil.CheckError(t, false, err)
+
+	service, err := afero.ReadFile(util.Fs, "out/service-web.yaml")
+	testutil.CheckErrorAndDeepEqual(t, false, err, "# Source: chart/templates/service.yaml\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\n", string(service))
+
+	deployment, err := afero.ReadFile(util.Fs, "out/staging-deployment-web.yaml")
+	testutil.CheckErrorAndDeepEqual(t, false, err, "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: staging\n", string(deployment))
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// SessionState maps each session to the hash of what it last deployed.
+type SessionState map[string]string
+
+// resumingDeployer skips the first deployment of a session when the same
+// images and manifests were already deployed by a previous, interrupted session.
+type resumingDeployer struct {
+	Deployer
+
+	config    *v1alpha2.DeployConfig
+	stateFile string
+	session   string
+	state     SessionState
+	resumed   bool
+}
+
+// WithSessionState wraps a Deployer so that what it deploys is recorded to the
+// given file, under the session's name. Cleaning up forgets the session.
+func WithSessionState(deployer Deployer, cfg *v1alpha2.DeployConfig, stateFile, session string) (Deployer, error) {
+	path, err := homedir.Expand(stateFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "expanding %s", stateFile)
+	}
+
+	state, err := readSessionState(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resumingDeployer{
+		Deployer:  deployer,
+		config:    cfg,
+		stateFile: path,
+		session:   session,
+		state:     state,
+	}, nil
+}
+
+func (d *resumingDeployer) Deploy(ctx context.Context, out io.Writer, b *build.BuildResult) (*Result, error) {
+	hash, err := d.hashDeployment(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "hashing deployment")
+	}
+
+	resuming := !d.resumed
+	d.resumed = true
+	if resuming && d.state[d.session] == hash {
+		fmt.Fprintln(out, "Nothing changed since the last session, skipping deploy")
+		return &Result{}, nil
+	}
+
+	res, err := d.Deployer.Deploy(ctx, out, b)
+	if err != nil {
+		delete(d.state, d.session)
+		d.save()
+		return nil, err
+	}
+
+	d.state[d.session] = hash
+	d.save()
+	return res, nil
+}
+
+func (d *resumingDeployer) Cleanup(ctx context.Context, out io.Writer) error {
+	delete(d.state, d.session)
+	d.save()
+
+	return d.Deployer.Cleanup(ctx, out)
+}
+
+func (d *resumingDeployer) save() {
+	if err := writeSessionState(d.stateFile, d.state); err != nil {
+		logrus.Warnf("Unable to save session state: %s", err)
+	}
+}
+
+// hashDeployment computes a hash of the deploy configuration, the deployed
+// tags and the content of the deployer's dependencies.
+func (d *resumingDeployer) hashDeployment(b *build.BuildResult) (string, error) {
+	h := sha256.New()
+
+	config, err := json.Marshal(d.config)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling deploy config")
+	}
+	h.Write(config)
+
+	var tags []string
+	for _, build := range b.Builds {
+		tags = append(tags, build.ImageName+"="+build.Tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		h.Write([]byte(tag + "\n"))
+	}
+
+	deps, err := d.Dependencies()
+	if err != nil {
+		return "", errors.Wrap(err, "getting dependencies")
+	}
+	sort.Strings(deps)
+
+	for _, dep := range deps {
+		contents, err := ioutil.ReadFile(dep)
+		if err != nil {
+			return "", errors.Wrapf(err, "reading %s", dep)
+		}
+		h.Write([]byte(dep))
+		h.Write(contents)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func readSessionState(path string) (SessionState, error) {
+	state := SessionState{}
+
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading session state %s", path)
+	}
+
+	if err := yaml.Unmarshal(contents, &state); err != nil {
+		logrus.Warnf("Ignoring invalid session state %s: %s", path, err)
+		return SessionState{}, nil
+	}
+	return state, nil
+}
+
+func writeSessionState(path string, state SessionState) error {
+	contents, err := yaml.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "marshalling session state")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "creating session state directory")
+	}
+	return ioutil.WriteFile(path, contents, 0644)
+}
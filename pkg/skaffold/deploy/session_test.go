@@ -0,0 +1,84 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestSessionState(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	manifest := filepath.Join(tmpDir, "k8s")
+	stateFile := filepath.Join(tmpDir, "sessions")
+	cfg := &v1alpha2.DeployConfig{}
+	ioutil.WriteFile(manifest+".yaml", []byte("kind: Deployment"), 0644)
+
+	v1 := &build.BuildResult{Builds: []build.Build{{ImageName: "app", Tag: "app:v1"}}}
+	v2 := &build.BuildResult{Builds: []build.Build{{ImageName: "app", Tag: "app:v2"}}}
+
+	newSession := func(calls *[]string) Deployer {
+		deployer, err := WithSessionState(&fakeDeployer{name: manifest, calls: calls}, cfg, stateFile, "session")
+		testutil.CheckError(t, false, err)
+		return deployer
+	}
+	deploy := func(d Deployer, b *build.BuildResult) {
+		_, err := d.Deploy(context.Background(), ioutil.Discard, b)
+		testutil.CheckError(t, false, err)
+	}
+	// First session deploys every time.
+	var calls []string
+	first := newSession(&calls)
+	deploy(first, v1)
+	deploy(first, v1)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 2, len(calls))
+
+	// Resuming with unchanged inputs skips the first deployment only.
+	calls = nil
+	resumed := newSession(&calls)
+	deploy(resumed, v1)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(calls))
+	deploy(resumed, v1)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 1, len(calls))
+
+	// A new tag is deployed.
+	calls = nil
+	deploy(newSession(&calls), v2)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 1, len(calls))
+
+	// So is a changed manifest.
+	ioutil.WriteFile(manifest+".yaml", []byte("kind: StatefulSet"), 0644)
+	calls = nil
+	deploy(newSession(&calls), v2)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 1, len(calls))
+
+	// Cleaning up forgets the session.
+	calls = nil
+	err := newSession(&calls).Cleanup(context.Background(), ioutil.Discard)
+	testutil.CheckError(t, false, err)
+	calls = nil
+	deploy(newSession(&calls), v2)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 1, len(calls))
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// injectSidecars adds the sidecars to the pods and pod templates they select.
+// Containers that already exist with the same name are left untouched.
+func (l *manifestList) injectSidecars(sidecars []v1alpha2.DevSidecar) (manifestList, error) {
+	var updatedManifests manifestList
+
+	for _, manifest := range *l {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			return nil, errors.Wrap(err, "reading kubernetes YAML")
+		}
+		if len(m) == 0 {
+			continue
+		}
+
+		template := podTemplate(m)
+		if m["kind"] == "Pod" {
+			template = m
+		}
+		if template != nil {
+			for _, sidecar := range sidecars {
+				if !matchesLabels(template, sidecar.Selector) {
+					continue
+				}
+				if addContainer(template, sidecar) {
+					logrus.Debugf("Adding sidecar %s to %s %s", sidecar.Name, m["kind"], nestedString(m, "metadata", "name"))
+				}
+			}
+		}
+
+		updatedManifest, err := yaml.Marshal(m)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling yaml")
+		}
+		updatedManifests = append(updatedManifests, updatedManifest)
+	}
+
+	return updatedManifests, nil
+}
+
+// matchesLabels checks that a pod or pod template has all the selected labels.
+func matchesLabels(template map[interface{}]interface{}, selector map[string]string) bool {
+	for key, value := range selector {
+		if nestedString(template, "metadata", "labels", key) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// addContainer appends a sidecar to the containers of a pod spec, unless a
+// container with the same name already exists.
+func addContainer(template map[interface{}]interface{}, sidecar v1alpha2.DevSidecar) bool {
+	spec, ok := template["spec"].(map[interface{}]interface{})
+	if !ok {
+		return false
+	}
+
+	containers, _ := spec["containers"].([]interface{})
+	for _, c := range containers {
+		if nestedString(c, "name") == sidecar.Name {
+			return false
+		}
+	}
+
+	container := map[interface{}]interface{}{
+		"name":  sidecar.Name,
+		"image": sidecar.Image,
+	}
+	if len(sidecar.Command) > 0 {
+		container["command"] = sidecar.Command
+	}
+	if len(sidecar.Args) > 0 {
+		container["args"] = sidecar.Args
+	}
+
+	spec["containers"] = append(containers, container)
+	return true
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const sidecarDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: gcr.io/k8s-skaffold/web`
+
+const sidecarPod = `apiVersion: v1
+kind: Pod
+metadata:
+  name: worker
+  labels:
+    app: worker
+spec:
+  containers:
+  - name: worker
+    image: gcr.io/k8s-skaffold/worker`
+
+func TestInjectSidecars(t *testing.T) {
+	profiler := v1alpha2.DevSidecar{Name: "profiler", Image: "gcr.io/k8s-skaffold/profiler", Args: []string{"--port=6060"}}
+
+	var tests = []struct {
+		description string
+		sidecars    []v1alpha2.DevSidecar
+		expected    [][]string
+	}{
+		{
+			description: "all workloads",
+			sidecars:    []v1alpha2.DevSidecar{profiler},
+			expected:    [][]string{{"web", "profiler"}, {"worker", "profiler"}},
+		},
+		{
+			description: "selected workloads",
+			sidecars: []v1alpha2.DevSidecar{{
+				Name:     "sync",
+				Image:    "gcr.io/k8s-skaffold/sync",
+				Selector: map[string]string{"app": "worker"},
+			}},
+			expected: [][]string{{"web"}, {"worker", "sync"}},
+		},
+		{
+			description: "existing container is kept",
+			sidecars:    []v1alpha2.DevSidecar{{Name: "web", Image: "other"}},
+			expected:    [][]string{{"web"}, {"worker", "web"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			manifests := manifestList{[]byte(sidecarDeployment), []byte(sidecarPod)}
+
+			injected, err := manifests.injectSidecars(test.sidecars)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, containerNames(t, injected))
+		})
+	}
+}
+
+func containerNames(t *testing.T, manifests manifestList) [][]string {
+	var names [][]string
+	for _, manifest := range manifests {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			t.Fatal(err)
+		}
+
+		template := podTemplate(m)
+		if template == nil {
+			template = m
+		}
+
+		var containerNames []string
+		for _, c := range template["spec"].(map[interface{}]interface{})["containers"].([]interface{}) {
+			containerNames = append(containerNames, nestedString(c, "name"))
+		}
+		names = append(names, containerNames)
+	}
+	return names
+}
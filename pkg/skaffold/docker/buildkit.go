@@ -0,0 +1,138 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// RequiresBuildKit tells if an artifact uses features only available
+// with BuildKit.
+func RequiresBuildKit(a *v1alpha2.DockerArtifact) bool {
+	return len(a.Secrets) > 0 || len(a.SSH) > 0
+}
+
+// RunBuildKitBuild builds an image with the docker CLI and BuildKit enabled.
+// The vendored API client can't open the session that BuildKit uses to
+// forward secrets and ssh agents, so the CLI does it instead.
+func RunBuildKitBuild(ctx context.Context, daemon *v1alpha2.DockerDaemon, kubeContext string, opts *BuildOptions) error {
+	logrus.Debugf("Running docker build with BuildKit: context: %s, dockerfile: %s", opts.ContextDir, opts.Dockerfile)
+
+	cmd := exec.CommandContext(ctx, "docker", buildKitArgs(opts)...)
+	cmd.Dir = opts.ContextDir
+	cmd.Env = append(os.Environ(), "DOCKER_BUILDKIT=1")
+	cmd.Env = append(cmd.Env, DaemonEnv(daemon, kubeContext)...)
+	if opts.DockerfileContent != nil {
+		cmd.Stdin = bytes.NewReader(opts.DockerfileContent)
+	}
+	cmd.Stdout = opts.BuildBuf
+	cmd.Stderr = opts.BuildBuf
+	if err := util.RunCmd(cmd); err != nil {
+		return errors.Wrap(err, "docker build")
+	}
+	return nil
+}
+
+func buildKitArgs(opts *BuildOptions) []string {
+	dockerfile := opts.Dockerfile
+	if opts.DockerfileContent != nil {
+		// The Dockerfile is read from stdin.
+		dockerfile = "-"
+	}
+	args := []string{"build", "--tag", opts.ImageName, "--file", dockerfile}
+
+	var keys []string
+	for k := range opts.BuildArgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if v := opts.BuildArgs[k]; v != nil {
+			args = append(args, "--build-arg", fmt.Sprintf("%s=%s", k, *v))
+		} else {
+			args = append(args, "--build-arg", k)
+		}
+	}
+
+	if opts.Target != "" {
+		args = append(args, "--target", opts.Target)
+	}
+	if opts.Network != "" {
+		args = append(args, "--network", opts.Network)
+	}
+	for _, from := range opts.CacheFrom {
+		args = append(args, "--cache-from", from)
+	}
+	if opts.Platform != "" {
+		args = append(args, "--platform", opts.Platform)
+	}
+	for _, secret := range opts.Secrets {
+		args = append(args, "--secret", fmt.Sprintf("id=%s,src=%s", secret.ID, secret.Src))
+	}
+	for _, ssh := range opts.SSH {
+		args = append(args, "--ssh", ssh)
+	}
+
+	return append(args, ".")
+}
+
+// DaemonEnv points CLIs that talk to docker to the same daemon as the API client:
+// the configured daemon, or else minikube's when it's the current context.
+func DaemonEnv(daemon *v1alpha2.DockerDaemon, kubeContext string) []string {
+	if daemon == nil || daemon.Host == "" {
+		if kubeContext != constants.DefaultMinikubeContext {
+			return nil
+		}
+		return minikubeDaemonEnv()
+	}
+
+	env := []string{"DOCKER_HOST=" + daemon.Host}
+	if daemon.CertPath != "" {
+		env = append(env, "DOCKER_CERT_PATH="+daemon.CertPath)
+	}
+	if daemon.TLSVerify {
+		env = append(env, "DOCKER_TLS_VERIFY=1")
+	}
+	return env
+}
+
+// minikubeDaemonEnv is the environment given by minikube docker-env.
+func minikubeDaemonEnv() []string {
+	vars, err := getMinikubeDockerEnv()
+	if err != nil {
+		logrus.Warnf("Could not get minikube docker env, falling back to local docker daemon")
+		return nil
+	}
+
+	var env []string
+	for k, v := range vars {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(env)
+	return env
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestRunBuildKitBuild(t *testing.T) {
+	value := "value"
+
+	var tests = []struct {
+		description string
+		opts        *BuildOptions
+		command     util.Command
+		shouldErr   bool
+	}{
+		{
+			description: "secrets and ssh",
+			opts: &BuildOptions{
+				ImageName:  "image",
+				Dockerfile: "Dockerfile",
+				Secrets:    []v1alpha2.DockerSecret{{ID: "npmrc", Src: ".npmrc"}},
+				SSH:        []string{"default"},
+			},
+			command: testutil.NewFakeCmd("docker build --tag image --file Dockerfile --secret id=npmrc,src=.npmrc --ssh default .", nil),
+		},
+		{
+			description: "all options",
+			opts: &BuildOptions{
+				ImageName:  "image",
+				Dockerfile: "Dockerfile.dev",
+				BuildArgs:  map[string]*string{"B": &value, "A": nil},
+				Target:     "builder",
+				Network:    "host",
+				CacheFrom:  []string{"from"},
+				Platform:   "linux/arm64",
+				SSH:        []string{"default"},
+			},
+			command: testutil.NewFakeCmd("docker build --tag image --file Dockerfile.dev --build-arg A --build-arg B=value --target builder --network host --cache-from from --platform linux/arm64 --ssh default .", nil),
+		},
+		{
+			description: "build failure",
+			opts: &BuildOptions{
+				ImageName:  "image",
+				Dockerfile: "Dockerfile",
+				SSH:        []string{"default"},
+			},
+			command:   testutil.NewFakeCmd("docker build --tag image --file Dockerfile --ssh default .", fmt.Errorf("")),
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = test.command
+			test.opts.BuildBuf = ioutil.Discard
+
+			err := RunBuildKitBuild(context.Background(), nil, "cluster", test.opts)
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
+
+func TestDaemonEnv(t *testing.T) {
+	var tests = []struct {
+		description string
+		daemon      *v1alpha2.DockerDaemon
+		kubeContext string
+		command     util.Command
+		expected    []string
+	}{
+		{
+			description: "default daemon",
+			kubeContext: "cluster",
+		},
+		{
+			description: "pinned daemon",
+			daemon:      &v1alpha2.DockerDaemon{Host: "tcp://host:2376", CertPath: "/certs", TLSVerify: true},
+			kubeContext: "minikube",
+			expected:    []string{"DOCKER_HOST=tcp://host:2376", "DOCKER_CERT_PATH=/certs", "DOCKER_TLS_VERIFY=1"},
+		},
+		{
+			description: "minikube daemon",
+			kubeContext: "minikube",
+			command: testutil.NewFakeCmdOut("minikube docker-env --shell none", `DOCKER_TLS_VERIFY=1
+DOCKER_HOST=tcp://192.168.99.100:2376
+DOCKER_CERT_PATH=testdata
+DOCKER_API_VERSION=1.23`, nil),
+			expected: []string{"DOCKER_API_VERSION=1.23", "DOCKER_CERT_PATH=testdata", "DOCKER_HOST=tcp://192.168.99.100:2376", "DOCKER_TLS_VERIFY=1"},
+		},
+		{
+			description: "minikube not running",
+			kubeContext: "minikube",
+			command:     testutil.NewFakeCmdOut("minikube docker-env --shell none", "", fmt.Errorf("")),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = test.command
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, DaemonEnv(test.daemon, test.kubeContext))
+		})
+	}
+}
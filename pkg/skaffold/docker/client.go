@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/docker/docker/api"
 	"github.com/docker/go-connections/tlsconfig"
@@ -70,10 +72,27 @@ func newDockerAPIClient(kubeContext string) (DockerAPIClient, error) {
 	return newEnvDockerAPIClient()
 }
 
+// NewDockerAPIClientForDaemon returns a docker client for a daemon explicitly
+// configured in the skaffold config.
+func NewDockerAPIClientForDaemon(daemon *v1alpha2.DockerDaemon) (DockerAPIClient, error) {
+	logrus.Debugf("Using docker daemon %s", daemon.Host)
+	cli, err := newDockerAPIClientForHost(daemon.Host, daemon.CertPath, daemon.TLSVerify, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting docker client for %s", daemon.Host)
+	}
+	cli.NegotiateAPIVersion(context.Background())
+
+	return cli, nil
+}
+
 // newEnvDockerAPIClient returns a docker client based on the environment variables set.
 // It will "negotiate" the highest possible API version supported by both the client
 // and the server if there is a mismatch.
 func newEnvDockerAPIClient() (DockerAPIClient, error) {
+	if host := os.Getenv("DOCKER_HOST"); strings.HasPrefix(host, "ssh://") {
+		return newSSHDockerAPIClient(host)
+	}
+
 	cli, err := client.NewEnvClient()
 	if err != nil {
 		return nil, fmt.Errorf("Error getting docker client: %s", err)
@@ -92,13 +111,23 @@ func newMinikubeDockerAPIClient() (DockerAPIClient, error) {
 		return newEnvDockerAPIClient()
 	}
 
+	return newDockerAPIClientForHost(env["DOCKER_HOST"], env["DOCKER_CERT_PATH"], env["DOCKER_TLS_VERIFY"] != "", env["DOCKER_API_VERSION"])
+}
+
+// newDockerAPIClientForHost returns a docker client for a given host, using the default
+// API version if none is given.
+func newDockerAPIClientForHost(host, certPath string, tlsVerify bool, version string) (DockerAPIClient, error) {
+	if strings.HasPrefix(host, "ssh://") {
+		return newSSHDockerAPIClient(host)
+	}
+
 	var httpclient *http.Client
-	if dockerCertPath := env["DOCKER_CERT_PATH"]; dockerCertPath != "" {
+	if certPath != "" {
 		options := tlsconfig.Options{
-			CAFile:             filepath.Join(dockerCertPath, "ca.pem"),
-			CertFile:           filepath.Join(dockerCertPath, "cert.pem"),
-			KeyFile:            filepath.Join(dockerCertPath, "key.pem"),
-			InsecureSkipVerify: env["DOCKER_TLS_VERIFY"] == "",
+			CAFile:             filepath.Join(certPath, "ca.pem"),
+			CertFile:           filepath.Join(certPath, "cert.pem"),
+			KeyFile:            filepath.Join(certPath, "key.pem"),
+			InsecureSkipVerify: !tlsVerify,
 		}
 		tlsc, err := tlsconfig.Client(options)
 		if err != nil {
@@ -113,11 +142,9 @@ func newMinikubeDockerAPIClient() (DockerAPIClient, error) {
 		}
 	}
 
-	host := env["DOCKER_HOST"]
 	if host == "" {
 		host = client.DefaultDockerHost
 	}
-	version := env["DOCKER_API_VERSION"]
 	if version == "" {
 		version = api.DefaultVersion
 	}
@@ -19,6 +19,7 @@ package docker
 import (
 	"testing"
 
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/GoogleContainerTools/skaffold/testutil"
 )
@@ -56,6 +57,51 @@ func TestNewEnvClient(t *testing.T) {
 
 }
 
+func TestNewDockerAPIClientForDaemon(t *testing.T) {
+	var tests = []struct {
+		description string
+		daemon      *v1alpha2.DockerDaemon
+		shouldErr   bool
+	}{
+		{
+			description: "tcp daemon",
+			daemon: &v1alpha2.DockerDaemon{
+				Host: "tcp://127.0.0.1:2375",
+			},
+		},
+		{
+			description: "tls daemon",
+			daemon: &v1alpha2.DockerDaemon{
+				Host:      "tcp://127.0.0.1:2376",
+				CertPath:  "testdata",
+				TLSVerify: true,
+			},
+		},
+		{
+			description: "invalid cert path",
+			daemon: &v1alpha2.DockerDaemon{
+				Host:     "tcp://127.0.0.1:2376",
+				CertPath: "invalid/cert/path",
+			},
+			shouldErr: true,
+		},
+		{
+			description: "invalid host",
+			daemon: &v1alpha2.DockerDaemon{
+				Host: "badurl",
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			_, err := NewDockerAPIClientForDaemon(test.daemon)
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
+
 func TestNewMinikubeImageAPIClient(t *testing.T) {
 	var tests = []struct {
 		description string
@@ -21,6 +21,7 @@ import (
 	"io"
 
 	cstorage "cloud.google.com/go/storage"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/simulate"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/pkg/errors"
 )
@@ -36,6 +37,19 @@ func CreateDockerTarContext(w io.Writer, dockerfilePath, context string) error {
 	return nil
 }
 
+// createDockerTarContextWithDockerfile creates a build context whose Dockerfile
+// is replaced by the given content.
+func createDockerTarContextWithDockerfile(w io.Writer, dockerfilePath, context string, dockerfile []byte) error {
+	paths, err := GetDockerfileDependencies(dockerfilePath, context)
+	if err != nil {
+		return errors.Wrap(err, "getting relative tar paths")
+	}
+	if err := util.CreateTarWithContents(w, context, paths, map[string][]byte{dockerfilePath: dockerfile}); err != nil {
+		return errors.Wrap(err, "creating tar")
+	}
+	return nil
+}
+
 func CreateDockerTarGzContext(w io.Writer, dockerfilePath, context string) error {
 	paths, err := GetDockerfileDependencies(dockerfilePath, context)
 	if err != nil {
@@ -48,6 +62,10 @@ func CreateDockerTarGzContext(w io.Writer, dockerfilePath, context string) error
 }
 
 func UploadContextToGCS(ctx context.Context, dockerfilePath, dockerCtx, bucket, objectName string) error {
+	if err := simulate.Inject(ctx, simulate.Upload); err != nil {
+		return err
+	}
+
 	c, err := cstorage.NewClient(ctx)
 	if err != nil {
 		return err
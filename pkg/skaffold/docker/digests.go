@@ -0,0 +1,239 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/google/go-containerregistry/name"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultContainerdSocket is where containerd listens by default.
+	DefaultContainerdSocket = "/run/containerd/containerd.sock"
+
+	// containerdNamespace is the namespace used by the kubelet's images.
+	containerdNamespace = "k8s.io"
+)
+
+// DigestBackend looks up the digest of images in one kind of image store.
+type DigestBackend interface {
+	Name() string
+	Digest(ctx context.Context, ref string) (string, error)
+}
+
+// DigestResolver looks up digests in several image stores, in order.
+type DigestResolver struct {
+	backends []DigestBackend
+}
+
+// NewDigestResolver creates a resolver that tries the backends in order.
+func NewDigestResolver(backends ...DigestBackend) *DigestResolver {
+	return &DigestResolver{
+		backends: backends,
+	}
+}
+
+// DefaultDigestResolver uses the local image stores that are available,
+// the docker daemon and then containerd, and the registry. Images that
+// were only built locally, like on minikube's docker daemon, can then be
+// resolved without a registry. With remoteFirst, for images that were
+// pushed by a remote builder, the registry is asked before the local
+// stores, which may have a stale image with the same tag.
+func DefaultDigestResolver(remoteFirst bool) *DigestResolver {
+	var backends []DigestBackend
+
+	if api, err := NewDockerAPIClient(); err == nil {
+		backends = append(backends, NewDaemonDigests(api))
+	} else {
+		logrus.Debugf("Not looking up digests in the docker daemon: %s", err)
+	}
+
+	if _, err := os.Stat(DefaultContainerdSocket); err == nil {
+		if _, err := exec.LookPath("ctr"); err == nil {
+			backends = append(backends, &ContainerdDigests{Socket: DefaultContainerdSocket})
+		}
+	}
+
+	if remoteFirst {
+		backends = append([]DigestBackend{&RegistryDigests{}}, backends...)
+	} else {
+		backends = append(backends, &RegistryDigests{})
+	}
+	return NewDigestResolver(backends...)
+}
+
+// Digest returns the digest found by the first backend that knows the image.
+func (r *DigestResolver) Digest(ctx context.Context, ref string) (string, error) {
+	var failures []string
+	for _, backend := range r.backends {
+		digest, err := backend.Digest(ctx, ref)
+		if err == nil {
+			logrus.Debugf("Found digest of %s in %s: %s", ref, backend.Name(), digest)
+			return digest, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %s", backend.Name(), err))
+	}
+
+	return "", fmt.Errorf("no digest for %s (%s)", ref, strings.Join(failures, ", "))
+}
+
+// Lookup is Digest without a context.
+func (r *DigestResolver) Lookup(ref string) (string, error) {
+	return r.Digest(context.Background(), ref)
+}
+
+// DaemonDigests looks up digests in a docker daemon. Only images that were
+// pushed or pulled have one: the id of an image can't be used to pull it.
+type DaemonDigests struct {
+	api DockerAPIClient
+}
+
+// NewDaemonDigests looks up digests with the given docker client.
+func NewDaemonDigests(api DockerAPIClient) *DaemonDigests {
+	return &DaemonDigests{api: api}
+}
+
+// Name of the backend.
+func (d *DaemonDigests) Name() string { return "docker daemon" }
+
+// Digest of a local image.
+func (d *DaemonDigests) Digest(ctx context.Context, ref string) (string, error) {
+	images, err := d.api.ImageList(ctx, types.ImageListOptions{
+		Filters: filters.NewArgs(filters.KeyValuePair{Key: "reference", Value: ref}),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "listing images")
+	}
+
+	for _, image := range images {
+		for _, tag := range image.RepoTags {
+			if tag != ref {
+				continue
+			}
+			if digest := repoDigest(ref, image.RepoDigests); digest != "" {
+				return digest, nil
+			}
+			return "", fmt.Errorf("image was neither pushed nor pulled")
+		}
+	}
+
+	return "", fmt.Errorf("image not found")
+}
+
+// LocalImageID returns the id of an image in the local docker daemon.
+func LocalImageID(ref string) (string, error) {
+	api, err := NewDockerAPIClient()
+	if err != nil {
+		return "", errors.Wrap(err, "getting docker client")
+	}
+
+	id, err := Digest(context.Background(), api, ref)
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		return "", fmt.Errorf("image %s not found", ref)
+	}
+	return id, nil
+}
+
+// repoDigest finds the repo digest that belongs to the repository of ref.
+func repoDigest(ref string, repoDigests []string) string {
+	tag, err := name.NewTag(ref, name.WeakValidation)
+	if err != nil {
+		return ""
+	}
+
+	for _, repoDigest := range repoDigests {
+		digest, err := name.NewDigest(repoDigest, name.WeakValidation)
+		if err != nil {
+			continue
+		}
+		if digest.Context().String() == tag.Context().String() {
+			return digest.DigestStr()
+		}
+	}
+	return ""
+}
+
+// RegistryDigests looks up digests in remote registries.
+type RegistryDigests struct{}
+
+// Name of the backend.
+func (r *RegistryDigests) Name() string { return "registry" }
+
+// Digest of a remote image.
+func (r *RegistryDigests) Digest(ctx context.Context, ref string) (string, error) {
+	return RemoteDigest(ref)
+}
+
+// ContainerdDigests looks up digests in containerd, through its ctr client.
+type ContainerdDigests struct {
+	Socket string
+}
+
+// Name of the backend.
+func (c *ContainerdDigests) Name() string { return "containerd" }
+
+// Digest of an image known by containerd.
+func (c *ContainerdDigests) Digest(ctx context.Context, ref string) (string, error) {
+	cmd := exec.CommandContext(ctx, "ctr", "--address", c.Socket, "--namespace", containerdNamespace, "images", "ls", "name=="+fullyQualified(ref))
+	out, err := util.RunCmdOut(cmd)
+	if err != nil {
+		return "", errors.Wrap(err, "listing containerd images")
+	}
+
+	// REF TYPE DIGEST SIZE PLATFORMS LABELS, after a header line.
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 {
+			return fields[2], nil
+		}
+	}
+
+	return "", fmt.Errorf("image not found")
+}
+
+// fullyQualified returns the name containerd knows an image by,
+// e.g. docker.io/library/busybox:latest for busybox.
+func fullyQualified(ref string) string {
+	parsed, err := name.ParseReference(ref, name.WeakValidation)
+	if err != nil {
+		return ref
+	}
+
+	repo := parsed.Context().Name()
+	if strings.HasPrefix(repo, name.DefaultRegistry+"/") {
+		repo = "docker.io/" + strings.TrimPrefix(repo, name.DefaultRegistry+"/")
+	}
+
+	if _, isDigest := parsed.(name.Digest); isDigest {
+		return repo + "@" + parsed.Identifier()
+	}
+	return repo + ":" + parsed.Identifier()
+}
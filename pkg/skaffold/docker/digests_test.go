@@ -0,0 +1,153 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+type fakeDigests map[string]string
+
+func (f fakeDigests) Name() string { return "fake" }
+
+func (f fakeDigests) Digest(ctx context.Context, ref string) (string, error) {
+	if digest, present := f[ref]; present {
+		return digest, nil
+	}
+	return "", fmt.Errorf("image not found")
+}
+
+func TestDigestResolver(t *testing.T) {
+	resolver := NewDigestResolver(
+		fakeDigests{"local": "sha256:local"},
+		fakeDigests{"local": "sha256:other", "remote": "sha256:remote"},
+	)
+
+	var tests = []struct {
+		description string
+		ref         string
+		shouldErr   bool
+		expected    string
+	}{
+		{
+			description: "first backend wins",
+			ref:         "local",
+			expected:    "sha256:local",
+		},
+		{
+			description: "fallback to next backend",
+			ref:         "remote",
+			expected:    "sha256:remote",
+		},
+		{
+			description: "unknown image",
+			ref:         "unknown",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			digest, err := resolver.Lookup(test.ref)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, digest)
+		})
+	}
+}
+
+func TestDefaultDigestResolverOrder(t *testing.T) {
+	local := DefaultDigestResolver(false).backends
+	_, registryLast := local[len(local)-1].(*RegistryDigests)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, registryLast)
+
+	_, registryFirst := DefaultDigestResolver(true).backends[0].(*RegistryDigests)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, registryFirst)
+}
+
+func TestDaemonDigests(t *testing.T) {
+	api := testutil.NewFakeImageAPIClient(map[string]string{
+		"gcr.io/project/app:v1": "sha256:imageid",
+		"gcr.io/project/app:v2": "sha256:localid",
+	}, &testutil.FakeImageAPIOptions{
+		RepoDigests: map[string][]string{"gcr.io/project/app:v1": {"gcr.io/project/app@sha256:27ffc7f352665cc50ae3cbcc4b2725e36062f1b38c611b6f95d6df9a7510de23"}},
+	})
+	daemon := NewDaemonDigests(api)
+
+	digest, err := daemon.Digest(context.Background(), "gcr.io/project/app:v1")
+	testutil.CheckErrorAndDeepEqual(t, false, err, "sha256:27ffc7f352665cc50ae3cbcc4b2725e36062f1b38c611b6f95d6df9a7510de23", digest)
+
+	_, err = daemon.Digest(context.Background(), "gcr.io/project/app:v2")
+	testutil.CheckError(t, true, err)
+
+	_, err = daemon.Digest(context.Background(), "gcr.io/project/app:v3")
+	testutil.CheckError(t, true, err)
+}
+
+func TestRepoDigest(t *testing.T) {
+	repoDigests := []string{
+		"gcr.io/project/other@sha256:3a47c0c61cb6d4a5a0c3ca8b14b8c2e1b1a6f2d6a6c2a36b4b5a5ac2a9f58a0b",
+		"gcr.io/project/app@sha256:27ffc7f352665cc50ae3cbcc4b2725e36062f1b38c611b6f95d6df9a7510de23",
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "sha256:27ffc7f352665cc50ae3cbcc4b2725e36062f1b38c611b6f95d6df9a7510de23", repoDigest("gcr.io/project/app:v1", repoDigests))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "", repoDigest("gcr.io/project/unknown:v1", repoDigests))
+}
+
+func TestContainerdDigests(t *testing.T) {
+	var tests = []struct {
+		description string
+		ref         string
+		command     string
+		output      string
+		shouldErr   bool
+		expected    string
+	}{
+		{
+			description: "found",
+			ref:         "busybox",
+			command:     "ctr --address /run/containerd/containerd.sock --namespace k8s.io images ls name==docker.io/library/busybox:latest",
+			output: `REF                               TYPE                                                 DIGEST                                                                  SIZE    PLATFORMS   LABELS
+docker.io/library/busybox:latest  application/vnd.docker.distribution.manifest.v2+json sha256:3a47c0c61cb6d4a5a0c3ca8b14b8c2e1b1a6f2d6a6c2a36b4b5a5ac2a9f58a0b 716.0 KiB linux/amd64 -
+`,
+			expected: "sha256:3a47c0c61cb6d4a5a0c3ca8b14b8c2e1b1a6f2d6a6c2a36b4b5a5ac2a9f58a0b",
+		},
+		{
+			description: "not found",
+			ref:         "gcr.io/project/app:v1",
+			command:     "ctr --address /run/containerd/containerd.sock --namespace k8s.io images ls name==gcr.io/project/app:v1",
+			output:      "REF TYPE DIGEST SIZE PLATFORMS LABELS\n",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = testutil.NewFakeCmdOut(test.command, test.output, nil)
+
+			containerd := &ContainerdDigests{Socket: DefaultContainerdSocket}
+			digest, err := containerd.Digest(context.Background(), test.ref)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, digest)
+		})
+	}
+}
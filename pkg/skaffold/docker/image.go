@@ -20,7 +20,11 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"time"
 
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/simulate"
 	"github.com/google/go-containerregistry/authn"
 	"github.com/google/go-containerregistry/name"
 	"github.com/google/go-containerregistry/v1"
@@ -44,6 +48,15 @@ type BuildOptions struct {
 	ProgressBuf io.Writer
 	BuildBuf    io.Writer
 	BuildArgs   map[string]*string
+	Target      string
+	Network     string
+	CacheFrom   []string
+	Platform    string
+	Secrets     []v1alpha2.DockerSecret
+	SSH         []string
+
+	// DockerfileContent, when set, replaces the content of the Dockerfile.
+	DockerfileContent []byte
 }
 
 // RunBuild performs a docker build and returns nothing
@@ -58,12 +71,23 @@ func RunBuild(ctx context.Context, cli DockerAPIClient, opts *BuildOptions) erro
 		Tags:        []string{opts.ImageName},
 		Dockerfile:  opts.Dockerfile,
 		BuildArgs:   opts.BuildArgs,
+		Target:      opts.Target,
+		NetworkMode: opts.Network,
+		CacheFrom:   opts.CacheFrom,
+		Platform:    opts.Platform,
 		AuthConfigs: authConfigs,
 	}
 
 	buildCtx, buildCtxWriter := io.Pipe()
 	go func() {
-		err := CreateDockerTarContext(buildCtxWriter, opts.Dockerfile, opts.ContextDir)
+		defer event.TimePhase(event.ContextPhase, time.Now())
+
+		var err error
+		if opts.DockerfileContent != nil {
+			err = createDockerTarContextWithDockerfile(buildCtxWriter, opts.Dockerfile, opts.ContextDir, opts.DockerfileContent)
+		} else {
+			err = CreateDockerTarContext(buildCtxWriter, opts.Dockerfile, opts.ContextDir)
+		}
 		if err != nil {
 			buildCtxWriter.CloseWithError(errors.Wrap(err, "creating docker context"))
 			return
@@ -89,6 +113,9 @@ func StreamDockerMessages(dst io.Writer, src io.Reader) error {
 }
 
 func RunPush(ctx context.Context, cli DockerAPIClient, ref string, out io.Writer) error {
+	if err := simulate.Inject(ctx, simulate.Upload); err != nil {
+		return errors.Wrap(err, "pushing image to repository")
+	}
 	registryAuth, err := encodedRegistryAuth(ctx, cli, DefaultAuthHelper, ref)
 	if err != nil {
 		return errors.Wrapf(err, "getting auth config for %s", ref)
@@ -103,6 +130,22 @@ func RunPush(ctx context.Context, cli DockerAPIClient, ref string, out io.Writer
 	return StreamDockerMessages(out, rc)
 }
 
+// RunPull pulls an image from its repository.
+func RunPull(ctx context.Context, cli DockerAPIClient, ref string, out io.Writer) error {
+	registryAuth, err := encodedRegistryAuth(ctx, cli, DefaultAuthHelper, ref)
+	if err != nil {
+		return errors.Wrapf(err, "getting auth config for %s", ref)
+	}
+	rc, err := cli.ImagePull(ctx, ref, types.ImagePullOptions{
+		RegistryAuth: registryAuth,
+	})
+	if err != nil {
+		return errors.Wrap(err, "pulling image from repository")
+	}
+	defer rc.Close()
+	return StreamDockerMessages(out, rc)
+}
+
 func AddTag(src, target string) error {
 	srcRef, err := name.ParseReference(src, name.WeakValidation)
 	if err != nil {
@@ -161,6 +204,16 @@ func Digest(ctx context.Context, cli DockerAPIClient, ref string) (string, error
 	return "", nil
 }
 
+// ImageSize returns the size of a local image and its number of layers.
+func ImageSize(ctx context.Context, cli DockerAPIClient, ref string) (int64, int, error) {
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "inspecting image")
+	}
+
+	return inspect.Size, len(inspect.RootFS.Layers), nil
+}
+
 func remoteImage(identifier string) (v1.Image, error) {
 	ref, err := name.ParseReference(identifier, name.WeakValidation)
 	if err != nil {
@@ -0,0 +1,194 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/moby/moby/builder/dockerfile/parser"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// LockFile records the digests that base images resolved to, so that
+// everyone building the artifacts gets the same base layers.
+type LockFile struct {
+	Images map[string]string `yaml:"images"`
+}
+
+// ReadLockFile reads a lock file. A missing file is an empty lock.
+func ReadLockFile(path string) (*LockFile, error) {
+	lock := &LockFile{Images: map[string]string{}}
+
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading lock file %s", path)
+	}
+	if err := yaml.Unmarshal(buf, lock); err != nil {
+		return nil, errors.Wrapf(err, "parsing lock file %s", path)
+	}
+	if lock.Images == nil {
+		lock.Images = map[string]string{}
+	}
+	return lock, nil
+}
+
+// Write saves the lock file.
+func (l *LockFile) Write(path string) error {
+	buf, err := yaml.Marshal(l)
+	if err != nil {
+		return errors.Wrap(err, "marshalling lock file")
+	}
+	return ioutil.WriteFile(path, buf, 0644)
+}
+
+// UpdateLockFile resolves the digests of the base images used by the artifacts' Dockerfiles.
+func UpdateLockFile(artifacts []*v1alpha2.Artifact, digest func(image string) (string, error)) (*LockFile, error) {
+	lock := &LockFile{Images: map[string]string{}}
+
+	for _, a := range artifacts {
+		if a.DockerArtifact == nil {
+			continue
+		}
+
+		images, err := BaseImages(a.Workspace, a.DockerArtifact.DockerfilePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing base images of %s", a.ImageName)
+		}
+		for _, image := range images {
+			if _, present := lock.Images[image]; present {
+				continue
+			}
+
+			d, err := digest(image)
+			if err != nil {
+				return nil, errors.Wrapf(err, "resolving digest of %s", image)
+			}
+			lock.Images[image] = d
+		}
+	}
+
+	return lock, nil
+}
+
+// BaseImages lists the images that a Dockerfile builds from. Scratch, previous
+// stages, images that are already pinned and images computed from build args
+// are left out.
+func BaseImages(workspace, dockerfilePath string) ([]string, error) {
+	res, err := parseDockerfile(workspace, dockerfilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []string
+	seen := map[string]bool{}
+	for _, from := range fromInstructions(res) {
+		if !seen[from.image] {
+			seen[from.image] = true
+			images = append(images, from.image)
+		}
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// PinnedDockerfile returns a Dockerfile whose FROM instructions refer to the
+// base images by the digests recorded in the lock. The boolean tells if any
+// instruction was changed.
+func PinnedDockerfile(workspace, dockerfilePath string, lock *LockFile) ([]byte, bool, error) {
+	path := filepath.Join(workspace, dockerfilePath)
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "reading dockerfile: %s", path)
+	}
+	res, err := parser.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, false, errors.Wrap(err, "parsing dockerfile")
+	}
+
+	lines := strings.Split(string(content), "\n")
+	pinned := false
+	for _, from := range fromInstructions(res) {
+		digest, present := lock.Images[from.image]
+		if !present {
+			logrus.Warnf("Base image %s isn't pinned in the lock file", from.image)
+			continue
+		}
+
+		i := from.line - 1
+		lines[i] = strings.Replace(lines[i], from.image, fmt.Sprintf("%s@%s", from.image, digest), 1)
+		pinned = true
+	}
+
+	return []byte(strings.Join(lines, "\n")), pinned, nil
+}
+
+type fromInstruction struct {
+	image string
+	line  int
+}
+
+func fromInstructions(res *parser.Result) []fromInstruction {
+	var froms []fromInstruction
+	stages := map[string]bool{}
+
+	for _, value := range res.AST.Children {
+		if value.Value != from || value.Next == nil {
+			continue
+		}
+
+		image := value.Next.Value
+		switch {
+		case strings.EqualFold(image, "scratch"):
+		case stages[strings.ToLower(image)]:
+		case strings.Contains(image, "@"), strings.Contains(image, "$"):
+		default:
+			froms = append(froms, fromInstruction{image: image, line: value.StartLine})
+		}
+
+		if as := value.Next.Next; as != nil && strings.EqualFold(as.Value, "as") && as.Next != nil {
+			stages[strings.ToLower(as.Next.Value)] = true
+		}
+	}
+	return froms
+}
+
+func parseDockerfile(workspace, dockerfilePath string) (*parser.Result, error) {
+	path := filepath.Join(workspace, dockerfilePath)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening dockerfile: %s", path)
+	}
+	defer f.Close()
+
+	res, err := parser.Parse(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing dockerfile")
+	}
+	return res, nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+const lockedDockerfile = `FROM golang:1.10 AS builder
+ARG BASE=alpine
+COPY . .
+
+FROM builder AS tests
+FROM scratch
+FROM gcr.io/distroless/base@sha256:123
+FROM $BASE
+from alpine:3.7
+COPY --from=builder /app /app
+`
+
+func TestBaseImages(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(lockedDockerfile), 0644)
+
+	images, err := BaseImages(dir, "Dockerfile")
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"alpine:3.7", "golang:1.10"}, images)
+}
+
+func TestPinnedDockerfile(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(lockedDockerfile), 0644)
+
+	var tests = []struct {
+		description    string
+		lock           *LockFile
+		expected       string
+		expectedPinned bool
+	}{
+		{
+			description: "nothing pinned",
+			lock:        &LockFile{Images: map[string]string{}},
+			expected:    lockedDockerfile,
+		},
+		{
+			description: "pinned images",
+			lock: &LockFile{Images: map[string]string{
+				"golang:1.10": "sha256:abc",
+				"alpine:3.7":  "sha256:def",
+			}},
+			expected: `FROM golang:1.10@sha256:abc AS builder
+ARG BASE=alpine
+COPY . .
+
+FROM builder AS tests
+FROM scratch
+FROM gcr.io/distroless/base@sha256:123
+FROM $BASE
+from alpine:3.7@sha256:def
+COPY --from=builder /app /app
+`,
+			expectedPinned: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			dockerfile, pinned, err := PinnedDockerfile(dir, "Dockerfile", test.lock)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, string(dockerfile))
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedPinned, pinned)
+		})
+	}
+}
+
+func TestUpdateLockFile(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(lockedDockerfile), 0644)
+
+	artifacts := []*v1alpha2.Artifact{{
+		ImageName: "image",
+		Workspace: dir,
+		ArtifactType: v1alpha2.ArtifactType{
+			DockerArtifact: &v1alpha2.DockerArtifact{DockerfilePath: "Dockerfile"},
+		},
+	}}
+
+	var tests = []struct {
+		description string
+		digest      func(string) (string, error)
+		shouldErr   bool
+		expected    *LockFile
+	}{
+		{
+			description: "resolve digests",
+			digest:      func(image string) (string, error) { return "sha256:" + image, nil },
+			expected: &LockFile{Images: map[string]string{
+				"alpine:3.7":  "sha256:alpine:3.7",
+				"golang:1.10": "sha256:golang:1.10",
+			}},
+		},
+		{
+			description: "registry error",
+			digest:      func(string) (string, error) { return "", fmt.Errorf("") },
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			lock, err := UpdateLockFile(artifacts, test.digest)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, lock)
+		})
+	}
+}
+
+func TestLockFileRoundTrip(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	path := filepath.Join(dir, "skaffold.lock")
+
+	missing, err := ReadLockFile(path)
+	testutil.CheckErrorAndDeepEqual(t, false, err, &LockFile{Images: map[string]string{}}, missing)
+
+	lock := &LockFile{Images: map[string]string{"golang:1.10": "sha256:abc"}}
+	err = lock.Write(path)
+	testutil.CheckError(t, false, err)
+
+	read, err := ReadLockFile(path)
+	testutil.CheckErrorAndDeepEqual(t, false, err, lock, read)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/authn"
+	"github.com/google/go-containerregistry/name"
+	"github.com/google/go-containerregistry/v1/remote/transport"
+	"github.com/google/go-containerregistry/v1/types"
+	"github.com/pkg/errors"
+)
+
+// PlatformImage is a pushed image built for a given platform, eg. linux/arm64.
+type PlatformImage struct {
+	Platform string
+	Ref      string
+}
+
+type manifestList struct {
+	SchemaVersion int                      `json:"schemaVersion"`
+	MediaType     types.MediaType          `json:"mediaType"`
+	Manifests     []manifestListDescriptor `json:"manifests"`
+}
+
+type manifestListDescriptor struct {
+	MediaType types.MediaType `json:"mediaType"`
+	Size      int64           `json:"size"`
+	Digest    string          `json:"digest"`
+	Platform  platform        `json:"platform"`
+}
+
+type platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// parsePlatform parses a platform of the form os/arch[/variant].
+func parsePlatform(s string) (platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return platform{}, fmt.Errorf("invalid platform %s, expected os/arch[/variant]", s)
+	}
+
+	p := platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// PushManifestList publishes a manifest list that references images already
+// pushed for each platform. It returns the digest of the list.
+func PushManifestList(ref string, images []PlatformImage) (string, error) {
+	listRef, err := name.ParseReference(ref, name.WeakValidation)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing manifest list reference")
+	}
+
+	list := manifestList{
+		SchemaVersion: 2,
+		MediaType:     types.DockerManifestList,
+	}
+	for _, image := range images {
+		descriptor, err := platformDescriptor(image)
+		if err != nil {
+			return "", errors.Wrapf(err, "getting manifest of %s", image.Ref)
+		}
+		list.Manifests = append(list.Manifests, descriptor)
+	}
+
+	raw, err := json.Marshal(list)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling manifest list")
+	}
+
+	auth, err := authn.DefaultKeychain.Resolve(listRef.Context().Registry)
+	if err != nil {
+		return "", errors.Wrap(err, "getting default keychain auth")
+	}
+
+	if err := putManifestList(listRef, raw, auth, http.DefaultTransport); err != nil {
+		return "", errors.Wrap(err, "pushing manifest list")
+	}
+
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(raw)), nil
+}
+
+func platformDescriptor(image PlatformImage) (manifestListDescriptor, error) {
+	p, err := parsePlatform(image.Platform)
+	if err != nil {
+		return manifestListDescriptor{}, err
+	}
+
+	img, err := remoteImage(image.Ref)
+	if err != nil {
+		return manifestListDescriptor{}, errors.Wrap(err, "getting image")
+	}
+	raw, err := img.RawManifest()
+	if err != nil {
+		return manifestListDescriptor{}, errors.Wrap(err, "getting manifest")
+	}
+	mediaType, err := img.MediaType()
+	if err != nil {
+		return manifestListDescriptor{}, errors.Wrap(err, "getting media type")
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return manifestListDescriptor{}, errors.Wrap(err, "getting digest")
+	}
+
+	return manifestListDescriptor{
+		MediaType: mediaType,
+		Size:      int64(len(raw)),
+		Digest:    digest.String(),
+		Platform:  p,
+	}, nil
+}
+
+func putManifestList(ref name.Reference, raw []byte, auth authn.Authenticator, t http.RoundTripper) error {
+	tr, err := transport.New(ref.Context().Registry, auth, t, []string{ref.Scope(transport.PushScope)})
+	if err != nil {
+		return err
+	}
+
+	u := url.URL{
+		Scheme: transport.Scheme(ref.Context().Registry),
+		Host:   ref.Context().RegistryStr(),
+		Path:   fmt.Sprintf("/v2/%s/manifests/%s", ref.Context().RepositoryStr(), ref.Identifier()),
+	}
+	req, err := http.NewRequest(http.MethodPut, u.String(), bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", string(types.DockerManifestList))
+
+	resp, err := (&http.Client{Transport: tr}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
+		return nil
+	default:
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, body)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/google/go-containerregistry/authn"
+	"github.com/google/go-containerregistry/name"
+)
+
+func TestParsePlatform(t *testing.T) {
+	var tests = []struct {
+		platform  string
+		expected  platform
+		shouldErr bool
+	}{
+		{platform: "linux/amd64", expected: platform{OS: "linux", Architecture: "amd64"}},
+		{platform: "linux/arm/v7", expected: platform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+		{platform: "linux", shouldErr: true},
+		{platform: "linux/", shouldErr: true},
+		{platform: "linux/arm/v7/extra", shouldErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.platform, func(t *testing.T) {
+			p, err := parsePlatform(test.platform)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, p)
+		})
+	}
+}
+
+func TestPutManifestList(t *testing.T) {
+	var (
+		method, path, contentType, body string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		method, path, contentType = r.Method, r.URL.Path, r.Header.Get("Content-Type")
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	ref, err := name.ParseReference(u.Host+"/app:v1", name.WeakValidation)
+	testutil.CheckError(t, false, err)
+
+	err = putManifestList(ref, []byte("{}"), authn.Anonymous, http.DefaultTransport)
+
+	testutil.CheckError(t, false, err)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "PUT", method)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "/v2/app/manifests/v1", path)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "application/vnd.docker.distribution.manifest.list.v2+json", contentType)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "{}", body)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/versions"
+	"github.com/pkg/errors"
+)
+
+const (
+	// targetMinAPIVersion is the first API version supporting multi-stage builds.
+	targetMinAPIVersion = "1.29"
+	// platformMinAPIVersion is the first API version supporting --platform.
+	platformMinAPIVersion = "1.32"
+	// platformStableAPIVersion is the first API version where --platform isn't experimental.
+	platformStableAPIVersion = "1.40"
+	// buildKitMinAPIVersion is the first API version of a daemon embedding BuildKit.
+	buildKitMinAPIVersion = "1.39"
+)
+
+// DaemonRequirements are the capabilities that builds expect from the docker daemon.
+type DaemonRequirements struct {
+	Target   bool
+	Platform string
+	BuildKit bool
+}
+
+// DaemonInfo describes a docker daemon.
+type DaemonInfo struct {
+	Version      string
+	APIVersion   string
+	OS           string
+	Arch         string
+	Experimental bool
+}
+
+func (d *DaemonInfo) String() string {
+	return fmt.Sprintf("docker %s (API %s, %s/%s, experimental=%t)", d.Version, d.APIVersion, d.OS, d.Arch, d.Experimental)
+}
+
+// Preflight negotiates the API version with the daemon and checks that it
+// supports everything the builds require.
+func Preflight(ctx context.Context, cli DockerAPIClient, req DaemonRequirements) (*DaemonInfo, error) {
+	cli.NegotiateAPIVersion(ctx)
+
+	version, err := cli.ServerVersion(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "connecting to the docker daemon")
+	}
+
+	info := &DaemonInfo{
+		Version:      version.Version,
+		APIVersion:   version.APIVersion,
+		OS:           version.Os,
+		Arch:         version.Arch,
+		Experimental: version.Experimental,
+	}
+
+	var missing []string
+	if req.Target && versions.LessThan(info.APIVersion, targetMinAPIVersion) {
+		missing = append(missing, fmt.Sprintf("building a target stage requires API %s", targetMinAPIVersion))
+	}
+	if req.Platform != "" {
+		if versions.LessThan(info.APIVersion, platformMinAPIVersion) {
+			missing = append(missing, fmt.Sprintf("building for platform %s requires API %s", req.Platform, platformMinAPIVersion))
+		} else if versions.LessThan(info.APIVersion, platformStableAPIVersion) && !info.Experimental {
+			missing = append(missing, fmt.Sprintf("building for platform %s requires experimental features to be enabled", req.Platform))
+		}
+	}
+
+	if req.BuildKit && versions.LessThan(info.APIVersion, buildKitMinAPIVersion) {
+		missing = append(missing, fmt.Sprintf("secrets and ssh forwarding require BuildKit, available from API %s", buildKitMinAPIVersion))
+	}
+
+	if len(missing) > 0 {
+		return info, fmt.Errorf("%s doesn't support the build: %s", info, strings.Join(missing, ", "))
+	}
+	return info, nil
+}
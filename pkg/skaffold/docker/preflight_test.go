@@ -0,0 +1,102 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/docker/docker/api/types"
+)
+
+func TestPreflight(t *testing.T) {
+	var tests = []struct {
+		description string
+		version     *types.Version
+		errVersion  bool
+		req         DaemonRequirements
+		shouldErr   bool
+	}{
+		{
+			description: "no requirement",
+			version:     &types.Version{APIVersion: "1.25"},
+		},
+		{
+			description: "unreachable daemon",
+			errVersion:  true,
+			shouldErr:   true,
+		},
+		{
+			description: "target supported",
+			version:     &types.Version{APIVersion: "1.29"},
+			req:         DaemonRequirements{Target: true},
+		},
+		{
+			description: "target not supported",
+			version:     &types.Version{APIVersion: "1.27"},
+			req:         DaemonRequirements{Target: true},
+			shouldErr:   true,
+		},
+		{
+			description: "platform on experimental daemon",
+			version:     &types.Version{APIVersion: "1.37", Experimental: true},
+			req:         DaemonRequirements{Platform: "linux/arm64"},
+		},
+		{
+			description: "platform requires experimental",
+			version:     &types.Version{APIVersion: "1.37"},
+			req:         DaemonRequirements{Platform: "linux/arm64"},
+			shouldErr:   true,
+		},
+		{
+			description: "platform on recent daemon",
+			version:     &types.Version{APIVersion: "1.40"},
+			req:         DaemonRequirements{Platform: "linux/arm64"},
+		},
+		{
+			description: "buildkit supported",
+			version:     &types.Version{APIVersion: "1.39"},
+			req:         DaemonRequirements{BuildKit: true},
+		},
+		{
+			description: "buildkit not supported",
+			version:     &types.Version{APIVersion: "1.38"},
+			req:         DaemonRequirements{BuildKit: true},
+			shouldErr:   true,
+		},
+		{
+			description: "platform not supported",
+			version:     &types.Version{APIVersion: "1.30", Experimental: true},
+			req:         DaemonRequirements{Platform: "linux/arm64"},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			api := testutil.NewFakeImageAPIClient(nil, &testutil.FakeImageAPIOptions{
+				ServerVersion: test.version,
+				ErrVersion:    test.errVersion,
+			})
+
+			_, err := Preflight(context.Background(), api, test.req)
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
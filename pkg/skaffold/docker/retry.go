@@ -0,0 +1,174 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/google/go-containerregistry/v1/remote"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRetryAttempts     = 3
+	defaultRetryInitialDelay = time.Second
+	defaultRetryMaxDelay     = 30 * time.Second
+)
+
+// RetryPolicy retries registry operations, such as pushes, that fail because
+// of transient errors. Delays grow exponentially, with jitter, up to MaxDelay.
+type RetryPolicy struct {
+	Attempts     int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+// sleep waits for the given delay, unless the context is cancelled first.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// NewRetryPolicy reads a retry policy from the configuration, filling the blanks with defaults.
+func NewRetryPolicy(cfg *v1alpha2.RetryPolicy) (*RetryPolicy, error) {
+	p := &RetryPolicy{
+		Attempts:     defaultRetryAttempts,
+		InitialDelay: defaultRetryInitialDelay,
+		MaxDelay:     defaultRetryMaxDelay,
+	}
+	if cfg == nil {
+		return p, nil
+	}
+
+	if cfg.Attempts < 0 {
+		return nil, fmt.Errorf("retry attempts should not be negative, got %d", cfg.Attempts)
+	}
+	if cfg.Attempts != 0 {
+		p.Attempts = cfg.Attempts
+	}
+	if cfg.InitialDelay != "" {
+		d, err := time.ParseDuration(cfg.InitialDelay)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing initial delay %s", cfg.InitialDelay)
+		}
+		p.InitialDelay = d
+	}
+	if cfg.MaxDelay != "" {
+		d, err := time.ParseDuration(cfg.MaxDelay)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing max delay %s", cfg.MaxDelay)
+		}
+		p.MaxDelay = d
+	}
+	return p, nil
+}
+
+// Do calls f until it succeeds, fails with a permanent error or the
+// attempts are exhausted. A nil policy calls f once.
+func (p *RetryPolicy) Do(ctx context.Context, operation string, f func() error) error {
+	attempts := 1
+	if p != nil && p.Attempts > 1 {
+		attempts = p.Attempts
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if err = f(); err == nil || !isTransient(err) || attempt == attempts-1 {
+			break
+		}
+
+		delay := p.delay(attempt)
+		logrus.Warnf("%s failed, retrying in %s: %s", operation, delay, err)
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// delay returns a random duration between half and all of the exponential
+// backoff, so that parallel builds don't retry in lockstep.
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	backoff := p.InitialDelay << uint(attempt)
+	if backoff > p.MaxDelay || backoff <= 0 {
+		backoff = p.MaxDelay
+	}
+	if backoff <= 1 {
+		return backoff
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)))
+}
+
+// transientMessages are fragments of the network errors that the docker
+// daemon flattens to strings when a push or a pull is interrupted.
+var transientMessages = []string{
+	"timeout",
+	"connection reset",
+	"connection refused",
+	"broken pipe",
+	"unexpected EOF",
+	"502 Bad Gateway",
+	"503 Service Unavailable",
+	"504 Gateway Timeout",
+}
+
+// isTransient tells if an operation might succeed when tried again.
+// Only network failures and timeouts are; any other error, like an
+// unauthorized or denied push, is permanent.
+func isTransient(err error) bool {
+	cause := errors.Cause(err)
+	if cause == context.Canceled || cause == context.DeadlineExceeded {
+		return false
+	}
+	if cause == io.ErrUnexpectedEOF {
+		return true
+	}
+
+	switch e := cause.(type) {
+	case *url.Error:
+		return isTransient(e.Err)
+	case *net.OpError:
+		return true
+	case net.Error:
+		return e.Timeout()
+	case *remote.Error:
+		return false
+	}
+	return hasTransientMessage(cause.Error())
+}
+
+func hasTransientMessage(msg string) bool {
+	for _, fragment := range transientMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
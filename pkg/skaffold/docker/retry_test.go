@@ -0,0 +1,161 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/google/go-containerregistry/v1/remote"
+	"github.com/pkg/errors"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var tests = []struct {
+		description      string
+		policy           *RetryPolicy
+		errs             []error
+		shouldErr        bool
+		expectedAttempts int
+	}{
+		{
+			description:      "success",
+			policy:           &RetryPolicy{Attempts: 3, InitialDelay: time.Second, MaxDelay: time.Second},
+			expectedAttempts: 1,
+		},
+		{
+			description:      "transient failures",
+			policy:           &RetryPolicy{Attempts: 3, InitialDelay: time.Second, MaxDelay: time.Second},
+			errs:             []error{fmt.Errorf("net/http: TLS handshake timeout"), &jsonmessage.JSONError{Message: "received unexpected HTTP status: 503 Service Unavailable"}},
+			expectedAttempts: 3,
+		},
+		{
+			description:      "attempts exhausted",
+			policy:           &RetryPolicy{Attempts: 2, InitialDelay: time.Second, MaxDelay: time.Second},
+			errs:             []error{io.ErrUnexpectedEOF, io.ErrUnexpectedEOF, io.ErrUnexpectedEOF},
+			shouldErr:        true,
+			expectedAttempts: 2,
+		},
+		{
+			description:      "registry rejection",
+			policy:           &RetryPolicy{Attempts: 3, InitialDelay: time.Second, MaxDelay: time.Second},
+			errs:             []error{errors.Wrap(&remote.Error{Errors: []remote.Diagnostic{{Code: remote.DeniedErrorCode}}}, "push")},
+			shouldErr:        true,
+			expectedAttempts: 1,
+		},
+		{
+			description:      "network failure",
+			policy:           &RetryPolicy{Attempts: 3, InitialDelay: time.Second, MaxDelay: time.Second},
+			errs:             []error{errors.Wrap(&url.Error{Op: "Put", URL: "https://gcr.io", Err: &net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}}, "push")},
+			expectedAttempts: 2,
+		},
+		{
+			description:      "denied by the daemon",
+			policy:           &RetryPolicy{Attempts: 3, InitialDelay: time.Second, MaxDelay: time.Second},
+			errs:             []error{&jsonmessage.JSONError{Message: "denied: requested access to the resource is denied"}},
+			shouldErr:        true,
+			expectedAttempts: 1,
+		},
+		{
+			description:      "unknown error",
+			policy:           &RetryPolicy{Attempts: 3, InitialDelay: time.Second, MaxDelay: time.Second},
+			errs:             []error{fmt.Errorf("no basic auth credentials")},
+			shouldErr:        true,
+			expectedAttempts: 1,
+		},
+		{
+			description:      "nil policy",
+			errs:             []error{io.ErrUnexpectedEOF},
+			shouldErr:        true,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer func(s func(context.Context, time.Duration) error) { sleep = s }(sleep)
+			sleep = func(context.Context, time.Duration) error { return nil }
+
+			attempts := 0
+			err := test.policy.Do(context.Background(), "pushing", func() error {
+				attempts++
+				if attempts <= len(test.errs) {
+					return test.errs[attempts-1]
+				}
+				return nil
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expectedAttempts, attempts)
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := &RetryPolicy{InitialDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	for attempt, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		delay := p.delay(attempt)
+		if delay < max/2 || delay > max {
+			t.Errorf("attempt %d: expected a delay between %s and %s, got %s", attempt, max/2, max, delay)
+		}
+	}
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	var tests = []struct {
+		description string
+		cfg         *v1alpha2.RetryPolicy
+		shouldErr   bool
+		expected    *RetryPolicy
+	}{
+		{
+			description: "defaults",
+			expected:    &RetryPolicy{Attempts: 3, InitialDelay: time.Second, MaxDelay: 30 * time.Second},
+		},
+		{
+			description: "configured",
+			cfg:         &v1alpha2.RetryPolicy{Attempts: 5, InitialDelay: "2s", MaxDelay: "1m"},
+			expected:    &RetryPolicy{Attempts: 5, InitialDelay: 2 * time.Second, MaxDelay: time.Minute},
+		},
+		{
+			description: "negative attempts",
+			cfg:         &v1alpha2.RetryPolicy{Attempts: -1},
+			shouldErr:   true,
+		},
+		{
+			description: "invalid delay",
+			cfg:         &v1alpha2.RetryPolicy{MaxDelay: "soon"},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			policy, err := NewRetryPolicy(test.cfg)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, policy)
+		})
+	}
+}
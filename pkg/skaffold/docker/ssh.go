@@ -0,0 +1,140 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"time"
+
+	"github.com/docker/docker/api"
+	"github.com/moby/moby/client"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// newSSHDockerAPIClient returns a docker client that talks to a remote daemon
+// through `ssh host docker system dial-stdio`.
+func newSSHDockerAPIClient(host string) (DockerAPIClient, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing docker host %s", host)
+	}
+	args, err := sshArgs(u)
+	if err != nil {
+		return nil, err
+	}
+
+	httpclient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialSSH(ctx, args)
+			},
+		},
+		CheckRedirect: client.CheckRedirect,
+	}
+
+	// The host is only used to build request URLs, the actual connection is made by the dialer.
+	cli, err := client.NewClient("http://docker", api.DefaultVersion, httpclient, nil)
+	if err != nil {
+		return nil, err
+	}
+	cli.NegotiateAPIVersion(context.Background())
+
+	return cli, nil
+}
+
+func sshArgs(u *url.URL) ([]string, error) {
+	if u.Scheme != "ssh" {
+		return nil, fmt.Errorf("expected an ssh:// docker host, got %s", u.String())
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("no host specified in %s", u.String())
+	}
+	if u.Path != "" && u.Path != "/" {
+		return nil, fmt.Errorf("extra path after the host: %s", u.Path)
+	}
+
+	var args []string
+	if u.User != nil {
+		args = append(args, "-l", u.User.Username())
+	}
+	if port := u.Port(); port != "" {
+		args = append(args, "-p", port)
+	}
+	args = append(args, "--", u.Hostname(), "docker", "system", "dial-stdio")
+	return args, nil
+}
+
+func dialSSH(ctx context.Context, args []string) (net.Conn, error) {
+	cmd := exec.CommandContext(ctx, "ssh", args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Debugf("Running command: %s", cmd.Args)
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrapf(err, "starting command %v", cmd.Args)
+	}
+
+	return &commandConn{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: stdout,
+	}, nil
+}
+
+// commandConn implements net.Conn on top of the standard streams of a command.
+type commandConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+}
+
+func (c *commandConn) Read(p []byte) (int, error)  { return c.stdout.Read(p) }
+func (c *commandConn) Write(p []byte) (int, error) { return c.stdin.Write(p) }
+
+func (c *commandConn) Close() error {
+	c.stdin.Close()
+	c.stdout.Close()
+	if c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+	c.cmd.Wait()
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr                { return dummyAddr{} }
+func (c *commandConn) RemoteAddr() net.Addr               { return dummyAddr{} }
+func (c *commandConn) SetDeadline(t time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type dummyAddr struct{}
+
+func (dummyAddr) Network() string { return "ssh" }
+func (dummyAddr) String() string  { return "ssh" }
@@ -0,0 +1,66 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestSSHArgs(t *testing.T) {
+	var tests = []struct {
+		description string
+		host        string
+		expected    []string
+		shouldErr   bool
+	}{
+		{
+			description: "host only",
+			host:        "ssh://builder.example.com",
+			expected:    []string{"--", "builder.example.com", "docker", "system", "dial-stdio"},
+		},
+		{
+			description: "user and port",
+			host:        "ssh://me@builder.example.com:2222",
+			expected:    []string{"-l", "me", "-p", "2222", "--", "builder.example.com", "docker", "system", "dial-stdio"},
+		},
+		{
+			description: "not ssh",
+			host:        "tcp://builder.example.com:2376",
+			shouldErr:   true,
+		},
+		{
+			description: "extra path",
+			host:        "ssh://builder.example.com/var/run/docker.sock",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			u, err := url.Parse(test.host)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			args, err := sshArgs(u)
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, args)
+		})
+	}
+}
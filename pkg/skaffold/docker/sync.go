@@ -0,0 +1,222 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/moby/moby/builder/dockerfile/parser"
+	"github.com/moby/moby/builder/dockerfile/shell"
+	"github.com/pkg/errors"
+)
+
+const (
+	run     = "run"
+	workdir = "workdir"
+)
+
+// stage is what a stage of a Dockerfile copies from the workspace.
+type stage struct {
+	name     string
+	copies   map[string]string
+	consumed map[string]bool
+}
+
+// SyncMap infers where the files of the workspace end up in the image,
+// from the COPY and ADD instructions of the stage that's built: the target,
+// or else the last one. Files that a RUN instruction might use, or that are
+// copied to other stages, are left out since changing them needs a rebuild.
+// Keys are paths relative to the workspace, with slashes.
+func SyncMap(workspace string, a *v1alpha2.DockerArtifact) (map[string]string, error) {
+	dockerfile := filepath.Join(workspace, a.DockerfilePath)
+	f, err := os.Open(dockerfile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening dockerfile: %s", dockerfile)
+	}
+	defer f.Close()
+
+	res, err := parser.Parse(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing dockerfile")
+	}
+
+	envs := map[string]string{}
+	for k, v := range a.BuildArgs {
+		if v != nil {
+			envs[k] = *v
+		}
+	}
+
+	var stages []*stage
+	var current *stage
+	workingDir := ""
+	slex := shell.NewLex('\\')
+
+	for _, value := range res.AST.Children {
+		switch value.Value {
+		case from:
+			current = &stage{copies: map[string]string{}, consumed: map[string]bool{}}
+			if as := value.Next.Next; as != nil && strings.EqualFold(as.Value, "as") && as.Next != nil {
+				current.name = as.Next.Value
+			}
+			stages = append(stages, current)
+			// The working directory of the base image isn't known.
+			workingDir = ""
+		case env:
+			envs[value.Next.Value] = value.Next.Next.Value
+		case workdir:
+			dir, err := processShellWord(slex, value.Next.Value, envs)
+			if err != nil {
+				return nil, errors.Wrap(err, "processing word")
+			}
+			switch {
+			case path.IsAbs(dir):
+				workingDir = dir
+			case workingDir != "":
+				workingDir = path.Join(workingDir, dir)
+			}
+		case run:
+			if current != nil {
+				for src := range current.copies {
+					current.consumed[src] = true
+				}
+			}
+		case add, copy:
+			if current == nil || hasMultiStageFlag(value.Flags) {
+				continue
+			}
+			if err := inferCopy(workspace, value, workingDir, envs, current.copies, value.Value == add); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	target := len(stages) - 1
+	for i, s := range stages {
+		if a.Target != "" && strings.EqualFold(s.name, a.Target) {
+			target = i
+		}
+	}
+	if target < 0 {
+		return map[string]string{}, nil
+	}
+
+	syncMap := map[string]string{}
+	for src, dst := range stages[target].copies {
+		if !stages[target].consumed[src] {
+			syncMap[src] = dst
+		}
+	}
+	for _, s := range stages[:target] {
+		for src := range s.copies {
+			delete(syncMap, src)
+		}
+	}
+
+	return syncMap, nil
+}
+
+// inferCopy records where a COPY or ADD instruction puts the files it copies.
+func inferCopy(workspace string, value *parser.Node, workingDir string, envs map[string]string, copies map[string]string, isAdd bool) error {
+	slex := shell.NewLex('\\')
+
+	var words []string
+	for node := value.Next; node != nil && !strings.HasPrefix(node.Value, "#"); node = node.Next {
+		word, err := processShellWord(slex, node.Value, envs)
+		if err != nil {
+			return errors.Wrap(err, "processing word")
+		}
+		words = append(words, word)
+	}
+	if len(words) < 2 {
+		return nil
+	}
+
+	srcs, dest := words[:len(words)-1], words[len(words)-1]
+	if !path.IsAbs(dest) {
+		if workingDir == "" {
+			return nil
+		}
+		dest = path.Join(workingDir, dest)
+	}
+	destIsDir := strings.HasSuffix(words[len(words)-1], "/") || len(srcs) > 1
+
+	for _, src := range srcs {
+		if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+			continue
+		}
+		// ADD extracts local archives, so their files can't be synced.
+		if isAdd && isArchive(src) {
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(workspace, src))
+		if err != nil {
+			return errors.Wrapf(err, "invalid source %s", src)
+		}
+		wildcard := strings.ContainsAny(src, `*?[`)
+
+		for _, match := range matches {
+			err := filepath.Walk(match, func(fpath string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+
+				rel, err := filepath.Rel(workspace, fpath)
+				if err != nil {
+					return err
+				}
+
+				var dst string
+				switch {
+				case fpath != match:
+					// A file in a copied directory keeps its path under the directory.
+					inDir, err := filepath.Rel(match, fpath)
+					if err != nil {
+						return err
+					}
+					dst = path.Join(dest, filepath.ToSlash(inDir))
+				case destIsDir || wildcard:
+					dst = path.Join(dest, filepath.Base(fpath))
+				default:
+					dst = dest
+				}
+
+				copies[filepath.ToSlash(rel)] = dst
+				return nil
+			})
+			if err != nil {
+				return errors.Wrapf(err, "walking %s", match)
+			}
+		}
+	}
+
+	return nil
+}
+
+func isArchive(src string) bool {
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tar.xz", ".txz"} {
+		if strings.HasSuffix(src, ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestSyncMap(t *testing.T) {
+	var tests = []struct {
+		description string
+		dockerfile  string
+		target      string
+		expected    map[string]string
+		shouldErr   bool
+	}{
+		{
+			description: "files and directories",
+			dockerfile: `FROM nginx
+WORKDIR /usr/share
+COPY index.html nginx/html/
+COPY static /srv/static
+COPY app.js /srv/main.js`,
+			expected: map[string]string{
+				"index.html":      "/usr/share/nginx/html/index.html",
+				"static/main.css": "/srv/static/main.css",
+				"static/img/logo": "/srv/static/img/logo",
+				"app.js":          "/srv/main.js",
+			},
+		},
+		{
+			description: "wildcards and env",
+			dockerfile: `FROM nginx
+ENV ROOT /srv
+COPY *.js $ROOT/`,
+			expected: map[string]string{
+				"app.js": "/srv/app.js",
+			},
+		},
+		{
+			description: "files used by a later run are left out",
+			dockerfile: `FROM node
+WORKDIR /app
+COPY package.json .
+RUN npm install
+COPY . .`,
+			expected: map[string]string{
+				"index.html":      "/app/index.html",
+				"app.js":          "/app/app.js",
+				"static/main.css": "/app/static/main.css",
+				"static/img/logo": "/app/static/img/logo",
+			},
+		},
+		{
+			description: "only the last stage",
+			dockerfile: `FROM golang AS builder
+COPY app.js /build/
+FROM nginx
+COPY --from=builder /build /srv
+COPY index.html app.js /srv/`,
+			expected: map[string]string{
+				"index.html": "/srv/index.html",
+			},
+		},
+		{
+			description: "target stage",
+			dockerfile: `FROM nginx AS dev
+COPY index.html /srv/
+FROM nginx
+COPY app.js /srv/`,
+			target: "dev",
+			expected: map[string]string{
+				"index.html": "/srv/index.html",
+			},
+		},
+		{
+			description: "unknown working directory",
+			dockerfile: `FROM nginx
+COPY index.html html/`,
+			expected: map[string]string{},
+		},
+		{
+			description: "remote files and archives",
+			dockerfile: `FROM nginx
+ADD https://example.com/index.html /srv/
+ADD static.tar.gz /srv/`,
+			expected: map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmp, teardown := testutil.TempDir(t)
+			defer teardown()
+
+			files := map[string]string{
+				"Dockerfile":      test.dockerfile,
+				"index.html":      "",
+				"app.js":          "",
+				"static/main.css": "",
+				"static/img/logo": "",
+				"static.tar.gz":   "",
+			}
+			for p, contents := range files {
+				path := filepath.Join(tmp, p)
+				os.MkdirAll(filepath.Dir(path), 0750)
+				ioutil.WriteFile(path, []byte(contents), 0644)
+			}
+
+			syncMap, err := SyncMap(tmp, &v1alpha2.DockerArtifact{
+				DockerfilePath: "Dockerfile",
+				Target:         test.target,
+			})
+
+			// `COPY . .` also copies these.
+			delete(syncMap, "Dockerfile")
+			delete(syncMap, "static.tar.gz")
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, syncMap)
+		})
+	}
+}
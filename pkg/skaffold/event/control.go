@@ -0,0 +1,94 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import "sync"
+
+// Steps of the dev loop that can be requested on demand.
+const (
+	BuildRequest  = "build"
+	DeployRequest = "deploy"
+)
+
+// Controls say which steps of the dev loop run as soon as files change.
+// Changes that aren't handled automatically wait for a request.
+type Controls struct {
+	AutoBuild  bool `json:"autoBuild"`
+	AutoSync   bool `json:"autoSync"`
+	AutoDeploy bool `json:"autoDeploy"`
+}
+
+// controller holds the controls and the pending requests.
+type controller struct {
+	lock     sync.Mutex
+	controls Controls
+	requests chan string
+}
+
+var control = newController()
+
+func newController() *controller {
+	return &controller{
+		controls: Controls{
+			AutoBuild:  true,
+			AutoSync:   true,
+			AutoDeploy: true,
+		},
+		requests: make(chan string, 10),
+	}
+}
+
+func (c *controller) current() Controls {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.controls
+}
+
+// update changes the controls set by the given function.
+func (c *controller) update(change func(*Controls) error) (Controls, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	controls := c.controls
+	if err := change(&controls); err != nil {
+		return c.controls, err
+	}
+	c.controls = controls
+	return controls, nil
+}
+
+// request queues a step. It returns false if too many steps are queued
+// already.
+func (c *controller) request(step string) bool {
+	select {
+	case c.requests <- step:
+		return true
+	default:
+		return false
+	}
+}
+
+// CurrentControls returns which steps of the dev loop run automatically.
+func CurrentControls() Controls {
+	return control.current()
+}
+
+// Requests returns the steps requested through the API.
+func Requests() <-chan string {
+	return control.requests
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+// Types of events.
+const (
+	Build       = "build"
+	Deploy      = "deploy"
+	PortForward = "portForward"
+	Logs        = "logs"
+	Iteration   = "iteration"
+)
+
+// Statuses of builds and deploys.
+const (
+	InProgress = "inProgress"
+	Complete   = "complete"
+	Failed     = "failed"
+)
+
+// maxHistory is how many events are replayed to new subscribers.
+const maxHistory = 1000
+
+// Event is something that happened in a skaffold session.
+type Event struct {
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+
+	// Artifact is the image name of a built artifact.
+	Artifact string `json:"artifact,omitempty"`
+	// Status is the status of a build or a deploy.
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	// Namespace, Pod and Container locate the logs being streamed and the
+	// ports being forwarded.
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	LocalPort  int32 `json:"localPort,omitempty"`
+	RemotePort int32 `json:"remotePort,omitempty"`
+
+	// Timings are the milliseconds spent in each phase of an iteration of
+	// the dev loop, along with the total.
+	Timings map[string]int64 `json:"timings,omitempty"`
+}
+
+// State sums up the events of the session.
+type State struct {
+	Builds       map[string]string `json:"builds"`
+	Deploy       string            `json:"deploy,omitempty"`
+	PortForwards []Event           `json:"portForwards"`
+	// LastIteration holds the timings of the last iteration of the dev loop.
+	LastIteration map[string]int64 `json:"lastIteration,omitempty"`
+}
+
+// handler keeps track of the events and notifies the subscribers.
+type handler struct {
+	lock        sync.Mutex
+	history     []Event
+	state       State
+	subscribers map[chan Event]bool
+}
+
+var events = newHandler()
+
+func newHandler() *handler {
+	return &handler{
+		state:       State{Builds: map[string]string{}},
+		subscribers: map[chan Event]bool{},
+	}
+}
+
+func (h *handler) handle(e Event) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+
+	switch e.Type {
+	case Build:
+		h.state.Builds[e.Artifact] = e.Status
+	case Deploy:
+		h.state.Deploy = e.Status
+	case PortForward:
+		h.state.PortForwards = append(h.state.PortForwards, e)
+	case Iteration:
+		h.state.LastIteration = e.Timings
+	}
+
+	h.history = append(h.history, e)
+	if len(h.history) > maxHistory {
+		h.history = h.history[len(h.history)-maxHistory:]
+	}
+
+	for c := range h.subscribers {
+		select {
+		case c <- e:
+		default:
+			// Slow subscribers miss events rather than block the session.
+		}
+	}
+}
+
+// subscribe returns the past events and a channel for the next ones.
+func (h *handler) subscribe() ([]Event, chan Event) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	c := make(chan Event, 100)
+	h.subscribers[c] = true
+	return append([]Event(nil), h.history...), c
+}
+
+func (h *handler) unsubscribe(c chan Event) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	delete(h.subscribers, c)
+}
+
+func (h *handler) currentState() State {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	state := State{
+		Builds:       map[string]string{},
+		Deploy:       h.state.Deploy,
+		PortForwards: append([]Event{}, h.state.PortForwards...),
+	}
+	if h.state.LastIteration != nil {
+		state.LastIteration = map[string]int64{}
+		for k, v := range h.state.LastIteration {
+			state.LastIteration[k] = v
+		}
+	}
+	for k, v := range h.state.Builds {
+		state.Builds[k] = v
+	}
+	return state
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// BuildInProgress notifies that an artifact started building.
+func BuildInProgress(artifact string) {
+	events.handle(Event{Type: Build, Artifact: artifact, Status: InProgress})
+}
+
+// BuildComplete notifies that an artifact was built.
+func BuildComplete(artifact string) {
+	events.handle(Event{Type: Build, Artifact: artifact, Status: Complete})
+}
+
+// BuildFailed notifies that an artifact failed to build.
+func BuildFailed(artifact string, err error) {
+	events.handle(Event{Type: Build, Artifact: artifact, Status: Failed, Error: errorString(err)})
+}
+
+// DeployInProgress notifies that a deploy started.
+func DeployInProgress() {
+	events.handle(Event{Type: Deploy, Status: InProgress})
+}
+
+// DeployComplete notifies that a deploy is complete.
+func DeployComplete() {
+	events.handle(Event{Type: Deploy, Status: Complete})
+}
+
+// DeployFailed notifies that a deploy failed.
+func DeployFailed(err error) {
+	events.handle(Event{Type: Deploy, Status: Failed, Error: errorString(err)})
+}
+
+// PortForwarded notifies that a container port is forwarded to a local port.
+func PortForwarded(namespace, container string, localPort, remotePort int32) {
+	events.handle(Event{Type: PortForward, Namespace: namespace, Container: container, LocalPort: localPort, RemotePort: remotePort})
+}
+
+// LogsStreamed notifies that the logs of a container are being streamed.
+func LogsStreamed(namespace, pod, container string) {
+	events.handle(Event{Type: Logs, Namespace: namespace, Pod: pod, Container: container})
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestState(t *testing.T) {
+	defer func(h *handler) { events = h }(events)
+	events = newHandler()
+
+	BuildInProgress("web")
+	BuildInProgress("api")
+	BuildComplete("web")
+	BuildFailed("api", fmt.Errorf("syntax error"))
+	DeployInProgress()
+	DeployComplete()
+	PortForwarded("default", "web", 8080, 8080)
+
+	state := events.currentState()
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]string{"web": Complete, "api": Failed}, state.Builds)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, Complete, state.Deploy)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 1, len(state.PortForwards))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, int32(8080), state.PortForwards[0].LocalPort)
+}
+
+func TestStreamEvents(t *testing.T) {
+	defer func(h *handler) { events = h }(events)
+	events = newHandler()
+
+	server := httptest.NewServer(newMux(events, newController()))
+	defer server.Close()
+
+	BuildInProgress("web")
+
+	resp, err := http.Get(server.URL + "/v1/events")
+	testutil.CheckError(t, false, err)
+	defer resp.Body.Close()
+
+	lines := bufio.NewScanner(resp.Body)
+	next := func() Event {
+		var e Event
+		if !lines.Scan() {
+			t.Fatalf("no more events: %v", lines.Err())
+		}
+		if err := json.Unmarshal(lines.Bytes(), &e); err != nil {
+			t.Fatalf("invalid event: %s", err)
+		}
+		return e
+	}
+
+	// Past events first, then the new ones.
+	e := next()
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{Build, "web", InProgress}, []string{e.Type, e.Artifact, e.Status})
+
+	DeployFailed(fmt.Errorf("forbidden"))
+	e = next()
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{Deploy, Failed, "forbidden"}, []string{e.Type, e.Status, e.Error})
+}
+
+func TestGetState(t *testing.T) {
+	defer func(h *handler) { events = h }(events)
+	events = newHandler()
+
+	server := httptest.NewServer(newMux(events, newController()))
+	defer server.Close()
+
+	BuildComplete("web")
+
+	resp, err := http.Get(server.URL + "/v1/state")
+	testutil.CheckError(t, false, err)
+	defer resp.Body.Close()
+
+	var state State
+	err = json.NewDecoder(resp.Body).Decode(&state)
+	testutil.CheckErrorAndDeepEqual(t, false, err, map[string]string{"web": Complete}, state.Builds)
+}
+
+func TestControls(t *testing.T) {
+	c := newController()
+	server := httptest.NewServer(newMux(newHandler(), c))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/v1/controls", strings.NewReader(`{"autoBuild": false}`))
+	testutil.CheckError(t, false, err)
+	resp, err := http.DefaultClient.Do(req)
+	testutil.CheckError(t, false, err)
+	resp.Body.Close()
+
+	expected := Controls{AutoBuild: false, AutoSync: true, AutoDeploy: true}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, expected, c.current())
+
+	resp, err = http.Get(server.URL + "/v1/controls")
+	testutil.CheckError(t, false, err)
+	defer resp.Body.Close()
+
+	var controls Controls
+	err = json.NewDecoder(resp.Body).Decode(&controls)
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, controls)
+
+	req, err = http.NewRequest(http.MethodPut, server.URL+"/v1/controls", strings.NewReader(`{"autoBuild": "no"}`))
+	testutil.CheckError(t, false, err)
+	resp, err = http.DefaultClient.Do(req)
+	testutil.CheckErrorAndDeepEqual(t, false, err, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+	testutil.CheckErrorAndDeepEqual(t, false, nil, expected, c.current())
+}
+
+func TestRequests(t *testing.T) {
+	c := newController()
+	server := httptest.NewServer(newMux(newHandler(), c))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/v1/deploy", "", nil)
+	testutil.CheckErrorAndDeepEqual(t, false, err, http.StatusAccepted, resp.StatusCode)
+	resp.Body.Close()
+	resp, err = http.Get(server.URL + "/v1/build")
+	testutil.CheckErrorAndDeepEqual(t, false, err, http.StatusMethodNotAllowed, resp.StatusCode)
+	resp.Body.Close()
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, DeployRequest, <-c.requests)
+}
+
+func TestRejectBrowsers(t *testing.T) {
+	c := newController()
+	server := httptest.NewServer(newMux(newHandler(), c))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/v1/build", nil)
+	testutil.CheckError(t, false, err)
+	req.Header.Set("Origin", "http://example.com")
+	resp, err := http.DefaultClient.Do(req)
+	testutil.CheckErrorAndDeepEqual(t, false, err, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(c.requests))
+}
+
+func TestTimings(t *testing.T) {
+	defer func(h *handler) { events = h }(events)
+	events = newHandler()
+
+	start := time.Now()
+	timer := &timer{}
+	timer.record(DependenciesPhase, start, start.Add(20*time.Millisecond))
+	timer.record(BuildPhase, start.Add(time.Second), start.Add(3*time.Second))
+	timer.record(BuildPhase, start.Add(3*time.Second), start.Add(4*time.Second))
+	timer.record(DeployPhase, start.Add(4*time.Second), start.Add(5500*time.Millisecond))
+
+	timings := timer.complete(start.Add(6 * time.Second))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "6s (dependencies 20ms, build 3s, deploy 1.5s)", timings.String())
+
+	// The next iteration starts afresh.
+	testutil.CheckErrorAndDeepEqual(t, false, nil, Timings{Phases: map[string]time.Duration{}}, timer.complete(start))
+
+	events.handle(Event{Type: Iteration, Timings: timings.milliseconds()})
+	expected := map[string]int64{"total": 6000, DependenciesPhase: 20, BuildPhase: 3000, DeployPhase: 1500}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, expected, events.currentState().LastIteration)
+}
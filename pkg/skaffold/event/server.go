@@ -0,0 +1,163 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Serve starts the event API on the given address and stops it when the
+// context is done. It returns the address it listens on.
+// GET /v1/events streams the events as json, one per line, starting with the
+// past events. GET /v1/state returns the status of the builds, the deploy
+// and the forwarded ports.
+// GET /v1/controls returns which steps of the dev loop run automatically and
+// PUT /v1/controls changes them, e.g. with {"autoBuild": false}. POST
+// /v1/build and POST /v1/deploy run a step on demand.
+// The API has no authentication: it should listen on localhost, and it
+// rejects the requests sent by browsers so that web pages can't use it.
+func Serve(ctx context.Context, addr string) (string, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", errors.Wrapf(err, "listening on %s", addr)
+	}
+	if tcpAddr, ok := l.Addr().(*net.TCPAddr); ok && !tcpAddr.IP.IsLoopback() {
+		logrus.Warnf("The event API listens on %s, anyone who can reach it can trigger builds and deploys. Use localhost:<port> instead.", l.Addr())
+	}
+
+	server := &http.Server{Handler: newMux(events, control)}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	go func() {
+		if err := server.Serve(l); err != nil && err != http.ErrServerClosed {
+			logrus.Warnf("event API: %s", err)
+		}
+	}()
+
+	return l.Addr().String(), nil
+}
+
+func newMux(h *handler, c *controller) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/events", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		streamEvents(r.Context(), h, w)
+	})
+	mux.HandleFunc("/v1/state", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(h.currentState())
+	})
+	mux.HandleFunc("/v1/controls", func(w http.ResponseWriter, r *http.Request) {
+		var controls Controls
+		switch r.Method {
+		case http.MethodGet:
+			controls = c.current()
+		case http.MethodPut:
+			var err error
+			controls, err = c.update(func(controls *Controls) error {
+				// Fields missing from the body are left unchanged.
+				return json.NewDecoder(r.Body).Decode(controls)
+			})
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid controls: %s", err), http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(controls)
+	})
+	for _, step := range []string{BuildRequest, DeployRequest} {
+		step := step
+		mux.HandleFunc("/v1/"+step, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if !c.request(step) {
+				http.Error(w, "too many pending requests", http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		})
+	}
+	return rejectBrowsers(mux)
+}
+
+// rejectBrowsers refuses the requests that carry an Origin header. Browsers
+// send it along with the requests that web pages make, even to localhost,
+// while the IDEs and tools using the API don't.
+func rejectBrowsers(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") != "" {
+			http.Error(w, "requests from browsers are not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func streamEvents(ctx context.Context, h *handler, w http.ResponseWriter) {
+	past, next := h.subscribe()
+	defer h.unsubscribe(next)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	for _, e := range past {
+		if err := encoder.Encode(e); err != nil {
+			return
+		}
+	}
+	flush()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-next:
+			if err := encoder.Encode(e); err != nil {
+				return
+			}
+			flush()
+		}
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phases of a dev loop iteration. The build phase covers the whole build
+// step, of which context, tag and push are the parts done by the local
+// builder. The time of each phase is summed over the artifacts.
+const (
+	DependenciesPhase = "dependencies"
+	SyncPhase         = "sync"
+	BuildPhase        = "build"
+	ContextPhase      = "context"
+	TagPhase          = "tag"
+	PushPhase         = "push"
+	DeployPhase       = "deploy"
+)
+
+// phases are the phases in the order they're reported.
+var phases = []string{DependenciesPhase, SyncPhase, BuildPhase, ContextPhase, TagPhase, PushPhase, DeployPhase}
+
+// Timings is how long an iteration of the dev loop took.
+type Timings struct {
+	Total  time.Duration
+	Phases map[string]time.Duration
+}
+
+// String lists the time spent in each phase.
+func (t Timings) String() string {
+	var parts []string
+	for _, phase := range phases {
+		if d, present := t.Phases[phase]; present {
+			parts = append(parts, fmt.Sprintf("%s %s", phase, d.Round(time.Millisecond)))
+		}
+	}
+	return fmt.Sprintf("%s (%s)", t.Total.Round(time.Millisecond), strings.Join(parts, ", "))
+}
+
+// milliseconds converts the timings for the events.
+func (t Timings) milliseconds() map[string]int64 {
+	ms := map[string]int64{"total": int64(t.Total / time.Millisecond)}
+	for phase, d := range t.Phases {
+		ms[phase] = int64(d / time.Millisecond)
+	}
+	return ms
+}
+
+// timer adds up the time spent in each phase of the current iteration.
+// The iteration starts with its first phase.
+type timer struct {
+	lock   sync.Mutex
+	start  time.Time
+	phases map[string]time.Duration
+}
+
+var timings = &timer{}
+
+func (t *timer) record(phase string, start time.Time, end time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.start.IsZero() || start.Before(t.start) {
+		t.start = start
+	}
+	if t.phases == nil {
+		t.phases = map[string]time.Duration{}
+	}
+	t.phases[phase] += end.Sub(start)
+}
+
+func (t *timer) complete(end time.Time) Timings {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	timings := Timings{Phases: map[string]time.Duration{}}
+	if !t.start.IsZero() {
+		timings.Total = end.Sub(t.start)
+	}
+	for phase, d := range t.phases {
+		timings.Phases[phase] = d
+	}
+
+	t.start = time.Time{}
+	t.phases = nil
+	return timings
+}
+
+// TimePhase adds the time elapsed since start to a phase of the current
+// iteration. It's meant to be deferred: defer event.TimePhase(phase, time.Now())
+func TimePhase(phase string, start time.Time) {
+	timings.record(phase, start, time.Now())
+}
+
+// IterationComplete ends the current iteration of the dev loop, notifies
+// its timings and returns them.
+func IterationComplete() Timings {
+	t := timings.complete(time.Now())
+	events.handle(Event{Type: Iteration, Timings: t.milliseconds()})
+	return t
+}
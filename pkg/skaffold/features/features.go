@@ -0,0 +1,141 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/warnings"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Sources of the state of a feature flag, from the lowest to the highest precedence.
+const (
+	SourceDefault = "default"
+	SourceConfig  = "config"
+	SourceEnv     = "env"
+)
+
+// Flag switches an experimental subsystem on or off.
+type Flag struct {
+	Name        string
+	Description string
+	Default     bool
+
+	enabled bool
+	source  string
+}
+
+// Experimental subsystems.
+var (
+	KanikoLocalContext = register("kaniko-local-context", "Copy the build context into the kaniko pod instead of uploading it to a bucket", true)
+	ParallelBuilds     = register("parallel-builds", "Build several artifacts at the same time when build.concurrency is above 1", true)
+	S2IOnCluster       = register("s2i-on-cluster", "Build s2i artifacts with kaniko, from the Dockerfile generated by s2i", true)
+)
+
+var flags []*Flag
+
+func register(name, description string, enabled bool) *Flag {
+	f := &Flag{
+		Name:        name,
+		Description: description,
+		Default:     enabled,
+		enabled:     enabled,
+		source:      SourceDefault,
+	}
+	flags = append(flags, f)
+	return f
+}
+
+// All returns the feature flags, in the order they were registered.
+func All() []*Flag {
+	return flags
+}
+
+// Enabled tells whether the feature is switched on.
+func (f *Flag) Enabled() bool {
+	return f.enabled
+}
+
+// Source tells where the state of the flag comes from.
+func (f *Flag) Source() string {
+	return f.source
+}
+
+// Disabled returns the error that reports a feature being used while it's switched off.
+func (f *Flag) Disabled() error {
+	return fmt.Errorf("%s is experimental: enable it with %s=%s", f.Name, EnvVar, f.Name)
+}
+
+// EnvVar lists flags to enable, or to disable when prefixed with a `-`.
+const EnvVar = "SKAFFOLD_EXPERIMENTAL"
+
+// globalConfig is the part of the global configuration file that holds feature flags.
+type globalConfig struct {
+	Experimental map[string]bool `yaml:"experimental"`
+}
+
+// Load sets the state of the flags from the global configuration file,
+// then from the value of the environment variable. A missing file is ignored
+// and unknown flags, that other versions of skaffold might know, are skipped
+// with a warning.
+func Load(configFile, env string) error {
+	path, err := homedir.Expand(configFile)
+	if err != nil {
+		return errors.Wrapf(err, "expanding %s", configFile)
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "reading %s", path)
+	}
+
+	var cfg globalConfig
+	if err := yaml.Unmarshal(buf, &cfg); err != nil {
+		return errors.Wrapf(err, "parsing %s", path)
+	}
+	for name, enabled := range cfg.Experimental {
+		set(name, enabled, SourceConfig, path)
+	}
+
+	for _, name := range strings.Split(env, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		enabled := !strings.HasPrefix(name, "-")
+		set(strings.TrimPrefix(name, "-"), enabled, SourceEnv, EnvVar)
+	}
+
+	return nil
+}
+
+func set(name string, enabled bool, source, origin string) {
+	for _, f := range flags {
+		if f.Name == name {
+			f.enabled = enabled
+			f.source = source
+			return
+		}
+	}
+	warnings.Warnf(warnings.UnsupportedOption, "Ignoring unknown feature flag %s from %s", name, origin)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func reset() {
+	for _, f := range flags {
+		f.enabled = f.Default
+		f.source = SourceDefault
+	}
+}
+
+func TestLoad(t *testing.T) {
+	var tests = []struct {
+		description string
+		config      string
+		env         string
+		shouldErr   bool
+		expected    map[string]string
+	}{
+		{
+			description: "defaults",
+			expected: map[string]string{
+				"kaniko-local-context": "true/default",
+				"parallel-builds":      "true/default",
+				"s2i-on-cluster":       "true/default",
+			},
+		},
+		{
+			description: "config file",
+			config:      "experimental:\n  parallel-builds: false\n  s2i-on-cluster: false\n",
+			expected: map[string]string{
+				"kaniko-local-context": "true/default",
+				"parallel-builds":      "false/config",
+				"s2i-on-cluster":       "false/config",
+			},
+		},
+		{
+			description: "env overrides config file",
+			config:      "experimental:\n  s2i-on-cluster: false\n",
+			env:         "-kaniko-local-context, s2i-on-cluster",
+			expected: map[string]string{
+				"kaniko-local-context": "false/env",
+				"parallel-builds":      "true/default",
+				"s2i-on-cluster":       "true/env",
+			},
+		},
+		{
+			description: "unknown flags are skipped",
+			config:      "experimental:\n  time-travel: true\n",
+			env:         "-parallel-builds, teleport",
+			expected: map[string]string{
+				"kaniko-local-context": "true/default",
+				"parallel-builds":      "false/env",
+				"s2i-on-cluster":       "true/default",
+			},
+		},
+		{
+			description: "invalid config file",
+			config:      "experimental: [",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer reset()
+			dir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+			configFile := filepath.Join(dir, "config")
+			if test.config != "" {
+				ioutil.WriteFile(configFile, []byte(test.config), 0644)
+			}
+
+			err := Load(configFile, test.env)
+
+			var states map[string]string
+			if err == nil {
+				states = map[string]string{}
+				for _, f := range All() {
+					states[f.Name] = fmt.Sprintf("%t/%s", f.Enabled(), f.Source())
+				}
+			}
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, states)
+		})
+	}
+}
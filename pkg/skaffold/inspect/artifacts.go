@@ -0,0 +1,129 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspect
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+)
+
+// ArtifactInfo describes what skaffold will do to build an artifact.
+type ArtifactInfo struct {
+	ImageName    string   `json:"imageName"`
+	Workspace    string   `json:"workspace"`
+	Type         string   `json:"type"`
+	Builder      string   `json:"builder"`
+	TagPolicy    string   `json:"tagPolicy"`
+	Dependencies []string `json:"dependencies"`
+	ContextSize  int64    `json:"contextSize"`
+}
+
+// Artifacts resolves the builder, tag policy and dependencies of every artifact
+// in a build config, without building anything.
+func Artifacts(cfg *v1alpha2.BuildConfig) ([]ArtifactInfo, error) {
+	infos := []ArtifactInfo{}
+	for _, a := range cfg.Artifacts {
+		deps, err := build.GetDependenciesForArtifact(a)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting dependencies for %s", a.ImageName)
+		}
+
+		size, err := contextSize(a.Workspace, deps)
+		if err != nil {
+			return nil, errors.Wrapf(err, "estimating context size for %s", a.ImageName)
+		}
+
+		buildType := cfg.BuildType
+		if a.Builder != nil {
+			buildType = *a.Builder
+		}
+
+		infos = append(infos, ArtifactInfo{
+			ImageName:    a.ImageName,
+			Workspace:    a.Workspace,
+			Type:         artifactType(a),
+			Builder:      builderName(buildType),
+			TagPolicy:    tagPolicyName(cfg.TagPolicy),
+			Dependencies: deps,
+			ContextSize:  size,
+		})
+	}
+
+	return infos, nil
+}
+
+// contextSize sums the sizes of the dependencies that would be sent as build context.
+func contextSize(workspace string, deps []string) (int64, error) {
+	var size int64
+	for _, dep := range deps {
+		fi, err := os.Stat(filepath.Join(workspace, dep))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, err
+		}
+		if !fi.IsDir() {
+			size += fi.Size()
+		}
+	}
+	return size, nil
+}
+
+func artifactType(a *v1alpha2.Artifact) string {
+	switch {
+	case a.DockerArtifact != nil:
+		return "docker"
+	case a.BazelArtifact != nil:
+		return "bazel"
+	case a.S2IArtifact != nil:
+		return "s2i"
+	}
+	return "unknown"
+}
+
+func builderName(b v1alpha2.BuildType) string {
+	switch {
+	case b.LocalBuild != nil:
+		return "local"
+	case b.GoogleCloudBuild != nil:
+		return "googleCloudBuild"
+	case b.KanikoBuild != nil:
+		return "kaniko"
+	}
+	return "unknown"
+}
+
+func tagPolicyName(t v1alpha2.TagPolicy) string {
+	switch {
+	case t.EnvTemplateTagger != nil:
+		return "envTemplate"
+	case t.CustomCommandTagger != nil:
+		return "customCommand"
+	case t.InputDigest != nil:
+		return "inputDigest"
+	case t.ShaTagger != nil:
+		return "sha256"
+	case t.GitTagger != nil:
+		return "gitCommit"
+	}
+	return "unknown"
+}
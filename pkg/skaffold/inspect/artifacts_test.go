@@ -0,0 +1,87 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspect
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+type fakeDependencyResolver struct {
+	deps []string
+}
+
+func (f *fakeDependencyResolver) GetDependencies(a *v1alpha2.Artifact) ([]string, error) {
+	return f.deps, nil
+}
+
+func TestArtifacts(t *testing.T) {
+	defer func(r build.DependencyResolver) { build.DefaultDockerfileDepResolver = r }(build.DefaultDockerfileDepResolver)
+	build.DefaultDockerfileDepResolver = &fakeDependencyResolver{deps: []string{"Dockerfile", "files/missing.txt"}}
+
+	cfg := &v1alpha2.BuildConfig{
+		Artifacts: []*v1alpha2.Artifact{
+			{
+				ImageName: "image",
+				Workspace: "../../../testdata/docker",
+				ArtifactType: v1alpha2.ArtifactType{
+					DockerArtifact: &v1alpha2.DockerArtifact{},
+				},
+			},
+			{
+				ImageName: "local-image",
+				Workspace: "../../../testdata/docker",
+				Builder: &v1alpha2.BuildType{
+					LocalBuild: &v1alpha2.LocalBuild{},
+				},
+				ArtifactType: v1alpha2.ArtifactType{
+					DockerArtifact: &v1alpha2.DockerArtifact{},
+				},
+			},
+		},
+		TagPolicy: v1alpha2.TagPolicy{ShaTagger: &v1alpha2.ShaTagger{}},
+		BuildType: v1alpha2.BuildType{
+			KanikoBuild: &v1alpha2.KanikoBuild{},
+		},
+	}
+
+	infos, err := Artifacts(cfg)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []ArtifactInfo{
+		{
+			ImageName:    "image",
+			Workspace:    "../../../testdata/docker",
+			Type:         "docker",
+			Builder:      "kaniko",
+			TagPolicy:    "sha256",
+			Dependencies: []string{"Dockerfile", "files/missing.txt"},
+			ContextSize:  31,
+		},
+		{
+			ImageName:    "local-image",
+			Workspace:    "../../../testdata/docker",
+			Type:         "docker",
+			Builder:      "local",
+			TagPolicy:    "sha256",
+			Dependencies: []string{"Dockerfile", "files/missing.txt"},
+			ContextSize:  31,
+		},
+	}, infos)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspect
+
+import (
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/docker/distribution/reference"
+	"github.com/pkg/errors"
+)
+
+// How an image reference is pinned.
+const (
+	PinnedByDigest = "digest"
+	PinnedByTag    = "tag"
+	NotPinned      = "none"
+	InvalidImage   = "invalid"
+)
+
+// ImageInfo describes an image that the deployed manifests or charts refer to.
+type ImageInfo struct {
+	Image    string `json:"image"`
+	Source   string `json:"source"`
+	Artifact string `json:"artifact,omitempty"`
+	Pinning  string `json:"pinning"`
+}
+
+var chartImages = deploy.ChartImages
+
+// Images lists the image references of the kubectl manifests and of the
+// rendered helm charts, with the artifact that builds each image, if any.
+func Images(cfg *v1alpha2.SkaffoldConfig) ([]ImageInfo, error) {
+	var refs []deploy.ImageReference
+	if cfg.Deploy.KubectlDeploy != nil {
+		manifestRefs, err := deploy.ManifestImages(cfg.Deploy.KubectlDeploy.Manifests)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading manifests")
+		}
+		refs = append(refs, manifestRefs...)
+	}
+	if cfg.Deploy.HelmDeploy != nil {
+		for _, release := range cfg.Deploy.HelmDeploy.Releases {
+			chartRefs, err := chartImages(release, cfg.Deploy.HelmDeploy.HelmVersion)
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading chart of release %s", release.Name)
+			}
+			refs = append(refs, chartRefs...)
+		}
+	}
+
+	artifacts := map[string]bool{}
+	for _, a := range cfg.Build.Artifacts {
+		artifacts[a.ImageName] = true
+	}
+
+	infos := []ImageInfo{}
+	for _, ref := range refs {
+		info := ImageInfo{
+			Image:   ref.Image,
+			Source:  ref.Source,
+			Pinning: pinning(ref.Image),
+		}
+		if name := baseName(ref.Image); artifacts[name] {
+			info.Artifact = name
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func pinning(image string) string {
+	r, err := reference.Parse(image)
+	if err != nil {
+		return InvalidImage
+	}
+	if _, digested := r.(reference.Digested); digested {
+		return PinnedByDigest
+	}
+	if tagged, isTagged := r.(reference.Tagged); isTagged && tagged.Tag() != "latest" {
+		return PinnedByTag
+	}
+	return NotPinned
+}
+
+func baseName(image string) string {
+	r, err := reference.Parse(image)
+	if err != nil {
+		return image
+	}
+	if named, isNamed := r.(reference.Named); isNamed {
+		return named.Name()
+	}
+	return image
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspect
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestImages(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	manifest := filepath.Join(dir, "pod.yaml")
+	ioutil.WriteFile(manifest, []byte(`apiVersion: v1
+kind: Pod
+spec:
+  containers:
+  - image: gcr.io/project/web
+  - image: redis:4.0
+  - image: envoyproxy/envoy@sha256:3a47c0c61cb6d4a5a0c3ca8b14b8c2e1b1a6f2d6a6c2a36b4b5a5ac2a9f58a0b`), 0644)
+
+	defer func(f func(v1alpha2.HelmRelease, string) ([]deploy.ImageReference, error)) { chartImages = f }(chartImages)
+	chartImages = func(r v1alpha2.HelmRelease, _ string) ([]deploy.ImageReference, error) {
+		return []deploy.ImageReference{{Image: "gcr.io/project/api:latest", Source: "helm release " + r.Name}}, nil
+	}
+
+	infos, err := Images(&v1alpha2.SkaffoldConfig{
+		Build: v1alpha2.BuildConfig{
+			Artifacts: []*v1alpha2.Artifact{
+				{ImageName: "gcr.io/project/web"},
+				{ImageName: "gcr.io/project/api"},
+			},
+		},
+		Deploy: v1alpha2.DeployConfig{
+			DeployType: v1alpha2.DeployType{
+				KubectlDeploy: &v1alpha2.KubectlDeploy{Manifests: []string{manifest}},
+				HelmDeploy:    &v1alpha2.HelmDeploy{Releases: []v1alpha2.HelmRelease{{Name: "api"}}},
+			},
+		},
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []ImageInfo{
+		{Image: "gcr.io/project/web", Source: manifest, Artifact: "gcr.io/project/web", Pinning: NotPinned},
+		{Image: "redis:4.0", Source: manifest, Pinning: PinnedByTag},
+		{Image: "envoyproxy/envoy@sha256:3a47c0c61cb6d4a5a0c3ca8b14b8c2e1b1a6f2d6a6c2a36b4b5a5ac2a9f58a0b", Source: manifest, Pinning: PinnedByDigest},
+		{Image: "gcr.io/project/api:latest", Source: "helm release api", Artifact: "gcr.io/project/api", Pinning: NotPinned},
+	}, infos)
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspect
+
+import (
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+)
+
+// PlaceholderDigest stands for the digest of an image that isn't built yet.
+const PlaceholderDigest = "sha256:<digest>"
+
+// TagInfo is the tag an artifact would be given if it was built now.
+type TagInfo struct {
+	ImageName string `json:"imageName"`
+	Tag       string `json:"tag"`
+}
+
+// Tags evaluates the tagger for every artifact without building them.
+// Digests aren't known before the build so they are replaced with PlaceholderDigest.
+func Tags(artifacts []*v1alpha2.Artifact, tagger tag.Tagger) ([]TagInfo, error) {
+	infos := []TagInfo{}
+	for _, a := range artifacts {
+		t, err := tagger.GenerateFullyQualifiedImageName(a.Workspace, &tag.TagOptions{
+			ImageName: a.ImageName,
+			Digest:    PlaceholderDigest,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "generating tag for %s", a.ImageName)
+		}
+
+		infos = append(infos, TagInfo{
+			ImageName: a.ImageName,
+			Tag:       t,
+		})
+	}
+
+	return infos, nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspect
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestTags(t *testing.T) {
+	envTemplate, err := tag.NewEnvTemplateTagger("{{.IMAGE_NAME}}:{{.DIGEST_HEX}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		description string
+		tagger      tag.Tagger
+		expected    []TagInfo
+	}{
+		{
+			description: "sha256",
+			tagger:      &tag.ChecksumTagger{},
+			expected:    []TagInfo{{ImageName: "gcr.io/project/app", Tag: "gcr.io/project/app:<digest>"}},
+		},
+		{
+			description: "custom tag",
+			tagger:      &tag.CustomTag{Tag: "v1"},
+			expected:    []TagInfo{{ImageName: "gcr.io/project/app", Tag: "gcr.io/project/app:v1"}},
+		},
+		{
+			description: "env template",
+			tagger:      envTemplate,
+			expected:    []TagInfo{{ImageName: "gcr.io/project/app", Tag: "gcr.io/project/app:<digest>"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tags, err := Tags([]*v1alpha2.Artifact{{ImageName: "gcr.io/project/app"}}, test.tagger)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, tags)
+		})
+	}
+}
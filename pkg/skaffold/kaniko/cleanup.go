@@ -0,0 +1,48 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// RunLabel marks the kaniko pods created by a skaffold process, so that
+// Cleanup leaves those of other users and sessions alone.
+const RunLabel = "skaffold.dev/kaniko-run"
+
+var runID = util.RandomID()
+
+// Cleanup deletes the pods that interrupted kaniko builds of this process
+// left in a namespace.
+func Cleanup(client corev1.CoreV1Interface, namespace string) error {
+	pods, err := client.Pods(namespace).List(metav1.ListOptions{LabelSelector: RunLabel + "=" + runID})
+	if err != nil {
+		return errors.Wrap(err, "listing kaniko pods")
+	}
+	for _, p := range pods.Items {
+		if err := client.Pods(namespace).Delete(p.Name, &metav1.DeleteOptions{
+			GracePeriodSeconds: new(int64),
+		}); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "deleting pod %s", p.Name)
+		}
+	}
+	return nil
+}
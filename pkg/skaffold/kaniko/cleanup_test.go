@@ -0,0 +1,61 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCleanup(t *testing.T) {
+	ours := map[string]string{"kaniko": "kaniko", RunLabel: runID}
+	theirs := map[string]string{"kaniko": "kaniko", RunLabel: "other-session"}
+
+	client := fake.NewSimpleClientset().CoreV1()
+	client.Pods("builds").Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "kaniko", Labels: ours}})
+	client.Pods("builds").Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "kaniko-other", Labels: theirs}})
+	client.Pods("builds").Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "kaniko-old", Labels: map[string]string{"kaniko": "kaniko"}}})
+	client.Pods("builds").Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web"}})
+	client.Pods("default").Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "kaniko", Labels: ours}})
+	client.Secrets("builds").Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "kaniko-secret"}})
+
+	err := Cleanup(client, "builds")
+	testutil.CheckError(t, false, err)
+
+	names := func(namespace string) []string {
+		var names []string
+		pods, _ := client.Pods(namespace).List(metav1.ListOptions{})
+		for _, p := range pods.Items {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"kaniko-other", "kaniko-old", "web"}, names("builds"))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"kaniko"}, names("default"))
+
+	// The secret holds the user's credentials.
+	_, err = client.Secrets("builds").Get("kaniko-secret", metav1.GetOptions{})
+	testutil.CheckError(t, false, err)
+
+	// Nothing left to clean up
+	err = Cleanup(client, "builds")
+	testutil.CheckError(t, false, err)
+}
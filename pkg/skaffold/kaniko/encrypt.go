@@ -0,0 +1,118 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	cstorage "cloud.google.com/go/storage"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/pkg/errors"
+)
+
+const (
+	saltHeader = "Salted__"
+	saltSize   = 8
+	keySize    = 32
+)
+
+// decryptCommand is run by the init container to fetch and decrypt the context.
+// It mirrors the format produced by encryptContext.
+const decryptCommand = `gcloud auth activate-service-account --key-file=/secret/kaniko-secret && \
+gsutil cp gs://%s/%s /tmp/context.enc && \
+openssl enc -d -aes-256-cbc -md sha256 -pass file:/key/key -in /tmp/context.enc | tar -xzf - -C %s`
+
+// uploadEncryptedContext creates the build context, encrypts it with the given key
+// and uploads the result to a GCS bucket.
+func uploadEncryptedContext(ctx context.Context, dockerfilePath, workspace, bucket, objectName string, key []byte) error {
+	var buf bytes.Buffer
+	if err := docker.CreateDockerTarGzContext(&buf, dockerfilePath, workspace); err != nil {
+		return errors.Wrap(err, "creating context")
+	}
+
+	c, err := cstorage.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	w := c.Bucket(bucket).Object(objectName).NewWriter(ctx)
+	if err := encryptContext(w, &buf, key); err != nil {
+		return errors.Wrap(err, "uploading encrypted context to google storage")
+	}
+	return w.Close()
+}
+
+// encryptContext encrypts r into w using AES-256-CBC. The output is compatible
+// with `openssl enc -d -aes-256-cbc -md sha256` so that it can be decrypted
+// in the cluster with stock tools.
+func encryptContext(w io.Writer, r io.Reader, passphrase []byte) error {
+	plaintext, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "reading context")
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return errors.Wrap(err, "generating salt")
+	}
+
+	key, iv := deriveKey(passphrase, salt)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return errors.Wrap(err, "creating cipher")
+	}
+
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	plaintext = append(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+
+	for _, b := range [][]byte{[]byte(saltHeader), salt, ciphertext} {
+		if _, err := w.Write(b); err != nil {
+			return errors.Wrap(err, "writing encrypted context")
+		}
+	}
+	return nil
+}
+
+// deriveKey implements openssl's EVP_BytesToKey with sha256 and a single iteration.
+func deriveKey(passphrase, salt []byte) ([]byte, []byte) {
+	var derived, prev []byte
+	for len(derived) < keySize+aes.BlockSize {
+		h := sha256.New()
+		h.Write(prev)
+		h.Write(passphrase)
+		h.Write(salt)
+		prev = h.Sum(nil)
+		derived = append(derived, prev...)
+	}
+	return derived[:keySize], derived[keySize : keySize+aes.BlockSize]
+}
+
+func decryptScript(bucket, objectName, contextDir string) string {
+	return fmt.Sprintf(decryptCommand, bucket, objectName, contextDir)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func decrypt(t *testing.T, encrypted, passphrase []byte) []byte {
+	if !bytes.HasPrefix(encrypted, []byte(saltHeader)) {
+		t.Fatalf("missing salt header")
+	}
+	salt := encrypted[len(saltHeader) : len(saltHeader)+saltSize]
+	ciphertext := encrypted[len(saltHeader)+saltSize:]
+
+	key, iv := deriveKey(passphrase, salt)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+	padding := int(plaintext[len(plaintext)-1])
+	return plaintext[:len(plaintext)-padding]
+}
+
+func TestEncryptContext(t *testing.T) {
+	var tests = []struct {
+		description string
+		content     string
+	}{
+		{
+			description: "empty context",
+			content:     "",
+		},
+		{
+			description: "block aligned context",
+			content:     "0123456789abcdef",
+		},
+		{
+			description: "unaligned context",
+			content:     "some tarball content",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var encrypted bytes.Buffer
+			err := encryptContext(&encrypted, bytes.NewBufferString(test.content), []byte("secret"))
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.content, string(decrypt(t, encrypted.Bytes(), []byte("secret"))))
+		})
+	}
+}
+
+func TestEncryptionKey(t *testing.T) {
+	var tests = []struct {
+		description string
+		key         string
+		shouldErr   bool
+		expected    string
+	}{
+		{
+			description: "key",
+			key:         "secret",
+			expected:    "secret",
+		},
+		{
+			description: "trailing newline",
+			key:         "secret\n",
+			expected:    "secret",
+		},
+		{
+			description: "several lines",
+			key:         "secret\nother",
+			shouldErr:   true,
+		},
+		{
+			description: "empty key",
+			key:         "\n",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			client := fake.NewSimpleClientset(&v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "context-key", Namespace: "builds"},
+				Data:       map[string][]byte{"key": []byte(test.key)},
+			})
+
+			key, err := encryptionKey(client, "builds", "context-key")
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, string(key))
+		})
+	}
+}
+
+func TestAddDecryptInitContainer(t *testing.T) {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name: "kaniko",
+					Args: []string{"--dockerfile=Dockerfile", "--bucket=bucket"},
+				},
+			},
+		},
+	}
+
+	addDecryptInitContainer(pod, &v1alpha2.KanikoBuild{
+		GCSBucket: "bucket",
+		ContextEncryption: &v1alpha2.ContextEncryption{
+			KeySecret: "context-key",
+		},
+	}, "context.tar.gz.enc")
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"--dockerfile=Dockerfile", "--context=/kaniko/buildcontext"}, pod.Spec.Containers[0].Args)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 1, len(pod.Spec.InitContainers))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "google/cloud-sdk:alpine", pod.Spec.InitContainers[0].Image)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "context-key", pod.Spec.Volumes[1].Secret.SecretName)
+}
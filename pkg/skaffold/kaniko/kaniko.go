@@ -17,19 +17,34 @@ limitations under the License.
 package kaniko
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/features"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/warnings"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgo "k8s.io/client-go/kubernetes"
+)
+
+const (
+	// contextDir is where the decrypted build context is extracted in the kaniko pod.
+	contextDir = "/kaniko/buildcontext"
+
+	encryptionKeyField = "key"
 )
 
 func RunKanikoBuild(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact, cfg *v1alpha2.KanikoBuild) (string, error) {
@@ -37,14 +52,37 @@ func RunKanikoBuild(ctx context.Context, out io.Writer, artifact *v1alpha2.Artif
 
 	initialTag := util.RandomID()
 	tarName := "context.tar.gz" // TODO(r2d4): until this is configurable upstream
-	if err := docker.UploadContextToGCS(ctx, dockerfilePath, artifact.Workspace, cfg.GCSBucket, tarName); err != nil {
-		return "", errors.Wrap(err, "uploading tar to gcs")
-	}
 	client, err := kubernetes.GetClientset()
 	if err != nil {
 		return "", errors.Wrap(err, "")
 	}
 
+	localContext := cfg.ContextType == constants.KanikoContextLocal
+	if localContext && !features.KanikoLocalContext.Enabled() {
+		return "", features.KanikoLocalContext.Disabled()
+	}
+	if localContext && cfg.ContextEncryption != nil {
+		return "", fmt.Errorf("context encryption is only supported with a %s context", constants.KanikoContextGCS)
+	}
+
+	switch {
+	case localContext:
+		// The context is copied into the pod once it's created.
+	case cfg.ContextEncryption != nil:
+		tarName = "context.tar.gz.enc"
+		key, err := encryptionKey(client, cfg.Namespace, cfg.ContextEncryption.KeySecret)
+		if err != nil {
+			return "", errors.Wrap(err, "getting context encryption key")
+		}
+		if err := uploadEncryptedContext(ctx, dockerfilePath, artifact.Workspace, cfg.GCSBucket, tarName, key); err != nil {
+			return "", errors.Wrap(err, "uploading encrypted tar to gcs")
+		}
+	default:
+		if err := docker.UploadContextToGCS(ctx, dockerfilePath, artifact.Workspace, cfg.GCSBucket, tarName); err != nil {
+			return "", errors.Wrap(err, "uploading tar to gcs")
+		}
+	}
+
 	imageList := kubernetes.NewImageList()
 	imageList.AddImage(constants.DefaultKanikoImage)
 
@@ -53,10 +91,16 @@ func RunKanikoBuild(ctx context.Context, out io.Writer, artifact *v1alpha2.Artif
 		return "", errors.Wrap(err, "starting log streamer")
 	}
 	imageDst := fmt.Sprintf("%s:%s", artifact.ImageName, initialTag)
-	p, err := client.CoreV1().Pods("default").Create(&v1.Pod{
+	resources, err := resourceRequirements(cfg.Resources)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing kaniko resources")
+	}
+
+	pod := &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   "kaniko",
-			Labels: map[string]string{"kaniko": "kaniko"},
+			Name:      "kaniko",
+			Namespace: cfg.Namespace,
+			Labels:    map[string]string{"kaniko": "kaniko", RunLabel: runID},
 		},
 		Spec: v1.PodSpec{
 			Containers: []v1.Container{
@@ -64,12 +108,8 @@ func RunKanikoBuild(ctx context.Context, out io.Writer, artifact *v1alpha2.Artif
 					Name:            "kaniko",
 					Image:           constants.DefaultKanikoImage,
 					ImagePullPolicy: v1.PullIfNotPresent,
-					Args: []string{
-						fmt.Sprintf("--dockerfile=%s", dockerfilePath),
-						fmt.Sprintf("--bucket=%s", cfg.GCSBucket),
-						fmt.Sprintf("--destination=%s", imageDst),
-						fmt.Sprintf("-v=%s", logrus.GetLevel().String()),
-					},
+					Args:            kanikoArgs(artifact.DockerArtifact, cfg, imageDst),
+					Resources:       resources,
 					VolumeMounts: []v1.VolumeMount{
 						{
 							Name:      "kaniko-secret",
@@ -94,26 +134,215 @@ func RunKanikoBuild(ctx context.Context, out io.Writer, artifact *v1alpha2.Artif
 					},
 				},
 			},
+			NodeSelector:  cfg.NodeSelector,
 			RestartPolicy: v1.RestartPolicyNever,
 		},
-	})
+	}
+	if cfg.ContextEncryption != nil {
+		addDecryptInitContainer(pod, cfg, tarName)
+	}
+	if localContext {
+		addLocalContextInitContainer(pod, cfg)
+	}
 
+	if err := checkQuota(client.CoreV1().ResourceQuotas(cfg.Namespace), pod); err != nil {
+		return "", errors.Wrapf(err, "checking resource quotas of namespace %s", cfg.Namespace)
+	}
+
+	p, err := client.CoreV1().Pods(cfg.Namespace).Create(pod)
 	if err != nil {
 		return "", errors.Wrap(err, "creating kaniko pod")
 	}
 
 	defer func() {
 		imageList.RemoveImage(constants.DefaultKanikoImage)
-		if err := client.CoreV1().Pods("default").Delete(p.Name, &metav1.DeleteOptions{
+		if err := client.CoreV1().Pods(cfg.Namespace).Delete(p.Name, &metav1.DeleteOptions{
 			GracePeriodSeconds: new(int64),
 		}); err != nil {
 			logrus.Fatalf("deleting pod: %s", err)
 		}
 	}()
 
-	if err := kubernetes.WaitForPodComplete(client.CoreV1().Pods("default"), p.Name); err != nil {
+	timeout, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing kaniko timeout %s", cfg.Timeout)
+	}
+	startTimeout, err := time.ParseDuration(cfg.StartTimeout)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing kaniko start timeout %s", cfg.StartTimeout)
+	}
+	if err := kubernetes.WaitForPodScheduled(client.CoreV1().Pods(cfg.Namespace), p.Name, startTimeout); err != nil {
+		return "", diagnoseNotStarted(client, cfg.Namespace, p.Name, err)
+	}
+	if localContext {
+		if err := copyLocalContext(ctx, client.CoreV1().Pods(cfg.Namespace), cfg.Namespace, p.Name, dockerfilePath, artifact.Workspace, timeout); err != nil {
+			return "", errors.Wrap(err, "copying context to kaniko pod")
+		}
+	}
+	if err := kubernetes.WaitForPodComplete(client.CoreV1().Pods(cfg.Namespace), p.Name, timeout); err != nil {
 		return "", errors.Wrap(err, "waiting for pod to complete")
 	}
 
+	if completed, err := client.CoreV1().Pods(cfg.Namespace).Get(p.Name, metav1.GetOptions{}); err != nil {
+		logrus.Debugf("Unable to get kaniko pod metrics: %s", err)
+	} else {
+		fmt.Fprintln(out, buildMetrics(completed))
+	}
+
 	return imageDst, nil
 }
+
+// resourceRequirements parses the resources of the kaniko pod.
+func resourceRequirements(cfg *v1alpha2.ResourceRequirements) (v1.ResourceRequirements, error) {
+	var resources v1.ResourceRequirements
+	if cfg == nil {
+		return resources, nil
+	}
+
+	var err error
+	if resources.Requests, err = resourceList(cfg.Requests); err != nil {
+		return resources, errors.Wrap(err, "parsing requests")
+	}
+	if resources.Limits, err = resourceList(cfg.Limits); err != nil {
+		return resources, errors.Wrap(err, "parsing limits")
+	}
+	return resources, nil
+}
+
+func resourceList(quantities map[string]string) (v1.ResourceList, error) {
+	if len(quantities) == 0 {
+		return nil, nil
+	}
+
+	list := v1.ResourceList{}
+	for name, value := range quantities {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s quantity %s", name, value)
+		}
+		list[v1.ResourceName(name)] = quantity
+	}
+	return list, nil
+}
+
+// kanikoArgs returns the arguments of the kaniko executor for a docker artifact.
+func kanikoArgs(artifact *v1alpha2.DockerArtifact, cfg *v1alpha2.KanikoBuild, imageDst string) []string {
+	args := []string{
+		fmt.Sprintf("--dockerfile=%s", artifact.DockerfilePath),
+		fmt.Sprintf("--bucket=%s", cfg.GCSBucket),
+		fmt.Sprintf("--destination=%s", imageDst),
+		fmt.Sprintf("-v=%s", logrus.GetLevel().String()),
+	}
+
+	var keys []string
+	for k := range artifact.BuildArgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if v := artifact.BuildArgs[k]; v != nil {
+			args = append(args, fmt.Sprintf("--build-arg=%s=%s", k, *v))
+		} else {
+			warnings.Warnf(warnings.UnsupportedOption, "Ignoring build arg %s without a value: not supported by kaniko", k)
+		}
+	}
+
+	if artifact.Target != "" {
+		args = append(args, fmt.Sprintf("--target=%s", artifact.Target))
+	}
+	if artifact.Network != "" {
+		warnings.Warnf(warnings.UnsupportedOption, "Ignoring network %s: not supported by kaniko", artifact.Network)
+	}
+	if docker.RequiresBuildKit(artifact) {
+		warnings.Warnf(warnings.UnsupportedOption, "Ignoring secrets and ssh: not supported by kaniko")
+	}
+
+	if cfg.Cache != nil && cfg.Cache.Enabled {
+		args = append(args, "--cache=true")
+		if cfg.Cache.Repo != "" {
+			args = append(args, fmt.Sprintf("--cache-repo=%s", cfg.Cache.Repo))
+		}
+	}
+
+	return args
+}
+
+func encryptionKey(client clientgo.Interface, namespace, secretName string) ([]byte, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting secret %s", secretName)
+	}
+	key, present := secret.Data[encryptionKeyField]
+	if !present || len(key) == 0 {
+		return nil, fmt.Errorf("secret %s has no %s field", secretName, encryptionKeyField)
+	}
+
+	// openssl only reads the first line of the key file, without its newline.
+	key = bytes.TrimRight(key, "\r\n")
+	if len(key) == 0 || bytes.ContainsAny(key, "\r\n") {
+		return nil, fmt.Errorf("the %s field of secret %s should be a single line", encryptionKeyField, secretName)
+	}
+	return key, nil
+}
+
+// addDecryptInitContainer makes the kaniko pod read its context from a local directory
+// populated by an init container that downloads and decrypts the uploaded context.
+func addDecryptInitContainer(pod *v1.Pod, cfg *v1alpha2.KanikoBuild, tarName string) {
+	initImage := cfg.ContextEncryption.InitImage
+	if initImage == "" {
+		initImage = constants.DefaultKanikoDecryptImage
+	}
+
+	contextMount := v1.VolumeMount{
+		Name:      "kaniko-context",
+		MountPath: contextDir,
+	}
+
+	pod.Spec.InitContainers = []v1.Container{
+		{
+			Name:            "kaniko-decrypt",
+			Image:           initImage,
+			ImagePullPolicy: v1.PullIfNotPresent,
+			Command:         []string{"sh", "-c", decryptScript(cfg.GCSBucket, tarName, contextDir)},
+			VolumeMounts: []v1.VolumeMount{
+				contextMount,
+				{
+					Name:      "kaniko-secret",
+					MountPath: "/secret",
+				},
+				{
+					Name:      "kaniko-context-key",
+					MountPath: "/key",
+				},
+			},
+		},
+	}
+
+	kaniko := &pod.Spec.Containers[0]
+	for i, arg := range kaniko.Args {
+		if strings.HasPrefix(arg, "--bucket=") {
+			kaniko.Args[i] = fmt.Sprintf("--context=%s", contextDir)
+		}
+	}
+	kaniko.VolumeMounts = append(kaniko.VolumeMounts, contextMount)
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes,
+		v1.Volume{
+			Name: "kaniko-context",
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		},
+		v1.Volume{
+			Name: "kaniko-context-key",
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: cfg.ContextEncryption.KeySecret,
+					Items: []v1.KeyToPath{
+						{Key: encryptionKeyField, Path: encryptionKeyField},
+					},
+				},
+			},
+		},
+	)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestKanikoArgs(t *testing.T) {
+	defer logrus.SetLevel(logrus.GetLevel())
+	logrus.SetLevel(logrus.InfoLevel)
+
+	var tests = []struct {
+		description string
+		artifact    *v1alpha2.DockerArtifact
+		cache       *v1alpha2.KanikoCache
+		expected    []string
+	}{
+		{
+			description: "default",
+			artifact: &v1alpha2.DockerArtifact{
+				DockerfilePath: "Dockerfile",
+			},
+			expected: []string{"--dockerfile=Dockerfile", "--bucket=bucket", "--destination=image:tag", "-v=info"},
+		},
+		{
+			description: "build args and target",
+			artifact: &v1alpha2.DockerArtifact{
+				DockerfilePath: "build/Dockerfile.prod",
+				BuildArgs: map[string]*string{
+					"VERSION": util.StringPtr("1.0"),
+					"BASE":    util.StringPtr("alpine"),
+					"EMPTY":   nil,
+				},
+				Target: "release",
+			},
+			expected: []string{"--dockerfile=build/Dockerfile.prod", "--bucket=bucket", "--destination=image:tag", "-v=info", "--build-arg=BASE=alpine", "--build-arg=VERSION=1.0", "--target=release"},
+		},
+		{
+			description: "cache",
+			artifact: &v1alpha2.DockerArtifact{
+				DockerfilePath: "Dockerfile",
+			},
+			cache:    &v1alpha2.KanikoCache{Enabled: true, Repo: "gcr.io/project/cache"},
+			expected: []string{"--dockerfile=Dockerfile", "--bucket=bucket", "--destination=image:tag", "-v=info", "--cache=true", "--cache-repo=gcr.io/project/cache"},
+		},
+		{
+			description: "disabled cache",
+			artifact: &v1alpha2.DockerArtifact{
+				DockerfilePath: "Dockerfile",
+			},
+			cache:    &v1alpha2.KanikoCache{Repo: "gcr.io/project/cache"},
+			expected: []string{"--dockerfile=Dockerfile", "--bucket=bucket", "--destination=image:tag", "-v=info"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			args := kanikoArgs(test.artifact, &v1alpha2.KanikoBuild{GCSBucket: "bucket", Cache: test.cache}, "image:tag")
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, args)
+		})
+	}
+}
+
+func TestResourceRequirements(t *testing.T) {
+	resources, err := resourceRequirements(&v1alpha2.ResourceRequirements{
+		Requests: map[string]string{"cpu": "500m"},
+		Limits:   map[string]string{"memory": "2Gi"},
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "500m", resources.Requests.Cpu().String())
+	testutil.CheckErrorAndDeepEqual(t, false, err, "2Gi", resources.Limits.Memory().String())
+
+	_, err = resourceRequirements(&v1alpha2.ResourceRequirements{
+		Limits: map[string]string{"memory": "lots"},
+	})
+	testutil.CheckError(t, true, err)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	"k8s.io/api/core/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	localContextInitContainer = "kaniko-init-container"
+
+	// localContextReady is created by skaffold once the context is extracted.
+	localContextReady = "/tmp/complete"
+)
+
+// addLocalContextInitContainer makes the kaniko pod read its context from a local
+// directory that an init container holds open until skaffold has copied the context.
+func addLocalContextInitContainer(pod *v1.Pod, cfg *v1alpha2.KanikoBuild) {
+	initImage := cfg.ContextImage
+	if initImage == "" {
+		initImage = constants.DefaultKanikoContextImage
+	}
+
+	contextMount := v1.VolumeMount{
+		Name:      "kaniko-context",
+		MountPath: contextDir,
+	}
+
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, v1.Container{
+		Name:            localContextInitContainer,
+		Image:           initImage,
+		ImagePullPolicy: v1.PullIfNotPresent,
+		Command:         []string{"sh", "-c", fmt.Sprintf("while [ ! -f %s ]; do sleep 1; done", localContextReady)},
+		VolumeMounts:    []v1.VolumeMount{contextMount},
+	})
+
+	kaniko := &pod.Spec.Containers[0]
+	for i, arg := range kaniko.Args {
+		if strings.HasPrefix(arg, "--bucket=") {
+			kaniko.Args[i] = fmt.Sprintf("--context=%s", contextDir)
+		}
+	}
+	kaniko.VolumeMounts = append(kaniko.VolumeMounts, contextMount)
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+		Name: "kaniko-context",
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{},
+		},
+	})
+}
+
+// copyLocalContext streams the build context into the init container of the
+// kaniko pod and then lets the build start.
+func copyLocalContext(ctx context.Context, pods corev1.PodInterface, namespace, podName, dockerfilePath, workspace string, timeout time.Duration) error {
+	if err := kubernetes.WaitForInitContainerRunning(pods, podName, localContextInitContainer, timeout); err != nil {
+		return errors.Wrap(err, "waiting for init container")
+	}
+
+	r, w := io.Pipe()
+	// Unblocks the tar writer if kubectl stops reading early.
+	defer r.Close()
+	go func() {
+		w.CloseWithError(docker.CreateDockerTarGzContext(w, dockerfilePath, workspace))
+	}()
+
+	if err := kubectlExec(ctx, r, namespace, podName, "tar", "-xzf", "-", "-C", contextDir); err != nil {
+		return errors.Wrap(err, "extracting context")
+	}
+
+	return kubectlExec(ctx, nil, namespace, podName, "touch", localContextReady)
+}
+
+func kubectlExec(ctx context.Context, stdin io.Reader, namespace, podName string, command ...string) error {
+	args := append([]string{"exec", "-i", podName, "-c", localContextInitContainer, "-n", namespace, "--"}, command...)
+
+	cmd := exec.Command("kubectl", args...)
+	cmd.Stdin = stdin
+	return util.RunCmdSupervised(ctx, cmd, util.DefaultSupervision(nil))
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+)
+
+func TestAddLocalContextInitContainer(t *testing.T) {
+	var tests = []struct {
+		description   string
+		cfg           *v1alpha2.KanikoBuild
+		expectedImage string
+	}{
+		{
+			description:   "default image",
+			cfg:           &v1alpha2.KanikoBuild{},
+			expectedImage: "busybox",
+		},
+		{
+			description:   "custom image",
+			cfg:           &v1alpha2.KanikoBuild{ContextImage: "registry.internal/busybox"},
+			expectedImage: "registry.internal/busybox",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			pod := &v1.Pod{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name: "kaniko",
+							Args: []string{"--dockerfile=Dockerfile", "--bucket="},
+						},
+					},
+				},
+			}
+
+			addLocalContextInitContainer(pod, test.cfg)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"--dockerfile=Dockerfile", "--context=/kaniko/buildcontext"}, pod.Spec.Containers[0].Args)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedImage, pod.Spec.InitContainers[0].Image)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, pod.Spec.Containers[0].VolumeMounts, pod.Spec.InitContainers[0].VolumeMounts)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, "kaniko-context", pod.Spec.Volumes[0].Name)
+		})
+	}
+}
+
+func TestKubectlExec(t *testing.T) {
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = testutil.NewFakeCmd("kubectl exec -i kaniko -c kaniko-init-container -n builds -- touch /tmp/complete", nil)
+
+	err := kubectlExec(context.Background(), nil, "builds", "kaniko", "touch", "/tmp/complete")
+
+	testutil.CheckError(t, false, err)
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/api/core/v1"
+)
+
+// buildMetrics describes where and how fast a kaniko pod ran: the node it
+// was scheduled on, how long it waited to be scheduled and how long the
+// executor, which also pushes the image, took.
+func buildMetrics(pod *v1.Pod) string {
+	metrics := fmt.Sprintf("Kaniko pod ran on node %s", pod.Spec.NodeName)
+	if pod.Spec.NodeName == "" {
+		metrics = "Kaniko pod ran on an unknown node"
+	}
+
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodScheduled && c.Status == v1.ConditionTrue {
+			metrics += fmt.Sprintf(", scheduled in %v", c.LastTransitionTime.Sub(pod.CreationTimestamp.Time).Round(time.Second))
+		}
+	}
+
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.Name == "kaniko" && s.State.Terminated != nil {
+			terminated := s.State.Terminated
+			metrics += fmt.Sprintf(", built and pushed in %v", terminated.FinishedAt.Sub(terminated.StartedAt.Time).Round(time.Second))
+		}
+	}
+
+	return metrics
+}
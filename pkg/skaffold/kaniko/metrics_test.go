@@ -0,0 +1,70 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildMetrics(t *testing.T) {
+	created := time.Date(2018, 7, 1, 10, 0, 0, 0, time.UTC)
+	at := func(seconds int) metav1.Time {
+		return metav1.NewTime(created.Add(time.Duration(seconds) * time.Second))
+	}
+
+	var tests = []struct {
+		description string
+		pod         *v1.Pod
+		expected    string
+	}{
+		{
+			description: "pending pod",
+			pod:         &v1.Pod{},
+			expected:    "Kaniko pod ran on an unknown node",
+		},
+		{
+			description: "completed pod",
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)},
+				Spec:       v1.PodSpec{NodeName: "node-1"},
+				Status: v1.PodStatus{
+					Conditions: []v1.PodCondition{
+						{Type: v1.PodScheduled, Status: v1.ConditionTrue, LastTransitionTime: at(12)},
+					},
+					ContainerStatuses: []v1.ContainerStatus{{
+						Name: "kaniko",
+						State: v1.ContainerState{
+							Terminated: &v1.ContainerStateTerminated{StartedAt: at(20), FinishedAt: at(95)},
+						},
+					}},
+				},
+			},
+			expected: "Kaniko pod ran on node node-1, scheduled in 12s, built and pushed in 1m15s",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, buildMetrics(test.pod))
+		})
+	}
+}
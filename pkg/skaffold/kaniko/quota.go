@@ -0,0 +1,93 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// checkQuota fails when the namespace's resource quotas leave no room for
+// the build pod. Otherwise the pod would be rejected, or stay pending until
+// the build times out.
+func checkQuota(quotas corev1.ResourceQuotaInterface, pod *v1.Pod) error {
+	list, err := quotas.List(metav1.ListOptions{})
+	if apierrors.IsForbidden(err) {
+		logrus.Debugf("Not allowed to check resource quotas: %s", err)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "listing resource quotas")
+	}
+
+	requested := podUsage(pod)
+
+	var exceeded []string
+	for _, quota := range list.Items {
+		for name, hard := range quota.Spec.Hard {
+			want, present := requested[name]
+			if !present {
+				continue
+			}
+
+			used := quota.Status.Used[name]
+			total := used.DeepCopy()
+			total.Add(want)
+			if total.Cmp(hard) > 0 {
+				exceeded = append(exceeded, fmt.Sprintf("%s (quota %s: %s of %s used, %s requested)", name, quota.Name, used.String(), hard.String(), want.String()))
+			}
+		}
+	}
+
+	if len(exceeded) > 0 {
+		sort.Strings(exceeded)
+		return fmt.Errorf("quota exceeded: %s", strings.Join(exceeded, ", "))
+	}
+	return nil
+}
+
+// podUsage returns what a pod counts against quotas.
+func podUsage(pod *v1.Pod) v1.ResourceList {
+	usage := v1.ResourceList{
+		v1.ResourcePods: resource.MustParse("1"),
+	}
+
+	add := func(name v1.ResourceName, quantity resource.Quantity) {
+		total := usage[name]
+		total.Add(quantity)
+		usage[name] = total
+	}
+	for _, c := range pod.Spec.Containers {
+		for name, quantity := range c.Resources.Requests {
+			add(name, quantity)
+			add(v1.ResourceName("requests."+string(name)), quantity)
+		}
+		for name, quantity := range c.Resources.Limits {
+			add(v1.ResourceName("limits."+string(name)), quantity)
+		}
+	}
+	return usage
+}
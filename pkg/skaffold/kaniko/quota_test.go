@@ -0,0 +1,98 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckQuota(t *testing.T) {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+					Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+			}},
+		},
+	}
+	quota := func(hard, used v1.ResourceList) *v1.ResourceQuota {
+		return &v1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "builds"},
+			Spec:       v1.ResourceQuotaSpec{Hard: hard},
+			Status:     v1.ResourceQuotaStatus{Hard: hard, Used: used},
+		}
+	}
+
+	var tests = []struct {
+		description string
+		quotas      []runtime.Object
+		shouldErr   bool
+		expected    string
+	}{
+		{
+			description: "no quota",
+		},
+		{
+			description: "enough headroom",
+			quotas: []runtime.Object{quota(
+				v1.ResourceList{v1.ResourceRequestsCPU: resource.MustParse("2"), v1.ResourcePods: resource.MustParse("10")},
+				v1.ResourceList{v1.ResourceRequestsCPU: resource.MustParse("1"), v1.ResourcePods: resource.MustParse("3")},
+			)},
+		},
+		{
+			description: "cpu requests exceeded",
+			quotas: []runtime.Object{quota(
+				v1.ResourceList{v1.ResourceRequestsCPU: resource.MustParse("2")},
+				v1.ResourceList{v1.ResourceRequestsCPU: resource.MustParse("1800m")},
+			)},
+			shouldErr: true,
+			expected:  "quota exceeded: requests.cpu (quota compute: 1800m of 2 used, 500m requested)",
+		},
+		{
+			description: "memory limits and pods exceeded",
+			quotas: []runtime.Object{quota(
+				v1.ResourceList{v1.ResourceLimitsMemory: resource.MustParse("2Gi"), v1.ResourcePods: resource.MustParse("2")},
+				v1.ResourceList{v1.ResourceLimitsMemory: resource.MustParse("1536Mi"), v1.ResourcePods: resource.MustParse("2")},
+			)},
+			shouldErr: true,
+			expected:  "quota exceeded: limits.memory (quota compute: 1536Mi of 2Gi used, 1Gi requested), pods (quota compute: 2 of 2 used, 1 requested)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.quotas...)
+
+			err := checkQuota(client.CoreV1().ResourceQuotas("builds"), pod)
+
+			var message string
+			if err != nil {
+				message = err.Error()
+			}
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, message)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgo "k8s.io/client-go/kubernetes"
+)
+
+// maxEvents is how many of the pod's events are reported when it can't start.
+const maxEvents = 5
+
+// NotStartedError reports a kaniko pod that wasn't scheduled in time,
+// along with what the cluster tells about it.
+type NotStartedError struct {
+	Pod    string
+	Cause  error
+	Nodes  string
+	Events []string
+}
+
+func (e *NotStartedError) Error() string {
+	msg := fmt.Sprintf("kaniko pod %s didn't start: %s", e.Pod, e.Cause)
+	if e.Nodes != "" {
+		msg += "\n" + e.Nodes
+	}
+	if len(e.Events) > 0 {
+		msg += "\nrecent events:\n  " + strings.Join(e.Events, "\n  ")
+	}
+	return msg
+}
+
+// diagnoseNotStarted gathers what can explain why a pod isn't scheduled. Each
+// piece of information is optional, since the user might not be allowed to read it.
+func diagnoseNotStarted(client clientgo.Interface, namespace, podName string, cause error) error {
+	diagnosis := &NotStartedError{
+		Pod:   podName,
+		Cause: cause,
+	}
+
+	if nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{}); err != nil {
+		logrus.Debugf("Unable to list nodes: %s", err)
+	} else {
+		diagnosis.Nodes = fmt.Sprintf("%d of %d nodes are ready", readyNodes(nodes.Items), len(nodes.Items))
+	}
+
+	if events, err := kubernetes.PodEvents(client.CoreV1().Events(namespace), podName, maxEvents); err != nil {
+		logrus.Debugf("Unable to list events of %s: %s", podName, err)
+	} else {
+		diagnosis.Events = events
+	}
+
+	return diagnosis
+}
+
+func readyNodes(nodes []v1.Node) int {
+	ready := 0
+	for _, node := range nodes {
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == v1.NodeReady && condition.Status == v1.ConditionTrue {
+				ready++
+			}
+		}
+	}
+	return ready
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDiagnoseNotStarted(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "ready"},
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+			},
+		},
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "not-ready"},
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionFalse}},
+			},
+		},
+		&v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "event", Namespace: "default"},
+			InvolvedObject: v1.ObjectReference{Name: "kaniko"},
+			Reason:         "FailedScheduling",
+			Message:        "0/2 nodes are available: 1 Insufficient cpu, 1 node(s) were not ready.",
+		},
+	)
+
+	err := diagnoseNotStarted(client, "default", "kaniko", fmt.Errorf("pod kaniko wasn't scheduled within 3m0s"))
+
+	testutil.CheckErrorAndDeepEqual(t, true, err, `kaniko pod kaniko didn't start: pod kaniko wasn't scheduled within 3m0s
+1 of 2 nodes are ready
+recent events:
+  FailedScheduling: 0/2 nodes are available: 1 Insufficient cpu, 1 node(s) were not ready.`, err.Error())
+}
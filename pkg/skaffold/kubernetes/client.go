@@ -18,9 +18,12 @@ package kubernetes
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	// Initialize all known client auth plugins
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -33,9 +36,77 @@ func GetClientset() (kubernetes.Interface, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Error creating kubeConfig: %s", err)
 	}
+	clientConfig, err = withUnauthorizedRetry(clientConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "configuring client transport")
+	}
 	client, err := kubernetes.NewForConfig(clientConfig)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error creating new client from kubeConfig.ClientConfig()")
 	}
 	return client, nil
 }
+
+// withUnauthorizedRetry returns a config whose transport retries a request
+// once when it's rejected with a 401.
+// Exec plugins and auth providers fetch new credentials when a token is
+// rejected, so the retry lets long running sessions survive token expiry.
+// The retrying transport has to wrap the authenticating transport, which is
+// why the transport is built here instead of through WrapTransport.
+func withUnauthorizedRetry(config *rest.Config) (*rest.Config, error) {
+	rt, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating transport")
+	}
+
+	return &rest.Config{
+		Host:          config.Host,
+		APIPath:       config.APIPath,
+		ContentConfig: config.ContentConfig,
+		UserAgent:     config.UserAgent,
+		QPS:           config.QPS,
+		Burst:         config.Burst,
+		RateLimiter:   config.RateLimiter,
+		Timeout:       config.Timeout,
+		Transport:     &unauthorizedRetrier{delegate: rt},
+	}, nil
+}
+
+type unauthorizedRetrier struct {
+	delegate http.RoundTripper
+}
+
+func (r *unauthorizedRetrier) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.delegate.RoundTrip(cloneRequest(req))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	retry := cloneRequest(req)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+
+	logrus.Debugf("Request to %s was unauthorized, retrying with refreshed credentials", req.URL)
+	resp.Body.Close()
+	return r.delegate.RoundTrip(retry)
+}
+
+// cloneRequest copies a request and its headers, since authenticating round
+// trippers set the Authorization header of the request they're given.
+func cloneRequest(req *http.Request) *http.Request {
+	clone := new(http.Request)
+	*clone = *req
+	clone.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		clone.Header[k] = append([]string(nil), v...)
+	}
+	return clone
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+// fakeAuthTransport authenticates requests like client-go's exec plugin
+// transport: it sets the Authorization header and refreshes its token on 401.
+type fakeAuthTransport struct {
+	token      string
+	validToken string
+	refresh    bool
+	bodies     []string
+}
+
+func (f *fakeAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+f.token)
+	}
+	body := ""
+	if req.Body != nil {
+		b, _ := ioutil.ReadAll(req.Body)
+		body = string(b)
+	}
+	f.bodies = append(f.bodies, body)
+
+	status := http.StatusOK
+	if req.Header.Get("Authorization") != "Bearer "+f.validToken {
+		status = http.StatusUnauthorized
+		if f.refresh {
+			f.token = f.validToken
+		}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+func TestUnauthorizedRetrier(t *testing.T) {
+	var tests = []struct {
+		description    string
+		token          string
+		refresh        bool
+		expectedStatus int
+		expectedBodies []string
+	}{
+		{
+			description:    "valid token",
+			token:          "valid",
+			expectedStatus: http.StatusOK,
+			expectedBodies: []string{"body"},
+		},
+		{
+			description:    "expired token is refreshed",
+			token:          "expired",
+			refresh:        true,
+			expectedStatus: http.StatusOK,
+			expectedBodies: []string{"body", "body"},
+		},
+		{
+			description:    "retry only once",
+			token:          "expired",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBodies: []string{"body", "body"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			fake := &fakeAuthTransport{
+				token:      test.token,
+				validToken: "valid",
+				refresh:    test.refresh,
+			}
+			retrier := &unauthorizedRetrier{delegate: fake}
+
+			req, err := http.NewRequest("POST", "https://kubernetes/api", strings.NewReader("body"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := retrier.RoundTrip(req)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectedStatus, resp.StatusCode)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedBodies, fake.bodies)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, "", req.Header.Get("Authorization"))
+		})
+	}
+}
@@ -27,6 +27,9 @@ var (
 	currentContextOnce sync.Once
 	currentContext     string
 	currentContextErr  error
+
+	defaultNamespaceOnce sync.Once
+	defaultNamespace     string
 )
 
 func CurrentContext() (string, error) {
@@ -45,3 +48,20 @@ func CurrentContext() (string, error) {
 
 	return currentContext, currentContextErr
 }
+
+// DefaultNamespace returns the namespace of the current context, where
+// resources without a namespace are deployed.
+func DefaultNamespace() string {
+	defaultNamespaceOnce.Do(func() {
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+		kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+
+		namespace, _, err := kubeConfig.Namespace()
+		if err != nil || namespace == "" {
+			namespace = "default"
+		}
+		defaultNamespace = namespace
+	})
+
+	return defaultNamespace
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RunJob runs a container to completion as a Job, prints its logs and deletes
+// the Job. It fails if the container fails or doesn't complete in time.
+func RunJob(client kubernetes.Interface, out io.Writer, namespace string, container v1.Container, timeout time.Duration) error {
+	backoffLimit := int32(0)
+	job, err := client.BatchV1().Jobs(namespace).Create(&batchv1.Job{
+		ObjectMeta: meta_v1.ObjectMeta{
+			GenerateName: container.Name + "-",
+			Labels:       map[string]string{"skaffold-test": container.Name},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Labels: map[string]string{"skaffold-test": container.Name},
+				},
+				Spec: v1.PodSpec{
+					Containers:    []v1.Container{container},
+					RestartPolicy: v1.RestartPolicyNever,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating job")
+	}
+
+	defer func() {
+		propagation := meta_v1.DeletePropagationBackground
+		if err := client.BatchV1().Jobs(namespace).Delete(job.Name, &meta_v1.DeleteOptions{
+			PropagationPolicy: &propagation,
+		}); err != nil {
+			logrus.Warnf("deleting job %s: %s", job.Name, err)
+		}
+	}()
+
+	waitErr := waitForJobComplete(client, namespace, job.Name, timeout)
+	printJobLogs(client, out, namespace, job.Name)
+	return waitErr
+}
+
+func waitForJobComplete(client kubernetes.Interface, namespace, jobName string, timeout time.Duration) error {
+	logrus.Infof("Waiting for job %s to complete", jobName)
+
+	err := wait.PollImmediate(time.Millisecond*500, timeout, func() (bool, error) {
+		job, err := client.BatchV1().Jobs(namespace).Get(jobName, meta_v1.GetOptions{})
+		if err != nil {
+			logrus.Infof("Getting job %s", err)
+			return false, nil
+		}
+		if job.Status.Succeeded > 0 {
+			return true, nil
+		}
+		if job.Status.Failed > 0 {
+			return false, fmt.Errorf("job %s failed", jobName)
+		}
+		return false, nil
+	})
+
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("job %s didn't complete within %s", jobName, timeout)
+	}
+	return err
+}
+
+func printJobLogs(client kubernetes.Interface, out io.Writer, namespace, jobName string) {
+	pods, err := client.CoreV1().Pods(namespace).List(meta_v1.ListOptions{
+		LabelSelector: "job-name=" + jobName,
+	})
+	if err != nil {
+		logrus.Warnf("listing pods of job %s: %s", jobName, err)
+		return
+	}
+
+	for _, pod := range pods.Items {
+		logs, err := client.CoreV1().Pods(namespace).GetLogs(pod.Name, &v1.PodLogOptions{}).Stream()
+		if err != nil {
+			logrus.Warnf("getting logs of %s: %s", pod.Name, err)
+			continue
+		}
+		io.Copy(out, logs)
+		logs.Close()
+	}
+}
+
+// ServiceEndpoints lists the services of a namespace as environment variables
+// named <SERVICE>_ENDPOINT, whose value is the host:port of the service's first port.
+func ServiceEndpoints(client kubernetes.Interface, namespace string) ([]v1.EnvVar, error) {
+	services, err := client.CoreV1().Services(namespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing services")
+	}
+
+	var env []v1.EnvVar
+	for _, service := range services.Items {
+		if len(service.Spec.Ports) == 0 {
+			continue
+		}
+		env = append(env, v1.EnvVar{
+			Name:  endpointVariable(service.Name),
+			Value: fmt.Sprintf("%s.%s.svc:%d", service.Name, namespace, service.Spec.Ports[0].Port),
+		})
+	}
+	sort.Slice(env, func(i, j int) bool { return env[i].Name < env[j].Name })
+
+	return env, nil
+}
+
+func endpointVariable(serviceName string) string {
+	return strings.ToUpper(strings.Replace(serviceName, "-", "_", -1)) + "_ENDPOINT"
+}
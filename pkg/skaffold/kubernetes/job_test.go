@@ -0,0 +1,103 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRunJob(t *testing.T) {
+	var tests = []struct {
+		description string
+		status      batchv1.JobStatus
+		shouldErr   bool
+	}{
+		{
+			description: "succeeded",
+			status:      batchv1.JobStatus{Succeeded: 1},
+		},
+		{
+			description: "failed",
+			status:      batchv1.JobStatus{Failed: 1},
+			shouldErr:   true,
+		},
+		{
+			description: "timeout",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			client.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				job := action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+				job.Name = "tests-abcde"
+				return false, nil, nil
+			})
+			client.PrependReactor("get", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, &batchv1.Job{
+					ObjectMeta: metav1.ObjectMeta{Name: "tests-abcde"},
+					Status:     test.status,
+				}, nil
+			})
+
+			err := RunJob(client, ioutil.Discard, "default", v1.Container{Name: "tests", Image: "tests:v1"}, time.Second)
+			testutil.CheckError(t, test.shouldErr, err)
+
+			jobs, _ := client.BatchV1().Jobs("default").List(metav1.ListOptions{})
+			testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(jobs.Items))
+		})
+	}
+}
+
+func TestServiceEndpoints(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-frontend", Namespace: "default"},
+			Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 80}, {Port: 443}}},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+			Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 8080}}},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "headless", Namespace: "default"},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "staging"},
+			Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 80}}},
+		},
+	)
+
+	env, err := ServiceEndpoints(client, "default")
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []v1.EnvVar{
+		{Name: "API_ENDPOINT", Value: "api.default.svc:8080"},
+		{Name: "WEB_FRONTEND_ENDPOINT", Value: "web-frontend.default.svc:80"},
+	}, env)
+}
@@ -25,6 +25,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
@@ -100,7 +101,7 @@ func (a *LogAggregator) streamLogs(ctx context.Context, client corev1.CoreV1Inte
 
 	for _, container := range pod.Status.ContainerStatuses {
 		containerID := container.ContainerID
-		if containerID == "" {
+		if containerID == "" || mutedContainers[container.Name] {
 			continue
 		}
 
@@ -110,6 +111,7 @@ func (a *LogAggregator) streamLogs(ctx context.Context, client corev1.CoreV1Inte
 		}
 
 		logrus.Infof("Stream logs from pod: %s container: %s", pod.Name, container.Name)
+		event.LogsStreamed(pod.Namespace, pod.Name, container.Name)
 
 		sinceSeconds := int64(time.Since(a.startTime).Seconds() + 0.5)
 		// 0s means all the logs
@@ -222,11 +224,34 @@ func (t *trackedContainers) remove(id string) {
 	t.Unlock()
 }
 
+// mutedContainers are the containers that service meshes inject into pods.
+// Their logs are rarely what a developer is looking for.
+var mutedContainers = map[string]bool{
+	"istio-init":    true,
+	"istio-proxy":   true,
+	"linkerd-init":  true,
+	"linkerd-proxy": true,
+}
+
 // PodSelector is used to choose which pods to log.
 type PodSelector interface {
 	Select(pod *v1.Pod) bool
 }
 
+// AnyOf implements PodSelector by selecting the pods that any of
+// the selectors picks.
+type AnyOf []PodSelector
+
+// Select returns true if one of the selectors selects the pod.
+func (selectors AnyOf) Select(pod *v1.Pod) bool {
+	for _, s := range selectors {
+		if s.Select(pod) {
+			return true
+		}
+	}
+	return false
+}
+
 // ImageList implements PodSelector based on a list of images names.
 type ImageList struct {
 	sync.RWMutex
@@ -0,0 +1,58 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAnyOf(t *testing.T) {
+	images := NewImageList()
+	images.AddImage("web:123")
+	selector := AnyOf{NewLabelSelector("skaffold.dev/run-id", "abc"), images}
+
+	var tests = []struct {
+		description string
+		pod         *v1.Pod
+		expected    bool
+	}{
+		{
+			description: "labelled with the run id",
+			pod:         &v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Labels: map[string]string{"skaffold.dev/run-id": "abc"}}},
+			expected:    true,
+		},
+		{
+			description: "running a built image",
+			pod:         &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{Image: "web:123"}}}},
+			expected:    true,
+		},
+		{
+			description: "other pod",
+			pod:         &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{Image: "redis"}}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, selector.Select(test.pod))
+		})
+	}
+}
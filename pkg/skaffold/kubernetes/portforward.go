@@ -0,0 +1,309 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// portForwardRetryDelay is how long to wait before forwarding a port again
+// after `kubectl port-forward` exits, e.g. because the container restarted.
+var portForwardRetryDelay = time.Second
+
+// PortForwarder forwards the container ports of the deployed pods to local
+// ports. A port keeps the same local port when its pod is replaced.
+type PortForwarder struct {
+	output      io.Writer
+	podSelector PodSelector
+	kubeContext string
+
+	lock       sync.Mutex
+	pods       map[string]*v1.Pod
+	localPorts map[forwardKey]int32
+	forwards   map[forwardKey]*portForward
+	running    sync.WaitGroup
+}
+
+// forwardKey identifies a container port independently of the pod's name,
+// by the workload that owns the pod.
+type forwardKey struct {
+	namespace string
+	workload  string
+	container string
+	port      int32
+}
+
+func (k forwardKey) String() string {
+	return fmt.Sprintf("%s/%s/%s:%d", k.namespace, k.workload, k.container, k.port)
+}
+
+// workloadName is the name of the workload that manages a pod: its
+// controller or the pod itself, without the hash that changes with each
+// rollout of a deployment.
+func workloadName(pod *v1.Pod) string {
+	name := pod.Name
+	if owner := meta_v1.GetControllerOf(pod); owner != nil {
+		name = owner.Name
+	}
+	if hash := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; hash != "" {
+		name = strings.TrimSuffix(name, "-"+hash)
+	}
+	return name
+}
+
+type portForward struct {
+	pod    string
+	cancel func()
+}
+
+// NewPortForwarder creates a PortForwarder for the pods picked by a selector.
+func NewPortForwarder(out io.Writer, podSelector PodSelector, kubeContext string) *PortForwarder {
+	return &PortForwarder{
+		output:      out,
+		podSelector: podSelector,
+		kubeContext: kubeContext,
+		pods:        map[string]*v1.Pod{},
+		localPorts:  map[forwardKey]int32{},
+		forwards:    map[forwardKey]*portForward{},
+	}
+}
+
+// Start forwards the ports of the selected pods as they start running.
+func (p *PortForwarder) Start(ctx context.Context, client corev1.CoreV1Interface) error {
+	watcher, err := client.Pods("").Watch(meta_v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer watcher.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				p.stopAll()
+				return
+			case evt, ok := <-watcher.ResultChan():
+				if !ok {
+					return
+				}
+
+				pod, ok := evt.Object.(*v1.Pod)
+				if !ok || !p.podSelector.Select(pod) {
+					continue
+				}
+
+				switch {
+				case evt.Type == watch.Deleted || pod.DeletionTimestamp != nil || pod.Status.Phase != v1.PodRunning:
+					p.stopPod(ctx, pod)
+				default:
+					p.startPod(ctx, pod)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (p *PortForwarder) startPod(ctx context.Context, pod *v1.Pod) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.pods[pod.Namespace+"/"+pod.Name] = pod
+	p.forwardPod(ctx, pod)
+}
+
+// stopPod stops forwarding the ports of a pod that's gone, and forwards them
+// to another running pod of the same workload, if any.
+func (p *PortForwarder) stopPod(ctx context.Context, pod *v1.Pod) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.pods, pod.Namespace+"/"+pod.Name)
+	for key, forward := range p.forwards {
+		if key.namespace == pod.Namespace && forward.pod == pod.Name {
+			forward.cancel()
+			delete(p.forwards, key)
+		}
+	}
+
+	for _, other := range p.pods {
+		p.forwardPod(ctx, other)
+	}
+}
+
+// forwardPod forwards the ports of a pod that aren't already forwarded to
+// another of the pods. It must be called with the lock held.
+func (p *PortForwarder) forwardPod(ctx context.Context, pod *v1.Pod) {
+	changed := false
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Protocol != "" && port.Protocol != v1.ProtocolTCP {
+				continue
+			}
+
+			key := forwardKey{namespace: pod.Namespace, workload: workloadName(pod), container: container.Name, port: port.ContainerPort}
+			if _, present := p.forwards[key]; present {
+				continue
+			}
+
+			localPort, present := p.localPorts[key]
+			if !present {
+				localPort = p.availablePort(port.ContainerPort)
+				p.localPorts[key] = localPort
+				changed = true
+				event.PortForwarded(key.namespace, key.container, localPort, key.port)
+			}
+
+			forwardCtx, cancel := context.WithCancel(ctx)
+			p.forwards[key] = &portForward{pod: pod.Name, cancel: cancel}
+			p.running.Add(1)
+			go p.forward(forwardCtx, pod.Namespace, pod.Name, localPort, port.ContainerPort)
+		}
+	}
+
+	if changed {
+		p.printTable()
+	}
+}
+
+// forward runs `kubectl port-forward` until the context is cancelled.
+func (p *PortForwarder) forward(ctx context.Context, namespace, pod string, localPort, port int32) {
+	defer p.running.Done()
+
+	for {
+		cmd := exec.CommandContext(ctx, "kubectl", "--context", p.kubeContext, "port-forward", "pod/"+pod, fmt.Sprintf("%d:%d", localPort, port), "--namespace", namespace)
+		if err := util.RunCmd(cmd); err != nil && ctx.Err() == nil {
+			logrus.Debugf("port forwarding %s/%s:%d stopped: %s", namespace, pod, port, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(portForwardRetryDelay):
+		}
+	}
+}
+
+func (p *PortForwarder) stopAll() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for key, forward := range p.forwards {
+		forward.cancel()
+		delete(p.forwards, key)
+	}
+}
+
+// availablePort picks the container port as local port if it's free, or
+// else the next free port after it, so that the same ports are used from
+// one session to the next. A port is kept for the whole session, even
+// when its pods restart.
+func (p *PortForwarder) availablePort(preferred int32) int32 {
+	taken := map[int32]bool{}
+	for _, port := range p.localPorts {
+		taken[port] = true
+	}
+
+	for port := preferred; port <= maxPort; port++ {
+		if !taken[port] && isPortFree(port) {
+			return port
+		}
+	}
+
+	// Every port after the preferred one is busy: let the system choose.
+	for {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return preferred
+		}
+		port := int32(l.Addr().(*net.TCPAddr).Port)
+		l.Close()
+
+		if !taken[port] {
+			return port
+		}
+	}
+}
+
+const maxPort = 65535
+
+var isPortFree = func(port int32) bool {
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+func (p *PortForwarder) printTable() {
+	var keys []forwardKey
+	for key := range p.localPorts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	fmt.Fprintln(p.output, "Port forwarding:")
+	w := tabwriter.NewWriter(p.output, 0, 4, 2, ' ', 0)
+	for _, key := range keys {
+		localPort := p.localPorts[key]
+		if localPort == key.port {
+			fmt.Fprintf(w, "  %s\t-> localhost:%d\n", key, localPort)
+		} else {
+			fmt.Fprintf(w, "  %s\t-> localhost:%d\t(%d was busy)\n", key, localPort, key.port)
+		}
+	}
+	w.Flush()
+}
+
+// LabelSelector implements PodSelector based on the value of a label.
+type LabelSelector struct {
+	key   string
+	value string
+}
+
+// NewLabelSelector creates a LabelSelector for the pods where key=value.
+func NewLabelSelector(key, value string) *LabelSelector {
+	return &LabelSelector{
+		key:   key,
+		value: value,
+	}
+}
+
+// Select returns true if the pod has the label.
+func (s *LabelSelector) Select(pod *v1.Pod) bool {
+	return pod.Labels[s.key] == s.value
+}
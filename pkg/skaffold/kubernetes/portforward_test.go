@@ -0,0 +1,137 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPortForwarder(t *testing.T) {
+	defer func(d time.Duration) { portForwardRetryDelay = d }(portForwardRetryDelay)
+	portForwardRetryDelay = time.Hour
+	defer func(f func(int32) bool) { isPortFree = f }(isPortFree)
+	isPortFree = func(port int32) bool { return port != 9090 && port != 9091 }
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = testutil.NewFakeCmd("", nil)
+
+	controller := true
+	pod := func(name, replicaSet string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				Labels:          map[string]string{"run": "1", "pod-template-hash": "5f7d"},
+				OwnerReferences: []meta_v1.OwnerReference{{Kind: "ReplicaSet", Name: replicaSet, Controller: &controller}},
+			},
+			Spec: v1.PodSpec{Containers: []v1.Container{{
+				Name:  "web",
+				Ports: []v1.ContainerPort{{ContainerPort: 8080}, {ContainerPort: 9090}, {ContainerPort: 53, Protocol: v1.ProtocolUDP}},
+			}}},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var out bytes.Buffer
+	forwarder := NewPortForwarder(&out, NewLabelSelector("run", "1"), "kubecontext")
+
+	forwarder.startPod(ctx, pod("web-5f7d-1", "web-5f7d"))
+	forwarder.startPod(ctx, pod("web-5f7d-2", "web-5f7d"))
+
+	// 9090 and 9091 are busy.
+	testutil.CheckErrorAndDeepEqual(t, false, nil, int32(9092), forwarder.localPorts[forwardKey{"default", "web", "web", 9090}])
+	testutil.CheckErrorAndDeepEqual(t, false, nil, int32(8080), forwarder.localPorts[forwardKey{"default", "web", "web", 8080}])
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "Port forwarding:\n  default/web/web:8080  -> localhost:8080\n  default/web/web:9090  -> localhost:9092  (9090 was busy)\n", out.String())
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 2, len(forwarder.localPorts))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "web-5f7d-1", forwarder.forwards[forwardKey{"default", "web", "web", 8080}].pod)
+
+	// The ports move to the other pod, on the same local ports.
+	out.Reset()
+	forwarder.stopPod(ctx, pod("web-5f7d-1", "web-5f7d"))
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "web-5f7d-2", forwarder.forwards[forwardKey{"default", "web", "web", 8080}].pod)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, int32(8080), forwarder.localPorts[forwardKey{"default", "web", "web", 8080}])
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "", out.String())
+
+	// Another workload with the same container gets its own ports.
+	forwarder.startPod(ctx, pod("admin-5f7d-1", "admin-5f7d"))
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "web-5f7d-2", forwarder.forwards[forwardKey{"default", "web", "web", 8080}].pod)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "admin-5f7d-1", forwarder.forwards[forwardKey{"default", "admin", "web", 8080}].pod)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, int32(8081), forwarder.localPorts[forwardKey{"default", "admin", "web", 8080}])
+
+	forwarder.stopPod(ctx, pod("web-5f7d-2", "web-5f7d"))
+	forwarder.stopPod(ctx, pod("admin-5f7d-1", "admin-5f7d"))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(forwarder.forwards))
+	forwarder.running.Wait()
+}
+
+func TestWorkloadName(t *testing.T) {
+	controller := true
+	var tests = []struct {
+		description string
+		pod         *v1.Pod
+		expected    string
+	}{
+		{
+			description: "bare pod",
+			pod:         &v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "web"}},
+			expected:    "web",
+		},
+		{
+			description: "deployment",
+			pod: &v1.Pod{ObjectMeta: meta_v1.ObjectMeta{
+				Name:            "web-5f7d-x2b8q",
+				Labels:          map[string]string{"pod-template-hash": "5f7d"},
+				OwnerReferences: []meta_v1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5f7d", Controller: &controller}},
+			}},
+			expected: "web",
+		},
+		{
+			description: "statefulset",
+			pod: &v1.Pod{ObjectMeta: meta_v1.ObjectMeta{
+				Name:            "db-0",
+				OwnerReferences: []meta_v1.OwnerReference{{Kind: "StatefulSet", Name: "db", Controller: &controller}},
+			}},
+			expected: "db",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, workloadName(test.pod))
+		})
+	}
+}
+
+func TestLabelSelector(t *testing.T) {
+	selector := NewLabelSelector("skaffold.dev/run-id", "abc")
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, selector.Select(&v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Labels: map[string]string{"skaffold.dev/run-id": "abc"}}}))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, selector.Select(&v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Labels: map[string]string{"skaffold.dev/run-id": "def"}}}))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, selector.Select(&v1.Pod{}))
+}
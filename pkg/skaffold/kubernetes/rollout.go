@@ -0,0 +1,292 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Workload is a Deployment, StatefulSet or DaemonSet whose rollout can be
+// waited for. An empty namespace is the namespace of the current context.
+type Workload struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (w Workload) String() string {
+	return strings.ToLower(w.Kind) + "/" + w.Name
+}
+
+// IsWorkloadKind tells if the rollout of a kind of resource can be waited for.
+func IsWorkloadKind(kind string) bool {
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+		return true
+	}
+	return false
+}
+
+type rollout struct {
+	done     bool
+	message  string
+	selector *meta_v1.LabelSelector
+}
+
+// WaitForRollouts waits for the workloads to be rolled out, which means that
+// the pods of their current revision are ready. It fails if a Deployment
+// exceeds its progress deadline or if the workloads are still not rolled out
+// after the given deadline. The failing pods are then described to out.
+// Waiting stops as soon as the context is cancelled.
+func WaitForRollouts(ctx context.Context, client kubernetes.Interface, out io.Writer, workloads []Workload, deadline time.Duration) error {
+	waitCtx, cancel := context.WithTimeout(ctx, deadline)
+	defer cancel()
+
+	for _, w := range workloads {
+		if w.Namespace == "" {
+			w.Namespace = DefaultNamespace()
+		}
+
+		var last rollout
+		err := pollImmediateUntil(time.Millisecond*500, func() (bool, error) {
+			current, err := rolloutStatus(client, w)
+			if err == nil && !current.done && current.message != last.message {
+				logrus.Infof("Waiting for %s to roll out: %s", w, current.message)
+			}
+			last = current
+			return current.done, err
+		}, waitCtx.Done())
+
+		if err == nil {
+			fmt.Fprintf(out, " - %s is rolled out\n", w)
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if last.selector != nil {
+			printFailingPods(client, out, w.Namespace, last.selector)
+		}
+		if err == wait.ErrWaitTimeout {
+			return fmt.Errorf("%s wasn't rolled out within %s: %s", w, deadline, last.message)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// pollImmediateUntil is like wait.PollUntil but it tries the condition right
+// away, instead of waiting for an interval first.
+func pollImmediateUntil(interval time.Duration, condition wait.ConditionFunc, stopCh <-chan struct{}) error {
+	if done, err := condition(); err != nil || done {
+		return err
+	}
+
+	select {
+	case <-stopCh:
+		return wait.ErrWaitTimeout
+	default:
+		return wait.PollUntil(interval, condition, stopCh)
+	}
+}
+
+// rolloutStatus mirrors the checks done by `kubectl rollout status`.
+func rolloutStatus(client kubernetes.Interface, w Workload) (rollout, error) {
+	switch w.Kind {
+	case "Deployment":
+		d, err := client.AppsV1().Deployments(w.Namespace).Get(w.Name, meta_v1.GetOptions{})
+		if err != nil {
+			return rollout{message: err.Error()}, nil
+		}
+		return deploymentRollout(d)
+
+	case "StatefulSet":
+		s, err := client.AppsV1().StatefulSets(w.Namespace).Get(w.Name, meta_v1.GetOptions{})
+		if err != nil {
+			return rollout{message: err.Error()}, nil
+		}
+		return statefulSetRollout(s), nil
+
+	case "DaemonSet":
+		d, err := client.AppsV1().DaemonSets(w.Namespace).Get(w.Name, meta_v1.GetOptions{})
+		if err != nil {
+			return rollout{message: err.Error()}, nil
+		}
+		return daemonSetRollout(d), nil
+	}
+
+	return rollout{}, fmt.Errorf("can't wait for the rollout of a %s", w.Kind)
+}
+
+func deploymentRollout(d *appsv1.Deployment) (rollout, error) {
+	r := rollout{selector: d.Spec.Selector}
+
+	if d.Status.ObservedGeneration < d.Generation {
+		r.message = "waiting for the spec update to be observed"
+		return r, nil
+	}
+	for _, condition := range d.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			r.message = condition.Message
+			return r, fmt.Errorf("deployment/%s exceeded its progress deadline", d.Name)
+		}
+	}
+
+	replicas := replicasOrDefault(d.Spec.Replicas)
+	switch {
+	case d.Status.UpdatedReplicas < replicas:
+		r.message = fmt.Sprintf("%d of %d replicas have been updated", d.Status.UpdatedReplicas, replicas)
+	case d.Status.Replicas > d.Status.UpdatedReplicas:
+		r.message = fmt.Sprintf("%d old replicas are pending termination", d.Status.Replicas-d.Status.UpdatedReplicas)
+	case d.Status.AvailableReplicas < d.Status.UpdatedReplicas:
+		r.message = fmt.Sprintf("%d of %d updated replicas are available", d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+	default:
+		r.done = true
+	}
+	return r, nil
+}
+
+func statefulSetRollout(s *appsv1.StatefulSet) rollout {
+	r := rollout{selector: s.Spec.Selector}
+
+	if s.Status.ObservedGeneration < s.Generation {
+		r.message = "waiting for the spec update to be observed"
+		return r
+	}
+
+	replicas := replicasOrDefault(s.Spec.Replicas)
+	if s.Status.ReadyReplicas < replicas {
+		r.message = fmt.Sprintf("%d of %d replicas are ready", s.Status.ReadyReplicas, replicas)
+		return r
+	}
+
+	if s.Spec.UpdateStrategy.Type == appsv1.RollingUpdateStatefulSetStrategyType {
+		// With a partition, only the replicas with a higher ordinal are updated.
+		partitioned := replicas
+		if update := s.Spec.UpdateStrategy.RollingUpdate; update != nil && update.Partition != nil {
+			partitioned = replicas - *update.Partition
+		}
+		if s.Status.UpdatedReplicas < partitioned {
+			r.message = fmt.Sprintf("%d of %d replicas have been updated", s.Status.UpdatedReplicas, partitioned)
+			return r
+		}
+	}
+
+	r.done = true
+	return r
+}
+
+func daemonSetRollout(d *appsv1.DaemonSet) rollout {
+	r := rollout{selector: d.Spec.Selector}
+
+	switch {
+	case d.Status.ObservedGeneration < d.Generation:
+		r.message = "waiting for the spec update to be observed"
+	case d.Status.UpdatedNumberScheduled < d.Status.DesiredNumberScheduled:
+		r.message = fmt.Sprintf("%d of %d updated pods are scheduled", d.Status.UpdatedNumberScheduled, d.Status.DesiredNumberScheduled)
+	case d.Status.NumberAvailable < d.Status.DesiredNumberScheduled:
+		r.message = fmt.Sprintf("%d of %d updated pods are available", d.Status.NumberAvailable, d.Status.DesiredNumberScheduled)
+	default:
+		r.done = true
+	}
+	return r
+}
+
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// printFailingPods describes the pods that aren't ready: why their containers
+// are waiting or have terminated, and their most recent events.
+func printFailingPods(client kubernetes.Interface, out io.Writer, namespace string, selector *meta_v1.LabelSelector) {
+	labelSelector, err := meta_v1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		logrus.Warnf("parsing selector: %s", err)
+		return
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(meta_v1.ListOptions{
+		LabelSelector: labelSelector.String(),
+	})
+	if err != nil {
+		logrus.Warnf("listing pods of namespace %s: %s", namespace, err)
+		return
+	}
+
+	for _, pod := range pods.Items {
+		if isPodReady(&pod) {
+			continue
+		}
+
+		fmt.Fprintf(out, " - pod %s is %s\n", pod.Name, pod.Status.Phase)
+		var statuses []v1.ContainerStatus
+		statuses = append(statuses, pod.Status.InitContainerStatuses...)
+		statuses = append(statuses, pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			if reason := containerProblem(status); reason != "" {
+				fmt.Fprintf(out, "   container %s %s\n", status.Name, reason)
+			}
+		}
+
+		events, err := PodEvents(client.CoreV1().Events(namespace), pod.Name, 3)
+		if err != nil {
+			logrus.Warnf("getting events of %s: %s", pod.Name, err)
+			continue
+		}
+		for _, event := range events {
+			fmt.Fprintf(out, "   %s\n", event)
+		}
+	}
+}
+
+func isPodReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func containerProblem(status v1.ContainerStatus) string {
+	if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" {
+		return strings.TrimSpace(fmt.Sprintf("is waiting: %s %s", waiting.Reason, waiting.Message))
+	}
+	if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+		return fmt.Sprintf("exited with code %d: %s", terminated.ExitCode, terminated.Reason)
+	}
+	if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.ExitCode != 0 {
+		return fmt.Sprintf("last exited with code %d: %s", terminated.ExitCode, terminated.Reason)
+	}
+	return ""
+}
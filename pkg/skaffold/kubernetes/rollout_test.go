@@ -0,0 +1,181 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForRollouts(t *testing.T) {
+	replicas := int32(2)
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+
+	var tests = []struct {
+		description string
+		objects     []runtime.Object
+		workloads   []Workload
+		shouldErr   bool
+		expected    []string
+	}{
+		{
+			description: "deployment rolled out",
+			objects: []runtime.Object{&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas, Selector: selector},
+				Status:     appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+			}},
+			workloads: []Workload{{Kind: "Deployment", Namespace: "default", Name: "web"}},
+			expected:  []string{"deployment/web is rolled out"},
+		},
+		{
+			description: "progress deadline exceeded",
+			objects: []runtime.Object{&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas, Selector: selector},
+				Status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{{
+					Type:   appsv1.DeploymentProgressing,
+					Reason: "ProgressDeadlineExceeded",
+				}}},
+			}, &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-1234", Namespace: "default", Labels: map[string]string{"app": "web"}},
+				Status: v1.PodStatus{
+					Phase: v1.PodPending,
+					ContainerStatuses: []v1.ContainerStatus{{
+						Name:  "web",
+						State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+					}},
+				},
+			}},
+			workloads: []Workload{{Kind: "Deployment", Namespace: "default", Name: "web"}},
+			shouldErr: true,
+			expected:  []string{"pod web-1234 is Pending", "container web is waiting: ImagePullBackOff"},
+		},
+		{
+			description: "statefulset not ready in time",
+			objects: []runtime.Object{&appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas, Selector: selector},
+				Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+			}},
+			workloads: []Workload{{Kind: "StatefulSet", Namespace: "default", Name: "db"}},
+			shouldErr: true,
+		},
+		{
+			description: "daemonset rolled out",
+			objects: []runtime.Object{&appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "system"},
+				Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3},
+			}},
+			workloads: []Workload{{Kind: "DaemonSet", Namespace: "system", Name: "agent"}},
+			expected:  []string{"daemonset/agent is rolled out"},
+		},
+		{
+			description: "missing workload",
+			workloads:   []Workload{{Kind: "Deployment", Namespace: "default", Name: "web"}},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.objects...)
+			out := &bytes.Buffer{}
+
+			err := WaitForRollouts(context.Background(), client, out, test.workloads, time.Second)
+
+			testutil.CheckError(t, test.shouldErr, err)
+			for _, expected := range test.expected {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected output to contain %q, got %q", expected, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestWaitForRolloutsCancelled(t *testing.T) {
+	replicas := int32(2)
+	client := fake.NewSimpleClientset(&appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	err := WaitForRollouts(ctx, client, &bytes.Buffer{}, []Workload{{Kind: "StatefulSet", Namespace: "default", Name: "db"}}, time.Hour)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, err == context.Canceled)
+}
+
+func TestStatefulSetRollout(t *testing.T) {
+	replicas := int32(3)
+	partition := int32(2)
+
+	var tests = []struct {
+		description string
+		spec        appsv1.StatefulSetSpec
+		status      appsv1.StatefulSetStatus
+		expected    bool
+	}{
+		{
+			description: "all updated",
+			spec:        appsv1.StatefulSetSpec{Replicas: &replicas, UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType}},
+			status:      appsv1.StatefulSetStatus{ReadyReplicas: 3, UpdatedReplicas: 3},
+			expected:    true,
+		},
+		{
+			description: "not updated",
+			spec:        appsv1.StatefulSetSpec{Replicas: &replicas, UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType}},
+			status:      appsv1.StatefulSetStatus{ReadyReplicas: 3, UpdatedReplicas: 2},
+		},
+		{
+			description: "partitioned",
+			spec: appsv1.StatefulSetSpec{Replicas: &replicas, UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type:          appsv1.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: &partition},
+			}},
+			status:   appsv1.StatefulSetStatus{ReadyReplicas: 3, UpdatedReplicas: 1},
+			expected: true,
+		},
+		{
+			description: "on delete",
+			spec:        appsv1.StatefulSetSpec{Replicas: &replicas, UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}},
+			status:      appsv1.StatefulSetStatus{ReadyReplicas: 3},
+			expected:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			r := statefulSetRollout(&appsv1.StatefulSet{Spec: test.spec, Status: test.status})
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, r.done)
+		})
+	}
+}
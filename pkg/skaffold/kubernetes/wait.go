@@ -18,6 +18,7 @@ package kubernetes
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/golang/glog"
@@ -73,9 +74,13 @@ func WaitForPodReady(pods corev1.PodInterface, podName string) error {
 	})
 }
 
-func WaitForPodComplete(pods corev1.PodInterface, podName string) error {
+// WaitForPodComplete waits for a pod to succeed. If it times out, the error
+// explains why the pod is still pending, for example if it can't be scheduled.
+func WaitForPodComplete(pods corev1.PodInterface, podName string, timeout time.Duration) error {
 	logrus.Infof("Waiting for %s to be ready", podName)
-	return wait.PollImmediate(time.Millisecond*500, time.Minute*10, func() (bool, error) {
+
+	var pendingReason string
+	err := wait.PollImmediate(time.Millisecond*500, timeout, func() (bool, error) {
 		pod, err := pods.Get(podName, meta_v1.GetOptions{
 			IncludeUninitialized: true,
 		})
@@ -91,10 +96,130 @@ func WaitForPodComplete(pods corev1.PodInterface, podName string) error {
 		case v1.PodFailed:
 			return false, fmt.Errorf("pod already in terminal phase: %s", pod.Status.Phase)
 		case v1.PodUnknown, v1.PodPending:
+			if reason := podPendingReason(pod); reason != "" && reason != pendingReason {
+				logrus.Warnf("Pod %s is pending: %s", podName, reason)
+				pendingReason = reason
+			}
 			return false, nil
 		}
 		return false, fmt.Errorf("unknown phase: %s", pod.Status.Phase)
 	})
+
+	if err == wait.ErrWaitTimeout && pendingReason != "" {
+		return fmt.Errorf("pod %s didn't complete within %s, still pending: %s", podName, timeout, pendingReason)
+	}
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("pod %s didn't complete within %s", podName, timeout)
+	}
+	return err
+}
+
+// WaitForPodScheduled waits for a pod to be assigned to a node. If it times out,
+// the error explains why the pod is still pending.
+func WaitForPodScheduled(pods corev1.PodInterface, podName string, timeout time.Duration) error {
+	logrus.Infof("Waiting for %s to be scheduled", podName)
+
+	var pendingReason string
+	err := wait.PollImmediate(time.Millisecond*500, timeout, func() (bool, error) {
+		pod, err := pods.Get(podName, meta_v1.GetOptions{
+			IncludeUninitialized: true,
+		})
+		if err != nil {
+			logrus.Infof("Getting pod %s", err)
+			return false, nil
+		}
+		if pod.Spec.NodeName != "" || pod.Status.Phase != v1.PodPending {
+			return true, nil
+		}
+
+		pendingReason = podPendingReason(pod)
+		return false, nil
+	})
+
+	if err == wait.ErrWaitTimeout && pendingReason != "" {
+		return fmt.Errorf("pod %s wasn't scheduled within %s: %s", podName, timeout, pendingReason)
+	}
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("pod %s wasn't scheduled within %s", podName, timeout)
+	}
+	return err
+}
+
+// PodEvents returns the messages of the most recent events about a pod, oldest first.
+func PodEvents(events corev1.EventInterface, podName string, max int) ([]string, error) {
+	list, err := events.List(meta_v1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.name", podName).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := list.Items
+	sort.Slice(items, func(i, j int) bool { return items[i].LastTimestamp.Before(&items[j].LastTimestamp) })
+	if len(items) > max {
+		items = items[len(items)-max:]
+	}
+
+	var messages []string
+	for _, event := range items {
+		messages = append(messages, fmt.Sprintf("%s: %s", event.Reason, event.Message))
+	}
+	return messages, nil
+}
+
+// WaitForInitContainerRunning waits for an init container of a pod to be running.
+func WaitForInitContainerRunning(pods corev1.PodInterface, podName, containerName string, timeout time.Duration) error {
+	logrus.Infof("Waiting for init container %s of %s to be running", containerName, podName)
+
+	var pendingReason string
+	err := wait.PollImmediate(time.Millisecond*500, timeout, func() (bool, error) {
+		pod, err := pods.Get(podName, meta_v1.GetOptions{
+			IncludeUninitialized: true,
+		})
+		if err != nil {
+			logrus.Infof("Getting pod %s", err)
+			return false, nil
+		}
+
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.Name != containerName {
+				continue
+			}
+			if status.State.Running != nil {
+				return true, nil
+			}
+			if status.State.Terminated != nil {
+				return false, fmt.Errorf("init container %s terminated: %s", containerName, status.State.Terminated.Reason)
+			}
+		}
+
+		pendingReason = podPendingReason(pod)
+		return false, nil
+	})
+
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("init container %s of pod %s isn't running after %s: %s", containerName, podName, timeout, pendingReason)
+	}
+	return err
+}
+
+// podPendingReason explains why a pod is pending: it can't be scheduled or
+// one of its containers is waiting, for example on an image pull.
+func podPendingReason(pod *v1.Pod) string {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse {
+			return fmt.Sprintf("%s: %s", condition.Reason, condition.Message)
+		}
+	}
+
+	statuses := append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" && waiting.Reason != "ContainerCreating" && waiting.Reason != "PodInitializing" {
+			return fmt.Sprintf("container %s is waiting: %s %s", status.Name, waiting.Reason, waiting.Message)
+		}
+	}
+
+	return ""
 }
 
 type PodStore struct {
@@ -144,3 +144,137 @@ func TestWaitForPodReady(t *testing.T) {
 		})
 	}
 }
+
+func TestWaitForPodCompleteUnschedulable(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "podname",
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodPending,
+			Conditions: []v1.PodCondition{
+				{
+					Type:    v1.PodScheduled,
+					Status:  v1.ConditionFalse,
+					Reason:  "Unschedulable",
+					Message: "0/3 nodes are available: 3 Insufficient memory.",
+				},
+			},
+		},
+	}
+	client := fake.NewSimpleClientset(pod)
+
+	err := WaitForPodComplete(client.CoreV1().Pods(""), "podname", time.Second)
+
+	testutil.CheckErrorAndDeepEqual(t, true, err, "pod podname didn't complete within 1s, still pending: Unschedulable: 0/3 nodes are available: 3 Insufficient memory.", err.Error())
+}
+
+func TestWaitForPodScheduled(t *testing.T) {
+	var tests = []struct {
+		description string
+		pod         *v1.Pod
+		shouldErr   bool
+		expected    string
+	}{
+		{
+			description: "scheduled",
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "podname"},
+				Spec:       v1.PodSpec{NodeName: "node-1"},
+				Status:     v1.PodStatus{Phase: v1.PodPending},
+			},
+		},
+		{
+			description: "unschedulable",
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "podname"},
+				Status: v1.PodStatus{
+					Phase: v1.PodPending,
+					Conditions: []v1.PodCondition{
+						{
+							Type:    v1.PodScheduled,
+							Status:  v1.ConditionFalse,
+							Reason:  "Unschedulable",
+							Message: "0/1 nodes are available: 1 node(s) were not ready.",
+						},
+					},
+				},
+			},
+			shouldErr: true,
+			expected:  "pod podname wasn't scheduled within 1s: Unschedulable: 0/1 nodes are available: 1 node(s) were not ready.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.pod)
+
+			err := WaitForPodScheduled(client.CoreV1().Pods(""), "podname", time.Second)
+
+			var message string
+			if err != nil {
+				message = err.Error()
+			}
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, message)
+		})
+	}
+}
+
+func TestPodEvents(t *testing.T) {
+	event := func(name, reason string, minutesAgo int) *v1.Event {
+		return &v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+			InvolvedObject: v1.ObjectReference{Name: "podname"},
+			Reason:         reason,
+			Message:        name,
+			LastTimestamp:  metav1.NewTime(time.Now().Add(-time.Duration(minutesAgo) * time.Minute)),
+		}
+	}
+	client := fake.NewSimpleClientset(
+		event("third", "FailedScheduling", 1),
+		event("first", "FailedScheduling", 3),
+		event("second", "FailedScheduling", 2),
+	)
+
+	events, err := PodEvents(client.CoreV1().Events("default"), "podname", 2)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"FailedScheduling: second", "FailedScheduling: third"}, events)
+}
+
+func TestPodPendingReason(t *testing.T) {
+	var tests = []struct {
+		description string
+		status      v1.PodStatus
+		expected    string
+	}{
+		{
+			description: "scheduled",
+			status:      podReadyState.Status,
+		},
+		{
+			description: "creating container",
+			status: v1.PodStatus{
+				ContainerStatuses: []v1.ContainerStatus{
+					{Name: "kaniko", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+				},
+			},
+		},
+		{
+			description: "image pull",
+			status: v1.PodStatus{
+				ContainerStatuses: []v1.ContainerStatus{
+					{Name: "kaniko", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ErrImagePull", Message: "not found"}}},
+				},
+			},
+			expected: "container kaniko is waiting: ErrImagePull not found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			reason := podPendingReason(&v1.Pod{Status: test.status})
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, reason)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preview
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Label marks the namespaces of preview environments. Its value is the
+	// slug of the branch.
+	Label = "skaffold.dev/preview"
+
+	// BranchAnnotation holds the name of the branch of a preview environment.
+	BranchAnnotation = "skaffold.dev/branch"
+
+	namespacePrefix = "preview-"
+	maxNameLength   = 63
+)
+
+var invalidChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// Preview is a preview environment deployed for a git branch.
+type Preview struct {
+	Branch    string
+	Namespace string
+	Created   time.Time
+}
+
+// CurrentBranch returns the git branch checked out in the repository
+// that contains dir.
+func CurrentBranch(dir string) (string, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", errors.Wrap(err, "opening git repo")
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "reading current git branch")
+	}
+	if !head.Name().IsBranch() {
+		return "", errors.New("not on a git branch")
+	}
+	return head.Name().Short(), nil
+}
+
+// Slug turns a branch name into a valid DNS label, to be used in names,
+// label values and image tags.
+func Slug(branch string) string {
+	slug := invalidChars.ReplaceAllString(strings.ToLower(branch), "-")
+	slug = strings.Trim(slug, "-")
+	if len(slug) > maxNameLength-len(namespacePrefix) {
+		slug = strings.TrimRight(slug[:maxNameLength-len(namespacePrefix)], "-")
+	}
+	return slug
+}
+
+// Namespace is the namespace of a branch's preview environment.
+func Namespace(branch string) string {
+	return namespacePrefix + Slug(branch)
+}
+
+// Apply changes a pipeline so that it deploys the preview environment of
+// a branch: helm releases are deployed to the preview namespace, with the
+// branch in their names, and images are tagged with the branch and the
+// current commit, plus a suffix unique to uncommitted changes.
+//
+// Manifests are deployed with the preview namespace too, so they can't set
+// metadata.namespace: kubectl refuses to apply a manifest to a namespace
+// other than its own.
+func Apply(cfg *v1alpha2.SkaffoldConfig, branch string) {
+	slug := Slug(branch)
+
+	cfg.Build.TagPolicy = v1alpha2.TagPolicy{
+		GitTagger: &v1alpha2.GitTagger{
+			Prefix: slug + "-",
+		},
+	}
+
+	if cfg.Deploy.HelmDeploy != nil {
+		for i := range cfg.Deploy.HelmDeploy.Releases {
+			r := &cfg.Deploy.HelmDeploy.Releases[i]
+			r.Name = fmt.Sprintf("%s-%s", r.Name, slug)
+			r.Namespace = Namespace(branch)
+		}
+	}
+}
+
+// CreateNamespace creates the namespace of a branch's preview environment,
+// unless it already exists.
+func CreateNamespace(client kubernetes.Interface, branch string) error {
+	_, err := client.CoreV1().Namespaces().Create(&v1.Namespace{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        Namespace(branch),
+			Labels:      map[string]string{Label: Slug(branch)},
+			Annotations: map[string]string{BranchAnnotation: branch},
+		},
+	})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "creating namespace %s", Namespace(branch))
+	}
+	return nil
+}
+
+// DeleteNamespace deletes the namespace of a branch's preview environment,
+// along with everything it contains.
+func DeleteNamespace(client kubernetes.Interface, branch string) error {
+	err := client.CoreV1().Namespaces().Delete(Namespace(branch), &meta_v1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "deleting namespace %s", Namespace(branch))
+	}
+	return nil
+}
+
+// List returns the preview environments of the cluster, sorted by branch.
+func List(client kubernetes.Interface) ([]Preview, error) {
+	namespaces, err := client.CoreV1().Namespaces().List(meta_v1.ListOptions{
+		LabelSelector: Label,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing preview namespaces")
+	}
+
+	var previews []Preview
+	for _, ns := range namespaces.Items {
+		branch := ns.Annotations[BranchAnnotation]
+		if branch == "" {
+			branch = ns.Labels[Label]
+		}
+		previews = append(previews, Preview{
+			Branch:    branch,
+			Namespace: ns.Name,
+			Created:   ns.CreationTimestamp.Time,
+		})
+	}
+
+	sort.Slice(previews, func(i, j int) bool { return previews[i].Branch < previews[j].Branch })
+	return previews, nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preview
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSlug(t *testing.T) {
+	var tests = []struct {
+		description string
+		branch      string
+		expected    string
+	}{
+		{
+			description: "simple branch",
+			branch:      "master",
+			expected:    "master",
+		},
+		{
+			description: "branch with slashes and capitals",
+			branch:      "feature/Login_Page",
+			expected:    "feature-login-page",
+		},
+		{
+			description: "leading and trailing invalid characters",
+			branch:      "_fix/",
+			expected:    "fix",
+		},
+		{
+			description: "long branch",
+			branch:      "a-very-long-branch-name-that-would-not-fit-in-a-namespace-name-at-all",
+			expected:    "a-very-long-branch-name-that-would-not-fit-in-a-namespa",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			slug := Slug(test.branch)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, slug)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, "preview-"+test.expected, Namespace(test.branch))
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	cfg := &v1alpha2.SkaffoldConfig{
+		Build: v1alpha2.BuildConfig{
+			TagPolicy: v1alpha2.TagPolicy{ShaTagger: &v1alpha2.ShaTagger{}},
+		},
+		Deploy: v1alpha2.DeployConfig{
+			DeployType: v1alpha2.DeployType{
+				HelmDeploy: &v1alpha2.HelmDeploy{
+					Releases: []v1alpha2.HelmRelease{{Name: "app", Namespace: "default"}},
+				},
+			},
+		},
+	}
+
+	Apply(cfg, "feature/login")
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, v1alpha2.TagPolicy{
+		GitTagger: &v1alpha2.GitTagger{Prefix: "feature-login-"},
+	}, cfg.Build.TagPolicy)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []v1alpha2.HelmRelease{{Name: "app-feature-login", Namespace: "preview-feature-login"}}, cfg.Deploy.HelmDeploy.Releases)
+}
+
+func TestNamespaceLifecycle(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.Namespace{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "default"},
+	})
+
+	for _, branch := range []string{"master", "feature/login", "master"} {
+		err := CreateNamespace(client, branch)
+		testutil.CheckError(t, false, err)
+	}
+
+	previews, err := List(client)
+	testutil.CheckErrorAndDeepEqual(t, false, err, []Preview{
+		{Branch: "feature/login", Namespace: "preview-feature-login"},
+		{Branch: "master", Namespace: "preview-master"},
+	}, previews)
+
+	for _, branch := range []string{"master", "master"} {
+		err := DeleteNamespace(client, branch)
+		testutil.CheckError(t, false, err)
+	}
+
+	previews, err = List(client)
+	testutil.CheckErrorAndDeepEqual(t, false, err, []Preview{
+		{Branch: "feature/login", Namespace: "preview-feature-login"},
+	}, previews)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/sirupsen/logrus"
+)
+
+// withImageRepositories makes the deployed images point to the repositories
+// that the built images are mirrored to. The tag or digest is kept, as well
+// as the image name that manifests refer to.
+func withImageRepositories(builds []build.Build, repositories map[string]string) []build.Build {
+	var mapped []build.Build
+	for _, b := range builds {
+		repository, present := repositories[b.ImageName]
+		if !present {
+			mapped = append(mapped, b)
+			continue
+		}
+
+		suffix := strings.TrimPrefix(b.Tag, b.ImageName)
+		if suffix == b.Tag || (suffix != "" && suffix[0] != ':' && suffix[0] != '@') {
+			logrus.Warnf("Not mapping %s to %s: its tag %s has a different repository", b.ImageName, repository, b.Tag)
+			mapped = append(mapped, b)
+			continue
+		}
+
+		logrus.Debugf("Deploying %s from %s", b.ImageName, repository)
+		b.Tag = repository + suffix
+		mapped = append(mapped, b)
+	}
+	return mapped
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestWithImageRepositories(t *testing.T) {
+	repositories := map[string]string{
+		"gcr.io/dev/web": "eu.gcr.io/prod/web",
+		"gcr.io/dev/api": "eu.gcr.io/prod/api",
+		"gcr.io/dev/db":  "eu.gcr.io/prod/db",
+	}
+
+	builds := []build.Build{
+		{ImageName: "gcr.io/dev/web", Tag: "gcr.io/dev/web:v1"},
+		{ImageName: "gcr.io/dev/api", Tag: "gcr.io/dev/api@sha256:abc"},
+		{ImageName: "gcr.io/dev/db", Tag: "gcr.io/dev/db-custom:v1"},
+		{ImageName: "gcr.io/dev/worker", Tag: "gcr.io/dev/worker:v1"},
+	}
+
+	expected := []build.Build{
+		{ImageName: "gcr.io/dev/web", Tag: "eu.gcr.io/prod/web:v1"},
+		{ImageName: "gcr.io/dev/api", Tag: "eu.gcr.io/prod/api@sha256:abc"},
+		{ImageName: "gcr.io/dev/db", Tag: "gcr.io/dev/db-custom:v1"},
+		{ImageName: "gcr.io/dev/worker", Tag: "gcr.io/dev/worker:v1"},
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, expected, withImageRepositories(builds, repositories))
+}
@@ -17,11 +17,17 @@ limitations under the License.
 package runner
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
+	gosync "sync"
 	"syscall"
 	"time"
 
@@ -30,8 +36,14 @@ import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kaniko"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/simulate"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/sync"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/watch"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -46,12 +58,17 @@ type SkaffoldRunner struct {
 	tag.Tagger
 	watch.WatcherFactory
 
-	opts       *config.SkaffoldOptions
-	config     *config.SkaffoldConfig
-	kubeclient clientgo.Interface
-	builds     []build.Build
-	depMap     *build.DependencyMap
-	out        io.Writer
+	opts        *config.SkaffoldOptions
+	config      *config.SkaffoldConfig
+	kubeclient  clientgo.Interface
+	kubeContext string
+	labels      map[string]string
+	builds      []build.Build
+	depMap      *build.DependencyMap
+	out         io.Writer
+
+	imageRepositories map[string]string
+	testJobs          map[string]*v1alpha2.TestJob
 }
 
 var kubernetesClient = kubernetes.GetClientset
@@ -64,17 +81,68 @@ func NewForConfig(opts *config.SkaffoldOptions, cfg *config.SkaffoldConfig, out
 	}
 	logrus.Infof("Using kubectl context: %s", kubeContext)
 
+	if opts.Simulate != "" {
+		if err := simulate.Enable(opts.Simulate); err != nil {
+			return nil, errors.Wrap(err, "parsing simulated faults")
+		}
+	}
+
 	builder, err := getBuilder(&cfg.Build, kubeContext)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing skaffold build config")
 	}
+	if opts.StubBuilds {
+		builder = build.NewStubBuilder()
+	} else if build.HasHooks(cfg.Build.Artifacts) {
+		builder = build.WithHooks(builder)
+	}
+	if cfg.Build.TryImportMissing && !opts.StubBuilds {
+		builder = build.WithExistingImages(builder, docker.RemoteDigest)
+	}
+	if opts.CacheArtifacts || opts.Resume {
+		builder, err = build.WithCache(builder, opts.CacheFile, tagPolicyKey(cfg.Build.TagPolicy, opts.CustomTag), func(ref string) (string, error) {
+			if id, err := docker.LocalImageID(ref); err == nil {
+				return id, nil
+			}
+			return docker.DefaultDigestResolver(false).Lookup(ref)
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "reading build cache")
+		}
+	}
+	if build.HasRequirements(cfg.Build.Artifacts) {
+		builder, err = build.WithRequirements(builder, cfg.Build.Artifacts)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing artifact requirements")
+		}
+	}
 
-	deployer, err := getDeployer(&cfg.Deploy, kubeContext)
+	labels, err := deployLabels(cfg.Deploy.Labels, opts.SkipRunLabels)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating labels")
+	}
+
+	deployer, err := getDeployer(&cfg.Deploy, kubeContext, opts, labels)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing skaffold deploy config")
 	}
+	if opts.Resume {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting working directory")
+		}
+		deployer, err = deploy.WithSessionState(deployer, &cfg.Deploy, opts.SessionFile, fmt.Sprintf("%s@%s", cwd, kubeContext))
+		if err != nil {
+			return nil, errors.Wrap(err, "reading dev session state")
+		}
+	}
+
+	jobs, err := testJobs(cfg.Build.Artifacts)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing artifact roles")
+	}
 
-	tagger, err := getTagger(cfg.Build.TagPolicy, opts.CustomTag)
+	tagger, err := NewTagger(cfg.Build.TagPolicy, opts.CustomTag, cfg.Build.Artifacts)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing skaffold tag config")
 	}
@@ -84,6 +152,11 @@ func NewForConfig(opts *config.SkaffoldOptions, cfg *config.SkaffoldConfig, out
 		return nil, errors.Wrap(err, "getting k8s client")
 	}
 
+	watcherFactory, err := getWatcherFactory(opts, out)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating watcher")
+	}
+
 	return &SkaffoldRunner{
 		config:         cfg,
 		Builder:        builder,
@@ -91,12 +164,100 @@ func NewForConfig(opts *config.SkaffoldOptions, cfg *config.SkaffoldConfig, out
 		Tagger:         tagger,
 		opts:           opts,
 		kubeclient:     client,
-		WatcherFactory: watch.NewWatcher,
+		kubeContext:    kubeContext,
+		labels:         labels,
+		WatcherFactory: watcherFactory,
 		out:            out,
+
+		imageRepositories: cfg.ImageRepositories,
+		testJobs:          jobs,
 	}, nil
 }
 
+// getWatcherFactory returns a factory for file watchers, or for watchers that
+// replay the changes of a previous session. Changes can also be recorded.
+func getWatcherFactory(opts *config.SkaffoldOptions, out io.Writer) (watch.WatcherFactory, error) {
+	var factory watch.WatcherFactory
+	switch opts.Trigger {
+	case watch.NotifyTrigger, "", watch.PollingTrigger:
+		var err error
+		if factory, err = FileWatcherFactory(opts); err != nil {
+			return nil, err
+		}
+	case watch.ManualTrigger:
+		factory = watch.NewKeypress(os.Stdin, out).NewWatcher
+	default:
+		return nil, fmt.Errorf("unknown trigger: %s", opts.Trigger)
+	}
+
+	if opts.ReplayFile != "" {
+		recording, err := watch.ReadRecording(opts.ReplayFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading changes to replay from %s", opts.ReplayFile)
+		}
+		factory = watch.NewReplayer(recording, out).NewWatcher
+	}
+	if opts.RecordFile != "" {
+		factory = watch.NewRecorder(opts.RecordFile, factory).NewWatcher
+	}
+
+	return factory, nil
+}
+
+// FileWatcherFactory returns a factory for watchers that notice when files
+// change: by polling if that's the configured trigger, and with file system
+// notifications otherwise. It's meant for files like skaffold.yaml, that are
+// watched whatever triggers the rebuilds.
+func FileWatcherFactory(opts *config.SkaffoldOptions) (watch.WatcherFactory, error) {
+	if opts.Trigger != watch.PollingTrigger {
+		return watch.NewWatcher, nil
+	}
+	if opts.WatchPollInterval <= 0 {
+		return nil, fmt.Errorf("invalid watch poll interval: %s", opts.WatchPollInterval)
+	}
+	return watch.NewPollingWatcherFactory(opts.WatchPollInterval), nil
+}
+
 func getBuilder(cfg *v1alpha2.BuildConfig, kubeContext string) (build.Builder, error) {
+	defaultBuilder, err := newBuilder(cfg, kubeContext)
+	if err != nil {
+		return nil, err
+	}
+
+	builders := map[string]build.Builder{}
+	for _, a := range cfg.Artifacts {
+		if a.Builder == nil {
+			continue
+		}
+
+		artifactCfg := *cfg
+		artifactCfg.Artifacts = []*v1alpha2.Artifact{a}
+		artifactCfg.BuildType = *a.Builder
+
+		builder, err := newBuilder(&artifactCfg, kubeContext)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating builder for %s", a.ImageName)
+		}
+		builders[a.ImageName] = builder
+	}
+	if len(builders) == 0 {
+		return defaultBuilder, nil
+	}
+
+	return build.WithPerArtifactBuilders(defaultBuilder, builders), nil
+}
+
+// builtRemotely tells if an artifact is built by kaniko or Google Cloud
+// Build, that push images straight to the registry.
+func builtRemotely(cfg *v1alpha2.BuildConfig, a *v1alpha2.Artifact) bool {
+	buildType := cfg.BuildType
+	if a != nil && a.Builder != nil {
+		buildType = *a.Builder
+	}
+	return buildType.LocalBuild == nil
+}
+
+func newBuilder(cfg *v1alpha2.BuildConfig, kubeContext string) (build.Builder, error) {
 	if cfg.LocalBuild != nil {
 		logrus.Debugf("Using builder: local")
 		return build.NewLocalBuilder(cfg, kubeContext)
@@ -107,38 +268,181 @@ func getBuilder(cfg *v1alpha2.BuildConfig, kubeContext string) (build.Builder, e
 	}
 	if cfg.KanikoBuild != nil {
 		logrus.Debugf("Using builder: kaniko")
-		return build.NewKanikoBuilder(cfg)
+		return build.NewKanikoBuilder(cfg, kubeContext)
 	}
 
 	return nil, fmt.Errorf("Unknown builder for config %+v", cfg)
 }
 
-func getDeployer(cfg *v1alpha2.DeployConfig, kubeContext string) (deploy.Deployer, error) {
+// deployable leaves out the test images from the build results, and moves
+// images to the repositories they're deployed from.
+func (r *SkaffoldRunner) deployable(bRes *build.BuildResult) *build.BuildResult {
+	if len(r.testJobs) > 0 {
+		bRes = &build.BuildResult{
+			Builds: withoutTestImages(bRes.Builds, r.testJobs),
+		}
+	}
+	if len(r.imageRepositories) > 0 {
+		bRes = &build.BuildResult{
+			Builds: withImageRepositories(bRes.Builds, r.imageRepositories),
+		}
+	}
+	return bRes
+}
+
+// checkStatus waits for the deployed workloads to be rolled out, when a
+// status check is configured or when after hooks need the new pods.
+func (r *SkaffoldRunner) checkStatus(ctx context.Context, dRes *deploy.Result) error {
+	if dRes == nil || len(dRes.Workloads) == 0 || !deploy.WaitsForRollouts(&r.config.Deploy) {
+		return nil
+	}
+
+	deadlineValue := constants.DefaultStatusCheckDeadline
+	if statusCheck := r.config.Deploy.StatusCheck; statusCheck != nil {
+		deadlineValue = statusCheck.Deadline
+	}
+	deadline, err := time.ParseDuration(deadlineValue)
+	if err != nil {
+		return errors.Wrapf(err, "parsing status check deadline %s", deadlineValue)
+	}
+
+	fmt.Fprintln(r.out, "Waiting for deployments to roll out...")
+	return kubernetes.WaitForRollouts(ctx, r.kubeclient, r.out, dRes.Workloads, deadline)
+}
+
+// deployLabels lists the labels of the deployed resources: those configured
+// and, unless they're skipped, the id of this run and managed-by.
+func deployLabels(configured map[string]string, skipRunLabels bool) (map[string]string, error) {
+	labels := map[string]string{}
+	for k, v := range configured {
+		labels[k] = v
+	}
+	if skipRunLabels {
+		return labels, nil
+	}
+
+	runID, err := newRunID()
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("Run id: %s", runID)
+
+	for k, v := range deploy.SkaffoldLabels(runID) {
+		labels[k] = v
+	}
+	return labels, nil
+}
+
+// newRunID generates a random, version 4, UUID.
+func newRunID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", errors.Wrap(err, "reading random bytes")
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// getDeployer returns the configured deployer. When both kubectl and helm are
+// configured, raw manifests are deployed before the charts.
+func getDeployer(cfg *v1alpha2.DeployConfig, kubeContext string, opts *config.SkaffoldOptions, labels map[string]string) (deploy.Deployer, error) {
+	var deployers deploy.DeployerMux
 	if cfg.KubectlDeploy != nil {
-		return deploy.NewKubectlDeployer(cfg, kubeContext), nil
+		kubectl := deploy.NewKubectlDeployer(cfg, kubeContext)
+		kubectl.DevMode = opts.DevMode
+		kubectl.Debug = opts.Debug
+		if opts.Namespace != "" {
+			kubectl.Namespace = opts.Namespace
+		}
+		kubectl.Labels = labels
+		deployers = append(deployers, deploy.NamedDeployer{Name: "kubectl", Deployer: kubectl})
+	}
+	if cfg.JsonnetDeploy != nil {
+		jsonnet := deploy.NewJsonnetDeployer(cfg, kubeContext, opts.Namespace)
+		jsonnet.Labels = labels
+		jsonnet.Debug = opts.Debug
+		deployers = append(deployers, deploy.NamedDeployer{Name: "jsonnet", Deployer: jsonnet})
 	}
 	if cfg.HelmDeploy != nil {
-		return deploy.NewHelmDeployer(cfg, kubeContext), nil
+		deployers = append(deployers, deploy.NamedDeployer{Name: "helm", Deployer: deploy.NewHelmDeployer(cfg, kubeContext)})
 	}
 
-	return nil, fmt.Errorf("Unknown deployer for config %+v", cfg)
+	switch len(deployers) {
+	case 0:
+		return nil, fmt.Errorf("Unknown deployer for config %+v", cfg)
+	case 1:
+		return deployers[0].Deployer, nil
+	default:
+		return deployers, nil
+	}
 }
 
-func getTagger(t v1alpha2.TagPolicy, customTag string) (tag.Tagger, error) {
+// NewTagger creates the tagger for a tag policy. A custom tag overrides the policy.
+// Generated tags are sanitized so that registries accept them.
+func NewTagger(t v1alpha2.TagPolicy, customTag string, artifacts []*v1alpha2.Artifact) (tag.Tagger, error) {
 	if customTag != "" {
-		return &tag.CustomTag{
+		return tag.WithSanitization(&tag.CustomTag{
 			Tag: customTag,
-		}, nil
+		}), nil
 	}
 
+	tagger, err := newPolicyTagger(t, artifacts)
+	if err != nil {
+		return nil, err
+	}
+	return tag.WithSanitization(tagger), nil
+}
+
+// tagPolicyKey identifies how images are tagged, so that cached builds are
+// only reused with the same tag policy.
+func tagPolicyKey(t v1alpha2.TagPolicy, customTag string) string {
+	if customTag != "" {
+		return "custom:" + customTag
+	}
+	policy, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Sprintf("%+v", t)
+	}
+	return string(policy)
+}
+
+func newPolicyTagger(t v1alpha2.TagPolicy, artifacts []*v1alpha2.Artifact) (tag.Tagger, error) {
+	tagger, err := newPrimaryTagger(t, artifacts)
+	if err != nil || len(t.Fallback) == 0 {
+		return tagger, err
+	}
+
+	taggers := []tag.Tagger{tagger}
+	for _, fallback := range t.Fallback {
+		tagger, err := newPolicyTagger(fallback, artifacts)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating fallback tagger")
+		}
+		taggers = append(taggers, tagger)
+	}
+
+	return &tag.FallbackTagger{Taggers: taggers}, nil
+}
+
+func newPrimaryTagger(t v1alpha2.TagPolicy, artifacts []*v1alpha2.Artifact) (tag.Tagger, error) {
 	if t.EnvTemplateTagger != nil {
 		return tag.NewEnvTemplateTagger(t.EnvTemplateTagger.Template)
 	}
+	if t.CustomCommandTagger != nil {
+		return &tag.CustomCommandTagger{
+			Command: t.CustomCommandTagger.Command,
+		}, nil
+	}
+	if t.InputDigest != nil {
+		return tag.NewInputDigestTagger(artifacts, build.InputDigest), nil
+	}
 	if t.ShaTagger != nil {
 		return &tag.ChecksumTagger{}, nil
 	}
 	if t.GitTagger != nil {
-		return &tag.GitCommit{}, nil
+		return tag.NewGitCommit(t.GitTagger.Variant, t.GitTagger.Prefix)
 	}
 
 	return nil, fmt.Errorf("Unknown tagger for strategy %s", t)
@@ -155,13 +459,66 @@ func (r *SkaffoldRunner) Build(ctx context.Context) error {
 		fmt.Fprintf(r.out, "%s -> %s\n", res.ImageName, res.Tag)
 	}
 
+	if r.opts.BuildOutputFile != "" {
+		lookupDigest := func(b build.Build) (string, error) {
+			return docker.DefaultDigestResolver(builtRemotely(&r.config.Build, b.Artifact)).Lookup(b.Tag)
+		}
+		if err := build.WriteOutputFile(r.opts.BuildOutputFile, bRes.Builds, lookupDigest, docker.LocalImageID); err != nil {
+			return errors.Wrapf(err, "writing build output to %s", r.opts.BuildOutputFile)
+		}
+	}
+
+	return nil
+}
+
+// Render builds the artifacts and writes the manifests that would be deployed,
+// either to out or, one file per resource, to a directory.
+func (r *SkaffoldRunner) Render(ctx context.Context, out io.Writer, outputDir string) error {
+	bRes, err := r.build(ctx, r.config.Build.Artifacts)
+	if err != nil {
+		return err
+	}
+
+	if outputDir == "" {
+		return r.Deployer.Render(ctx, out, r.deployable(bRes))
+	}
+
+	var buf bytes.Buffer
+	if err := r.Deployer.Render(ctx, &buf, r.deployable(bRes)); err != nil {
+		return err
+	}
+	if err := deploy.WriteManifests(outputDir, buf.Bytes()); err != nil {
+		return errors.Wrapf(err, "writing manifests to %s", outputDir)
+	}
+
+	fmt.Fprintln(r.out, "Manifests written to", outputDir)
 	return nil
 }
 
 // Run runs the skaffold build and deploy pipeline.
 func (r *SkaffoldRunner) Run(ctx context.Context) error {
-	_, _, err := r.buildAndDeploy(ctx, r.config.Build.Artifacts, nil)
-	return err
+	bRes, _, err := r.buildAndDeploy(ctx, r.config.Build.Artifacts, nil)
+	if err != nil || !r.opts.Tail {
+		return err
+	}
+
+	return r.tailLogs(ctx, bRes)
+}
+
+// tailLogs streams the logs of the deployed pods until interrupted.
+func (r *SkaffoldRunner) tailLogs(ctx context.Context, bRes *build.BuildResult) error {
+	podSelector := kubernetes.NewImageList()
+	for _, b := range bRes.Builds {
+		podSelector.AddImage(b.Tag)
+	}
+
+	logger := kubernetes.NewLogAggregator(r.out, r.runSelector(podSelector), kubernetes.NewColorPicker(r.config.Build.Artifacts))
+	if err := logger.Start(ctx, r.kubeclient.CoreV1()); err != nil {
+		return errors.Wrap(err, "starting logger")
+	}
+
+	<-ctx.Done()
+	return nil
 }
 
 // Dev watches for changes and runs the skaffold build and deploy
@@ -177,30 +534,20 @@ func (r *SkaffoldRunner) watchBuildDeploy(ctx context.Context) error {
 	artifacts := r.config.Build.Artifacts
 
 	var err error
-	r.depMap, err = build.NewDependencyMap(artifacts)
+	r.depMap, err = r.resolveDependencies()
 	if err != nil {
 		return errors.Wrap(err, "getting path to dependency map")
 	}
 
-	watcher, err := r.WatcherFactory(r.depMap.Paths())
-	if err != nil {
-		return errors.Wrap(err, "creating watcher")
-	}
-
 	deployDeps, err := r.Deployer.Dependencies()
 	if err != nil {
 		return errors.Wrap(err, "getting deploy dependencies")
 	}
 	logrus.Infof("Deployer dependencies: %s", deployDeps)
 
-	deployWatcher, err := r.WatcherFactory(deployDeps)
-	if err != nil {
-		return errors.Wrap(err, "creating deploy watcher")
-	}
-
 	podSelector := kubernetes.NewImageList()
 	colorPicker := kubernetes.NewColorPicker(artifacts)
-	logger := kubernetes.NewLogAggregator(r.out, podSelector, colorPicker)
+	logger := kubernetes.NewLogAggregator(r.out, r.runSelector(podSelector), colorPicker)
 
 	onBuildSuccess := func(bRes *build.BuildResult) {
 		// Update which images are logged with which color
@@ -209,59 +556,314 @@ func (r *SkaffoldRunner) watchBuildDeploy(ctx context.Context) error {
 		}
 	}
 
-	onChange := func(changedPaths []string) {
+	// Changes that weren't handled automatically wait for a request.
+	var (
+		lock         gosync.Mutex
+		pendingPaths []string
+		undeployed   bool
+	)
+
+	deploy := func() {
+		undeployed = false
+		if _, err := r.deployAndTest(ctx); err != nil {
+			r.reportFailure("Deploy", err)
+		}
+	}
+
+	// handleChanges syncs or rebuilds the artifacts affected by the changes
+	// and deploys them. When only deploy inputs changed, it redeploys the
+	// previous builds.
+	handleChanges := func(changedPaths []string, redeploy bool, requested bool) {
+		lock.Lock()
+		defer lock.Unlock()
 		logger.Mute()
 
-		changedArtifacts := r.depMap.ArtifactsForPaths(changedPaths)
+		controls := event.CurrentControls()
+		changedPaths = appendNew(pendingPaths, changedPaths...)
 
-		br, _, err := r.buildAndDeploy(ctx, changedArtifacts, onBuildSuccess)
-		if err != nil {
-			// In dev mode, we only log on pipeline errors
-			logrus.Errorf("run: %s", err)
-			if br == nil {
-				logrus.Error("Skipping Deploy due to build error.")
+		changedArtifacts, held := r.syncFiles(ctx, r.depMap.ArtifactsForPaths(changedPaths), changedPaths, !controls.AutoSync && !requested)
+		if len(changedArtifacts) > 0 && !controls.AutoBuild && !requested {
+			for _, a := range changedArtifacts {
+				held = appendNew(held, r.depMap.ChangedPaths(a, changedPaths)...)
 			}
+			changedArtifacts = nil
+		}
+		pendingPaths = held
+		if len(pendingPaths) > 0 {
+			fmt.Fprintf(r.out, "%d changed files are waiting for a build to be requested\n", len(pendingPaths))
 		}
 
+		if len(changedArtifacts) > 0 {
+			bRes, err := r.buildAndMerge(ctx, changedArtifacts, onBuildSuccess)
+			switch {
+			case bRes == nil:
+				r.reportFailure("Build", err)
+			case controls.AutoDeploy:
+				if err != nil {
+					fmt.Fprintln(r.out, "Deploying the artifacts that were built")
+				}
+				deploy()
+				if err != nil {
+					r.reportFailure("Build", err)
+				}
+			default:
+				if err != nil {
+					r.reportFailure("Build", err)
+				}
+				undeployed = true
+				fmt.Fprintln(r.out, "The new builds are waiting for a deploy to be requested")
+			}
+		} else if redeploy {
+			if controls.AutoDeploy {
+				deploy()
+			} else {
+				undeployed = true
+				fmt.Fprintln(r.out, "The changes are waiting for a deploy to be requested")
+			}
+		}
+
+		r.reportTimings()
 		fmt.Fprint(r.out, "Watching for changes...\n")
 		logger.Unmute()
 	}
 
-	onDeployChange := func(changedPaths []string) {
-		logger.Mute()
-		_, err := r.deploy(ctx, &build.BuildResult{
-			Builds: r.builds,
-		})
-		if err != nil {
-			logrus.Warnf("deploy: %s", err)
+	onChange := func(changedPaths []string, redeploy bool) {
+		handleChanges(changedPaths, redeploy, false)
+	}
+
+	onRequest := func(step string) {
+		switch step {
+		case event.BuildRequest:
+			handleChanges(nil, false, true)
+		case event.DeployRequest:
+			lock.Lock()
+			defer lock.Unlock()
+			logger.Mute()
+
+			if !undeployed {
+				fmt.Fprintln(r.out, "Redeploying")
+			}
+			deploy()
+
+			r.reportTimings()
+			fmt.Fprint(r.out, "Watching for changes...\n")
+			logger.Unmute()
 		}
-		fmt.Fprint(r.out, "Watching for changes...\n")
-		logger.Unmute()
 	}
 
-	onChange(r.depMap.Paths())
+	// The first build and deploy are not held back by the controls
+	handleChanges(r.depMap.Paths(), true, true)
 
 	// Start logs
 	if err = logger.Start(ctx, r.kubeclient.CoreV1()); err != nil {
 		return errors.Wrap(err, "starting logger")
 	}
 
+	if r.opts.PortForward {
+		forwarder := kubernetes.NewPortForwarder(r.out, r.runSelector(podSelector), r.kubeContext)
+		if err := forwarder.Start(ctx, r.kubeclient.CoreV1()); err != nil {
+			return errors.Wrap(err, "starting port forwarding")
+		}
+	}
+
 	// Watch files and rebuild
 	g, watchCtx := errgroup.WithContext(ctx)
 	g.Go(func() error {
-		return watcher.Start(watchCtx, onChange)
-	})
-	g.Go(func() error {
-		return deployWatcher.Start(watchCtx, onDeployChange)
+		return r.watchDependencies(watchCtx, deployDeps, onChange)
 	})
 
-	return g.Wait()
+	// Run the requested steps until the watchers stop
+	requestsCtx, stopRequests := context.WithCancel(watchCtx)
+	requestsDone := make(chan struct{})
+	go func() {
+		defer close(requestsDone)
+		for {
+			select {
+			case <-requestsCtx.Done():
+				return
+			case step := <-event.Requests():
+				onRequest(step)
+			}
+		}
+	}()
+
+	err = g.Wait()
+	stopRequests()
+	<-requestsDone
+	return err
+}
+
+// reportFailure prints an error of the dev loop so that it stands out from
+// the logs of the pods, which keep streaming from the previous version.
+func (r *SkaffoldRunner) reportFailure(step string, err error) {
+	fmt.Fprintf(r.out, "\n*** %s failed: %s\n*** The previous version is still running. Fix the error to try again.\n\n", step, err)
+}
+
+// reportTimings ends an iteration of the dev loop and prints where its time
+// went, unless the changes were all held back.
+func (r *SkaffoldRunner) reportTimings() {
+	timings := event.IterationComplete()
+	for _, phase := range []string{event.SyncPhase, event.BuildPhase, event.DeployPhase} {
+		if _, present := timings.Phases[phase]; present {
+			fmt.Fprintf(r.out, "Iteration complete in %s\n", timings)
+			return
+		}
+	}
+}
+
+// watchDependencies watches the dependencies of the artifacts and of the
+// deployer. Each change tells whether deploy inputs changed, in which case
+// a redeploy is needed even if nothing is rebuilt. After each change, the
+// dependencies are resolved again and the watch restarts if they've changed,
+// e.g. because a Dockerfile now copies more files.
+func (r *SkaffoldRunner) watchDependencies(ctx context.Context, deployDeps []string, onChange func([]string, bool)) error {
+	for {
+		watched := appendNew(r.depMap.Paths(), deployDeps...)
+		watcher, err := r.WatcherFactory(watched)
+		if err != nil {
+			return errors.Wrap(err, "creating watcher")
+		}
+
+		restart := false
+		watchCtx, cancel := context.WithCancel(ctx)
+		err = watcher.Start(watchCtx, func(changedPaths []string) {
+			// Resolve first so that the changes are built with the new dependencies
+			depMap, err := r.resolveDependencies()
+			if err != nil {
+				logrus.Warnf("resolving dependencies: %s", err)
+			} else {
+				r.depMap = depMap
+			}
+			redeploy := containsAnyPath(deployDeps, changedPaths)
+			if newDeployDeps, err := r.Deployer.Dependencies(); err != nil {
+				logrus.Warnf("getting deploy dependencies: %s", err)
+			} else {
+				redeploy = redeploy || containsAnyPath(newDeployDeps, changedPaths)
+				deployDeps = newDeployDeps
+			}
+			restart = strings.Join(appendNew(r.depMap.Paths(), deployDeps...), "\n") != strings.Join(watched, "\n")
+
+			onChange(changedPaths, redeploy)
+			if restart {
+				cancel()
+			}
+		})
+		cancel()
+
+		if err != nil || !restart {
+			return err
+		}
+		logrus.Infof("Dependencies changed, now watching: %s", appendNew(r.depMap.Paths(), deployDeps...))
+	}
+}
+
+// containsAnyPath tells if any of the paths is in the slice. The watchers
+// report cleaned paths while the deployers list them as configured.
+func containsAnyPath(slice []string, paths []string) bool {
+	for _, p := range paths {
+		for _, s := range slice {
+			if filepath.Clean(s) == filepath.Clean(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveDependencies maps the artifacts to the files they depend on.
+func (r *SkaffoldRunner) resolveDependencies() (*build.DependencyMap, error) {
+	defer event.TimePhase(event.DependenciesPhase, time.Now())
+	return build.NewDependencyMap(r.config.Build.Artifacts)
+}
+
+// runSelector picks the pods labelled with the id of this run, along with
+// those running the built images, since helm releases aren't labelled.
+func (r *SkaffoldRunner) runSelector(images *kubernetes.ImageList) kubernetes.PodSelector {
+	if runID, present := r.labels[deploy.RunIDLabel]; present {
+		return kubernetes.AnyOf{kubernetes.NewLabelSelector(deploy.RunIDLabel, runID), images}
+	}
+	return images
+}
+
+// syncFiles copies the changed files into the running containers of the
+// artifacts whose sync rules cover all their changes. It returns the
+// artifacts that still have to be rebuilt. When hold is true, nothing is
+// copied and the paths that would have been synced are returned instead.
+func (r *SkaffoldRunner) syncFiles(ctx context.Context, artifacts []*v1alpha2.Artifact, changedPaths []string, hold bool) ([]*v1alpha2.Artifact, []string) {
+	var (
+		rebuild []*v1alpha2.Artifact
+		held    []string
+	)
+
+	for _, a := range artifacts {
+		paths := r.depMap.ChangedPaths(a, changedPaths)
+		item, err := sync.NewItem(a, paths, r.deployable(&build.BuildResult{Builds: r.builds}).Builds)
+		if err != nil {
+			logrus.Warnf("Rebuilding %s: %s", a.ImageName, err)
+		}
+		if item == nil {
+			rebuild = append(rebuild, a)
+			continue
+		}
+		if hold {
+			held = appendNew(held, paths...)
+			continue
+		}
+
+		start := time.Now()
+		namespace, selector := r.syncScope()
+		err = sync.Perform(ctx, r.kubeclient, r.kubeContext, namespace, selector, item)
+		event.TimePhase(event.SyncPhase, start)
+		if err != nil {
+			logrus.Warnf("Rebuilding %s: %s", a.ImageName, err)
+			rebuild = append(rebuild, a)
+			continue
+		}
+		fmt.Fprintf(r.out, "Synced %d files and deleted %d files for %s\n", len(item.Copy), len(item.Delete), a.ImageName)
+	}
+
+	return rebuild, held
+}
+
+// appendNew appends the values that aren't in the slice yet.
+func appendNew(slice []string, values ...string) []string {
+	for _, v := range values {
+		if !util.StrSliceContains(slice, v) {
+			slice = append(slice, v)
+		}
+	}
+	return slice
 }
 
 func (r *SkaffoldRunner) buildAndDeploy(ctx context.Context, artifacts []*v1alpha2.Artifact, onBuildSuccess func(*build.BuildResult)) (*build.BuildResult, *deploy.Result, error) {
-	bRes, err := r.build(ctx, artifacts)
+	bRes, buildErr := r.buildAndMerge(ctx, artifacts, onBuildSuccess)
+	if bRes == nil {
+		return nil, nil, buildErr
+	}
+	if buildErr != nil {
+		fmt.Fprintln(r.out, "Deploying the artifacts that were built")
+	}
+
+	dRes, err := r.deployAndTest(ctx)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "build")
+		return bRes, dRes, err
+	}
+	return bRes, dRes, buildErr
+}
+
+// buildAndMerge builds the artifacts and adds them to the builds to deploy.
+// When some of the artifacts fail to build, the others are still added and
+// both the result and the error are returned.
+func (r *SkaffoldRunner) buildAndMerge(ctx context.Context, artifacts []*v1alpha2.Artifact, onBuildSuccess func(*build.BuildResult)) (*build.BuildResult, error) {
+	bRes, buildErr := r.build(ctx, artifacts)
+	if buildErr != nil {
+		partial, ok := errors.Cause(buildErr).(*build.PartialBuildError)
+		if !ok {
+			return nil, errors.Wrap(buildErr, "build")
+		}
+
+		bRes = partial.Result
+		buildErr = errors.Wrap(buildErr, "partial build")
 	}
 
 	if onBuildSuccess != nil {
@@ -271,21 +873,39 @@ func (r *SkaffoldRunner) buildAndDeploy(ctx context.Context, artifacts []*v1alph
 	// Make sure all artifacts are redeployed. Not only those that were just rebuilt.
 	r.builds = mergeWithPreviousBuilds(bRes.Builds, r.builds)
 
+	return bRes, buildErr
+}
+
+// deployAndTest deploys and tests every artifact built so far.
+func (r *SkaffoldRunner) deployAndTest(ctx context.Context) (*deploy.Result, error) {
 	dRes, err := r.deploy(ctx, &build.BuildResult{
 		Builds: r.builds,
 	})
 	if err != nil {
-		return bRes, nil, errors.Wrap(err, "deploy")
+		return nil, errors.Wrap(err, "deploy")
 	}
-
-	return bRes, dRes, nil
+	if err := r.test(r.builds); err != nil {
+		return dRes, errors.Wrap(err, "test")
+	}
+	return dRes, nil
 }
 
 func (r *SkaffoldRunner) build(ctx context.Context, artifacts []*v1alpha2.Artifact) (*build.BuildResult, error) {
 	start := time.Now()
+	defer event.TimePhase(event.BuildPhase, start)
 	fmt.Fprintln(r.out, "Starting build...")
 
+	if err := simulate.Inject(ctx, simulate.Build); err != nil {
+		return nil, errors.Wrap(err, "build step")
+	}
+	if err := checkTags(r.Tagger, artifacts); err != nil {
+		return nil, errors.Wrap(err, "build step")
+	}
+	for _, a := range artifacts {
+		event.BuildInProgress(a.ImageName)
+	}
 	bRes, err := r.Builder.Build(ctx, r.out, r.Tagger, artifacts)
+	notifyBuilds(artifacts, bRes, err)
 	if err != nil {
 		return nil, errors.Wrap(err, "build step")
 	}
@@ -295,14 +915,79 @@ func (r *SkaffoldRunner) build(ctx context.Context, artifacts []*v1alpha2.Artifa
 	return bRes, nil
 }
 
-func (r *SkaffoldRunner) deploy(ctx context.Context, bRes *build.BuildResult) (*deploy.Result, error) {
+// checkTags fails before anything is built if a tag can't be pushed, for the
+// taggers that can be run ahead of the build.
+func checkTags(tagger tag.Tagger, artifacts []*v1alpha2.Artifact) error {
+	for _, a := range artifacts {
+		if err := tag.CheckTag(tagger, a.Workspace, &tag.TagOptions{ImageName: a.ImageName}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyBuilds sends an event for each artifact that was built or failed to
+// build. After a partial build, the artifacts that were built are complete.
+func notifyBuilds(artifacts []*v1alpha2.Artifact, bRes *build.BuildResult, err error) {
+	if partial, ok := errors.Cause(err).(*build.PartialBuildError); ok {
+		bRes = partial.Result
+	} else if err != nil {
+		bRes = nil
+	}
+
+	built := map[string]bool{}
+	if bRes != nil {
+		for _, b := range bRes.Builds {
+			built[b.ImageName] = true
+			event.BuildComplete(b.ImageName)
+		}
+	}
+	for _, a := range artifacts {
+		if !built[a.ImageName] {
+			event.BuildFailed(a.ImageName, err)
+		}
+	}
+}
+
+func (r *SkaffoldRunner) deploy(ctx context.Context, bRes *build.BuildResult) (res *deploy.Result, err error) {
 	start := time.Now()
+	defer event.TimePhase(event.DeployPhase, start)
 	fmt.Fprintln(r.out, "Starting deploy...")
 
+	event.DeployInProgress()
+	defer func() {
+		if err != nil {
+			event.DeployFailed(err)
+		} else {
+			event.DeployComplete()
+		}
+	}()
+
+	if err := simulate.Inject(ctx, simulate.Deploy); err != nil {
+		return nil, errors.Wrap(err, "deploy step")
+	}
+
+	bRes = r.deployable(bRes)
+	hooks := r.config.Deploy.Hooks
+	if hooks != nil {
+		if err := deploy.RunHooks(ctx, r.out, hooks.Before, r.kubeContext, r.deployNamespace(), bRes.Builds); err != nil {
+			return nil, errors.Wrap(err, "running before deploy hooks")
+		}
+	}
+
 	dRes, err := r.Deployer.Deploy(ctx, r.out, bRes)
 	if err != nil {
 		return nil, errors.Wrap(err, "deploy step")
 	}
+	if err := r.checkStatus(ctx, dRes); err != nil {
+		return nil, errors.Wrap(err, "deploy step")
+	}
+
+	if hooks != nil {
+		if err := deploy.RunHooks(ctx, r.out, hooks.After, r.kubeContext, r.deployNamespace(), bRes.Builds); err != nil {
+			return nil, errors.Wrap(err, "running after deploy hooks")
+		}
+	}
 	if r.opts.Notification {
 		fmt.Fprint(r.out, constants.TerminalBell)
 	}
@@ -312,8 +997,41 @@ func (r *SkaffoldRunner) deploy(ctx context.Context, bRes *build.BuildResult) (*
 	return dRes, nil
 }
 
+// deployNamespace is where the manifests that don't have a namespace are
+// deployed: the namespace of the command line, then the one of kubectl.
+func (r *SkaffoldRunner) deployNamespace() string {
+	if r.opts.Namespace != "" {
+		return r.opts.Namespace
+	}
+	if kubectl := r.config.Deploy.KubectlDeploy; kubectl != nil {
+		return kubectl.Namespace
+	}
+	return ""
+}
+
+// syncScope returns the namespace and the label selector of the pods that
+// files are synced to: those deployed by this run, in the deploy namespace.
+// Without a run label, only the namespace of the current context is searched
+// when no namespace is configured.
+func (r *SkaffoldRunner) syncScope() (string, string) {
+	namespace := r.deployNamespace()
+
+	runID, present := r.labels[deploy.RunIDLabel]
+	if !present {
+		if namespace == "" {
+			namespace = kubernetes.DefaultNamespace()
+		}
+		return namespace, ""
+	}
+	return namespace, deploy.RunIDLabel + "=" + runID
+}
+
+// cleanUpOnCtrlC cleans up once the dev session is interrupted by a signal
+// or stops on its own. A session cancelled by its caller, to switch profiles
+// or to reload the configuration, keeps what it deployed for the next one.
 func cleanUpOnCtrlC(ctx context.Context, runDevMode func(context.Context) error, cleanup func(context.Context)) error {
-	ctx, cancel := context.WithCancel(ctx)
+	devCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt,
@@ -322,21 +1040,47 @@ func cleanUpOnCtrlC(ctx context.Context, runDevMode func(context.Context) error,
 		syscall.SIGPIPE,
 	)
 
+	interrupted := make(chan struct{})
 	go func() {
-		<-signals
-		cancel()
+		select {
+		case <-signals:
+			// From now on, a second Ctrl-C exits without cleaning up.
+			signal.Stop(signals)
+			close(interrupted)
+			cancel()
+		case <-devCtx.Done():
+		}
 	}()
 
-	errRun := runDevMode(ctx)
-	cleanup(ctx)
+	errRun := runDevMode(devCtx)
+
+	// The dev context is cancelled by now.
+	signal.Stop(signals)
+
+	select {
+	case <-interrupted:
+	default:
+		if ctx.Err() != nil {
+			return errRun
+		}
+	}
+
+	cleanup(context.Background())
 	return errRun
 }
 
+// cleanup deletes what was deployed, along with what interrupted builds
+// left on the cluster.
 func (r *SkaffoldRunner) cleanup(ctx context.Context) {
 	start := time.Now()
 	fmt.Fprintln(r.out, "Cleaning up...")
 
 	err := r.Deployer.Cleanup(ctx, r.out)
+	for _, namespace := range kanikoNamespaces(&r.config.Build) {
+		if kErr := kaniko.Cleanup(r.kubeclient.CoreV1(), namespace); kErr != nil {
+			logrus.Warnf("cleaning up kaniko builds: %s", kErr)
+		}
+	}
 	if err != nil {
 		logrus.Warnf("cleanup: %s", err)
 		return
@@ -345,6 +1089,20 @@ func (r *SkaffoldRunner) cleanup(ctx context.Context) {
 	fmt.Fprintln(r.out, "Cleanup complete in", time.Since(start))
 }
 
+// kanikoNamespaces lists the namespaces where artifacts are built with kaniko.
+func kanikoNamespaces(cfg *v1alpha2.BuildConfig) []string {
+	var namespaces []string
+	if cfg.KanikoBuild != nil {
+		namespaces = append(namespaces, cfg.KanikoBuild.Namespace)
+	}
+	for _, a := range cfg.Artifacts {
+		if a.Builder != nil && a.Builder.KanikoBuild != nil {
+			namespaces = appendNew(namespaces, a.Builder.KanikoBuild.Namespace)
+		}
+	}
+	return namespaces
+}
+
 func mergeWithPreviousBuilds(builds, previous []build.Build) []build.Build {
 	updatedBuilds := map[string]bool{}
 	for _, build := range builds {
@@ -21,6 +21,9 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"regexp"
+	"syscall"
 	"testing"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
@@ -62,22 +65,29 @@ func (t *TestBuildAll) Build(ctx context.Context, w io.Writer, tagger tag.Tagger
 }
 
 type TestDeployer struct {
-	res *deploy.Result
-	err error
+	res          *deploy.Result
+	err          error
+	dependencies []string
+	deploys      int
 }
 
 func (t *TestDeployer) Deploy(context.Context, io.Writer, *build.BuildResult) (*deploy.Result, error) {
+	t.deploys++
 	return t.res, t.err
 }
 
 func (t *TestDeployer) Dependencies() ([]string, error) {
-	return nil, nil
+	return t.dependencies, nil
 }
 
 func (t *TestDeployer) Cleanup(ctx context.Context, out io.Writer) error {
 	return nil
 }
 
+func (t *TestDeployer) Render(context.Context, io.Writer, *build.BuildResult) error {
+	return t.err
+}
+
 type TestDeployAll struct {
 	deployed *build.BuildResult
 }
@@ -95,6 +105,11 @@ func (t *TestDeployAll) Cleanup(ctx context.Context, out io.Writer) error {
 	return nil
 }
 
+func (t *TestDeployAll) Render(ctx context.Context, out io.Writer, bRes *build.BuildResult) error {
+	t.deployed = bRes
+	return nil
+}
+
 type TestTagger struct {
 	out string
 	err error
@@ -342,6 +357,21 @@ func TestDev(t *testing.T) {
 				out:            ioutil.Discard,
 			},
 		},
+		{
+			description: "run dev mode deploy error, continue",
+			runner: &SkaffoldRunner{
+				config:     &v1alpha2.SkaffoldConfig{},
+				kubeclient: client,
+				Builder: &TestBuilder{
+					res: &build.BuildResult{},
+				},
+				Deployer:       &TestDeployer{err: fmt.Errorf("")},
+				Tagger:         &TestTagger{},
+				WatcherFactory: NewWatcherFactory(nil, []string{}),
+				opts:           &config.SkaffoldOptions{},
+				out:            ioutil.Discard,
+			},
+		},
 		{
 			description: "bad watch dev mode",
 			runner: &SkaffoldRunner{
@@ -369,16 +399,63 @@ func TestDev(t *testing.T) {
 	}
 }
 
+func TestDevRedeploysOnDeployChanges(t *testing.T) {
+	client, _ := fakeGetClient()
+	var tests = []struct {
+		description string
+		changes     [][]string
+		deploys     int
+	}{
+		{
+			description: "manifest changed",
+			changes:     [][]string{{"k8s/pod.yaml"}},
+			deploys:     2,
+		},
+		{
+			description: "manifest changed twice",
+			changes:     [][]string{{"./k8s/pod.yaml"}, {"k8s/pod.yaml"}},
+			deploys:     3,
+		},
+		{
+			description: "unrelated change",
+			changes:     [][]string{{"README.md"}},
+			deploys:     1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			deployer := &TestDeployer{dependencies: []string{"k8s/pod.yaml"}}
+			runner := &SkaffoldRunner{
+				config:         &v1alpha2.SkaffoldConfig{},
+				kubeclient:     client,
+				Builder:        &TestBuilder{res: &build.BuildResult{}},
+				Deployer:       deployer,
+				Tagger:         &TestTagger{},
+				WatcherFactory: NewWatcherFactory(nil, test.changes...),
+				opts:           &config.SkaffoldOptions{},
+				out:            ioutil.Discard,
+			}
+
+			err := runner.Dev(context.Background())
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.deploys, deployer.deploys)
+		})
+	}
+}
+
 func TestBuildAndDeployAllArtifacts(t *testing.T) {
 	kubeclient, _ := fakeGetClient()
 	builder := &TestBuildAll{}
 	deployer := &TestDeployAll{}
 
 	runner := &SkaffoldRunner{
+		config:     &v1alpha2.SkaffoldConfig{},
 		opts:       &config.SkaffoldOptions{},
 		kubeclient: kubeclient,
 		Builder:    builder,
 		Deployer:   deployer,
+		Tagger:     &tag.ChecksumTagger{},
 		out:        ioutil.Discard,
 	}
 
@@ -415,3 +492,224 @@ func TestBuildAndDeployAllArtifacts(t *testing.T) {
 		t.Errorf("Expected 2 artifacts to be deployed. Got %d", len(deployer.deployed.Builds))
 	}
 }
+
+func TestPartialBuildIsDeployed(t *testing.T) {
+	built := build.Build{ImageName: "image1", Tag: "image1:tag"}
+	deployer := &TestDeployAll{}
+	runner := &SkaffoldRunner{
+		config: &v1alpha2.SkaffoldConfig{},
+		Builder: &TestBuilder{
+			err: &build.PartialBuildError{
+				Result: &build.BuildResult{Builds: []build.Build{built}},
+				Err:    fmt.Errorf("image2 failed"),
+			},
+		},
+		Deployer: deployer,
+		opts:     &config.SkaffoldOptions{},
+		Tagger:   &tag.ChecksumTagger{},
+		out:      ioutil.Discard,
+	}
+
+	err := runner.Run(context.Background())
+
+	testutil.CheckError(t, true, err)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []build.Build{built}, deployer.deployed.Builds)
+}
+
+func TestCheckTags(t *testing.T) {
+	var tests = []struct {
+		description string
+		tagger      tag.Tagger
+		shouldErr   bool
+	}{
+		{
+			description: "valid tag",
+			tagger:      tag.WithSanitization(&tag.CustomTag{Tag: "v1"}),
+		},
+		{
+			description: "tag that can't be sanitized",
+			tagger:      tag.WithSanitization(&tag.CustomTag{Tag: "..."}),
+			shouldErr:   true,
+		},
+		{
+			description: "tag that depends on the built image",
+			tagger:      tag.WithSanitization(&tag.ChecksumTagger{}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			err := checkTags(test.tagger, []*v1alpha2.Artifact{{ImageName: "image"}})
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
+
+func TestDeployLabels(t *testing.T) {
+	labels, err := deployLabels(map[string]string{"team": "payments"}, false)
+	testutil.CheckError(t, false, err)
+
+	runID := labels[deploy.RunIDLabel]
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(runID) {
+		t.Errorf("invalid run id %s", runID)
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]string{
+		"team":                "payments",
+		deploy.ManagedByLabel: "skaffold",
+		deploy.RunIDLabel:     runID,
+	}, labels)
+
+	labels, err = deployLabels(map[string]string{"team": "payments"}, true)
+	testutil.CheckErrorAndDeepEqual(t, false, err, map[string]string{"team": "payments"}, labels)
+}
+
+func TestKanikoNamespaces(t *testing.T) {
+	cfg := &v1alpha2.BuildConfig{
+		BuildType: v1alpha2.BuildType{
+			KanikoBuild: &v1alpha2.KanikoBuild{Namespace: "builds"},
+		},
+		Artifacts: []*v1alpha2.Artifact{
+			{ImageName: "web"},
+			{ImageName: "api", Builder: &v1alpha2.BuildType{KanikoBuild: &v1alpha2.KanikoBuild{Namespace: "api-builds"}}},
+			{ImageName: "worker", Builder: &v1alpha2.BuildType{KanikoBuild: &v1alpha2.KanikoBuild{Namespace: "builds"}}},
+			{ImageName: "local", Builder: &v1alpha2.BuildType{LocalBuild: &v1alpha2.LocalBuild{}}},
+		},
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"builds", "api-builds"}, kanikoNamespaces(cfg))
+}
+
+func TestDeployNamespace(t *testing.T) {
+	var tests = []struct {
+		description string
+		opts        *config.SkaffoldOptions
+		deploy      v1alpha2.DeployConfig
+		expected    string
+	}{
+		{
+			description: "no namespace",
+			opts:        &config.SkaffoldOptions{},
+		},
+		{
+			description: "kubectl namespace",
+			opts:        &config.SkaffoldOptions{},
+			deploy: v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{KubectlDeploy: &v1alpha2.KubectlDeploy{Namespace: "apps"}},
+			},
+			expected: "apps",
+		},
+		{
+			description: "command line namespace",
+			opts:        &config.SkaffoldOptions{Namespace: "preview-login"},
+			deploy: v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{KubectlDeploy: &v1alpha2.KubectlDeploy{Namespace: "apps"}},
+			},
+			expected: "preview-login",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			r := &SkaffoldRunner{
+				opts:   test.opts,
+				config: &config.SkaffoldConfig{Deploy: test.deploy},
+			}
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, r.deployNamespace())
+		})
+	}
+}
+
+func TestCleanUpOnCtrlC(t *testing.T) {
+	var tests = []struct {
+		description string
+		runDevMode  func(ctx context.Context, cancel func()) error
+		cleanedUp   bool
+	}{
+		{
+			description: "dev mode stops",
+			runDevMode: func(context.Context, func()) error {
+				return fmt.Errorf("BUG")
+			},
+			cleanedUp: true,
+		},
+		{
+			description: "interrupted by a signal",
+			runDevMode: func(ctx context.Context, _ func()) error {
+				syscall.Kill(os.Getpid(), syscall.SIGINT)
+				<-ctx.Done()
+				return nil
+			},
+			cleanedUp: true,
+		},
+		{
+			description: "cancelled to reload the configuration",
+			runDevMode: func(ctx context.Context, cancel func()) error {
+				cancel()
+				<-ctx.Done()
+				return nil
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			cleanedUp := false
+			cleanUpOnCtrlC(ctx, func(ctx context.Context) error {
+				return test.runDevMode(ctx, cancel)
+			}, func(context.Context) {
+				cleanedUp = true
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.cleanedUp, cleanedUp)
+		})
+	}
+}
+
+func TestSyncScope(t *testing.T) {
+	var tests = []struct {
+		description       string
+		opts              *config.SkaffoldOptions
+		labels            map[string]string
+		expectedNamespace string
+		expectedSelector  string
+	}{
+		{
+			description:      "pods of the run",
+			opts:             &config.SkaffoldOptions{},
+			labels:           map[string]string{deploy.RunIDLabel: "1234"},
+			expectedSelector: "skaffold.dev/run-id=1234",
+		},
+		{
+			description:       "pods of the run in the deploy namespace",
+			opts:              &config.SkaffoldOptions{Namespace: "preview-login"},
+			labels:            map[string]string{deploy.RunIDLabel: "1234"},
+			expectedNamespace: "preview-login",
+			expectedSelector:  "skaffold.dev/run-id=1234",
+		},
+		{
+			description:       "no run label",
+			opts:              &config.SkaffoldOptions{Namespace: "preview-login"},
+			expectedNamespace: "preview-login",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			r := &SkaffoldRunner{
+				opts:   test.opts,
+				config: &config.SkaffoldConfig{},
+				labels: test.labels,
+			}
+
+			namespace, selector := r.syncScope()
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedNamespace, namespace)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedSelector, selector)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+	"k8s.io/api/core/v1"
+)
+
+var invalidNameChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// testJobs returns the Job configuration of artifacts with the test role, by image name.
+func testJobs(artifacts []*v1alpha2.Artifact) (map[string]*v1alpha2.TestJob, error) {
+	jobs := map[string]*v1alpha2.TestJob{}
+	for _, a := range artifacts {
+		switch a.Role {
+		case "":
+		case constants.RoleTest:
+			jobs[a.ImageName] = a.Test
+		default:
+			return nil, fmt.Errorf("unknown role %s for %s", a.Role, a.ImageName)
+		}
+	}
+	return jobs, nil
+}
+
+// withoutTestImages removes the test images, which are run but never deployed.
+func withoutTestImages(builds []build.Build, jobs map[string]*v1alpha2.TestJob) []build.Build {
+	var filtered []build.Build
+	for _, b := range builds {
+		if _, isTest := jobs[b.ImageName]; !isTest {
+			filtered = append(filtered, b)
+		}
+	}
+	return filtered
+}
+
+// test runs each built test image as a Job, with the endpoints of the services
+// of its namespace in its environment. Any failing test fails the run.
+func (r *SkaffoldRunner) test(builds []build.Build) error {
+	if len(r.testJobs) == 0 {
+		return nil
+	}
+	if len(r.imageRepositories) > 0 {
+		builds = withImageRepositories(builds, r.imageRepositories)
+	}
+
+	for _, b := range builds {
+		job, isTest := r.testJobs[b.ImageName]
+		if !isTest {
+			continue
+		}
+
+		fmt.Fprintf(r.out, "Testing with %s...\n", b.ImageName)
+		start := time.Now()
+
+		timeout, err := time.ParseDuration(job.Timeout)
+		if err != nil {
+			return errors.Wrapf(err, "parsing test timeout %s", job.Timeout)
+		}
+		env, err := kubernetes.ServiceEndpoints(r.kubeclient, job.Namespace)
+		if err != nil {
+			return errors.Wrapf(err, "getting service endpoints of namespace %s", job.Namespace)
+		}
+
+		container := v1.Container{
+			Name:  testJobName(b.ImageName),
+			Image: b.Tag,
+			Args:  job.Args,
+			Env:   env,
+		}
+		if err := kubernetes.RunJob(r.kubeclient, r.out, job.Namespace, container, timeout); err != nil {
+			return errors.Wrapf(err, "testing with %s", b.ImageName)
+		}
+
+		fmt.Fprintln(r.out, "Test passed in", time.Since(start))
+	}
+
+	return nil
+}
+
+// testJobName derives a valid Job name from the last part of an image name.
+func testJobName(imageName string) string {
+	name := imageName[strings.LastIndex(imageName, "/")+1:]
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if name == "" {
+		return "test"
+	}
+	return name
+}
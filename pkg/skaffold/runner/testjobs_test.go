@@ -0,0 +1,86 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestTestJobs(t *testing.T) {
+	job := &v1alpha2.TestJob{Namespace: "default", Timeout: "10m"}
+
+	var tests = []struct {
+		description string
+		artifacts   []*v1alpha2.Artifact
+		shouldErr   bool
+		expected    map[string]*v1alpha2.TestJob
+	}{
+		{
+			description: "test role",
+			artifacts: []*v1alpha2.Artifact{
+				{ImageName: "app"},
+				{ImageName: "e2e", Role: "test", Test: job},
+			},
+			expected: map[string]*v1alpha2.TestJob{"e2e": job},
+		},
+		{
+			description: "unknown role",
+			artifacts:   []*v1alpha2.Artifact{{ImageName: "app", Role: "unknown"}},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			jobs, err := testJobs(test.artifacts)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, jobs)
+		})
+	}
+}
+
+func TestWithoutTestImages(t *testing.T) {
+	builds := []build.Build{
+		{ImageName: "app", Tag: "app:v1"},
+		{ImageName: "e2e", Tag: "e2e:v1"},
+	}
+
+	filtered := withoutTestImages(builds, map[string]*v1alpha2.TestJob{"e2e": {}})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []build.Build{{ImageName: "app", Tag: "app:v1"}}, filtered)
+}
+
+func TestTestJobName(t *testing.T) {
+	var tests = []struct {
+		imageName string
+		expected  string
+	}{
+		{imageName: "gcr.io/project/e2e-tests", expected: "e2e-tests"},
+		{imageName: "Integration_Tests", expected: "integration-tests"},
+		{imageName: "gcr.io/project/__", expected: "test"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.imageName, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, testJobName(test.imageName))
+		})
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s2i
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/docker/docker/builder/dockerignore"
+	"github.com/docker/docker/pkg/fileutils"
+	"github.com/pkg/errors"
+)
+
+// ignoreFiles list the sources that aren't handed to the builder image.
+// source-to-image reads .s2iignore, .dockerignore is honoured for
+// projects that are also built with docker.
+var ignoreFiles = []string{".dockerignore", ".s2iignore"}
+
+// defaultExcludes are never sources, like for source-to-image itself.
+var defaultExcludes = []string{".git"}
+
+type S2IDependencyResolver struct{}
+
+// GetDependencies lists the files of the workspace that are handed to the
+// builder image's assemble script.
+func (*S2IDependencyResolver) GetDependencies(a *v1alpha2.Artifact) ([]string, error) {
+	excludes, err := excludes(a)
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []string
+	err = filepath.Walk(a.Workspace, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(a.Workspace, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+
+		ignored, err := fileutils.Matches(relPath, excludes)
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.IsDir() && ignored:
+			return filepath.SkipDir
+		case !info.IsDir() && !ignored:
+			deps = append(deps, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "walking workspace")
+	}
+
+	sort.Strings(deps)
+	return deps, nil
+}
+
+// CreateContext copies the dependencies of an artifact into a temporary
+// directory, from which s2i builds the artifact. Ignored files are left out.
+func CreateContext(a *v1alpha2.Artifact) (string, error) {
+	deps, err := (&S2IDependencyResolver{}).GetDependencies(a)
+	if err != nil {
+		return "", errors.Wrap(err, "getting dependencies")
+	}
+
+	dir, err := ioutil.TempDir("", "skaffold-s2i-context")
+	if err != nil {
+		return "", errors.Wrap(err, "creating context directory")
+	}
+	for _, dep := range deps {
+		if err := copyFile(filepath.Join(a.Workspace, dep), filepath.Join(dir, dep)); err != nil {
+			os.RemoveAll(dir)
+			return "", errors.Wrapf(err, "copying %s", dep)
+		}
+	}
+
+	return dir, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// excludes returns the patterns of the ignore files, followed by those
+// configured on the artifact.
+func excludes(a *v1alpha2.Artifact) ([]string, error) {
+	excludes := append([]string{}, defaultExcludes...)
+	for _, name := range ignoreFiles {
+		patterns, err := readIgnoreFile(filepath.Join(a.Workspace, name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", name)
+		}
+		excludes = append(excludes, patterns...)
+	}
+	return append(excludes, a.S2IArtifact.Ignore...), nil
+}
+
+func readIgnoreFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return dockerignore.ReadAll(f)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s2i
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestGetDependencies(t *testing.T) {
+	var tests = []struct {
+		description string
+		files       map[string]string
+		ignore      []string
+		expected    []string
+	}{
+		{
+			description: "all files",
+			files: map[string]string{
+				"package.json":   "{}",
+				"src/server.js":  "",
+				"src/lib/api.js": "",
+			},
+			expected: []string{"package.json", "src/lib/api.js", "src/server.js"},
+		},
+		{
+			description: "s2iignore",
+			files: map[string]string{
+				".s2iignore":          "node_modules\n*.log",
+				"server.js":           "",
+				"debug.log":           "",
+				"node_modules/dep.js": "",
+			},
+			expected: []string{".s2iignore", "server.js"},
+		},
+		{
+			description: "dockerignore and ignore list",
+			files: map[string]string{
+				".dockerignore":   "build",
+				"server.js":       "",
+				"build/server.js": "",
+				"vendor/dep.js":   "",
+			},
+			ignore:   []string{"vendor"},
+			expected: []string{".dockerignore", "server.js"},
+		},
+		{
+			description: "git directory",
+			files: map[string]string{
+				".git/HEAD": "",
+				"server.js": "",
+			},
+			expected: []string{"server.js"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			dir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+			for path, content := range test.files {
+				os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0750)
+				ioutil.WriteFile(filepath.Join(dir, path), []byte(content), 0644)
+			}
+
+			deps, err := (&S2IDependencyResolver{}).GetDependencies(&v1alpha2.Artifact{
+				Workspace: dir,
+				ArtifactType: v1alpha2.ArtifactType{
+					S2IArtifact: &v1alpha2.S2IArtifact{BuilderImage: "builder", Ignore: test.ignore},
+				},
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, deps)
+		})
+	}
+}
+
+func TestCreateContext(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	os.MkdirAll(filepath.Join(dir, "src"), 0750)
+	os.MkdirAll(filepath.Join(dir, "node_modules"), 0750)
+	ioutil.WriteFile(filepath.Join(dir, "src", "server.js"), []byte("listen()"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "node_modules", "dep.js"), []byte(""), 0644)
+
+	context, err := CreateContext(&v1alpha2.Artifact{
+		Workspace: dir,
+		ArtifactType: v1alpha2.ArtifactType{
+			S2IArtifact: &v1alpha2.S2IArtifact{BuilderImage: "builder", Ignore: []string{"node_modules"}},
+		},
+	})
+	testutil.CheckError(t, false, err)
+	defer os.RemoveAll(context)
+
+	content, err := ioutil.ReadFile(filepath.Join(context, "src", "server.js"))
+	testutil.CheckErrorAndDeepEqual(t, false, err, "listen()", string(content))
+	if _, err := os.Stat(filepath.Join(context, "node_modules")); !os.IsNotExist(err) {
+		t.Errorf("expected node_modules to be left out of the context")
+	}
+}
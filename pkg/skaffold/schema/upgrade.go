@@ -0,0 +1,105 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ParseConfig parses a configuration of any supported version. Older
+// versions are upgraded to the latest one before the defaults are applied.
+func ParseConfig(contents []byte, useDefaults bool) (*config.SkaffoldConfig, error) {
+	apiVersion := &config.ApiVersion{}
+	if err := yaml.Unmarshal(contents, apiVersion); err != nil {
+		return nil, errors.Wrap(err, "parsing api version")
+	}
+
+	if apiVersion.Version != config.LatestVersion {
+		upgraded, err := Upgrade(contents)
+		if err != nil {
+			return nil, err
+		}
+		contents = upgraded
+	}
+
+	cfg, err := config.GetConfig(contents, useDefaults)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing skaffold config")
+	}
+	latest, ok := cfg.(*config.SkaffoldConfig)
+	if !ok {
+		return nil, fmt.Errorf("unsupported version: %s", cfg.GetVersion())
+	}
+	return latest, nil
+}
+
+// Upgrade rewrites a configuration with the latest version of the schema.
+// Comments are lost along the way, except for those at the top of the file.
+func Upgrade(contents []byte) ([]byte, error) {
+	cfg, err := config.GetConfig(contents, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing skaffold config")
+	}
+
+	upgraded, err := RunTransform(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := yaml.Marshal(upgraded)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling upgraded config")
+	}
+	return append(leadingComments(contents), buf...), nil
+}
+
+// leadingComments returns the comment lines at the top of a yaml file,
+// usually a license header or a description of the pipeline.
+func leadingComments(contents []byte) []byte {
+	var (
+		comments bytes.Buffer
+		blanks   int
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			blanks++
+		case strings.HasPrefix(line, "#"):
+			if comments.Len() > 0 {
+				comments.WriteString(strings.Repeat("\n", blanks))
+			}
+			blanks = 0
+			comments.WriteString(line + "\n")
+		default:
+			if comments.Len() > 0 && blanks > 0 {
+				comments.WriteString("\n")
+			}
+			return comments.Bytes()
+		}
+	}
+	return comments.Bytes()
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+const v1alpha1Config = `# The pipeline of the web app.
+# Owned by the web team.
+
+apiVersion: skaffold/v1alpha1
+kind: Config
+build:
+  tagPolicy: gitCommit
+  artifacts:
+  # The frontend
+  - imageName: gcr.io/k8s-skaffold/web
+    workspace: web
+deploy:
+  kubectl:
+    manifests:
+    - paths:
+      - k8s/*.yaml
+`
+
+func TestTransformers(t *testing.T) {
+	for _, version := range config.Versions[:len(config.Versions)-1] {
+		if _, present := transformers[version]; !present {
+			t.Errorf("No upgrade from %s", version)
+		}
+	}
+	if _, present := transformers[config.LatestVersion]; present {
+		t.Errorf("The latest version %s shouldn't be upgraded", config.LatestVersion)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	upgraded, err := Upgrade([]byte(v1alpha1Config))
+	testutil.CheckError(t, false, err)
+
+	if !strings.HasPrefix(string(upgraded), "# The pipeline of the web app.\n# Owned by the web team.\n\napiVersion: skaffold/v1alpha2\n") {
+		t.Errorf("Expected the header comments and the new version, got:\n%s", upgraded)
+	}
+
+	cfg, err := config.GetConfig(upgraded, false)
+	testutil.CheckError(t, false, err)
+	latest := cfg.(*config.SkaffoldConfig)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "gcr.io/k8s-skaffold/web", latest.Build.Artifacts[0].ImageName)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"k8s/*.yaml"}, latest.Deploy.KubectlDeploy.Manifests)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, &v1alpha2.GitTagger{}, latest.Build.TagPolicy.GitTagger)
+}
+
+func TestParseConfig(t *testing.T) {
+	var tests = []struct {
+		description string
+		contents    string
+		shouldErr   bool
+	}{
+		{
+			description: "old version",
+			contents:    v1alpha1Config,
+		},
+		{
+			description: "latest version",
+			contents:    "apiVersion: skaffold/v1alpha2\nkind: Config\nbuild:\n  artifacts:\n  - imageName: gcr.io/k8s-skaffold/web\n    workspace: web\n",
+		},
+		{
+			description: "unknown version",
+			contents:    "apiVersion: skaffold/v9\nkind: Config\n",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cfg, err := ParseConfig([]byte(test.contents), true)
+
+			testutil.CheckError(t, test.shouldErr, err)
+			if !test.shouldErr {
+				testutil.CheckErrorAndDeepEqual(t, false, nil, config.LatestVersion, cfg.APIVersion)
+				// The defaults of the latest version are applied.
+				testutil.CheckErrorAndDeepEqual(t, false, nil, "Dockerfile", cfg.Build.Artifacts[0].DockerArtifact.DockerfilePath)
+			}
+		})
+	}
+}
+
+func TestLeadingComments(t *testing.T) {
+	var tests = []struct {
+		description string
+		contents    string
+		expected    string
+	}{
+		{
+			description: "no comments",
+			contents:    "apiVersion: skaffold/v1alpha1\n# kind\nkind: Config\n",
+			expected:    "",
+		},
+		{
+			description: "header",
+			contents:    "\n# first\n\n\n# second\napiVersion: skaffold/v1alpha1\n",
+			expected:    "# first\n\n\n# second\n",
+		},
+		{
+			description: "header followed by blank lines",
+			contents:    "# header\n\n\napiVersion: skaffold/v1alpha1\n",
+			expected:    "# header\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, string(leadingComments([]byte(test.contents))))
+		})
+	}
+}
@@ -18,6 +18,7 @@ package v1alpha2
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	homedir "github.com/mitchellh/go-homedir"
@@ -33,9 +34,10 @@ type SkaffoldConfig struct {
 	APIVersion string `yaml:"apiVersion"`
 	Kind       string `yaml:"kind"`
 
-	Build    BuildConfig  `yaml:"build,omitempty"`
-	Deploy   DeployConfig `yaml:"deploy,omitempty"`
-	Profiles []Profile    `yaml:"profiles,omitempty"`
+	Build             BuildConfig       `yaml:"build,omitempty"`
+	Deploy            DeployConfig      `yaml:"deploy,omitempty"`
+	ImageRepositories map[string]string `yaml:"imageRepositories,omitempty"`
+	Profiles          []Profile         `yaml:"profiles,omitempty"`
 }
 
 func (c *SkaffoldConfig) GetVersion() string {
@@ -44,29 +46,59 @@ func (c *SkaffoldConfig) GetVersion() string {
 
 // BuildConfig contains all the configuration for the build steps
 type BuildConfig struct {
-	Artifacts []*Artifact `yaml:"artifacts,omitempty"`
-	TagPolicy TagPolicy   `yaml:"tagPolicy,omitempty"`
-	BuildType `yaml:",inline"`
+	Artifacts        []*Artifact  `yaml:"artifacts,omitempty"`
+	TagPolicy        TagPolicy    `yaml:"tagPolicy,omitempty"`
+	Concurrency      int          `yaml:"concurrency,omitempty"`
+	ContinueOnError  bool         `yaml:"continueOnError,omitempty"`
+	PartialDeploy    bool         `yaml:"partialDeploy,omitempty"`
+	Retry            *RetryPolicy `yaml:"retry,omitempty"`
+	LockFile         string       `yaml:"lockFile,omitempty"`
+	TryImportMissing bool         `yaml:"tryImportMissing,omitempty"`
+	BuildType        `yaml:",inline"`
+}
+
+// RetryPolicy configures how pushes and other registry operations are
+// retried after transient failures.
+type RetryPolicy struct {
+	Attempts     int    `yaml:"attempts,omitempty"`
+	InitialDelay string `yaml:"initialDelay,omitempty"`
+	MaxDelay     string `yaml:"maxDelay,omitempty"`
 }
 
 // TagPolicy contains all the configuration for the tagging step
 type TagPolicy struct {
-	GitTagger         *GitTagger         `yaml:"gitCommit"`
-	ShaTagger         *ShaTagger         `yaml:"sha256"`
-	EnvTemplateTagger *EnvTemplateTagger `yaml:"envTemplate"`
+	GitTagger           *GitTagger           `yaml:"gitCommit"`
+	ShaTagger           *ShaTagger           `yaml:"sha256"`
+	EnvTemplateTagger   *EnvTemplateTagger   `yaml:"envTemplate"`
+	CustomCommandTagger *CustomCommandTagger `yaml:"customCommand"`
+	InputDigest         *InputDigest         `yaml:"inputDigest"`
+
+	// Fallback lists the tag policies to try, in order, when this one fails.
+	Fallback []TagPolicy `yaml:"fallback,omitempty"`
 }
 
 // ShaTagger contains the configuration for the SHA tagger.
 type ShaTagger struct{}
 
 // GitTagger contains the configuration for the git tagger.
-type GitTagger struct{}
+type GitTagger struct {
+	Variant string `yaml:"variant,omitempty"`
+	Prefix  string `yaml:"prefix,omitempty"`
+}
 
 // EnvTemplateTagger contains the configuration for the envTemplate tagger.
 type EnvTemplateTagger struct {
 	Template string `yaml:"template"`
 }
 
+// CustomCommandTagger contains the configuration for the customCommand tagger.
+type CustomCommandTagger struct {
+	Command string `yaml:"command"`
+}
+
+// InputDigest contains the configuration for the inputDigest tagger.
+type InputDigest struct{}
+
 // BuildType contains the specific implementation and parameters needed
 // for the build step. Only one field should be populated.
 type BuildType struct {
@@ -78,83 +110,311 @@ type BuildType struct {
 // LocalBuild contains the fields needed to do a build on the local docker daemon
 // and optionally push to a repository.
 type LocalBuild struct {
-	SkipPush *bool `yaml:"skipPush"`
+	SkipPush *bool         `yaml:"skipPush"`
+	Push     string        `yaml:"push,omitempty"`
+	Daemon   *DockerDaemon `yaml:"daemon,omitempty"`
+	Platform string        `yaml:"platform,omitempty"`
+}
+
+// DockerDaemon pins the docker daemon used by a local build. Host supports
+// the same values as DOCKER_HOST, including ssh:// connection strings.
+type DockerDaemon struct {
+	Host      string `yaml:"host"`
+	CertPath  string `yaml:"certPath,omitempty"`
+	TLSVerify bool   `yaml:"tlsVerify,omitempty"`
 }
 
 // GoogleCloudBuild contains the fields needed to do a remote build on
 // Google Container Builder.
 type GoogleCloudBuild struct {
-	ProjectID string `yaml:"projectId"`
+	ProjectID   string `yaml:"projectId"`
+	MachineType string `yaml:"machineType,omitempty"`
+	DiskSizeGb  int64  `yaml:"diskSizeGb,omitempty"`
+	Timeout     string `yaml:"timeout,omitempty"`
+	DockerImage string `yaml:"dockerImage,omitempty"`
+	KanikoImage string `yaml:"kanikoImage,omitempty"`
 }
 
 // KanikoBuild contains the fields needed to do a on-cluster build using
 // the kaniko image
 type KanikoBuild struct {
-	GCSBucket  string `yaml:"gcsBucket,omitempty"`
-	PullSecret string `yaml:"pullSecret,omitempty"`
+	GCSBucket         string                `yaml:"gcsBucket,omitempty"`
+	ContextType       string                `yaml:"contextType,omitempty"`
+	ContextImage      string                `yaml:"contextImage,omitempty"`
+	PullSecret        string                `yaml:"pullSecret,omitempty"`
+	ContextEncryption *ContextEncryption    `yaml:"contextEncryption,omitempty"`
+	Cache             *KanikoCache          `yaml:"cache,omitempty"`
+	Namespace         string                `yaml:"namespace,omitempty"`
+	Timeout           string                `yaml:"timeout,omitempty"`
+	StartTimeout      string                `yaml:"startTimeout,omitempty"`
+	Fallback          string                `yaml:"fallback,omitempty"`
+	NodeSelector      map[string]string     `yaml:"nodeSelector,omitempty"`
+	Resources         *ResourceRequirements `yaml:"resources,omitempty"`
+}
+
+// ResourceRequirements are the resource requests and limits of a build pod,
+// expressed as Kubernetes quantities, e.g. `cpu: 500m` or `memory: 1Gi`.
+type ResourceRequirements struct {
+	Requests map[string]string `yaml:"requests,omitempty"`
+	Limits   map[string]string `yaml:"limits,omitempty"`
+}
+
+// KanikoCache configures kaniko's layer cache. Cached layers are pushed
+// to Repo, which defaults to the repository of the built image.
+type KanikoCache struct {
+	Enabled bool   `yaml:"enabled"`
+	Repo    string `yaml:"repo,omitempty"`
+}
+
+// ContextEncryption contains the fields needed to encrypt the build context
+// before it is uploaded. The key is read from a Kubernetes secret and the
+// context is decrypted by an init container in the build pod.
+type ContextEncryption struct {
+	KeySecret string `yaml:"keySecret"`
+	InitImage string `yaml:"initImage,omitempty"`
 }
 
 // DeployConfig contains all the configuration needed by the deploy steps
 type DeployConfig struct {
 	DeployType `yaml:",inline"`
+
+	StatusCheck *StatusCheck `yaml:"statusCheck,omitempty"`
+
+	// Labels are added to every resource deployed with kubectl or jsonnet,
+	// along with the id of the run and `app.kubernetes.io/managed-by`.
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	Hooks *DeployHooks `yaml:"hooks,omitempty"`
+}
+
+// DeployHooks run before and after each deploy. The after hooks run once
+// the deployed workloads are rolled out.
+type DeployHooks struct {
+	Before []DeployHook `yaml:"before,omitempty"`
+	After  []DeployHook `yaml:"after,omitempty"`
+}
+
+// DeployHook is either a shell command run on the host, or a command run
+// in a container of a running pod.
+type DeployHook struct {
+	Command string    `yaml:"command,omitempty"`
+	Exec    *ExecHook `yaml:"exec,omitempty"`
+}
+
+// ExecHook runs a command with `kubectl exec` in the first running pod that
+// matches the selector.
+type ExecHook struct {
+	Namespace string            `yaml:"namespace,omitempty"`
+	Selector  map[string]string `yaml:"selector"`
+	Container string            `yaml:"container,omitempty"`
+	Command   []string          `yaml:"command"`
+}
+
+// StatusCheck makes deploys wait for the Deployments, StatefulSets and
+// DaemonSets deployed with kubectl, jsonnet or helm to be rolled out. A deploy
+// fails if they aren't rolled out before the deadline.
+type StatusCheck struct {
+	Deadline string `yaml:"deadline,omitempty"`
 }
 
 // DeployType contains the specific implementation and parameters needed
-// for the deploy step. Only one field should be populated.
+// for the deploy step. When both kubectl and helm are populated, kubectl runs first.
 type DeployType struct {
 	HelmDeploy    *HelmDeploy    `yaml:"helm"`
 	KubectlDeploy *KubectlDeploy `yaml:"kubectl"`
+	JsonnetDeploy *JsonnetDeploy `yaml:"jsonnet"`
 }
 
 // KubectlDeploy contains the configuration needed for deploying with `kubectl apply`
 type KubectlDeploy struct {
-	Manifests       []string `yaml:"manifests,omitempty"`
-	RemoteManifests []string `yaml:"remoteManifests,omitempty"`
+	Manifests           []string     `yaml:"manifests,omitempty"`
+	RemoteManifests     []string     `yaml:"remoteManifests,omitempty"`
+	ChecksumAnnotations bool         `yaml:"checksumAnnotations,omitempty"`
+	DevSidecars         []DevSidecar `yaml:"devSidecars,omitempty"`
+	ImagePullSecret     string       `yaml:"imagePullSecret,omitempty"`
+	Flags               KubectlFlags `yaml:"flags,omitempty"`
+	ImagePaths          []ImagePath  `yaml:"imagePaths,omitempty"`
+
+	// Namespace is where the manifests without a namespace are deployed,
+	// instead of the namespace of the current context. It's created first
+	// if CreateNamespace is true and it doesn't exist.
+	Namespace       string `yaml:"namespace,omitempty"`
+	CreateNamespace bool   `yaml:"createNamespace,omitempty"`
+
+	// Prune deletes the resources that were deployed before but are no
+	// longer part of the manifests, e.g. between iterations of the dev loop.
+	Prune bool `yaml:"prune,omitempty"`
+}
+
+// ImagePath is a field of custom resources that holds an image, in addition to
+// the `image` fields that are always replaced. Path is a dot separated list of
+// fields, like `spec.runtime.image`, where `[*]` selects every item of a list.
+// It applies to every kind of resource if Kind is empty.
+type ImagePath struct {
+	Kind string `yaml:"kind,omitempty"`
+	Path string `yaml:"path"`
+}
+
+// KubectlFlags are additional flags passed to kubectl, either on every
+// invocation, or only to `kubectl apply` or `kubectl delete`.
+type KubectlFlags struct {
+	Global []string `yaml:"global,omitempty"`
+	Apply  []string `yaml:"apply,omitempty"`
+	Delete []string `yaml:"delete,omitempty"`
+}
+
+// DevSidecar is a container added to workloads when deploying in dev mode only,
+// e.g. a file sync agent or a profiler. It's added to the pod templates whose
+// labels match the selector, or to every pod template without a selector.
+type DevSidecar struct {
+	Name     string            `yaml:"name"`
+	Image    string            `yaml:"image"`
+	Command  []string          `yaml:"command,omitempty"`
+	Args     []string          `yaml:"args,omitempty"`
+	Selector map[string]string `yaml:"selector,omitempty"`
+}
+
+// JsonnetDeploy contains the configuration needed for deploying manifests
+// rendered from jsonnet files, or from a ksonnet app environment.
+type JsonnetDeploy struct {
+	Files []string `yaml:"files,omitempty"`
+	JPath []string `yaml:"jpath,omitempty"`
+
+	// Values maps external variables to the name of images. The variables
+	// are set to the tags of the built images.
+	Values map[string]string `yaml:"values,omitempty"`
+
+	KsonnetApp         string `yaml:"ksonnetApp,omitempty"`
+	KsonnetEnvironment string `yaml:"ksonnetEnvironment,omitempty"`
 }
 
 // HelmDeploy contains the configuration needed for deploying with helm
 type HelmDeploy struct {
 	Releases []HelmRelease `yaml:"releases,omitempty"`
+
+	// HelmVersion is the major version of helm, 2 or 3. It's detected
+	// from `helm version` if not set.
+	HelmVersion string `yaml:"helmVersion,omitempty"`
 }
 
 type HelmRelease struct {
 	Name           string            `yaml:"name"`
 	ChartPath      string            `yaml:"chartPath"`
+	RemoteChart    string            `yaml:"remoteChart,omitempty"`
+	Repo           string            `yaml:"repo,omitempty"`
 	ValuesFilePath string            `yaml:"valuesFilePath"`
+	ValuesFiles    []string          `yaml:"valuesFiles,omitempty"`
 	Values         map[string]string `yaml:"values,omitempty"`
 	Namespace      string            `yaml:"namespace"`
 	Version        string            `yaml:"version"`
 	SetValues      map[string]string `yaml:"setValues"`
+
+	// ImageStrategy is how built images are written into Values:
+	// `fqn` (default), `repoTag` or `digest`.
+	ImageStrategy string `yaml:"imageStrategy,omitempty"`
+
+	SkipBuildDependencies bool          `yaml:"skipBuildDependencies,omitempty"`
+	Packaged              *HelmPackaged `yaml:"packaged,omitempty"`
+
+	// Wait, Timeout (in seconds) and Atomic apply to installs and upgrades.
+	// Force and RecreatePods only apply to upgrades.
+	Wait         bool `yaml:"wait,omitempty"`
+	Timeout      int  `yaml:"timeout,omitempty"`
+	Atomic       bool `yaml:"atomic,omitempty"`
+	Force        bool `yaml:"force,omitempty"`
+	RecreatePods bool `yaml:"recreatePods,omitempty"`
+}
+
+// HelmPackaged packages a local chart with `helm package` before installing it.
+// Version and AppVersion are go templates, like the Values and SetValues of
+// the release.
+type HelmPackaged struct {
+	Version    string `yaml:"version,omitempty"`
+	AppVersion string `yaml:"appVersion,omitempty"`
 }
 
 // Artifact represents items that need should be built, along with the context in which
 // they should be built.
 type Artifact struct {
-	ImageName    string `yaml:"imageName"`
-	Workspace    string `yaml:"workspace,omitempty"`
+	ImageName    string     `yaml:"imageName"`
+	Workspace    string     `yaml:"workspace,omitempty"`
+	Requires     []string   `yaml:"requires,omitempty"`
+	Builder      *BuildType `yaml:"builder,omitempty"`
+	Platforms    []string   `yaml:"platforms,omitempty"`
+	Role         string     `yaml:"role,omitempty"`
+	Test         *TestJob   `yaml:"test,omitempty"`
+	Hooks        *Hooks     `yaml:"hooks,omitempty"`
 	ArtifactType `yaml:",inline"`
+
+	// Sync maps globs of files, relative to the workspace, to the directory
+	// of the containers they're copied to in dev mode, instead of rebuilding.
+	Sync map[string]string `yaml:"sync,omitempty"`
+
+	// InferSync lists globs of files that are synced to where the COPY and ADD
+	// instructions of the Dockerfile put them. Globs without a slash match
+	// file names in any directory.
+	InferSync []string `yaml:"inferSync,omitempty"`
+}
+
+// Hooks are shell commands run on the host before and after an artifact is built.
+type Hooks struct {
+	Before []string `yaml:"before,omitempty"`
+	After  []string `yaml:"after,omitempty"`
+}
+
+// TestJob configures the Job that runs an artifact with the test role
+// once the application is deployed.
+type TestJob struct {
+	Namespace string   `yaml:"namespace,omitempty"`
+	Timeout   string   `yaml:"timeout,omitempty"`
+	Args      []string `yaml:"args,omitempty"`
 }
 
 // Profile is additional configuration that overrides default
 // configuration when it is activated.
 type Profile struct {
-	Name   string       `yaml:"name"`
-	Build  BuildConfig  `yaml:"build,omitempty"`
-	Deploy DeployConfig `yaml:"deploy,omitempty"`
+	Name              string            `yaml:"name"`
+	Build             BuildConfig       `yaml:"build,omitempty"`
+	Deploy            DeployConfig      `yaml:"deploy,omitempty"`
+	ImageRepositories map[string]string `yaml:"imageRepositories,omitempty"`
 }
 
 type ArtifactType struct {
 	DockerArtifact *DockerArtifact `yaml:"docker"`
 	BazelArtifact  *BazelArtifact  `yaml:"bazel"`
+	S2IArtifact    *S2IArtifact    `yaml:"s2i,omitempty"`
 }
 
 type DockerArtifact struct {
 	DockerfilePath string             `yaml:"dockerfilePath,omitempty"`
 	BuildArgs      map[string]*string `yaml:"buildArgs,omitempty"`
+	Target         string             `yaml:"target,omitempty"`
+	Network        string             `yaml:"network,omitempty"`
+	CacheFrom      []string           `yaml:"cacheFrom,omitempty"`
+	Secrets        []DockerSecret     `yaml:"secrets,omitempty"`
+	SSH            []string           `yaml:"ssh,omitempty"`
+}
+
+// DockerSecret is a file exposed to RUN --mount=type=secret instructions
+// of a BuildKit build, without being stored in the image.
+type DockerSecret struct {
+	ID  string `yaml:"id"`
+	Src string `yaml:"src"`
 }
 
 type BazelArtifact struct {
-	BuildTarget string `yaml:"target"`
+	BuildTarget string   `yaml:"target"`
+	BuildArgs   []string `yaml:"args,omitempty"`
+	Configs     []string `yaml:"configs,omitempty"`
+}
+
+// S2IArtifact builds an image from sources with source-to-image.
+type S2IArtifact struct {
+	BuilderImage string            `yaml:"builderImage"`
+	ScriptsURL   string            `yaml:"scriptsURL,omitempty"`
+	Environment  map[string]string `yaml:"environment,omitempty"`
+	Incremental  bool              `yaml:"incremental,omitempty"`
+	Ignore       []string          `yaml:"ignore,omitempty"`
 }
 
 // Parse reads a SkaffoldConfig from yaml.
@@ -178,6 +438,9 @@ func (c *SkaffoldConfig) setDefaultValues() error {
 	c.setDefaultTagger()
 	c.setDefaultDockerfiles()
 	c.setDefaultWorkspaces()
+	c.setDefaultKanikoValues()
+	c.setDefaultTestJobs()
+	c.setDefaultStatusCheck()
 	return c.expandKanikoSecretPath()
 }
 
@@ -203,11 +466,13 @@ func (c *SkaffoldConfig) defaultToDockerArtifacts() {
 }
 
 func (c *SkaffoldConfig) setDefaultTagger() {
-	if c.Build.TagPolicy != (TagPolicy{}) {
+	primary := c.Build.TagPolicy
+	primary.Fallback = nil
+	if !reflect.DeepEqual(primary, TagPolicy{}) {
 		return
 	}
 
-	c.Build.TagPolicy = TagPolicy{GitTagger: &GitTagger{}}
+	c.Build.TagPolicy.GitTagger = &GitTagger{}
 }
 
 func (c *SkaffoldConfig) setDefaultDockerfiles() {
@@ -226,17 +491,83 @@ func (c *SkaffoldConfig) setDefaultWorkspaces() {
 	}
 }
 
+func (c *SkaffoldConfig) setDefaultKanikoValues() {
+	setDefaultKanikoValues(c.Build.KanikoBuild)
+	for _, artifact := range c.Build.Artifacts {
+		if artifact.Builder != nil {
+			setDefaultKanikoValues(artifact.Builder.KanikoBuild)
+		}
+	}
+}
+
+func setDefaultKanikoValues(kaniko *KanikoBuild) {
+	if kaniko == nil {
+		return
+	}
+	if kaniko.Namespace == "" {
+		kaniko.Namespace = constants.DefaultKanikoNamespace
+	}
+	if kaniko.ContextType == "" {
+		kaniko.ContextType = constants.KanikoContextGCS
+	}
+	if kaniko.Timeout == "" {
+		kaniko.Timeout = constants.DefaultKanikoTimeout
+	}
+	if kaniko.StartTimeout == "" {
+		kaniko.StartTimeout = constants.DefaultKanikoStartTimeout
+	}
+}
+
+func (c *SkaffoldConfig) setDefaultTestJobs() {
+	for _, artifact := range c.Build.Artifacts {
+		if artifact.Role != constants.RoleTest {
+			continue
+		}
+		if artifact.Test == nil {
+			artifact.Test = &TestJob{}
+		}
+		if artifact.Test.Namespace == "" {
+			artifact.Test.Namespace = constants.DefaultTestNamespace
+		}
+		if artifact.Test.Timeout == "" {
+			artifact.Test.Timeout = constants.DefaultTestTimeout
+		}
+	}
+}
+
+func (c *SkaffoldConfig) setDefaultStatusCheck() {
+	if c.Deploy.StatusCheck != nil && c.Deploy.StatusCheck.Deadline == "" {
+		c.Deploy.StatusCheck.Deadline = constants.DefaultStatusCheckDeadline
+	}
+}
+
 func (c *SkaffoldConfig) expandKanikoSecretPath() error {
-	if c.Build.KanikoBuild == nil || c.Build.KanikoBuild.PullSecret == "" {
+	if err := expandKanikoSecretPath(c.Build.KanikoBuild); err != nil {
+		return err
+	}
+
+	for _, artifact := range c.Build.Artifacts {
+		if artifact.Builder == nil {
+			continue
+		}
+		if err := expandKanikoSecretPath(artifact.Builder.KanikoBuild); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func expandKanikoSecretPath(kaniko *KanikoBuild) error {
+	if kaniko == nil || kaniko.PullSecret == "" {
 		return nil
 	}
 
-	absPath, err := homedir.Expand(c.Build.KanikoBuild.PullSecret)
+	absPath, err := homedir.Expand(kaniko.PullSecret)
 	if err != nil {
-		return fmt.Errorf("unable to expand pullSecret %s", c.Build.KanikoBuild.PullSecret)
+		return fmt.Errorf("unable to expand pullSecret %s", kaniko.PullSecret)
 	}
 
-	c.Build.KanikoBuild.PullSecret = absPath
+	kaniko.PullSecret = absPath
 	return nil
 }
 
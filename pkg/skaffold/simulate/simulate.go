@@ -0,0 +1,129 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Phases of the pipeline in which faults can be injected.
+const (
+	Build  = "build"
+	Upload = "upload"
+	Deploy = "deploy"
+)
+
+// Fault slows a phase down and makes it fail some of the time.
+type Fault struct {
+	Latency     time.Duration
+	FailureRate float64
+}
+
+var (
+	faults = map[string]Fault{}
+
+	// random is replaced in tests.
+	random = rand.Float64
+)
+
+// Enable injects faults described as a comma separated list of
+// phase=effect, where effects are a latency, `fail`, a failure rate
+// such as `fail:0.3`, or several effects joined by `+`.
+// For example: build=2s,upload=fail:0.5,deploy=500ms+fail
+func Enable(spec string) error {
+	parsed, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+
+	logrus.Warnf("Simulating faults: %s", spec)
+	faults = parsed
+	return nil
+}
+
+// Parse reads the faults to inject in each phase.
+func Parse(spec string) (map[string]Fault, error) {
+	parsed := map[string]Fault{}
+
+	for _, entry := range strings.Split(spec, ",") {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid fault %q, expected phase=effect", entry)
+		}
+
+		phase := strings.TrimSpace(kv[0])
+		switch phase {
+		case Build, Upload, Deploy:
+		default:
+			return nil, fmt.Errorf("unknown phase %q, expected %s, %s or %s", phase, Build, Upload, Deploy)
+		}
+
+		var fault Fault
+		for _, effect := range strings.Split(kv[1], "+") {
+			effect = strings.TrimSpace(effect)
+			switch {
+			case effect == "fail":
+				fault.FailureRate = 1
+			case strings.HasPrefix(effect, "fail:"):
+				rate, err := strconv.ParseFloat(strings.TrimPrefix(effect, "fail:"), 64)
+				if err != nil || rate < 0 || rate > 1 {
+					return nil, fmt.Errorf("invalid failure rate in %q, expected a number between 0 and 1", effect)
+				}
+				fault.FailureRate = rate
+			default:
+				latency, err := time.ParseDuration(effect)
+				if err != nil {
+					return nil, errors.Wrapf(err, "parsing effect %q of phase %s", effect, phase)
+				}
+				fault.Latency = latency
+			}
+		}
+		parsed[phase] = fault
+	}
+
+	return parsed, nil
+}
+
+// Inject delays and fails a phase as configured. It does nothing
+// unless faults were enabled.
+func Inject(ctx context.Context, phase string) error {
+	fault, present := faults[phase]
+	if !present {
+		return nil
+	}
+
+	if fault.Latency > 0 {
+		logrus.Infof("Simulating %s latency of %s", phase, fault.Latency)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(fault.Latency):
+		}
+	}
+	if fault.FailureRate > 0 && random() < fault.FailureRate {
+		return fmt.Errorf("simulated %s failure", phase)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		description string
+		spec        string
+		shouldErr   bool
+		expected    map[string]Fault
+	}{
+		{
+			description: "latency",
+			spec:        "build=2s",
+			expected:    map[string]Fault{Build: {Latency: 2 * time.Second}},
+		},
+		{
+			description: "all phases",
+			spec:        "build=fail,upload=fail:0.5,deploy=500ms+fail",
+			expected: map[string]Fault{
+				Build:  {FailureRate: 1},
+				Upload: {FailureRate: 0.5},
+				Deploy: {Latency: 500 * time.Millisecond, FailureRate: 1},
+			},
+		},
+		{
+			description: "unknown phase",
+			spec:        "test=fail",
+			shouldErr:   true,
+		},
+		{
+			description: "missing effect",
+			spec:        "build",
+			shouldErr:   true,
+		},
+		{
+			description: "invalid failure rate",
+			spec:        "deploy=fail:2",
+			shouldErr:   true,
+		},
+		{
+			description: "invalid latency",
+			spec:        "deploy=slow",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			parsed, err := Parse(test.spec)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, parsed)
+		})
+	}
+}
+
+func TestInject(t *testing.T) {
+	defer func(f map[string]Fault, r func() float64) { faults, random = f, r }(faults, random)
+	random = func() float64 { return 0.4 }
+
+	var tests = []struct {
+		description string
+		faults      map[string]Fault
+		shouldErr   bool
+	}{
+		{
+			description: "no fault",
+		},
+		{
+			description: "failure",
+			faults:      map[string]Fault{Deploy: {FailureRate: 1}},
+			shouldErr:   true,
+		},
+		{
+			description: "lucky roll",
+			faults:      map[string]Fault{Deploy: {FailureRate: 0.3}},
+		},
+		{
+			description: "other phase",
+			faults:      map[string]Fault{Build: {FailureRate: 1}},
+		},
+		{
+			description: "latency",
+			faults:      map[string]Fault{Deploy: {Latency: time.Millisecond}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			faults = test.faults
+
+			err := Inject(context.Background(), Deploy)
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
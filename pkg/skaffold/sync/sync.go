@@ -0,0 +1,244 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgo "k8s.io/client-go/kubernetes"
+)
+
+// Item lists the files to copy into, or delete from, the containers
+// running an image. Both maps go from paths on disk to paths in the containers.
+type Item struct {
+	Image  string
+	Copy   map[string]string
+	Delete map[string]string
+}
+
+// NewItem returns what to sync for the changes to an artifact, or nil if the
+// artifact has to be rebuilt: it was never built, or a change isn't covered
+// by its sync rules.
+func NewItem(a *v1alpha2.Artifact, changed []string, builds []build.Build) (*Item, error) {
+	if (len(a.Sync) == 0 && len(a.InferSync) == 0) || len(changed) == 0 {
+		return nil, nil
+	}
+
+	var tag string
+	for _, b := range builds {
+		if b.ImageName == a.ImageName {
+			tag = b.Tag
+		}
+	}
+	if tag == "" {
+		return nil, nil
+	}
+
+	var inferred map[string]string
+	if len(a.InferSync) > 0 && a.DockerArtifact != nil {
+		var err error
+		if inferred, err = docker.SyncMap(a.Workspace, a.DockerArtifact); err != nil {
+			return nil, errors.Wrapf(err, "inferring sync rules of %s", a.ImageName)
+		}
+	}
+
+	item := &Item{
+		Image:  tag,
+		Copy:   map[string]string{},
+		Delete: map[string]string{},
+	}
+	for _, p := range changed {
+		dst, err := destination(a, p, inferred)
+		if err != nil {
+			return nil, err
+		}
+		if dst == "" {
+			logrus.Debugf("%s isn't covered by the sync rules of %s", p, a.ImageName)
+			return nil, nil
+		}
+
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			item.Delete[p] = dst
+		} else {
+			item.Copy[p] = dst
+		}
+	}
+
+	return item, nil
+}
+
+// destination finds where a file goes in the containers, or returns "" if no
+// sync rule matches it. Files keep their path relative to the part of the
+// glob that has no wildcards, e.g. with `static/*/*.css: /srv`,
+// static/css/main.css is copied to /srv/css/main.css.
+// Files matching no rule can still go where the Dockerfile copies them.
+func destination(a *v1alpha2.Artifact, p string, inferred map[string]string) (string, error) {
+	rel, err := filepath.Rel(a.Workspace, p)
+	if err != nil {
+		return "", errors.Wrapf(err, "locating %s in the workspace of %s", p, a.ImageName)
+	}
+	rel = filepath.ToSlash(rel)
+
+	var patterns []string
+	for pattern := range a.Sync {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		matches, err := path.Match(pattern, rel)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid sync pattern %s", pattern)
+		}
+		if !matches {
+			continue
+		}
+
+		base := staticPrefix(pattern)
+		return path.Join(a.Sync[pattern], strings.TrimPrefix(rel, base)), nil
+	}
+
+	for _, pattern := range a.InferSync {
+		name := rel
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(rel)
+		}
+
+		matches, err := path.Match(pattern, name)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid sync pattern %s", pattern)
+		}
+		if matches {
+			return inferred[rel], nil
+		}
+	}
+
+	return "", nil
+}
+
+// staticPrefix returns the leading directories of a glob that have no wildcards.
+// A pattern without wildcards is a single file, whose prefix is its directory.
+func staticPrefix(pattern string) string {
+	segments := strings.Split(pattern, "/")
+
+	var prefix []string
+	for _, segment := range segments[:len(segments)-1] {
+		if strings.ContainsAny(segment, `*?[\`) {
+			break
+		}
+		prefix = append(prefix, segment)
+	}
+
+	if len(prefix) == 0 {
+		return ""
+	}
+	return path.Join(prefix...) + "/"
+}
+
+// Perform copies and deletes the files of the item in every running
+// container of its image, among the pods of a namespace that match a label
+// selector. It fails if no such container is found.
+func Perform(ctx context.Context, client clientgo.Interface, kubeContext, namespace, selector string, item *Item) error {
+	pods, err := client.CoreV1().Pods(namespace).List(meta_v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrap(err, "listing pods")
+	}
+
+	synced := 0
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+
+		for _, container := range pod.Spec.Containers {
+			if container.Image != item.Image {
+				continue
+			}
+
+			if err := syncContainer(ctx, kubeContext, pod.Namespace, pod.Name, container.Name, item); err != nil {
+				return errors.Wrapf(err, "syncing files to %s/%s", pod.Name, container.Name)
+			}
+			synced++
+		}
+	}
+
+	if synced == 0 {
+		return fmt.Errorf("no running container for %s", item.Image)
+	}
+	return nil
+}
+
+func syncContainer(ctx context.Context, kubeContext, namespace, pod, container string, item *Item) error {
+	args := []string{"--context", kubeContext, "exec", pod, "--namespace", namespace, "-c", container}
+
+	if len(item.Copy) > 0 {
+		files := map[string]string{}
+		for src, dst := range item.Copy {
+			files[src] = strings.TrimPrefix(dst, "/")
+		}
+
+		var tar bytes.Buffer
+		if err := util.CreateMappedTar(&tar, files); err != nil {
+			return errors.Wrap(err, "creating tar of the changed files")
+		}
+
+		cmd := exec.CommandContext(ctx, "kubectl", append(args, "-i", "--", "tar", "xmf", "-", "-C", "/", "--no-same-owner")...)
+		cmd.Stdin = &tar
+		if _, err := util.RunCmdOut(cmd); err != nil {
+			return errors.Wrap(err, "copying files")
+		}
+	}
+
+	if len(item.Delete) > 0 {
+		deleteArgs := append(args, "--", "rm", "-rf", "--")
+		for _, dst := range sortedValues(item.Delete) {
+			deleteArgs = append(deleteArgs, dst)
+		}
+
+		cmd := exec.CommandContext(ctx, "kubectl", deleteArgs...)
+		if _, err := util.RunCmdOut(cmd); err != nil {
+			return errors.Wrap(err, "deleting files")
+		}
+	}
+
+	return nil
+}
+
+func sortedValues(m map[string]string) []string {
+	var values []string
+	for _, v := range m {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
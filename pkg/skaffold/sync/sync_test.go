@@ -0,0 +1,248 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNewItem(t *testing.T) {
+	tmp, teardown := testutil.TempDir(t)
+	defer teardown()
+
+	for _, p := range []string{"app.py", "static/css/main.css", "web/index.html"} {
+		path := filepath.Join(tmp, p)
+		os.MkdirAll(filepath.Dir(path), 0750)
+		ioutil.WriteFile(path, []byte(""), 0644)
+	}
+	ioutil.WriteFile(filepath.Join(tmp, "Dockerfile"), []byte("FROM nginx\nCOPY web /usr/share/nginx/html"), 0644)
+
+	artifact := &v1alpha2.Artifact{
+		ImageName: "web",
+		Workspace: tmp,
+		Sync: map[string]string{
+			"*.py":       "/app",
+			"static/*/*": "/srv/static",
+		},
+	}
+	builds := []build.Build{{ImageName: "web", Tag: "web:123"}}
+
+	var tests = []struct {
+		description string
+		artifact    *v1alpha2.Artifact
+		changed     []string
+		builds      []build.Build
+		expected    *Item
+		shouldErr   bool
+	}{
+		{
+			description: "copy and delete",
+			artifact:    artifact,
+			changed:     []string{"app.py", "static/css/main.css", "old.py"},
+			builds:      builds,
+			expected: &Item{
+				Image: "web:123",
+				Copy: map[string]string{
+					filepath.Join(tmp, "app.py"):              "/app/app.py",
+					filepath.Join(tmp, "static/css/main.css"): "/srv/static/css/main.css",
+				},
+				Delete: map[string]string{
+					filepath.Join(tmp, "old.py"): "/app/old.py",
+				},
+			},
+		},
+		{
+			description: "change not covered by the rules",
+			artifact:    artifact,
+			changed:     []string{"app.py", "Dockerfile"},
+			builds:      builds,
+		},
+		{
+			description: "inferred from the Dockerfile",
+			artifact: &v1alpha2.Artifact{
+				ImageName: "web",
+				Workspace: tmp,
+				InferSync: []string{"*.html"},
+				ArtifactType: v1alpha2.ArtifactType{
+					DockerArtifact: &v1alpha2.DockerArtifact{DockerfilePath: "Dockerfile"},
+				},
+			},
+			changed: []string{"web/index.html"},
+			builds:  builds,
+			expected: &Item{
+				Image:  "web:123",
+				Copy:   map[string]string{filepath.Join(tmp, "web/index.html"): "/usr/share/nginx/html/index.html"},
+				Delete: map[string]string{},
+			},
+		},
+		{
+			description: "never built",
+			artifact:    artifact,
+			changed:     []string{"app.py"},
+		},
+		{
+			description: "no rules",
+			artifact:    &v1alpha2.Artifact{ImageName: "web", Workspace: tmp},
+			changed:     []string{"app.py"},
+			builds:      builds,
+		},
+		{
+			description: "invalid pattern",
+			artifact:    &v1alpha2.Artifact{ImageName: "web", Workspace: tmp, Sync: map[string]string{"[": "/app"}},
+			changed:     []string{"app.py"},
+			builds:      builds,
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var changed []string
+			for _, p := range test.changed {
+				changed = append(changed, filepath.Join(tmp, p))
+			}
+
+			item, err := NewItem(test.artifact, changed, test.builds)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, item)
+		})
+	}
+}
+
+func TestStaticPrefix(t *testing.T) {
+	var tests = []struct {
+		pattern  string
+		expected string
+	}{
+		{pattern: "*.py", expected: ""},
+		{pattern: "static/*/*.css", expected: "static/"},
+		{pattern: "src/web/*.js", expected: "src/web/"},
+		{pattern: "src/web/index.html", expected: "src/web/"},
+		{pattern: "*/web/*.js", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, staticPrefix(test.pattern))
+		})
+	}
+}
+
+// recordingCommand records the commands it runs.
+type recordingCommand struct {
+	commands []string
+}
+
+func (r *recordingCommand) RunCmdOut(cmd *exec.Cmd) ([]byte, error) {
+	return nil, r.RunCmd(cmd)
+}
+
+func (r *recordingCommand) RunCmd(cmd *exec.Cmd) error {
+	r.commands = append(r.commands, strings.Join(cmd.Args, " "))
+	return nil
+}
+
+func TestPerform(t *testing.T) {
+	pod := func(name string, phase v1.PodPhase, image string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"run": "1"}},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "web", Image: image}}},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+	}
+
+	var tests = []struct {
+		description string
+		pods        []*v1.Pod
+		expected    []string
+		shouldErr   bool
+	}{
+		{
+			description: "running containers of the image",
+			pods: []*v1.Pod{
+				pod("web-1", v1.PodRunning, "web:123"),
+				pod("web-2", v1.PodPending, "web:123"),
+				pod("other", v1.PodRunning, "other:123"),
+			},
+			expected: []string{
+				"kubectl --context kubecontext exec web-1 --namespace default -c web -i -- tar xmf - -C / --no-same-owner",
+				"kubectl --context kubecontext exec web-1 --namespace default -c web -- rm -rf -- /app/old.py",
+			},
+		},
+		{
+			description: "pods of other runs and namespaces",
+			pods: []*v1.Pod{
+				{
+					ObjectMeta: meta_v1.ObjectMeta{Name: "web-1", Namespace: "default", Labels: map[string]string{"run": "2"}},
+					Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "web", Image: "web:123"}}},
+					Status:     v1.PodStatus{Phase: v1.PodRunning},
+				},
+				{
+					ObjectMeta: meta_v1.ObjectMeta{Name: "web-1", Namespace: "other", Labels: map[string]string{"run": "1"}},
+					Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "web", Image: "web:123"}}},
+					Status:     v1.PodStatus{Phase: v1.PodRunning},
+				},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "no running container",
+			pods:        []*v1.Pod{pod("web-2", v1.PodPending, "web:123")},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmp, teardown := testutil.TempDir(t)
+			defer teardown()
+			ioutil.WriteFile(filepath.Join(tmp, "app.py"), []byte(""), 0644)
+			item := &Item{
+				Image:  "web:123",
+				Copy:   map[string]string{filepath.Join(tmp, "app.py"): "/app/app.py"},
+				Delete: map[string]string{"old.py": "/app/old.py"},
+			}
+
+			client := fake.NewSimpleClientset()
+			for _, p := range test.pods {
+				client.CoreV1().Pods(p.Namespace).Create(p)
+			}
+
+			recorder := &recordingCommand{}
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = recorder
+
+			err := Perform(context.Background(), client, "kubecontext", "default", "run=1", item)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, recorder.commands)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultInactivityTimeout is how long a supervised command can stay
+	// silent before it's considered hung.
+	DefaultInactivityTimeout = 10 * time.Minute
+
+	// DefaultHeartbeatInterval is how often skaffold reports that it's still
+	// waiting for a silent command.
+	DefaultHeartbeatInterval = 30 * time.Second
+)
+
+// Supervision configures how a long running command is watched over.
+type Supervision struct {
+	// InactivityTimeout kills the command when it hasn't written anything
+	// for that long. Zero disables the timeout.
+	InactivityTimeout time.Duration
+
+	// HeartbeatInterval is how often a message is printed to Out while
+	// the command is silent, or logged as a warning if Out is nil.
+	// Zero disables the messages.
+	HeartbeatInterval time.Duration
+	Out               io.Writer
+}
+
+// DefaultSupervision reports silent commands to out and kills those that
+// look hung.
+func DefaultSupervision(out io.Writer) Supervision {
+	return Supervision{
+		InactivityTimeout: DefaultInactivityTimeout,
+		HeartbeatInterval: DefaultHeartbeatInterval,
+		Out:               out,
+	}
+}
+
+// supervisedCommand is implemented by the Commands that can watch over
+// the processes they run.
+type supervisedCommand interface {
+	RunCmdSupervised(ctx context.Context, cmd *exec.Cmd, s Supervision) error
+}
+
+// RunCmdSupervised runs a command that's killed, along with its children,
+// when the context is cancelled or when it stays silent for too long.
+func RunCmdSupervised(ctx context.Context, cmd *exec.Cmd, s Supervision) error {
+	if sc, ok := DefaultExecCommand.(supervisedCommand); ok {
+		return sc.RunCmdSupervised(ctx, cmd, s)
+	}
+	return DefaultExecCommand.RunCmd(cmd)
+}
+
+// RunCmdSupervised runs an exec.Command in its own process group.
+func (*Commander) RunCmdSupervised(ctx context.Context, cmd *exec.Cmd, s Supervision) error {
+	logrus.Debugf("Running command: %s", cmd.Args)
+
+	activity := &activity{last: time.Now()}
+	stdout := activity.wrap(cmd.Stdout)
+	stderr := stdout
+	if !sameWriter(cmd.Stdout, cmd.Stderr) {
+		stderr = activity.wrap(cmd.Stderr)
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if cmd.Stdin != nil {
+		cmd.Stdin = &activityReader{activity: activity, in: cmd.Stdin}
+	}
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return errors.Wrapf(err, "starting command %v", cmd.Args)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	ticker := time.NewTicker(s.tick())
+	defer ticker.Stop()
+
+	name := strings.Join(cmd.Args, " ")
+	lastHeartbeat := time.Now()
+	for {
+		select {
+		case err := <-done:
+			return err
+
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+			<-done
+			return ctx.Err()
+
+		case now := <-ticker.C:
+			silence := activity.silence(now)
+
+			if s.InactivityTimeout > 0 && silence >= s.InactivityTimeout {
+				killProcessGroup(cmd)
+				<-done
+				return fmt.Errorf("%s was killed after producing no output for %s", name, s.InactivityTimeout)
+			}
+
+			if s.HeartbeatInterval > 0 && silence >= s.HeartbeatInterval && now.Sub(lastHeartbeat) >= s.HeartbeatInterval {
+				s.heartbeat(name, silence)
+				lastHeartbeat = now
+			}
+		}
+	}
+}
+
+func (s Supervision) heartbeat(name string, silence time.Duration) {
+	if s.Out == nil {
+		logrus.Warnf("Still waiting for %s (no output for %s)", name, silence.Round(time.Second))
+		return
+	}
+	fmt.Fprintf(s.Out, "Still waiting for %s (no output for %s)\n", name, silence.Round(time.Second))
+}
+
+// tick is how often a supervised command is checked.
+func (s Supervision) tick() time.Duration {
+	tick := time.Second
+	for _, d := range []time.Duration{s.InactivityTimeout, s.HeartbeatInterval} {
+		if d > 0 && d/2 < tick {
+			tick = d / 2
+		}
+	}
+	return tick
+}
+
+// activity records when a command last read or wrote something.
+type activity struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+func (a *activity) wrap(w io.Writer) io.Writer {
+	return &activityWriter{activity: a, out: w}
+}
+
+func (a *activity) silence(now time.Time) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return now.Sub(a.last)
+}
+
+func (a *activity) touch() {
+	a.mu.Lock()
+	a.last = time.Now()
+	a.mu.Unlock()
+}
+
+type activityReader struct {
+	activity *activity
+	in       io.Reader
+}
+
+func (r *activityReader) Read(p []byte) (int, error) {
+	n, err := r.in.Read(p)
+	if n > 0 {
+		r.activity.touch()
+	}
+	return n, err
+}
+
+type activityWriter struct {
+	activity *activity
+	out      io.Writer
+}
+
+func (w *activityWriter) Write(p []byte) (int, error) {
+	w.activity.touch()
+
+	if w.out == nil {
+		return len(p), nil
+	}
+	return w.out.Write(p)
+}
+
+// sameWriter mimics exec.Cmd which uses a single pipe when stdout and stderr
+// are the same writer, so that writes are not interleaved concurrently.
+func sameWriter(a, b io.Writer) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
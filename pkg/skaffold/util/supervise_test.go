@@ -0,0 +1,98 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestRunCmdSupervised(t *testing.T) {
+	var tests = []struct {
+		description string
+		script      string
+		supervision Supervision
+		cancel      bool
+		shouldErr   bool
+		heartbeat   bool
+	}{
+		{
+			description: "command completes",
+			script:      "echo done",
+			supervision: Supervision{InactivityTimeout: time.Minute},
+		},
+		{
+			description: "failing command",
+			script:      "exit 1",
+			supervision: Supervision{InactivityTimeout: time.Minute},
+			shouldErr:   true,
+		},
+		{
+			description: "silent command is killed with its children",
+			script:      "sleep 30 & wait",
+			supervision: Supervision{InactivityTimeout: 200 * time.Millisecond},
+			shouldErr:   true,
+		},
+		{
+			description: "chatty command is not killed",
+			script:      "for i in 1 2 3 4 5; do echo $i; sleep 0.1; done",
+			supervision: Supervision{InactivityTimeout: 300 * time.Millisecond},
+		},
+		{
+			description: "cancelled context",
+			script:      "sleep 30 & wait",
+			cancel:      true,
+			shouldErr:   true,
+		},
+		{
+			description: "heartbeat",
+			script:      "sleep 0.5",
+			supervision: Supervision{HeartbeatInterval: 100 * time.Millisecond},
+			heartbeat:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.cancel {
+				time.AfterFunc(200*time.Millisecond, cancel)
+			}
+
+			var heartbeats bytes.Buffer
+			test.supervision.Out = &heartbeats
+
+			cmd := exec.Command("sh", "-c", test.script)
+			cmd.Stdout = &bytes.Buffer{}
+
+			start := time.Now()
+			err := (&Commander{}).RunCmdSupervised(ctx, cmd, test.supervision)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.heartbeat, strings.Contains(heartbeats.String(), "Still waiting for sh -c"))
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("command was not killed in time: %s", elapsed)
+			}
+		})
+	}
+}
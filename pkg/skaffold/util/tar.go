@@ -22,16 +22,26 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
 func CreateTar(w io.Writer, root string, paths []string) error {
+	return CreateTarWithContents(w, root, paths, nil)
+}
+
+// CreateTarWithContents creates a tar like CreateTar, except that the files
+// found in contents are written from memory instead of being read from disk.
+func CreateTarWithContents(w io.Writer, root string, paths []string, contents map[string][]byte) error {
 	tw := tar.NewWriter(w)
 	defer tw.Close()
 
 	for _, p := range paths {
+		if _, present := contents[p]; present {
+			continue
+		}
 		tarPath := filepath.ToSlash(filepath.Join(root, p))
 
 		if err := addFileToTar(tarPath, p, tw); err != nil {
@@ -39,6 +49,46 @@ func CreateTar(w io.Writer, root string, paths []string) error {
 		}
 
 	}
+
+	var names []string
+	for name := range contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		content := contents[name]
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     filepath.ToSlash(name),
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return errors.Wrapf(err, "writing %s", name)
+		}
+	}
+	return nil
+}
+
+// CreateMappedTar creates a tar of the files, keyed by their path on disk,
+// under the names they're mapped to.
+func CreateMappedTar(w io.Writer, files map[string]string) error {
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	var paths []string
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		if err := addFileToTar(p, filepath.ToSlash(files[p]), tw); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -160,3 +160,66 @@ func Test_addLinksToTar(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateTarWithContents(t *testing.T) {
+	testDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	files := map[string]string{
+		"Dockerfile": "FROM alpine",
+		"app/main":   "main",
+	}
+	if err := setupFiles(testDir, files); err != nil {
+		t.Fatalf("Error setting up fs: %s", err)
+	}
+
+	var b bytes.Buffer
+	err := CreateTarWithContents(&b, testDir, []string{"Dockerfile", "app/main"}, map[string][]byte{
+		"Dockerfile": []byte("FROM alpine@sha256:abc"),
+	})
+	testutil.CheckError(t, false, err)
+
+	actual := map[string]string{}
+	tr := tar.NewReader(&b)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error reading tar: %s", err)
+		}
+		contents, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("Error %s reading file %s from tar", err, hdr.Name)
+		}
+		actual[hdr.Name] = string(contents)
+	}
+
+	expected := map[string]string{
+		"Dockerfile": "FROM alpine@sha256:abc",
+		"app/main":   "main",
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, expected, actual)
+}
+
+func TestCreateMappedTar(t *testing.T) {
+	testDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	if err := setupFiles(testDir, map[string]string{"static/main.css": "body {}"}); err != nil {
+		t.Fatalf("Error setting up fs: %s", err)
+	}
+
+	var b bytes.Buffer
+	err := CreateMappedTar(&b, map[string]string{
+		filepath.Join(testDir, "static/main.css"): "srv/css/main.css",
+	})
+	testutil.CheckError(t, false, err)
+
+	tr := tar.NewReader(&b)
+	hdr, err := tr.Next()
+	testutil.CheckErrorAndDeepEqual(t, false, err, "srv/css/main.css", hdr.Name)
+	contents, err := ioutil.ReadAll(tr)
+	testutil.CheckErrorAndDeepEqual(t, false, err, "body {}", string(contents))
+}
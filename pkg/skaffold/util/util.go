@@ -22,6 +22,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -64,18 +66,28 @@ func StrSliceContains(sl []string, s string) bool {
 	return false
 }
 
-// ExpandPathsGlob expands paths according to filepath.Glob patterns
+// ExpandPathsGlob expands paths according to filepath.Glob patterns,
+// where `**` also matches any number of directories.
 // Returns a list of unique files that match the glob patterns passed in.
 func ExpandPathsGlob(paths []string) ([]string, error) {
 	expandedPaths := make(map[string]bool)
 	for _, p := range paths {
-		if _, err := Fs.Stat(p); err == nil {
+		info, err := Fs.Stat(p)
+		if err == nil && !info.IsDir() {
 			// This is a file reference, so just add it
 			expandedPaths[p] = true
 			continue
 		}
 
-		files, err := afero.Glob(Fs, p)
+		var files []string
+		if err == nil {
+			// Directories are walked below
+			files = []string{p}
+		} else if strings.Contains(p, "**") {
+			files, err = globStar(p)
+		} else {
+			files, err = afero.Glob(Fs, p)
+		}
 		if err != nil {
 			return nil, errors.Wrap(err, "glob")
 		}
@@ -105,31 +117,103 @@ func ExpandPathsGlob(paths []string) ([]string, error) {
 	return ret, nil
 }
 
+// globStar lists the files that match a pattern with `**`.
+func globStar(pattern string) ([]string, error) {
+	re, err := globStarRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk from the longest directory without wildcards.
+	root := pattern[:strings.IndexAny(pattern, "*?[")]
+	root = filepath.Dir(root + "x")
+
+	var files []string
+	err = afero.Walk(Fs, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !info.IsDir() && re.MatchString(filepath.ToSlash(path)) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// globStarRegexp translates a glob pattern with `**` into a regexp.
+func globStarRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				re.WriteString("(.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				re.WriteString(".*")
+				i++
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+
+	return regexp.Compile(re.String())
+}
+
+// IsURL tells whether a path is an http or https url.
+func IsURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
 // BoolPtr returns a pointer to a bool
 func BoolPtr(b bool) *bool {
 	o := b
 	return &o
 }
 
+// StringPtr returns a pointer to a string
+func StringPtr(s string) *string {
+	o := s
+	return &o
+}
+
 func ReadConfiguration(filename string) ([]byte, error) {
 	switch {
 	case filename == "":
 		return nil, errors.New("filename not specified")
 	case filename == "-":
 		return ioutil.ReadAll(os.Stdin)
-	case strings.HasPrefix(filename, "http://") || strings.HasPrefix(filename, "https://"):
-		return download(filename)
+	case IsURL(filename):
+		return Download(filename)
 	default:
 		return ioutil.ReadFile(filename)
 	}
 }
 
-func download(url string) ([]byte, error) {
+// Download reads the content at an url.
+func Download(url string) ([]byte, error) {
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+
 	return ioutil.ReadAll(resp.Body)
 }
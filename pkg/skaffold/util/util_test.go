@@ -69,6 +69,11 @@ func TestExpandPathsGlob(t *testing.T) {
 	Fs.MkdirAll("dir_b/sub_dir_b", 0700)
 	afero.WriteFile(Fs, "dir_b/sub_dir_b/file", []byte(""), 0650)
 	afero.WriteFile(Fs, "dir/sub_dir/file", []byte(""), 0650)
+	Fs.MkdirAll("k8s/app/base", 0700)
+	afero.WriteFile(Fs, "k8s/service.yaml", []byte(""), 0650)
+	afero.WriteFile(Fs, "k8s/app/deployment.yaml", []byte(""), 0650)
+	afero.WriteFile(Fs, "k8s/app/base/config.yaml", []byte(""), 0650)
+	afero.WriteFile(Fs, "k8s/app/README.md", []byte(""), 0650)
 
 	var tests = []struct {
 		description string
@@ -91,6 +96,26 @@ func TestExpandPathsGlob(t *testing.T) {
 			in:          []string{"dir*"},
 			out:         []string{"dir/sub_dir/file", "dir_b/sub_dir_b/file"},
 		},
+		{
+			description: "match directory",
+			in:          []string{"dir"},
+			out:         []string{"dir/sub_dir/file"},
+		},
+		{
+			description: "match any directory",
+			in:          []string{"k8s/**.yaml"},
+			out:         []string{"k8s/app/base/config.yaml", "k8s/app/deployment.yaml", "k8s/service.yaml"},
+		},
+		{
+			description: "match sub directories",
+			in:          []string{"k8s/**/base/*.yaml"},
+			out:         []string{"k8s/app/base/config.yaml"},
+		},
+		{
+			description: "match from the current directory",
+			in:          []string{"**/*.md"},
+			out:         []string{"k8s/app/README.md"},
+		},
 		{
 			description: "error unmatched glob",
 			in:          []string{"dir/sub_dir_c/*"},
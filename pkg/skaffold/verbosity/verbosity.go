@@ -0,0 +1,160 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verbosity
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// subsystems maps each subsystem to the packages whose logs it controls.
+var subsystems = map[string][]string{
+	"watcher":  {"pkg/skaffold/watch"},
+	"builder":  {"pkg/skaffold/build", "pkg/skaffold/docker", "pkg/skaffold/bazel", "pkg/skaffold/kaniko", "pkg/skaffold/s2i"},
+	"deployer": {"pkg/skaffold/deploy"},
+	"kube":     {"pkg/skaffold/kubernetes"},
+}
+
+// Levels are the log levels of each subsystem.
+type Levels struct {
+	Default    logrus.Level
+	Subsystems map[string]logrus.Level
+}
+
+// Parse reads a global level optionally followed by per subsystem levels,
+// e.g. `warn,watcher=debug,kube=info`.
+func Parse(spec string) (*Levels, error) {
+	levels := &Levels{
+		Default:    logrus.WarnLevel,
+		Subsystems: map[string]logrus.Level{},
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) == 1 {
+			lvl, err := logrus.ParseLevel(part)
+			if err != nil {
+				return nil, errors.Wrap(err, "parsing log level")
+			}
+			levels.Default = lvl
+			continue
+		}
+
+		if _, known := subsystems[kv[0]]; !known {
+			return nil, fmt.Errorf("unknown subsystem %q, expected one of %s", kv[0], strings.Join(Subsystems(), ", "))
+		}
+		lvl, err := logrus.ParseLevel(kv[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing log level of %s", kv[0])
+		}
+		levels.Subsystems[kv[0]] = lvl
+	}
+
+	return levels, nil
+}
+
+// Subsystems lists the names of the subsystems.
+func Subsystems() []string {
+	var names []string
+	for name := range subsystems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Max is the most verbose of the levels.
+func (l *Levels) Max() logrus.Level {
+	max := l.Default
+	for _, lvl := range l.Subsystems {
+		if lvl > max {
+			max = lvl
+		}
+	}
+	return max
+}
+
+// levelFor returns the level of the subsystem a function belongs to.
+func (l *Levels) levelFor(function string) logrus.Level {
+	for name, lvl := range l.Subsystems {
+		for _, pkg := range subsystems[name] {
+			if strings.Contains(function, "/"+pkg+".") {
+				return lvl
+			}
+		}
+	}
+	return l.Default
+}
+
+// Apply configures logrus, and the glog logger used by client-go, for the levels.
+func Apply(logger *logrus.Logger, levels *Levels) {
+	logger.SetLevel(levels.Max())
+	if len(levels.Subsystems) > 0 {
+		logger.Formatter = &filteringFormatter{
+			Formatter: logger.Formatter,
+			levels:    levels,
+		}
+	}
+
+	// client-go logs its requests at verbosity 6.
+	if lvl, present := levels.Subsystems["kube"]; present && lvl >= logrus.DebugLevel && flag.Lookup("v") != nil {
+		flag.Set("logtostderr", "true")
+		flag.Set("v", "6")
+	}
+}
+
+// filteringFormatter drops the entries that are more verbose than what
+// their subsystem allows.
+type filteringFormatter struct {
+	logrus.Formatter
+	levels *Levels
+}
+
+func (f *filteringFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if entry.Level > f.levels.levelFor(caller()) {
+		return nil, nil
+	}
+	return f.Formatter.Format(entry)
+}
+
+// caller finds the function that logged, outside of logrus and this package.
+func caller() string {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.Function, "sirupsen/logrus.") && !strings.Contains(frame.Function, "pkg/skaffold/verbosity.") {
+			return frame.Function
+		}
+		if !more {
+			return ""
+		}
+	}
+}
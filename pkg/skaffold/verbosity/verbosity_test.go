@@ -0,0 +1,101 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verbosity
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		description string
+		spec        string
+		shouldErr   bool
+		expected    *Levels
+	}{
+		{
+			description: "global level",
+			spec:        "info",
+			expected:    &Levels{Default: logrus.InfoLevel, Subsystems: map[string]logrus.Level{}},
+		},
+		{
+			description: "per subsystem",
+			spec:        "error, watcher=debug,kube=info",
+			expected: &Levels{Default: logrus.ErrorLevel, Subsystems: map[string]logrus.Level{
+				"watcher": logrus.DebugLevel,
+				"kube":    logrus.InfoLevel,
+			}},
+		},
+		{
+			description: "only a subsystem",
+			spec:        "builder=debug",
+			expected:    &Levels{Default: logrus.WarnLevel, Subsystems: map[string]logrus.Level{"builder": logrus.DebugLevel}},
+		},
+		{
+			description: "unknown subsystem",
+			spec:        "warn,unknown=debug",
+			shouldErr:   true,
+		},
+		{
+			description: "invalid level",
+			spec:        "loud",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			levels, err := Parse(test.spec)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, levels)
+		})
+	}
+}
+
+func TestLevelFor(t *testing.T) {
+	levels, err := Parse("warn,watcher=debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, logrus.DebugLevel, levels.Max())
+	testutil.CheckErrorAndDeepEqual(t, false, nil, logrus.DebugLevel, levels.levelFor("github.com/GoogleContainerTools/skaffold/pkg/skaffold/watch.(*mtimeWatcher).Run"))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, logrus.WarnLevel, levels.levelFor("github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy.(*KubectlDeployer).Deploy"))
+}
+
+func TestApply(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.Out = &out
+
+	levels, err := Parse("info,watcher=debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Apply(logger, levels)
+
+	// This test isn't part of the watcher subsystem.
+	logger.Debug("hidden")
+	logger.Info("shown")
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, bytes.Contains(out.Bytes(), []byte("hidden")))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, bytes.Contains(out.Bytes(), []byte("shown")))
+}
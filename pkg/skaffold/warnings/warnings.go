@@ -0,0 +1,106 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warnings
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Categories of warnings, used to group them in the summary.
+const (
+	UnusedArtifact    = "unused artifact"
+	MissingIgnoreFile = "missing .dockerignore"
+	DeprecatedField   = "deprecated field"
+	UnsupportedOption = "unsupported option"
+	OutdatedConfig    = "outdated config"
+	InvalidTag        = "invalid tag"
+)
+
+// Warning is a non-fatal issue found during a run.
+type Warning struct {
+	Category string `json:"category"`
+	Message  string `json:"message"`
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("[%s] %s", w.Category, w.Message)
+}
+
+// Collector records warnings so they can be reported together at the end of a run.
+type Collector struct {
+	mu       sync.Mutex
+	warnings []Warning
+	seen     map[Warning]bool
+}
+
+// Default is the collector that commands report at the end of a run.
+var Default = &Collector{}
+
+// raise shows a warning as soon as it's found, so that long running
+// commands like dev don't wait for the summary to report it.
+var raise = func(w Warning) {
+	logrus.Warn(w.String())
+}
+
+// Warnf records a warning on the default collector.
+func Warnf(category, format string, args ...interface{}) {
+	Default.Warnf(category, format, args...)
+}
+
+// Warnf records and shows a warning. The same warning is only recorded
+// and shown once.
+func (c *Collector) Warnf(category, format string, args ...interface{}) {
+	w := Warning{Category: category, Message: fmt.Sprintf(format, args...)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.seen == nil {
+		c.seen = map[Warning]bool{}
+	}
+	if c.seen[w] {
+		return
+	}
+	c.seen[w] = true
+	c.warnings = append(c.warnings, w)
+	raise(w)
+}
+
+// Warnings returns the recorded warnings, in the order they were found.
+func (c *Collector) Warnings() []Warning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Warning(nil), c.warnings...)
+}
+
+// PrintSummary prints the recorded warnings, if any.
+func (c *Collector) PrintSummary(out io.Writer) {
+	warnings := c.Warnings()
+	if len(warnings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(out, "%d warning(s):\n", len(warnings))
+	for _, w := range warnings {
+		fmt.Fprintf(out, " - %s\n", w)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warnings
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestPrintSummary(t *testing.T) {
+	var tests = []struct {
+		description string
+		warn        func(c *Collector)
+		expected    string
+	}{
+		{
+			description: "no warnings",
+			warn:        func(c *Collector) {},
+		},
+		{
+			description: "deduplicated warnings",
+			warn: func(c *Collector) {
+				c.Warnf(UnusedArtifact, "image [%s] is not used by the deployment", "gcr.io/k8s-skaffold/web")
+				c.Warnf(DeprecatedField, "skipPush is ignored, push is set to %s", "auto")
+				c.Warnf(UnusedArtifact, "image [%s] is not used by the deployment", "gcr.io/k8s-skaffold/web")
+			},
+			expected: `2 warning(s):
+ - [unused artifact] image [gcr.io/k8s-skaffold/web] is not used by the deployment
+ - [deprecated field] skipPush is ignored, push is set to auto
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			c := &Collector{}
+			test.warn(c)
+
+			var out bytes.Buffer
+			c.PrintSummary(&out)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, out.String())
+		})
+	}
+}
+
+func TestWarnfRaisesOnce(t *testing.T) {
+	defer func(r func(Warning)) { raise = r }(raise)
+	var raised []Warning
+	raise = func(w Warning) { raised = append(raised, w) }
+
+	c := &Collector{}
+	c.Warnf(MissingIgnoreFile, "no .dockerignore in %s", "web")
+	c.Warnf(MissingIgnoreFile, "no .dockerignore in %s", "web")
+	c.Warnf(MissingIgnoreFile, "no .dockerignore in %s", "api")
+
+	expected := []Warning{
+		{Category: MissingIgnoreFile, Message: "no .dockerignore in web"},
+		{Category: MissingIgnoreFile, Message: "no .dockerignore in api"},
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, expected, raised)
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Recording is the list of changes observed during a dev session.
+type Recording struct {
+	Events []RecordedEvent `yaml:"events"`
+}
+
+// RecordedEvent is a change, when it happened since the files started being
+// watched, and how long the dev loop took to handle it.
+type RecordedEvent struct {
+	Offset   time.Duration `yaml:"offset"`
+	Paths    []string      `yaml:"paths"`
+	Duration time.Duration `yaml:"duration"`
+}
+
+// ReadRecording reads a recording saved by a Recorder.
+func ReadRecording(filename string) (*Recording, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading recording")
+	}
+
+	var recording Recording
+	if err := yaml.Unmarshal(contents, &recording); err != nil {
+		return nil, errors.Wrap(err, "parsing recording")
+	}
+	return &recording, nil
+}
+
+// Recorder saves the changes seen by the watchers it creates to a file.
+// The file is rewritten after each change so that interrupting the session
+// doesn't lose the recording.
+type Recorder struct {
+	filename string
+	factory  WatcherFactory
+
+	lock      sync.Mutex
+	start     time.Time
+	recording Recording
+}
+
+// NewRecorder creates a Recorder that wraps the watchers of a factory.
+func NewRecorder(filename string, factory WatcherFactory) *Recorder {
+	return &Recorder{
+		filename: filename,
+		factory:  factory,
+	}
+}
+
+// NewWatcher is a WatcherFactory for recorded watchers.
+func (r *Recorder) NewWatcher(paths []string) (Watcher, error) {
+	w, err := r.factory(paths)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingWatcher{recorder: r, watcher: w}, nil
+}
+
+func (r *Recorder) started() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.start.IsZero() {
+		r.start = time.Now()
+	}
+}
+
+func (r *Recorder) record(event RecordedEvent) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.recording.Events = append(r.recording.Events, event)
+
+	contents, err := yaml.Marshal(r.recording)
+	if err != nil {
+		return errors.Wrap(err, "marshalling recording")
+	}
+	return ioutil.WriteFile(r.filename, contents, 0644)
+}
+
+type recordingWatcher struct {
+	recorder *Recorder
+	watcher  Watcher
+}
+
+func (w *recordingWatcher) Start(ctx context.Context, onChange func([]string)) error {
+	w.recorder.started()
+
+	return w.watcher.Start(ctx, func(paths []string) {
+		changed := time.Now()
+		onChange(paths)
+
+		event := RecordedEvent{
+			Offset:   changed.Sub(w.recorder.start),
+			Paths:    paths,
+			Duration: time.Since(changed),
+		}
+		if err := w.recorder.record(event); err != nil {
+			logrus.Warnf("recording change: %s", err)
+		}
+	})
+}
+
+// Replayer creates watchers that trigger the changes of a recording, at the
+// same pace, instead of watching files. Each watcher replays the events that
+// touch the paths it was created for and returns once they're all replayed.
+type Replayer struct {
+	recording *Recording
+	out       io.Writer
+
+	once  sync.Once
+	start time.Time
+}
+
+// NewReplayer creates a Replayer that reports how long each change took to out.
+func NewReplayer(recording *Recording, out io.Writer) *Replayer {
+	return &Replayer{
+		recording: recording,
+		out:       out,
+	}
+}
+
+// NewWatcher is a WatcherFactory for replaying watchers.
+func (r *Replayer) NewWatcher(paths []string) (Watcher, error) {
+	watched := map[string]bool{}
+	for _, p := range paths {
+		watched[p] = true
+	}
+
+	var events []RecordedEvent
+	for _, e := range r.recording.Events {
+		var changed []string
+		for _, p := range e.Paths {
+			if watched[p] {
+				changed = append(changed, p)
+			}
+		}
+		if len(changed) > 0 {
+			events = append(events, RecordedEvent{Offset: e.Offset, Paths: changed, Duration: e.Duration})
+		}
+	}
+
+	return &replayWatcher{replayer: r, events: events}, nil
+}
+
+type replayWatcher struct {
+	replayer *Replayer
+	events   []RecordedEvent
+}
+
+func (w *replayWatcher) Start(ctx context.Context, onChange func([]string)) error {
+	w.replayer.once.Do(func() { w.replayer.start = time.Now() })
+
+	for _, e := range w.events {
+		select {
+		case <-time.After(time.Until(w.replayer.start.Add(e.Offset))):
+		case <-ctx.Done():
+			return nil
+		}
+
+		changed := time.Now()
+		onChange(e.Paths)
+		fmt.Fprintf(w.replayer.out, "Replayed change to %v in %v (recorded: %v)\n", e.Paths, time.Since(changed), e.Duration)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+type fakeWatcher struct {
+	changes [][]string
+}
+
+func (f *fakeWatcher) Start(ctx context.Context, onChange func([]string)) error {
+	for _, change := range f.changes {
+		onChange(change)
+	}
+	return nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	filename := filepath.Join(tmpDir, "recording.yaml")
+	recorder := NewRecorder(filename, func([]string) (Watcher, error) {
+		return &fakeWatcher{changes: [][]string{{"main.go"}, {"main.go", "k8s.yaml"}}}, nil
+	})
+
+	watcher, err := recorder.NewWatcher([]string{"main.go", "k8s.yaml"})
+	testutil.CheckError(t, false, err)
+	err = watcher.Start(context.Background(), func([]string) {})
+	testutil.CheckError(t, false, err)
+
+	recording, err := ReadRecording(filename)
+	testutil.CheckError(t, false, err)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 2, len(recording.Events))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"main.go", "k8s.yaml"}, recording.Events[1].Paths)
+
+	var out bytes.Buffer
+	replayer := NewReplayer(recording, &out)
+
+	var replayed [][]string
+	onChange := func(paths []string) { replayed = append(replayed, paths) }
+
+	sourceWatcher, err := replayer.NewWatcher([]string{"main.go"})
+	testutil.CheckError(t, false, err)
+	err = sourceWatcher.Start(context.Background(), onChange)
+	testutil.CheckError(t, false, err)
+
+	deployWatcher, err := replayer.NewWatcher([]string{"k8s.yaml"})
+	testutil.CheckError(t, false, err)
+	err = deployWatcher.Start(context.Background(), onChange)
+	testutil.CheckError(t, false, err)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, [][]string{{"main.go"}, {"main.go"}, {"k8s.yaml"}}, replayed)
+}
+
+func TestReplayCancelled(t *testing.T) {
+	recording := &Recording{Events: []RecordedEvent{{Offset: 1 << 62, Paths: []string{"main.go"}}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	watcher, err := NewReplayer(recording, &bytes.Buffer{}).NewWatcher([]string{"main.go"})
+	testutil.CheckError(t, false, err)
+	err = watcher.Start(ctx, func([]string) { t.Error("change shouldn't be replayed") })
+
+	testutil.CheckError(t, false, err)
+}
+
+func TestReadRecordingErrors(t *testing.T) {
+	_, err := ReadRecording("does-not-exist.yaml")
+
+	testutil.CheckError(t, true, err)
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Triggers select how the dev loop notices changes.
+const (
+	NotifyTrigger  = "notify"
+	PollingTrigger = "polling"
+	ManualTrigger  = "manual"
+)
+
+// fileState is what a file looked like when it was last checked.
+// Missing files have a zero state.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+type snapshot map[string]fileState
+
+func takeSnapshot(paths []string) snapshot {
+	s := snapshot{}
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil {
+			s[p] = fileState{modTime: info.ModTime(), size: info.Size()}
+		} else {
+			s[p] = fileState{}
+		}
+	}
+	return s
+}
+
+// changes lists the paths that differ between two snapshots of the same files.
+func (s snapshot) changes(next snapshot) []string {
+	var changed []string
+	for p, state := range next {
+		if s[p] != state {
+			changed = append(changed, p)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// NewPollingWatcherFactory creates watchers that check the modification times
+// of the files at regular intervals, for file systems where inotify doesn't
+// work, e.g. network or shared folders.
+func NewPollingWatcherFactory(interval time.Duration) WatcherFactory {
+	return func(paths []string) (Watcher, error) {
+		return &pollingWatcher{
+			paths:    paths,
+			interval: interval,
+		}, nil
+	}
+}
+
+type pollingWatcher struct {
+	paths    []string
+	interval time.Duration
+}
+
+func (w *pollingWatcher) Start(ctx context.Context, onChange func([]string)) error {
+	last := takeSnapshot(w.paths)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			next := takeSnapshot(w.paths)
+			if changes := last.changes(next); len(changes) > 0 {
+				onChange(changes)
+			}
+			last = next
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Keypress creates watchers that only report the files that changed when
+// Enter is pressed. All its watchers are triggered by the same keypress.
+type Keypress struct {
+	in  io.Reader
+	out io.Writer
+
+	once        sync.Once
+	lock        sync.Mutex
+	subscribers map[chan struct{}]bool
+}
+
+// NewKeypress creates a Keypress that reads lines from in and prompts on out.
+func NewKeypress(in io.Reader, out io.Writer) *Keypress {
+	return &Keypress{
+		in:          in,
+		out:         out,
+		subscribers: map[chan struct{}]bool{},
+	}
+}
+
+// NewWatcher is a WatcherFactory for watchers triggered by a keypress.
+func (k *Keypress) NewWatcher(paths []string) (Watcher, error) {
+	return &keypressWatcher{keypress: k, paths: paths}, nil
+}
+
+func (k *Keypress) readLines() {
+	fmt.Fprintln(k.out, "Press Enter to build and deploy the changes")
+
+	scanner := bufio.NewScanner(k.in)
+	for scanner.Scan() {
+		k.lock.Lock()
+		for c := range k.subscribers {
+			select {
+			case c <- struct{}{}:
+			default:
+			}
+		}
+		k.lock.Unlock()
+	}
+}
+
+func (k *Keypress) subscribe() chan struct{} {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	c := make(chan struct{}, 1)
+	k.subscribers[c] = true
+	return c
+}
+
+func (k *Keypress) unsubscribe(c chan struct{}) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	delete(k.subscribers, c)
+}
+
+type keypressWatcher struct {
+	keypress *Keypress
+	paths    []string
+}
+
+func (w *keypressWatcher) Start(ctx context.Context, onChange func([]string)) error {
+	last := takeSnapshot(w.paths)
+
+	pressed := w.keypress.subscribe()
+	defer w.keypress.unsubscribe(pressed)
+	w.keypress.once.Do(func() { go w.keypress.readLines() })
+
+	for {
+		select {
+		case <-pressed:
+			next := takeSnapshot(w.paths)
+			if changes := last.changes(next); len(changes) > 0 {
+				onChange(changes)
+			}
+			last = next
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
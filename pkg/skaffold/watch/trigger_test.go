@@ -0,0 +1,105 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestSnapshotChanges(t *testing.T) {
+	tmp, teardown := testutil.TempDir(t)
+	defer teardown()
+
+	paths := prependParentDir(tmp, []string{"a", "b", "c"})
+	write(t, paths[0], "")
+	write(t, paths[1], "")
+
+	before := takeSnapshot(paths)
+	write(t, paths[1], "CONTENT")
+	write(t, paths[2], "")
+	after := takeSnapshot(paths)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, paths[1:], before.changes(after))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string(nil), after.changes(after))
+}
+
+func TestPollingWatcher(t *testing.T) {
+	tmp, teardown := testutil.TempDir(t)
+	defer teardown()
+
+	paths := prependParentDir(tmp, []string{"a", "b"})
+	for _, p := range paths {
+		write(t, p, "")
+	}
+
+	watcher, err := NewPollingWatcherFactory(10 * time.Millisecond)(paths)
+	testutil.CheckError(t, false, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		write(t, paths[1], "CONTENT")
+	}()
+
+	var actual []string
+	watcher.Start(ctx, func(changes []string) {
+		actual = changes
+		cancel()
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, paths[1:], actual)
+}
+
+func TestKeypressWatcher(t *testing.T) {
+	tmp, teardown := testutil.TempDir(t)
+	defer teardown()
+
+	paths := prependParentDir(tmp, []string{"a", "b"})
+	for _, p := range paths {
+		write(t, p, "")
+	}
+
+	in, keyboard := io.Pipe()
+	defer keyboard.Close()
+
+	watcher, err := NewKeypress(in, ioutil.Discard).NewWatcher(paths)
+	testutil.CheckError(t, false, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		os.Remove(paths[0])
+		// Nothing is reported until Enter is pressed.
+		time.Sleep(50 * time.Millisecond)
+		keyboard.Write([]byte("\n"))
+	}()
+
+	var actual []string
+	watcher.Start(ctx, func(changes []string) {
+		actual = changes
+		cancel()
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, paths[:1], actual)
+}
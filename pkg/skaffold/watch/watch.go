@@ -18,6 +18,7 @@ package watch
 
 import (
 	"context"
+	"os"
 	"path/filepath"
 	"sort"
 	"time"
@@ -47,6 +48,9 @@ type fsWatcher struct {
 }
 
 // NewWatcher creates a new Watcher on a list of files.
+// Only the directories containing the files are watched, each one once, which
+// keeps the number of inotify watches low on large workspaces and catches
+// editors that save files by renaming a temporary file over them.
 func NewWatcher(paths []string) (Watcher, error) {
 	w, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -54,24 +58,32 @@ func NewWatcher(paths []string) (Watcher, error) {
 	}
 
 	files := map[string]bool{}
+	dirs := map[string]bool{}
 
 	sort.Strings(paths)
 	for _, p := range paths {
-		files[p] = true
-		logrus.Infof("Added watch for %s", p)
-
-		if err := w.Add(p); err != nil {
+		p = filepath.Clean(p)
+		if _, err := os.Stat(p); err != nil {
 			w.Close()
 			return nil, errors.Wrapf(err, "adding watch for %s", p)
 		}
 
-		if err := w.Add(filepath.Dir(p)); err != nil {
+		files[p] = true
+		logrus.Debugf("Added watch for %s", p)
+
+		dir := filepath.Dir(p)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+
+		if err := w.Add(dir); err != nil {
 			w.Close()
 			return nil, errors.Wrapf(err, "adding watch for %s", p)
 		}
 	}
 
-	logrus.Info("Watch is ready")
+	logrus.Infof("Watching %d files in %d directories", len(files), len(dirs))
 	return &fsWatcher{
 		watcher: w,
 		files:   files,
@@ -92,12 +104,14 @@ func (f *fsWatcher) Start(ctx context.Context, onChange func([]string)) error {
 			if ev.Op == fsnotify.Chmod {
 				continue // TODO(dgageot): VSCode seems to chmod randomly
 			}
-			if !f.files[ev.Name] {
+			// Events on files in the current directory are named ./file
+			name := filepath.Clean(ev.Name)
+			if !f.files[name] {
 				continue // File is not directly watched. Maybe its parent is
 			}
 			timer.Reset(quietPeriod)
 			logrus.Infof("Change: %s", ev)
-			changedPaths[ev.Name] = true
+			changedPaths[name] = true
 		case err := <-f.watcher.Errors:
 			return errors.Wrap(err, "watch error")
 		case <-timer.C:
@@ -18,6 +18,7 @@ package watch
 import (
 	"context"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -31,6 +32,7 @@ func TestWatch(t *testing.T) {
 		createFiles     []string
 		watchFiles      []string
 		writes          []string
+		renames         []string
 		deletes         []string
 		expectedChanges []string
 		shouldErr       bool
@@ -55,6 +57,20 @@ func TestWatch(t *testing.T) {
 			writes:          []string{"a", "b"},
 			expectedChanges: []string{"a"},
 		},
+		{
+			description:     "files in sub directories",
+			createFiles:     []string{"a", "sub/b", "sub/c"},
+			watchFiles:      []string{"a", "sub/b", "sub/c"},
+			writes:          []string{"sub/c"},
+			expectedChanges: []string{"sub/c"},
+		},
+		{
+			description:     "file replaced by a rename",
+			createFiles:     []string{"a", "a.tmp"},
+			watchFiles:      []string{"a"},
+			renames:         []string{"a"},
+			expectedChanges: []string{"a"},
+		},
 	}
 
 	for _, test := range tests {
@@ -82,6 +98,11 @@ func TestWatch(t *testing.T) {
 			for _, p := range prependParentDir(tmp, test.writes) {
 				write(t, p, "CONTENT")
 			}
+			for _, p := range prependParentDir(tmp, test.renames) {
+				if err := os.Rename(p+".tmp", p); err != nil {
+					t.Errorf("renaming mock fs file: %s", err)
+				}
+			}
 
 			ctx, cancel := context.WithCancel(context.Background())
 			watcher.Start(ctx, func(actual []string) {
@@ -96,7 +117,38 @@ func TestWatch(t *testing.T) {
 		})
 	}
 }
+
+func TestWatchCurrentDirectory(t *testing.T) {
+	tmp, teardown := testutil.TempDir(t)
+	defer teardown()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	write(t, "skaffold.yaml", "")
+	watcher, err := NewWatcher([]string{"skaffold.yaml"})
+	testutil.CheckError(t, false, err)
+
+	write(t, "skaffold.yaml", "CONTENT")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher.Start(ctx, func(actual []string) {
+		defer cancel()
+
+		testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"skaffold.yaml"}, actual)
+	})
+}
+
 func write(t *testing.T, path string, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Errorf("creating mock fs dir: %s", err)
+	}
 	if err := ioutil.WriteFile(path, []byte(content), 0640); err != nil {
 		t.Errorf("writing mock fs file: %s", err)
 	}
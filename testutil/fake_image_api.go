@@ -40,9 +40,20 @@ type FakeImageAPIOptions struct {
 	ErrImageListEmpty bool
 	ErrImageTag       bool
 	ErrImagePush      bool
+	ErrImagePull      bool
+	ErrVersion        bool
+
+	// ServerVersion is returned by ServerVersion. Defaults to API 1.37.
+	ServerVersion *types.Version
 
 	BuildImageID string
 
+	// RepoDigests lists the repo digests of images, by tag.
+	RepoDigests map[string][]string
+
+	ImageSize   int64
+	ImageLayers int
+
 	ReturnBody io.ReadCloser
 }
 
@@ -94,8 +105,9 @@ func (f *FakeImageAPIClient) ImageList(ctx context.Context, options types.ImageL
 				continue
 			}
 			ret = append(ret, types.ImageSummary{
-				ID:       imageID,
-				RepoTags: []string{ref},
+				ID:          imageID,
+				RepoTags:    []string{ref},
+				RepoDigests: f.opts.RepoDigests[ref],
 			})
 		}
 	}
@@ -122,10 +134,44 @@ func (f *FakeImageAPIClient) ImagePush(_ context.Context, _ string, _ types.Imag
 	return f.opts.ReturnBody, err
 }
 
+func (f *FakeImageAPIClient) ImagePull(_ context.Context, _ string, _ types.ImagePullOptions) (io.ReadCloser, error) {
+	var err error
+	if f.opts.ErrImagePull {
+		err = fmt.Errorf("")
+	}
+	return f.opts.ReturnBody, err
+}
+
 func (f *FakeImageAPIClient) Info(ctx context.Context) (types.Info, error) {
 	return types.Info{
 		IndexServerAddress: registry.IndexServer,
 	}, nil
 }
 
+func (f *FakeImageAPIClient) ServerVersion(ctx context.Context) (types.Version, error) {
+	if f.opts.ErrVersion {
+		return types.Version{}, fmt.Errorf("cannot connect")
+	}
+	if f.opts.ServerVersion != nil {
+		return *f.opts.ServerVersion, nil
+	}
+	return types.Version{
+		Version:    "18.03.0-ce",
+		APIVersion: "1.37",
+		Os:         "linux",
+		Arch:       "amd64",
+	}, nil
+}
+
+func (f *FakeImageAPIClient) ImageInspectWithRaw(ctx context.Context, image string) (types.ImageInspect, []byte, error) {
+	return types.ImageInspect{
+		Size: f.opts.ImageSize,
+		RootFS: types.RootFS{
+			Layers: make([]string, f.opts.ImageLayers),
+		},
+	}, nil, nil
+}
+
+func (f *FakeImageAPIClient) NegotiateAPIVersion(ctx context.Context) {}
+
 func (f *FakeImageAPIClient) Close() error { return nil }